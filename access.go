@@ -0,0 +1,141 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Levels a member can ask the admins for, from lowest to highest
+var requestableLevels = map[string]int{"VIEWER": 0, "COMMENTER": 1, "EDITOR": 2}
+
+// RequestAccess asks the admins to raise the level of the current member, who wants to work on
+// the project. A member has at most one pending request.
+func (s *service) RequestAccess(projectID string, id string, level string) (*AccessRequest, error) {
+	want, ok := requestableLevels[level]
+	if !ok || level == "VIEWER" {
+		return nil, errors.New("level invalid")
+	}
+	if have, ok := requestableLevels[s.Member.Level]; !ok || have >= want {
+		return nil, errors.New("already has access")
+	}
+
+	p, err := s.r.GetProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		return nil, errors.New("project not found")
+	}
+
+	if x, _ := s.r.GetPendingAccessRequestByMember(s.Member.WorkspaceID, s.Member.ID); x != nil {
+		return nil, errors.New("request already pending")
+	}
+	if x, _ := s.r.GetAccessRequest(s.Member.WorkspaceID, id); x != nil {
+		return nil, errors.New("already exists")
+	}
+
+	x := &AccessRequest{
+		WorkspaceID: s.Member.WorkspaceID,
+		ID:          id,
+		ProjectID:   p.ID,
+		MemberID:    s.Member.ID,
+		Level:       level,
+		Status:      "PENDING",
+		CreatedAt:   time.Now().UTC(),
+	}
+	s.r.StoreAccessRequest(x)
+	s.notifyAdmins(p, x)
+
+	return x, nil
+}
+
+func (s *service) GetAccessRequests(projectID string) []*AccessRequest {
+	x, err := s.r.FindPendingAccessRequestsByProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		log.Println(err)
+	}
+	return x
+}
+
+// ApproveAccessRequest gives the member the level asked for, if there is a seat for it. A member
+// whose level has since been raised to it or above is left as is.
+func (s *service) ApproveAccessRequest(projectID string, id string) (*AccessRequest, error) {
+	x, err := s.pendingAccessRequest(projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.r.GetMember(s.Member.WorkspaceID, x.MemberID)
+	if err != nil {
+		return nil, errors.New("member not found")
+	}
+	if have, ok := requestableLevels[m.Level]; !ok || have >= requestableLevels[x.Level] {
+		return nil, errors.New("already has access")
+	}
+
+	if _, err := s.UpdateMemberLevel(x.MemberID, x.Level); err != nil {
+		return nil, err
+	}
+
+	s.decide(x, "APPROVED")
+	return x, nil
+}
+
+// DenyAccessRequest closes the request and leaves the member as is
+func (s *service) DenyAccessRequest(projectID string, id string) (*AccessRequest, error) {
+	x, err := s.pendingAccessRequest(projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.decide(x, "DENIED")
+	return x, nil
+}
+
+func (s *service) pendingAccessRequest(projectID string, id string) (*AccessRequest, error) {
+	x, err := s.r.GetAccessRequest(s.Member.WorkspaceID, id)
+	if err != nil || x.ProjectID != projectID {
+		return nil, errors.New("access request not found")
+	}
+	if x.Status != "PENDING" {
+		return nil, errors.New("access request already decided")
+	}
+	return x, nil
+}
+
+func (s *service) decide(x *AccessRequest, status string) {
+	t := time.Now().UTC()
+	x.Status = status
+	x.DecidedAt = &t
+	x.DecidedByName = s.Acc.Name
+	s.r.StoreAccessRequest(x)
+}
+
+// notifyAdmins emails the admins and owners of the workspace about a new request
+func (s *service) notifyAdmins(p *Project, x *AccessRequest) {
+	mm, err := s.r.FindMembersByWorkspace(s.Member.WorkspaceID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	body, err := accessRequestNotificationBody(accessRequestBody{
+		AppSiteURL:    s.config.AppSiteURL,
+		WorkspaceName: s.ws.Name,
+		ProjectID:     p.ID,
+		ProjectTitle:  p.Title,
+		Requester:     s.Acc.Name,
+		Level:         strings.ToLower(x.Level),
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, m := range mm {
+		if m.Level != "ADMIN" && m.Level != "OWNER" {
+			continue
+		}
+		s.notify(&NotificationEmail{AccountID: m.AccountID, Email: m.Email, Subject: "Featmap: " + s.Acc.Name + " requests access", Body: body}, s.sendNotificationEmail)
+	}
+}
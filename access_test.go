@@ -0,0 +1,138 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// accessRepo keeps the members and access requests of workspace "ws" in memory
+type accessRepo struct {
+	notificationRepo
+	members  []*Member
+	requests map[string]*AccessRequest
+}
+
+func (a *accessRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	if id != "p1" {
+		return nil, errNotFound
+	}
+	return &Project{WorkspaceID: workspaceID, ID: id, Title: "Roadmap"}, nil
+}
+
+func (a *accessRepo) FindMembersByWorkspace(id string) ([]*Member, error) {
+	return a.members, nil
+}
+
+func (a *accessRepo) GetMember(workspaceID string, id string) (*Member, error) {
+	for _, m := range a.members {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *accessRepo) StoreMember(x *Member) {}
+
+func (a *accessRepo) FindSubscriptionsByWorkspace(id string) ([]*Subscription, error) {
+	return []*Subscription{{WorkspaceID: id, NumberOfEditors: 5}}, nil
+}
+
+func (a *accessRepo) GetAccessRequest(workspaceID string, id string) (*AccessRequest, error) {
+	if x, ok := a.requests[id]; ok {
+		return x, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *accessRepo) GetPendingAccessRequestByMember(workspaceID string, memberID string) (*AccessRequest, error) {
+	for _, x := range a.requests {
+		if x.MemberID == memberID && x.Status == "PENDING" {
+			return x, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *accessRepo) StoreAccessRequest(x *AccessRequest) {
+	a.requests[x.ID] = x
+}
+
+// Over the cap, notifications are stored for the digest instead of sent
+func (a *accessRepo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	return 1, nil
+}
+
+func TestAccessRequests(t *testing.T) {
+	repo := &accessRepo{
+		members: []*Member{
+			{WorkspaceID: "ws", ID: "owner", AccountID: "a-owner", Email: "owner@example.com", Level: "OWNER"},
+			{WorkspaceID: "ws", ID: "bob", AccountID: "a-bob", Email: "bob@example.com", Level: "VIEWER"},
+			{WorkspaceID: "ws", ID: "cat", AccountID: "a-cat", Email: "cat@example.com", Level: "VIEWER"},
+		},
+		requests: map[string]*AccessRequest{},
+	}
+	config := Configuration{DailyNotificationCap: 1}
+
+	as := func(m *Member, name string) Service {
+		s := NewFeatmapService()
+		s.SetRepoObject(repo)
+		s.SetConfig(config)
+		s.SetMemberObject(m)
+		s.SetAccountObject(&Account{ID: m.AccountID, Name: name})
+		s.SetWorkspaceObject(&Workspace{ID: "ws", Name: "acme"})
+		return s
+	}
+	owner, bob, cat := as(repo.members[0], "Owner"), as(repo.members[1], "Bob"), as(repo.members[2], "Cat")
+
+	x, err := bob.RequestAccess("p1", "r1", "EDITOR")
+	if err != nil || x.Status != "PENDING" || x.MemberID != "bob" {
+		t.Fatal("request should be recorded", x, err)
+	}
+	if len(repo.emails) != 1 || repo.emails[0].Email != "owner@example.com" {
+		t.Error("admins should be notified", repo.emails)
+	}
+	if _, err := bob.RequestAccess("p1", "r2", "COMMENTER"); err == nil {
+		t.Error("a second pending request should be rejected")
+	}
+	if _, err := bob.RequestAccess("p1", "r2", "ADMIN"); err == nil {
+		t.Error("only commenter and editor can be requested")
+	}
+	if _, err := owner.RequestAccess("p1", "r2", "EDITOR"); err == nil {
+		t.Error("members with the level already should not request it")
+	}
+
+	if x, err := owner.ApproveAccessRequest("p1", "r1"); err != nil || x.Status != "APPROVED" || x.DecidedByName != "Owner" {
+		t.Error("request should be approved", x, err)
+	}
+	if repo.members[1].Level != "EDITOR" {
+		t.Error("approval should grant the level", repo.members[1].Level)
+	}
+	if _, err := owner.DenyAccessRequest("p1", "r1"); err == nil {
+		t.Error("a decided request should not be decided again")
+	}
+
+	if _, err := cat.RequestAccess("p1", "r3", "COMMENTER"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owner.ApproveAccessRequest("p2", "r3"); err == nil {
+		t.Error("request should only be decided on its project")
+	}
+	if x, err := owner.DenyAccessRequest("p1", "r3"); err != nil || x.Status != "DENIED" {
+		t.Error("request should be denied", x, err)
+	}
+	if repo.members[2].Level != "VIEWER" {
+		t.Error("denial should leave the level as is", repo.members[2].Level)
+	}
+	if _, err := cat.RequestAccess("p1", "r4", "COMMENTER"); err != nil {
+		t.Error("a new request should be possible once the last one was decided", err)
+	}
+
+	repo.members[2].Level = "ADMIN"
+	if _, err := owner.ApproveAccessRequest("p1", "r4"); err == nil {
+		t.Error("a request below the level the member has since been given should not be approved")
+	}
+	if repo.members[2].Level != "ADMIN" || repo.requests["r4"].Status != "PENDING" {
+		t.Error("the member should keep the level", repo.members[2].Level, repo.requests["r4"].Status)
+	}
+}
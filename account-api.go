@@ -13,16 +13,21 @@ func accountAPI(r chi.Router) {
 		r.Use(RequireAccount())
 
 		r.Get("/app", getApp)
+		r.Get("/me", getMe)
 
 		r.Route("/emailupdate/{EMAIL}", func(r chi.Router) {
 			r.Post("/", updateEmail)
 		})
 
 		r.Post("/nameupdate", updateName)
+		r.Post("/localeupdate", updateLocale)
+		r.Post("/workspace-defaults", updateWorkspaceDefaults)
 		r.Post("/resend", resend)
 		r.Post("/delete", deleteAccount)
+		r.Get("/data-export.zip", exportAccountData)
 
 		r.Post("/workspaces", createWorkspace)
+		r.Post("/workspaces/clone", cloneWorkspace)
 
 	})
 }
@@ -51,6 +56,27 @@ func getApp(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func getMe(w http.ResponseWriter, r *http.Request) {
+	type flags struct {
+		Mode           string `json:"mode"`
+		CSRFProtection bool   `json:"csrfProtection"`
+	}
+	type response struct {
+		Account     *Account      `json:"account"`
+		Memberships []*Membership `json:"memberships"`
+		Flags       flags         `json:"flags"`
+	}
+
+	s := GetEnv(r).Service
+	c := s.GetConfig()
+
+	render.JSON(w, r, response{
+		Account:     s.GetAccountObject(),
+		Memberships: s.GetMembershipsByAccount(),
+		Flags:       flags{Mode: c.Mode, CSRFProtection: c.CSRFProtection},
+	})
+}
+
 func updateEmail(w http.ResponseWriter, r *http.Request) {
 	email := chi.URLParam(r, "EMAIL")
 
@@ -87,6 +113,64 @@ func updateName(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+type updateLocaleRequest struct {
+	Locale string `json:"locale"`
+}
+
+func (p *updateLocaleRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func updateLocale(w http.ResponseWriter, r *http.Request) {
+	data := &updateLocaleRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	if err := GetEnv(r).Service.UpdateLocale(data.Locale); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+}
+
+type updateWorkspaceDefaultsRequest struct {
+	workspaceSettings
+}
+
+func (p *updateWorkspaceDefaultsRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func updateWorkspaceDefaults(w http.ResponseWriter, r *http.Request) {
+	data := &updateWorkspaceDefaultsRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	a, err := GetEnv(r).Service.UpdateWorkspaceDefaults(data.workspaceSettings)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, a)
+}
+
+func exportAccountData(w http.ResponseWriter, r *http.Request) {
+	x, err := GetEnv(r).Service.ExportAccountData()
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="featmap-data-export.zip"`)
+	if err := writeAccountExport(w, x); err != nil {
+		log.Println(err)
+	}
+}
+
 func deleteAccount(w http.ResponseWriter, r *http.Request) {
 
 	s := GetEnv(r).Service
@@ -111,7 +195,8 @@ func resend(w http.ResponseWriter, r *http.Request) {
 
 // Workspace
 type createWorkspaceRequest struct {
-	Name string `json:"name"`
+	Name     string            `json:"name"`
+	Settings workspaceSettings `json:"settings"`
 }
 
 func (p *createWorkspaceRequest) Bind(r *http.Request) error {
@@ -125,7 +210,41 @@ func createWorkspace(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s := GetEnv(r).Service
-	workspace, _, _, err := s.CreateWorkspace(data.Name)
+	workspace, _, _, err := s.CreateWorkspace(data.Name, data.Settings)
+	if e, ok := err.(*workspaceLimitError); ok {
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, e)
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, workspace)
+}
+
+type cloneWorkspaceRequest struct {
+	SourceWorkspaceID string `json:"sourceWorkspaceId"`
+	Name              string `json:"name"`
+}
+
+func (p *cloneWorkspaceRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func cloneWorkspace(w http.ResponseWriter, r *http.Request) {
+	data := &cloneWorkspaceRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	workspace, err := GetEnv(r).Service.CloneWorkspace(data.SourceWorkspaceID, data.Name)
+	if e, ok := err.(*workspaceLimitError); ok {
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, e)
+		return
+	}
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+func adminAPI(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(RequireAccount())
+		r.Use(RequireSuperuser())
+
+		r.Get("/stats", getAdminStats)
+		r.Get("/workspaces", getAdminWorkspaces)
+		r.Get("/accounts", getAdminAccounts)
+		r.Get("/accounts/duplicates", getDuplicateAccounts)
+		r.Get("/jobs", getAdminJobs)
+
+		r.Post("/workspaces/{ID}/suspend", suspendWorkspace)
+		r.Post("/workspaces/{ID}/unsuspend", unsuspendWorkspace)
+		r.Post("/accounts/{ID}/reset-password", adminResetPassword)
+		r.Post("/accounts/merge", mergeAccounts)
+	})
+}
+
+func getAdminStats(w http.ResponseWriter, r *http.Request) {
+	x, err := GetEnv(r).Service.AdminGetStats()
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func getAdminJobs(w http.ResponseWriter, r *http.Request) {
+	if jobs == nil {
+		render.JSON(w, r, []scheduledJob{})
+		return
+	}
+	render.JSON(w, r, jobs.status())
+}
+
+func getAdminWorkspaces(w http.ResponseWriter, r *http.Request) {
+	x, err := GetEnv(r).Service.AdminGetWorkspaces()
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func getAdminAccounts(w http.ResponseWriter, r *http.Request) {
+	x, err := GetEnv(r).Service.AdminGetAccounts()
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func setWorkspaceSuspended(w http.ResponseWriter, r *http.Request, suspended bool) {
+	id := chi.URLParam(r, "ID")
+	x, err := GetEnv(r).Service.AdminSetWorkspaceSuspended(id, suspended)
+	if err != nil {
+		_ = render.Render(w, r, ErrNotFound(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func suspendWorkspace(w http.ResponseWriter, r *http.Request) {
+	setWorkspaceSuspended(w, r, true)
+}
+
+func unsuspendWorkspace(w http.ResponseWriter, r *http.Request) {
+	setWorkspaceSuspended(w, r, false)
+}
+
+func adminResetPassword(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	if err := GetEnv(r).Service.AdminResetPassword(id); err != nil {
+		_ = render.Render(w, r, ErrNotFound(err))
+		return
+	}
+	render.Status(r, http.StatusOK)
+}
+
+func getDuplicateAccounts(w http.ResponseWriter, r *http.Request) {
+	x, err := GetEnv(r).Service.AdminGetDuplicateAccounts()
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+type mergeAccountsRequest struct {
+	Email       string `json:"email"`
+	CanonicalID string `json:"canonicalId"`
+}
+
+func (p *mergeAccountsRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func mergeAccounts(w http.ResponseWriter, r *http.Request) {
+	data := &mergeAccountsRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	a, err := GetEnv(r).Service.AdminMergeAccounts(data.Email, data.CanonicalID)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, a)
+}
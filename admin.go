@@ -0,0 +1,90 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// isSuperuser tells if the account is one of the configured superusers. The email has to be
+// verified, otherwise anyone could sign up with a superuser's address before they do.
+func isSuperuser(emails []string, a *Account) bool {
+	if a == nil || !a.EmailConfirmed {
+		return false
+	}
+	for _, e := range emails {
+		if strings.EqualFold(strings.TrimSpace(e), a.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) IsSuperuser() bool {
+	return isSuperuser(s.config.SuperuserEmails, s.Acc)
+}
+
+// auditAdminAction stores who did what through the admin API
+func (s *service) auditAdminAction(action string, target string) {
+	x := &AdminAction{
+		ID:           uuid.Must(uuid.NewV4(), nil).String(),
+		AccountID:    s.Acc.ID,
+		AccountEmail: s.Acc.Email,
+		Action:       action,
+		Target:       target,
+		CreatedAt:    time.Now().UTC(),
+	}
+	s.r.StoreAdminAction(x)
+	log.Printf("admin: %s %s %s", x.AccountEmail, action, target)
+}
+
+func (s *service) AdminGetWorkspaces() ([]*Workspace, error) {
+	s.auditAdminAction("LIST_WORKSPACES", "")
+	return s.r.FindAllWorkspaces()
+}
+
+func (s *service) AdminGetAccounts() ([]*Account, error) {
+	s.auditAdminAction("LIST_ACCOUNTS", "")
+	return s.r.FindAllAccounts()
+}
+
+func (s *service) AdminSetWorkspaceSuspended(id string, suspended bool) (*Workspace, error) {
+	ws, err := s.r.GetWorkspace(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.Suspended = suspended
+	s.r.StoreWorkspace(ws)
+
+	if suspended {
+		s.auditAdminAction("SUSPEND_WORKSPACE", ws.ID)
+	} else {
+		s.auditAdminAction("UNSUSPEND_WORKSPACE", ws.ID)
+	}
+
+	return ws, nil
+}
+
+// AdminResetPassword invalidates any earlier reset link and mails a new one to the account
+func (s *service) AdminResetPassword(accountID string) error {
+	a, err := s.r.GetAccount(accountID)
+	if err != nil {
+		return errors.New("account not found")
+	}
+
+	a.PasswordResetKey = uuid.Must(uuid.NewV4(), nil).String()
+	s.r.StoreAccount(a)
+
+	s.auditAdminAction("RESET_PASSWORD", a.ID)
+
+	return s.SendResetEmail(a.Email)
+}
+
+func (s *service) AdminGetStats() (*InstanceStats, error) {
+	s.auditAdminAction("VIEW_STATS", "")
+	return s.r.GetInstanceStats(time.Now().UTC().AddDate(0, 0, -30))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/jwtauth"
+)
+
+func TestIsSuperuser(t *testing.T) {
+	emails := []string{"ops@example.com", " root@example.com"}
+
+	if isSuperuser(emails, nil) {
+		t.Error("no account should not be a superuser")
+	}
+	if isSuperuser(emails, &Account{Email: "someone@example.com", EmailConfirmed: true}) {
+		t.Error("unlisted account should not be a superuser")
+	}
+	if isSuperuser(emails, &Account{Email: "ops@example.com"}) {
+		t.Error("unverified email should not be a superuser")
+	}
+	if !isSuperuser(emails, &Account{Email: "Root@example.com", EmailConfirmed: true}) {
+		t.Error("listed account should be a superuser")
+	}
+}
+
+func TestRequireSuperuser(t *testing.T) {
+	serve := func(acc *Account) int {
+		s := NewFeatmapService()
+		s.SetConfig(Configuration{SuperuserEmails: []string{"ops@example.com"}})
+		s.SetAccountObject(acc)
+
+		req := httptest.NewRequest("GET", "/v1/admin/stats", nil)
+		req = withService(req, s)
+
+		w := httptest.NewRecorder()
+		RequireSuperuser()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if serve(&Account{Email: "member@example.com", EmailConfirmed: true}) != 403 {
+		t.Error("non-superuser should be rejected")
+	}
+	if serve(&Account{Email: "ops@example.com", EmailConfirmed: true}) != 200 {
+		t.Error("superuser should pass")
+	}
+}
+
+// suspendedRepo holds account a1, a member of workspaces "active" and "suspended"
+type suspendedRepo struct {
+	Repository
+}
+
+func (a *suspendedRepo) GetAccount(id string) (*Account, error) {
+	return &Account{ID: id, Email: "ann@example.com"}, nil
+}
+
+func (a *suspendedRepo) GetMemberByAccountAndWorkspace(accountID string, workspaceID string) (*Member, error) {
+	return &Member{WorkspaceID: workspaceID, ID: "m-" + workspaceID, AccountID: accountID, Level: "EDITOR"}, nil
+}
+
+func (a *suspendedRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Suspended: id == "suspended"}, nil
+}
+
+func (a *suspendedRepo) FindSubscriptionsByWorkspace(id string) ([]*Subscription, error) {
+	return []*Subscription{{WorkspaceID: id, Status: "active"}}, nil
+}
+
+func TestSuspendedWorkspaceBlocksMembers(t *testing.T) {
+	serve := func(workspaceID string) int {
+		s := NewFeatmapService()
+		s.SetRepoObject(&suspendedRepo{})
+
+		req := httptest.NewRequest("GET", "/v1/projects", nil)
+		req.Header.Set("Workspace", workspaceID)
+		ctx := jwtauth.NewContext(req.Context(), &jwt.Token{Claims: jwt.MapClaims{"id": "a1"}}, nil)
+		req = withService(req.WithContext(ctx), s)
+
+		w := httptest.NewRecorder()
+		User()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve("suspended"); code != 403 {
+		t.Error("members of a suspended workspace should be blocked", code)
+	}
+	if code := serve("active"); code != 200 {
+		t.Error("members of an active workspace should pass", code)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// defaultArchiveRetentionDays is how long the export of a deleted project is kept unless
+// projectArchiveRetentionDays says otherwise
+const defaultArchiveRetentionDays = 30
+
+func (s *service) archiveRetention() time.Duration {
+	days := s.config.ProjectArchiveRetentionDays
+	if days <= 0 {
+		days = defaultArchiveRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func (s *service) ChangeArchiveDeletedProjects(value bool) error {
+	w := s.GetWorkspaceByContext()
+
+	w.ArchiveDeletedProjects = value
+
+	s.r.StoreWorkspace(w)
+
+	return nil
+}
+
+// archiveProject stores an export of the project, in the same transaction as its deletion so a
+// project is never deleted without its archive
+func (s *service) archiveProject(id string) error {
+	p, err := s.r.GetProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		return errors.New("project not found")
+	}
+
+	content, err := s.projectContent(p)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	s.r.StoreProjectArchive(&ProjectArchive{
+		WorkspaceID:   p.WorkspaceID,
+		ID:            uuid.Must(uuid.NewV4(), nil).String(),
+		ProjectID:     p.ID,
+		ProjectTitle:  p.Title,
+		CreatedAt:     time.Now().UTC(),
+		CreatedByName: s.Acc.Name,
+		Size:          len(data),
+		Data:          data,
+	})
+
+	return nil
+}
+
+// GetProjectArchives lists the archives of the workspace that are within the retention window
+func (s *service) GetProjectArchives() []*ProjectArchive {
+	aa, err := s.r.FindProjectArchivesByWorkspace(s.Member.WorkspaceID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	since := time.Now().UTC().Add(-s.archiveRetention())
+	x := []*ProjectArchive{}
+	for _, a := range aa {
+		if a.CreatedAt.After(since) {
+			x = append(x, a)
+		}
+	}
+	return x
+}
+
+func (s *service) GetProjectArchive(id string) (*ProjectArchive, error) {
+	a, err := s.r.GetProjectArchive(s.Member.WorkspaceID, id)
+	if err != nil || a.CreatedAt.Before(time.Now().UTC().Add(-s.archiveRetention())) {
+		return nil, errors.New("archive not found")
+	}
+	return a, nil
+}
+
+// PurgeProjectArchives deletes the archives of all workspaces that are past the retention window.
+// It runs outside of a request.
+func (s *service) PurgeProjectArchives(now time.Time) {
+	s.r.DeleteProjectArchivesBefore(now.Add(-s.archiveRetention()))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// archiveRepo holds one project of workspace "ws" and the archives taken of it
+type archiveRepo struct {
+	Repository
+	archive  bool
+	deleted  bool
+	archives []*ProjectArchive
+}
+
+func (a *archiveRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Name: "acme", ArchiveDeletedProjects: a.archive}, nil
+}
+
+func (a *archiveRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	if id != "p1" || a.deleted {
+		return nil, errNotFound
+	}
+	return &Project{WorkspaceID: workspaceID, ID: id, Title: "Roadmap"}, nil
+}
+
+func (a *archiveRepo) FindMilestonesByProject(workspaceID string, projectID string) ([]*Milestone, error) {
+	return []*Milestone{{WorkspaceID: workspaceID, ProjectID: projectID, ID: "m1", Title: "MVP"}}, nil
+}
+
+func (a *archiveRepo) FindWorkflowsByProject(workspaceID string, projectID string) ([]*Workflow, error) {
+	return []*Workflow{}, nil
+}
+
+func (a *archiveRepo) FindSubWorkflowsByProject(workspaceID string, projectID string) ([]*SubWorkflow, error) {
+	return []*SubWorkflow{}, nil
+}
+
+func (a *archiveRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	return []*Feature{{WorkspaceID: workspaceID, ID: "f1", MilestoneID: "m1", Title: "Login"}}, nil
+}
+
+func (a *archiveRepo) FindFeatureCommentsByProject(workspaceID string, projectID string) ([]*FeatureComment, error) {
+	return []*FeatureComment{}, nil
+}
+
+func (a *archiveRepo) FindPersonasByProject(workspaceID string, projectID string) ([]*Persona, error) {
+	return []*Persona{}, nil
+}
+
+func (a *archiveRepo) FindWorkflowPersonasByProject(workspaceID string, projectID string) ([]*WorkflowPersona, error) {
+	return []*WorkflowPersona{}, nil
+}
+
+func (a *archiveRepo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	return []*ProjectStatus{}, nil
+}
+
+func (a *archiveRepo) DeleteProject(workspaceID string, projectID string) {
+	a.deleted = true
+}
+
+func (a *archiveRepo) StoreProjectArchive(x *ProjectArchive) {
+	a.archives = append(a.archives, x)
+}
+
+func (a *archiveRepo) GetProjectArchive(workspaceID string, id string) (*ProjectArchive, error) {
+	for _, x := range a.archives {
+		if x.ID == id {
+			return x, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *archiveRepo) FindProjectArchivesByWorkspace(workspaceID string) ([]*ProjectArchive, error) {
+	return a.archives, nil
+}
+
+func TestArchiveDeletedProject(t *testing.T) {
+	repo := &archiveRepo{}
+
+	s := memberService(repo, "ADMIN")
+
+	if err := s.DeleteProject("p1"); err != nil || !repo.deleted || len(repo.archives) != 0 {
+		t.Error("projects should not be archived unless the workspace opted in", err, len(repo.archives))
+	}
+
+	repo.archive, repo.deleted = true, false
+	if err := s.DeleteProject("p1"); err != nil || !repo.deleted {
+		t.Fatal("project should be deleted", err)
+	}
+
+	aa := s.GetProjectArchives()
+	if len(aa) != 1 || aa[0].ProjectID != "p1" || aa[0].CreatedByName != "ann" {
+		t.Fatal("the deleted project should be archived", aa)
+	}
+
+	a, err := s.GetProjectArchive(aa[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := &projectResponse{}
+	if err := json.Unmarshal(a.Data, x); err != nil {
+		t.Fatal(err)
+	}
+	if x.Project.Title != "Roadmap" || len(x.Milestones) != 1 || len(x.Features) != 1 || x.Features[0].Title != "Login" {
+		t.Error("the archive should hold the content of the project", string(a.Data))
+	}
+
+	a.CreatedAt = time.Now().UTC().AddDate(0, 0, -(defaultArchiveRetentionDays + 1))
+	if _, err := s.GetProjectArchive(a.ID); err == nil || len(s.GetProjectArchives()) != 0 {
+		t.Error("archives past the retention window should not be retrievable")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// autoJoinRepo holds workspace "acme", which auto-joins example.com as EDITOR, and the accounts
+// waiting for their confirmation keys
+type autoJoinRepo struct {
+	Repository
+	accounts map[string]*Account
+	members  []*Member
+}
+
+func (a *autoJoinRepo) GetAccountByConfirmationKey(key string) (*Account, error) {
+	if x, ok := a.accounts[key]; ok {
+		return x, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *autoJoinRepo) GetAccountByEmail(email string) (*Account, error) { return nil, errNotFound }
+
+func (a *autoJoinRepo) StoreAccount(x *Account) {}
+
+func (a *autoJoinRepo) FindWorkspacesByAutoJoinDomain(domain string) ([]*Workspace, error) {
+	w := &Workspace{ID: "acme", Name: "acme", AutoJoinDomains: "example.org,example.com", AutoJoinLevel: "EDITOR"}
+	for _, d := range strings.Split(w.AutoJoinDomains, ",") {
+		if d == domain {
+			return []*Workspace{w}, nil
+		}
+	}
+	return []*Workspace{}, nil
+}
+
+func (a *autoJoinRepo) GetMemberByAccountAndWorkspace(accountID string, workspaceID string) (*Member, error) {
+	for _, m := range a.members {
+		if m.AccountID == accountID && m.WorkspaceID == workspaceID {
+			return m, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *autoJoinRepo) FindSubscriptionsByWorkspace(id string) ([]*Subscription, error) {
+	return []*Subscription{{WorkspaceID: id, Level: "PRO", NumberOfEditors: 10}}, nil
+}
+
+func (a *autoJoinRepo) FindMembersByWorkspace(id string) ([]*Member, error) { return a.members, nil }
+
+func (a *autoJoinRepo) StoreMember(x *Member) { a.members = append(a.members, x) }
+
+func TestAutoJoinOnConfirmation(t *testing.T) {
+	pending := func(id string, email string) *Account {
+		return &Account{ID: id, Email: email, EmailConfirmationSentTo: email, EmailConfirmationPending: true}
+	}
+	repo := &autoJoinRepo{accounts: map[string]*Account{
+		"k-ann": pending("ann", "Ann@Example.com"),
+		"k-bob": pending("bob", "bob@elsewhere.com"),
+		"k-cy":  pending("cy", "cy@example.com"),
+	}}
+	repo.members = []*Member{{WorkspaceID: "acme", AccountID: "cy", Level: "VIEWER"}}
+
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+
+	s.(*service).autoJoinWorkspaces(&Account{ID: "dan", Email: "dan@example.com"})
+	if len(repo.members) != 1 {
+		t.Fatal("an account should not join before its email is confirmed", repo.members)
+	}
+
+	for _, key := range []string{"k-ann", "k-bob", "k-cy"} {
+		if err := s.ConfirmEmail(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(repo.members) != 2 {
+		t.Fatal("only the new account with a matching domain should join", repo.members)
+	}
+	if m := repo.members[1]; m.AccountID != "ann" || m.WorkspaceID != "acme" || m.Level != "EDITOR" {
+		t.Error("a confirmed account should join with the default level", m)
+	}
+
+	// Confirming again changes nothing
+	repo.accounts["k-ann"].EmailConfirmationPending = true
+	if err := s.ConfirmEmail("k-ann"); err != nil || len(repo.members) != 2 {
+		t.Error("an account should not join a workspace twice", repo.members)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// autosaveDedup remembers the last value written to a field of an entity, so that the
+// identical updates fired by autosave right after can be skipped. It is per process, which is
+// enough since a skipped update never loses a change.
+type autosaveDedup struct {
+	mu    sync.Mutex
+	saves map[string]autosave
+}
+
+type autosave struct {
+	value string
+	at    time.Time
+}
+
+var autosaves = newAutosaveDedup()
+
+func newAutosaveDedup() *autosaveDedup {
+	return &autosaveDedup{saves: map[string]autosave{}}
+}
+
+// repeated tells if value was saved to key within the window
+func (d *autosaveDedup) repeated(key string, value string, window time.Duration, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	x, ok := d.saves[key]
+	return ok && x.value == value && now.Sub(x.at) <= window
+}
+
+// record remembers a save, and forgets the ones older than the window
+func (d *autosaveDedup) record(key string, value string, window time.Duration, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for k, x := range d.saves {
+		if now.Sub(x.at) > window {
+			delete(d.saves, k)
+		}
+	}
+	d.saves[key] = autosave{value: value, at: now}
+}
+
+// autosaved tells if an update of a field can be skipped: the field already has the value and
+// the same update was just made. Updates that change the value are always written.
+func (s *service) autosaved(kind string, id string, field string, current string, value string) bool {
+	window := time.Duration(s.config.AutosaveDedupWindowMs) * time.Millisecond
+	if window <= 0 {
+		return false
+	}
+
+	key := s.Member.WorkspaceID + "/" + kind + "/" + id + "/" + field
+	now := time.Now()
+	if current == value && autosaves.repeated(key, value, window, now) {
+		return true
+	}
+	autosaves.record(key, value, window, now)
+	return false
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// autosaveRepo holds one feature and counts the writes to it
+type autosaveRepo struct {
+	Repository
+	feature *Feature
+	writes  int
+}
+
+func (a *autosaveRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	c := *a.feature
+	return &c, nil
+}
+
+func (a *autosaveRepo) StoreFeature(x *Feature) {
+	a.feature = x
+	a.writes++
+}
+
+func (a *autosaveRepo) StoreFeatureEvent(x *FeatureEvent) {}
+
+func TestAutosaveDedup(t *testing.T) {
+	autosaves = newAutosaveDedup()
+	repo := &autosaveRepo{feature: &Feature{ID: "f1", Title: "Login"}}
+	s := memberService(repo, "EDITOR")
+	s.SetConfig(Configuration{AutosaveDedupWindowMs: 60000})
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.UpdateFeatureDescription("f1", "draft"); err != nil {
+			t.Error(err)
+		}
+	}
+	if repo.writes != 1 {
+		t.Error("three identical updates should be written once", repo.writes)
+	}
+	if f, _ := s.UpdateFeatureDescription("f1", "final"); f.Description != "final" || repo.writes != 2 {
+		t.Error("a changed value should be written", repo.writes)
+	}
+	s.RenameFeature("f1", "Login")
+	s.RenameFeature("f1", "Login")
+	if repo.writes != 3 {
+		t.Error("fields should be deduplicated separately", repo.writes)
+	}
+
+	autosaves.record("ws/feature/f1/description", "final", time.Minute, time.Now().Add(-2*time.Minute))
+	if _, err := s.UpdateFeatureDescription("f1", "final"); err != nil || repo.writes != 4 {
+		t.Error("an identical update after the window should be written", repo.writes)
+	}
+
+	s.SetConfig(Configuration{})
+	s.UpdateFeatureDescription("f1", "final")
+	s.UpdateFeatureDescription("f1", "final")
+	if repo.writes != 6 {
+		t.Error("every update should be written without a window", repo.writes)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Events on a card a workspace can batch the notifications of
+var batchableEvents = []string{"comments", "status"}
+
+// maxBatchWindowMinutes keeps a batch from holding notifications back for more than a day
+const maxBatchWindowMinutes = 24 * 60
+
+// parseBatchWindows reads the batching windows of a workspace, such as comments=5,status=10,
+// with the window of each event in minutes
+func parseBatchWindows(value string) (map[string]int, error) {
+	x := map[string]int{}
+	if value == "" {
+		return x, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 || !stringInSlice(kv[0], batchableEvents) {
+			return nil, errors.New("invalid event")
+		}
+		m, err := strconv.Atoi(kv[1])
+		if err != nil || m < 0 || m > maxBatchWindowMinutes {
+			return nil, errors.New("invalid window")
+		}
+		x[kv[0]] = m
+	}
+
+	return x, nil
+}
+
+// batchWindow returns how long notifications of an event are batched, or zero if they are not
+func batchWindow(windows string, event string) time.Duration {
+	x, err := parseBatchWindows(windows)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(x[event]) * time.Minute
+}
+
+func (s *service) ChangeNotificationBatchWindows(value string) error {
+	x, err := parseBatchWindows(strings.ReplaceAll(value, " ", ""))
+	if err != nil {
+		return err
+	}
+
+	parts := []string{}
+	for event, m := range x {
+		if m > 0 {
+			parts = append(parts, event+"="+strconv.Itoa(m))
+		}
+	}
+	sort.Strings(parts)
+
+	w := s.GetWorkspaceByContext()
+	w.NotificationBatchWindows = strings.Join(parts, ",")
+	s.r.StoreWorkspace(w)
+
+	return nil
+}
+
+// notifyBatched sends the first notification of a key right away and holds back the ones that
+// follow within the window, to be sent together once it ends
+func (s *service) notifyBatched(n *NotificationEmail, key string, window time.Duration, send func(n *NotificationEmail)) {
+	n.BatchKey = key
+
+	now := time.Now().UTC()
+	x, _ := s.r.GetLatestNotificationEmailByBatchKey(n.AccountID, key)
+	if x == nil || !now.Before(x.CreatedAt.Add(window)) {
+		s.notify(n, send)
+		return
+	}
+
+	until := x.CreatedAt.Add(window)
+	n.ID = uuid.Must(uuid.NewV4(), nil).String()
+	n.CreatedAt = now
+	n.BatchUntil = &until
+	s.r.StoreNotificationEmail(n)
+}
+
+// SendNotificationBatches mails one summary per batch whose window has ended. It runs outside of
+// a request.
+func (s *service) SendNotificationBatches(now time.Time) int {
+	return s.sendNotificationBatches(now, s.sendNotificationEmail)
+}
+
+func (s *service) sendNotificationBatches(now time.Time, send func(n *NotificationEmail)) int {
+	nn, err := s.r.FindHeldNotificationEmailsBefore(now)
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
+	batches := map[string][]*NotificationEmail{}
+	order := []string{}
+	for _, n := range nn {
+		k := n.AccountID + "/" + n.BatchKey
+		if _, ok := batches[k]; !ok {
+			order = append(order, k)
+		}
+		batches[k] = append(batches[k], n)
+	}
+
+	for _, k := range order {
+		items := batches[k]
+		body, err := batchBody(items)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		s.notify(&NotificationEmail{AccountID: items[0].AccountID, Email: items[0].Email, Subject: fmt.Sprintf("%s (%d more updates)", items[0].Subject, len(items)), Body: body, BatchKey: items[0].BatchKey}, send)
+	}
+
+	s.r.DeleteHeldNotificationEmailsBefore(now)
+
+	return len(order)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// batchRepo keeps notification emails in memory, batched ones included
+type batchRepo struct {
+	notificationRepo
+}
+
+func (a *batchRepo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	n := 0
+	for _, x := range a.emails {
+		if x.AccountID == accountID && !x.CreatedAt.Before(t) && !x.Digest && x.BatchUntil == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (a *batchRepo) GetLatestNotificationEmailByBatchKey(accountID string, key string) (*NotificationEmail, error) {
+	var latest *NotificationEmail
+	for _, x := range a.emails {
+		if x.AccountID == accountID && x.BatchKey == key && x.BatchUntil == nil {
+			latest = x
+		}
+	}
+	if latest == nil {
+		return nil, errNotFound
+	}
+	return latest, nil
+}
+
+func (a *batchRepo) FindHeldNotificationEmailsBefore(t time.Time) ([]*NotificationEmail, error) {
+	x := []*NotificationEmail{}
+	for _, n := range a.emails {
+		if n.BatchUntil != nil && !n.BatchUntil.After(t) {
+			x = append(x, n)
+		}
+	}
+	return x, nil
+}
+
+func (a *batchRepo) DeleteHeldNotificationEmailsBefore(t time.Time) {
+	x := []*NotificationEmail{}
+	for _, n := range a.emails {
+		if n.BatchUntil == nil || n.BatchUntil.After(t) {
+			x = append(x, n)
+		}
+	}
+	a.emails = x
+}
+
+func TestNotificationBatching(t *testing.T) {
+	repo := &batchRepo{}
+
+	s := &service{}
+	s.SetRepoObject(repo)
+
+	sent := []*NotificationEmail{}
+	send := func(n *NotificationEmail) { sent = append(sent, n) }
+
+	for _, post := range []string{"first", "second", "third"} {
+		s.notifyBatched(&NotificationEmail{AccountID: "ann", Email: "ann@example.com", Subject: "Featmap: Checkout", Body: "Bob commented: " + post}, "comments:f1", 5*time.Minute, send)
+	}
+	s.notifyBatched(&NotificationEmail{AccountID: "ann", Email: "ann@example.com", Subject: "Featmap: Search", Body: "Bob commented: other"}, "comments:f2", 5*time.Minute, send)
+
+	if len(sent) != 2 || !strings.HasSuffix(sent[0].Body, "first") || !strings.HasSuffix(sent[1].Body, "other") {
+		t.Fatal("the first notification of each card should be sent right away", len(sent))
+	}
+
+	if n := s.sendNotificationBatches(time.Now().UTC(), send); n != 0 || len(sent) != 2 {
+		t.Error("a batch should be held until its window ends", n)
+	}
+
+	if n := s.sendNotificationBatches(time.Now().UTC().Add(10*time.Minute), send); n != 1 || len(sent) != 3 {
+		t.Fatal("the rapid comments should be sent as one batch after the window", n, len(sent))
+	}
+
+	batch := sent[2]
+	if batch.Subject != "Featmap: Checkout (2 more updates)" {
+		t.Error("wrong subject", batch.Subject)
+	}
+	if !strings.Contains(batch.Body, "second") || !strings.Contains(batch.Body, "third") || strings.Contains(batch.Body, "first") {
+		t.Error("the batch should hold the comments after the first", batch.Body)
+	}
+
+	if n := s.sendNotificationBatches(time.Now().UTC().Add(20*time.Minute), send); n != 0 {
+		t.Error("a batch should be sent once", n)
+	}
+}
+
+func TestNotificationBatchingWithinCap(t *testing.T) {
+	repo := &batchRepo{}
+
+	s := &service{}
+	s.SetConfig(Configuration{DailyNotificationCap: 2})
+	s.SetRepoObject(repo)
+
+	sent := 0
+	send := func(n *NotificationEmail) { sent++ }
+
+	for i := 0; i < 5; i++ {
+		s.notifyBatched(&NotificationEmail{AccountID: "ann", Subject: "Featmap: Checkout"}, "comments:f1", 5*time.Minute, send)
+	}
+	s.notify(&NotificationEmail{AccountID: "ann", Subject: "Featmap: Search"}, send)
+
+	if sent != 2 {
+		t.Error("held notifications should not count against the daily cap", sent)
+	}
+}
+
+func TestParseBatchWindows(t *testing.T) {
+	x, err := parseBatchWindows("comments=5,status=10")
+	if err != nil || x["comments"] != 5 || x["status"] != 10 {
+		t.Error("wrong windows", x, err)
+	}
+
+	for _, v := range []string{"comments", "likes=5", "comments=-1", "comments=x", "comments=100000"} {
+		if _, err := parseBatchWindows(v); err == nil {
+			t.Error("should be invalid", v)
+		}
+	}
+
+	if batchWindow("comments=5", "comments") != 5*time.Minute || batchWindow("comments=5", "status") != 0 || batchWindow("", "comments") != 0 {
+		t.Error("wrong batch window")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const defaultBootstrapWorkspace = "example"
+
+// bootstrapOutline is the story map of the project a bootstrapped workspace starts with
+var bootstrapOutline = outline{Milestones: []*outlineMilestone{
+	{Title: "MVP", Columns: []*outlineColumn{
+		{Title: "Browse products", Cards: []string{"List products", "Show product details"}},
+		{Title: "Check out", Cards: []string{"Add to cart", "Pay by card"}},
+	}},
+	{Title: "Later", Columns: []*outlineColumn{
+		{Title: "Browse products", Cards: []string{"Search products"}},
+		{Title: "Check out", Cards: []string{"Discount codes"}},
+	}},
+}}
+
+// Bootstrap sets up a fresh instance that has no accounts yet: an owner account with the
+// configured email, a workspace and a sample project. Without a configured password one is
+// generated and logged. It runs once at startup, outside of a request.
+func (s *service) Bootstrap() bool {
+	n, err := s.r.CountAccounts()
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	if n > 0 {
+		return false
+	}
+
+	name := s.config.BootstrapWorkspace
+	if name == "" {
+		name = defaultBootstrapWorkspace
+	}
+	password := s.config.BootstrapPassword
+	if password == "" {
+		password = uuid.Must(uuid.NewV4(), nil).String()
+	}
+
+	ws, acc, _, err := s.signUp(name, "Admin", s.config.BootstrapEmail, password)
+	if err != nil {
+		log.Printf("bootstrap failed: %v", err)
+		return false
+	}
+
+	acc.EmailConfirmed = true
+	acc.EmailConfirmationPending = false
+	s.r.StoreAccount(acc)
+
+	o := bootstrapOutline
+	if _, err := s.ImportOutline("Example project", &o); err != nil {
+		log.Printf("bootstrap: no sample project: %v", err)
+	}
+
+	if s.config.BootstrapPassword == "" {
+		log.Printf("bootstrap: created account %s with password %s in workspace %s", acc.Email, password, ws.Name)
+	} else {
+		log.Printf("bootstrap: created account %s in workspace %s", acc.Email, ws.Name)
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+// bootstrapRepo is an instance with the given number of accounts
+type bootstrapRepo struct {
+	importRepo
+	accounts   int
+	stored     []*Account
+	workspaces []*Workspace
+	members    []*Member
+}
+
+func (a *bootstrapRepo) CountAccounts() (int, error) { return a.accounts, nil }
+
+func (a *bootstrapRepo) GetAccountByEmail(email string) (*Account, error) { return nil, errNotFound }
+
+func (a *bootstrapRepo) GetWorkspaceByName(name string) (*Workspace, error) { return nil, errNotFound }
+
+func (a *bootstrapRepo) StoreAccount(x *Account) { a.stored = append(a.stored, x) }
+
+func (a *bootstrapRepo) StoreWorkspace(x *Workspace) { a.workspaces = append(a.workspaces, x) }
+
+func (a *bootstrapRepo) StoreSubscription(x *Subscription) {}
+
+func (a *bootstrapRepo) StoreMember(x *Member) { a.members = append(a.members, x) }
+
+func newBootstrapRepo(accounts int) *bootstrapRepo {
+	return &bootstrapRepo{
+		importRepo: importRepo{projects: map[string]*Project{}, milestones: map[string]*Milestone{}, subWorkflows: map[string]*SubWorkflow{}, features: map[string]*Feature{}},
+		accounts:   accounts,
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	repo := newBootstrapRepo(0)
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{BootstrapEmail: "Admin@Example.com", BootstrapPassword: "secret123"})
+	s.SetRepoObject(repo)
+
+	if !s.Bootstrap() {
+		t.Fatal("bootstrap should run on an instance without accounts")
+	}
+
+	if len(repo.workspaces) != 1 || repo.workspaces[0].Name != defaultBootstrapWorkspace {
+		t.Error("should create the workspace", repo.workspaces)
+	}
+	acc := repo.stored[len(repo.stored)-1]
+	if acc.Email != "admin@example.com" || !acc.EmailConfirmed || acc.EmailConfirmationPending {
+		t.Error("should create a confirmed account", acc)
+	}
+	if len(repo.members) != 1 || repo.members[0].Level != "OWNER" || repo.members[0].AccountID != acc.ID {
+		t.Error("the account should own the workspace", repo.members)
+	}
+	if len(repo.projects) != 1 || len(repo.milestones) != 2 || len(repo.features) != 6 {
+		t.Error("should create the example project", len(repo.projects), len(repo.milestones), len(repo.features))
+	}
+}
+
+func TestBootstrapSkipped(t *testing.T) {
+	repo := newBootstrapRepo(1)
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{BootstrapEmail: "admin@example.com"})
+	s.SetRepoObject(repo)
+
+	if s.Bootstrap() || len(repo.stored) != 0 || len(repo.workspaces) != 0 {
+		t.Error("bootstrap should be skipped when there are accounts")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// bulkRepo is placementRepo with one feature, ranked "n", already in every cell
+type bulkRepo struct {
+	placementRepo
+}
+
+func (a *bulkRepo) FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, mid string, swid string) ([]*Feature, error) {
+	return []*Feature{{ID: "existing", MilestoneID: mid, SubWorkflowID: swid, Rank: "n"}}, nil
+}
+
+func TestCreateFeatures(t *testing.T) {
+	repo := &bulkRepo{placementRepo{project: &Project{WorkspaceID: "ws", ID: "p1"}}}
+	s := memberService(repo, "EDITOR")
+	s.SetConfig(Configuration{MaxFeaturesPerCell: 4})
+
+	ff, err := s.CreateFeatures("m1", "s1", []*newFeature{{Title: "One"}, {Title: "Two", Color: "RED"}, {Title: "Three"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := "n"
+	for i, f := range ff {
+		if f.Title != []string{"One", "Two", "Three"}[i] || f.MilestoneID != "m1" || f.SubWorkflowID != "s1" {
+			t.Error("features should be returned in the order given", f)
+		}
+		if f.Rank <= prev {
+			t.Error("features should be ranked in order after the existing ones", prev, f.Rank)
+		}
+		prev = f.Rank
+	}
+	if ff[0].Color != "WHITE" || ff[1].Color != "RED" {
+		t.Error("colors should default to white", ff[0].Color, ff[1].Color)
+	}
+	if len(repo.stored) != 3 || repo.stored[0] != ff[0] || repo.stored[2] != ff[2] {
+		t.Error("the features should be stored in order", repo.stored)
+	}
+
+	repo.stored = nil
+	if _, err := s.CreateFeatures("m1", "s1", []*newFeature{{Title: "Four"}, {Title: "Five"}, {Title: "Six"}, {Title: "Seven"}}); err == nil {
+		t.Error("features past the cap of the cell should be rejected")
+	}
+	if _, err := s.CreateFeatures("m1", "s1", []*newFeature{{Title: "Four"}, {Title: ""}}); err == nil {
+		t.Error("a feature without a title should be rejected")
+	}
+	if _, err := s.CreateFeatures("other", "s1", []*newFeature{{Title: "Four"}}); err == nil {
+		t.Error("a subworkflow of another project should be rejected")
+	}
+	if len(repo.stored) != 0 {
+		t.Error("nothing should be stored when the request is rejected", repo.stored)
+	}
+}
+
+func TestCreateFeaturesRepeatedID(t *testing.T) {
+	repo := &bulkRepo{placementRepo{project: &Project{WorkspaceID: "ws", ID: "p1"}}}
+	s := memberService(repo, "EDITOR")
+
+	if _, err := s.CreateFeatures("m1", "s1", []*newFeature{{ID: "f1", Title: "One"}, {ID: "f1", Title: "Two"}}); err != errRepeatedFeatureID {
+		t.Error("an ID repeated in the batch should be rejected", err)
+	}
+	if len(repo.stored) != 0 {
+		t.Error("nothing should be stored when an ID is repeated", repo.stored)
+	}
+
+	w := serveWith(s, "/v1/", workspaceAPI, jsonRequest("POST", "/v1/milestones/m1/features/bulk",
+		`{"subWorkflowId":"s1","features":[{"id":"f2","title":"One"},{"id":"f2","title":"Two"}]}`))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Error("a repeated ID should answer 422", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "time"
+
+type burndownPoint struct {
+	Date      string `json:"date"`
+	Remaining int    `json:"remaining"`
+	Open      int    `json:"open"`
+}
+
+// burndown replays feature events and returns, for each day from..to in loc, the open
+// estimate in the milestone at the end of that day.
+func burndown(events []*FeatureEvent, milestoneID string, from time.Time, to time.Time, loc *time.Location) []*burndownPoint {
+	state := map[string]*FeatureEvent{}
+	points := []*burndownPoint{}
+
+	from = from.In(loc)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	i := 0
+
+	for !day.After(to) {
+		end := day.AddDate(0, 0, 1)
+		for ; i < len(events) && events[i].CreatedAt.Before(end); i++ {
+			state[events[i].FeatureID] = events[i]
+		}
+
+		p := &burndownPoint{Date: day.Format("2006-01-02")}
+		for _, e := range state {
+			if e.MilestoneID == milestoneID && e.Status == "OPEN" {
+				p.Remaining += e.Estimate
+				p.Open++
+			}
+		}
+		points = append(points, p)
+
+		day = end
+	}
+
+	return points
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBurndown(t *testing.T) {
+	day := func(d int, h int) time.Time { return time.Date(2020, 3, d, h, 0, 0, 0, time.UTC) }
+
+	events := []*FeatureEvent{
+		{FeatureID: "a", MilestoneID: "m", Status: "OPEN", Estimate: 3, CreatedAt: day(1, 9)},
+		{FeatureID: "b", MilestoneID: "m", Status: "OPEN", Estimate: 5, CreatedAt: day(1, 10)},
+		{FeatureID: "c", MilestoneID: "other", Status: "OPEN", Estimate: 8, CreatedAt: day(1, 11)},
+		{FeatureID: "a", MilestoneID: "m", Status: "CLOSED", Estimate: 3, CreatedAt: day(2, 15)},
+		{FeatureID: "c", MilestoneID: "m", Status: "OPEN", Estimate: 8, CreatedAt: day(3, 8)},
+		{FeatureID: "b", MilestoneID: "m", Status: "CLOSED", Estimate: 5, CreatedAt: day(4, 23)},
+	}
+
+	points := burndown(events, "m", day(1, 0), day(5, 12), time.UTC)
+
+	expected := []int{8, 5, 13, 8, 8}
+	if len(points) != len(expected) {
+		t.Fatal("expected one point per day, got", len(points))
+	}
+	for i, p := range points {
+		if p.Remaining != expected[i] {
+			t.Error(p.Date, "expected", expected[i], "got", p.Remaining)
+		}
+	}
+
+	// 23:00 UTC on the 4th is already the 5th in Oslo
+	oslo, _ := time.LoadLocation("Europe/Oslo")
+	points = burndown(events, "m", day(1, 0), day(5, 12), oslo)
+	if points[3].Remaining != 13 || points[4].Remaining != 8 {
+		t.Error("days should be bucketed in the given time zone")
+	}
+}
+
+// burndownRepo holds the events of milestone m and workspace ws
+type burndownRepo struct {
+	Repository
+	workspace *Workspace
+	events    []*FeatureEvent
+}
+
+func (a *burndownRepo) GetWorkspace(id string) (*Workspace, error) { return a.workspace, nil }
+
+func (a *burndownRepo) StoreWorkspace(x *Workspace) { a.workspace = x }
+
+func (a *burndownRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *burndownRepo) FindFeatureEventsByMilestone(workspaceID string, milestoneID string) ([]*FeatureEvent, error) {
+	return a.events, nil
+}
+
+func TestBurndownWorkspaceTimezone(t *testing.T) {
+	repo := &burndownRepo{
+		workspace: &Workspace{ID: "ws"},
+		events: []*FeatureEvent{
+			{FeatureID: "a", MilestoneID: "m-tz", Status: "OPEN", Estimate: 3, CreatedAt: time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC)},
+			{FeatureID: "a", MilestoneID: "m-tz", Status: "CLOSED", Estimate: 3, CreatedAt: time.Date(2020, 3, 2, 23, 0, 0, 0, time.UTC)},
+		},
+	}
+	s := memberService(repo, "ADMIN")
+
+	if err := s.ChangeTimezone("Mars/Olympus"); err == nil {
+		t.Error("an unknown time zone should be rejected")
+	}
+	if err := s.ChangeTimezone("Europe/Oslo"); err != nil || repo.workspace.Timezone != "Europe/Oslo" {
+		t.Fatal("the time zone should be stored", err)
+	}
+	s.SetWorkspaceObject(repo.workspace)
+
+	remaining := func(query string) map[string]int {
+		w := serveWith(s, "/v1/", workspaceAPI, httptest.NewRequest("GET", "/v1/milestones/m-tz/burndown?from=2020-03-01&to=2020-03-03"+query, nil))
+		if w.Code != 200 {
+			t.Fatal(w.Code, w.Body.String())
+		}
+		points := []*burndownPoint{}
+		if err := json.Unmarshal(w.Body.Bytes(), &points); err != nil {
+			t.Fatal(err)
+		}
+		x := map[string]int{}
+		for _, p := range points {
+			x[p.Date] = p.Remaining
+		}
+		return x
+	}
+
+	// 23:00 UTC on the 2nd is already the 3rd in Oslo
+	if x := remaining(""); x["2020-03-02"] != 3 || x["2020-03-03"] != 0 {
+		t.Error("days should be counted in the time zone of the workspace", x)
+	}
+	if x := remaining("&tz=UTC"); x["2020-03-02"] != 0 {
+		t.Error("the tz parameter should override the time zone of the workspace", x)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// defaultAggregateCacheTTL is how long an aggregate is served from the cache unless configured
+const defaultAggregateCacheTTL = 30 * time.Second
+
+// maxAggregateCacheEntries is the size at which expired entries are swept out of the cache
+const maxAggregateCacheEntries = 10000
+
+// aggregateCache keeps the rendered responses of the read-only aggregate endpoints, such as
+// rollups and burndowns. Keys start with the workspace ID, so all of a workspace can be dropped
+// at once. A shared store can take the place of the memory one when there is more than one
+// instance.
+type aggregateCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	InvalidateWorkspace(workspaceID string)
+}
+
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+var aggregates aggregateCache = newMemoryCache()
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: map[string]cacheEntry{}, now: time.Now}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxAggregateCacheEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cacheEntry{value: value, expires: now.Add(ttl)}
+}
+
+func (c *memoryCache) InvalidateWorkspace(workspaceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if strings.HasPrefix(k, workspaceID+"/") {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// aggregateCacheTTL returns how long aggregates are cached, zero if caching is turned off
+func aggregateCacheTTL(c Configuration) time.Duration {
+	switch {
+	case c.AggregateCacheTTLSeconds < 0:
+		return 0
+	case c.AggregateCacheTTLSeconds > 0:
+		return time.Duration(c.AggregateCacheTTLSeconds) * time.Second
+	}
+	return defaultAggregateCacheTTL
+}
+
+// renderAggregate renders what compute returns as JSON, from the cache if a response to the same
+// request in the workspace is still there. Readers who get redacted responses have entries of
+// their own.
+func renderAggregate(w http.ResponseWriter, r *http.Request, compute func() (interface{}, error)) {
+	s := GetEnv(r).Service
+	ttl := aggregateCacheTTL(s.GetConfig())
+
+	class := "full"
+	if redactsFor(s.GetMemberObject().Level) {
+		class = "redacted"
+	}
+	key := s.GetWorkspaceObject().ID + "/" + class + r.URL.RequestURI()
+
+	b, ok := []byte(nil), false
+	if ttl > 0 {
+		b, ok = aggregates.Get(key)
+	}
+
+	if !ok {
+		v, err := compute()
+		if err != nil {
+			_ = render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+		if b, err = json.Marshal(v); err != nil {
+			http.Error(w, http.StatusText(500), 500)
+			return
+		}
+		if ttl > 0 {
+			aggregates.Set(key, b, ttl)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(b)
+}
+
+// InvalidateAggregates drops the cached aggregates of the workspace after a request that may have
+// changed it. It goes before Transaction, so that it runs once the changes are committed.
+func InvalidateAggregates() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return
+			}
+			if ws := GetEnv(r).Service.GetWorkspaceObject(); ws != nil {
+				aggregates.InvalidateWorkspace(ws.ID)
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// rollupRepo counts how often the features behind a rollup are read
+type rollupRepo struct {
+	Repository
+	reads    int
+	estimate int
+}
+
+func (a *rollupRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	a.reads++
+	return []*Feature{{ID: "f1", MilestoneID: "m1", SubWorkflowID: "s1", Estimate: a.estimate}}, nil
+}
+
+func (a *rollupRepo) FindTimeEntriesByProject(workspaceID string, projectID string) ([]*TimeEntry, error) {
+	return []*TimeEntry{}, nil
+}
+
+func (a *rollupRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	return &Project{WorkspaceID: workspaceID, ID: id}, nil
+}
+
+func (a *rollupRepo) StoreProjectFavorite(x *ProjectFavorite) {}
+
+func TestAggregateCache(t *testing.T) {
+	aggregates = newMemoryCache()
+	repo := &rollupRepo{estimate: 3}
+
+	serve := func(method string, path string) *httptest.ResponseRecorder {
+		s := memberService(repo, "EDITOR")
+		return serveWith(s, "/v1/", workspaceAPI, httptest.NewRequest(method, path, nil), InvalidateAggregates())
+	}
+
+	first := serve("GET", "/v1/projects/p1/rollup")
+	repo.estimate = 5
+	second := serve("GET", "/v1/projects/p1/rollup")
+	if repo.reads != 1 || first.Body.String() != second.Body.String() {
+		t.Error("a rollup should be served from the cache within the ttl", repo.reads)
+	}
+
+	if w := serve("POST", "/v1/projects/p1/favorite"); w.Code != 200 {
+		t.Fatal("mutation failed", w.Code)
+	}
+
+	third := serve("GET", "/v1/projects/p1/rollup")
+	if repo.reads != 2 || third.Body.String() == first.Body.String() {
+		t.Error("a mutation should drop the cached rollup", repo.reads, third.Body.String())
+	}
+}
+
+func TestAggregateCacheByRedaction(t *testing.T) {
+	aggregates = newMemoryCache()
+	repo := &rollupRepo{estimate: 3}
+
+	serve := func(level string) string {
+		s := memberService(repo, level)
+		s.SetWorkspaceObject(&Workspace{ID: "ws", ViewerRedactions: "estimates"})
+		w := serveWith(s, "/v1/", workspaceAPI, httptest.NewRequest("GET", "/v1/projects/p1/rollup", nil))
+		return w.Body.String()
+	}
+
+	viewer := serve("VIEWER")
+	admin := serve("ADMIN")
+	if viewer == admin || serve("VIEWER") != viewer || serve("ADMIN") != admin {
+		t.Error("viewers and admins should be cached apart", viewer, admin)
+	}
+	if repo.reads != 2 {
+		t.Error("each class of reader should be served from its own entry", repo.reads)
+	}
+}
+
+func TestMemoryCacheExpires(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newMemoryCache()
+	c.now = func() time.Time { return now }
+
+	c.Set("ws/a", []byte("1"), time.Minute)
+	c.Set("other/a", []byte("2"), time.Minute)
+
+	if v, ok := c.Get("ws/a"); !ok || string(v) != "1" {
+		t.Error("should be cached")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("ws/a"); ok {
+		t.Error("should expire after the ttl")
+	}
+
+	c.Set("ws/a", []byte("1"), time.Hour)
+	c.Set("other/a", []byte("2"), time.Hour)
+	c.InvalidateWorkspace("ws")
+	if _, ok := c.Get("ws/a"); ok {
+		t.Error("should be dropped with its workspace")
+	}
+	if _, ok := c.Get("other/a"); !ok {
+		t.Error("other workspaces should stay cached")
+	}
+}
+
+func TestAggregateCacheTTL(t *testing.T) {
+	if aggregateCacheTTL(Configuration{}) != defaultAggregateCacheTTL {
+		t.Error("wrong default ttl")
+	}
+	if aggregateCacheTTL(Configuration{AggregateCacheTTLSeconds: 5}) != 5*time.Second {
+		t.Error("wrong configured ttl")
+	}
+	if aggregateCacheTTL(Configuration{AggregateCacheTTLSeconds: -1}) != 0 {
+		t.Error("the cache should be off")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// cloneRepo holds workspace "src" with project p1, and keeps what a clone stores
+type cloneRepo struct {
+	limitRepo
+	projects         []*Project
+	milestones       []*Milestone
+	workflows        []*Workflow
+	subWorkflows     []*SubWorkflow
+	personas         []*Persona
+	workflowPersonas []*WorkflowPersona
+	statuses         []*ProjectStatus
+}
+
+func (a *cloneRepo) GetMemberByAccountAndWorkspace(accountID string, workspaceID string) (*Member, error) {
+	for _, m := range a.members {
+		if m.AccountID == accountID && m.WorkspaceID == workspaceID {
+			return m, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *cloneRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Name: "agency", AllowExternalSharing: false, ViewerRedactions: "descriptions", MinEstimate: 1, MaxEstimate: 8}, nil
+}
+
+func (a *cloneRepo) FindProjectsByWorkspace(workspaceID string) ([]*Project, error) {
+	return []*Project{{WorkspaceID: workspaceID, ID: "p1", Title: "Shop", Description: "The shop", ExternalLink: "link", DefaultMilestoneID: "m1", DefaultSubWorkflowID: "s1"}}, nil
+}
+
+func (a *cloneRepo) FindMilestonesByProject(workspaceID string, projectID string) ([]*Milestone, error) {
+	return []*Milestone{{WorkspaceID: workspaceID, ProjectID: projectID, ID: "m1", Title: "MVP"}}, nil
+}
+
+func (a *cloneRepo) FindWorkflowsByProject(workspaceID string, projectID string) ([]*Workflow, error) {
+	return []*Workflow{{WorkspaceID: workspaceID, ProjectID: projectID, ID: "w1", Title: "Buy"}}, nil
+}
+
+func (a *cloneRepo) FindSubWorkflowsByProject(workspaceID string, projectID string) ([]*SubWorkflow, error) {
+	return []*SubWorkflow{{WorkspaceID: workspaceID, WorkflowID: "w1", ID: "s1", Title: "Check out"}}, nil
+}
+
+func (a *cloneRepo) FindPersonasByProject(workspaceID string, projectID string) ([]*Persona, error) {
+	return []*Persona{{WorkspaceID: workspaceID, ProjectID: projectID, ID: "pe1", Name: "Buyer"}}, nil
+}
+
+func (a *cloneRepo) FindWorkflowPersonasByProject(workspaceID string, projectID string) ([]*WorkflowPersona, error) {
+	return []*WorkflowPersona{{WorkspaceID: workspaceID, ProjectID: projectID, ID: "wp1", WorkflowID: "w1", PersonaID: "pe1"}}, nil
+}
+
+func (a *cloneRepo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	return []*ProjectStatus{{WorkspaceID: workspaceID, ProjectID: projectID, ID: "st1", Title: "Doing"}}, nil
+}
+
+func (a *cloneRepo) StoreProject(x *Project) {
+	for i, p := range a.projects {
+		if p.WorkspaceID == x.WorkspaceID && p.ID == x.ID {
+			a.projects[i] = x
+			return
+		}
+	}
+	a.projects = append(a.projects, x)
+}
+
+func (a *cloneRepo) StoreMilestone(x *Milestone) { a.milestones = append(a.milestones, x) }
+
+func (a *cloneRepo) StoreWorkflow(x *Workflow) { a.workflows = append(a.workflows, x) }
+
+func (a *cloneRepo) StoreSubWorkflow(x *SubWorkflow) { a.subWorkflows = append(a.subWorkflows, x) }
+
+func (a *cloneRepo) StorePersona(x *Persona) { a.personas = append(a.personas, x) }
+
+func (a *cloneRepo) StoreWorkflowPersona(x *WorkflowPersona) {
+	a.workflowPersonas = append(a.workflowPersonas, x)
+}
+
+func (a *cloneRepo) StoreProjectStatus(x *ProjectStatus) { a.statuses = append(a.statuses, x) }
+
+// Cloning never reads cards, so features, comments and time entries have no fakes
+func TestCloneWorkspace(t *testing.T) {
+	repo := &cloneRepo{}
+	repo.members = []*Member{{WorkspaceID: "src", AccountID: "a1", Level: "EDITOR"}}
+
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{MaxWorkspacesPerAccount: 2})
+	s.SetRepoObject(repo)
+	s.SetAccountObject(&Account{ID: "a1", Name: "ann", DefaultAutoJoinLevel: "VIEWER"})
+
+	if _, err := s.CloneWorkspace("src", "client"); err == nil {
+		t.Error("an editor should not clone a workspace")
+	}
+
+	repo.members[0].Level = "ADMIN"
+	w, err := s.CloneWorkspace("src", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.ID == "src" || w.Name != "client" || w.AllowExternalSharing || w.ViewerRedactions != "descriptions" || w.MinEstimate != 1 || w.MaxEstimate != 8 {
+		t.Error("the settings should be copied to the new workspace", w)
+	}
+	if len(repo.members) != 2 || repo.members[1].WorkspaceID != w.ID || repo.members[1].AccountID != "a1" || repo.members[1].Level != "OWNER" {
+		t.Error("only the caller should be a member of the new workspace", repo.members)
+	}
+
+	if len(repo.projects) != 1 || len(repo.milestones) != 1 || len(repo.workflows) != 1 || len(repo.subWorkflows) != 1 ||
+		len(repo.personas) != 1 || len(repo.workflowPersonas) != 1 || len(repo.statuses) != 1 {
+		t.Fatal("the structure of every project should be copied")
+	}
+	p := repo.projects[0]
+	if p.WorkspaceID != w.ID || p.ID == "p1" || p.Title != "Shop" || p.Description != "The shop" || p.ExternalLink == "link" {
+		t.Error("the project should be copied with new IDs and share link", p)
+	}
+	m := repo.milestones[0]
+	if m.WorkspaceID != w.ID || m.ProjectID != p.ID || m.ID == "m1" {
+		t.Error("the milestone should be moved to the new project", m)
+	}
+	wf := repo.workflows[0]
+	sw := repo.subWorkflows[0]
+	if sw.WorkspaceID != w.ID || sw.WorkflowID != wf.ID || sw.ID == "s1" {
+		t.Error("the subworkflow should follow its workflow", sw)
+	}
+	if p.DefaultMilestoneID != m.ID || p.DefaultSubWorkflowID != sw.ID {
+		t.Error("the default placement should point at the copies", p.DefaultMilestoneID, p.DefaultSubWorkflowID)
+	}
+	if wp := repo.workflowPersonas[0]; wp.WorkflowID != wf.ID || wp.PersonaID != repo.personas[0].ID {
+		t.Error("the workflow persona should follow its workflow and persona", wp)
+	}
+	if st := repo.statuses[0]; st.WorkspaceID != w.ID || st.ProjectID != p.ID || st.ID == "st1" {
+		t.Error("the status should be moved to the new project", st)
+	}
+
+	w2, err := s.CloneWorkspace("src", "client2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CloneWorkspace(w2.ID, "client3"); err == nil || err.Error() != "workspace_limit_reached" {
+		t.Error("a clone past the workspace limit should be refused")
+	}
+}
+
+// brokenCloneRepo is cloneRepo that fails to read personas
+type brokenCloneRepo struct {
+	cloneRepo
+}
+
+func (a *brokenCloneRepo) FindPersonasByProject(workspaceID string, projectID string) ([]*Persona, error) {
+	return nil, errors.New("connection lost")
+}
+
+func TestCloneWorkspaceReadError(t *testing.T) {
+	repo := &brokenCloneRepo{}
+	repo.members = []*Member{{WorkspaceID: "src", AccountID: "a1", Level: "ADMIN"}}
+
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+	s.SetAccountObject(&Account{ID: "a1", Name: "ann", DefaultAutoJoinLevel: "VIEWER"})
+
+	if _, err := s.CloneWorkspace("src", "client"); err == nil {
+		t.Fatal("a failed read should fail the clone")
+	}
+	if len(repo.members) != 1 || len(repo.subs) != 0 || len(repo.projects) != 0 {
+		t.Error("a failed clone should store nothing", repo.members, repo.projects)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closeRepo holds feature "f1" watched by ann, bob and cy
+type closeRepo struct {
+	notificationRepo
+	feature *Feature
+}
+
+func (a *closeRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	if id != a.feature.ID {
+		return nil, errNotFound
+	}
+	return a.feature, nil
+}
+
+func (a *closeRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *closeRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Name: "acme"}, nil
+}
+
+func (a *closeRepo) StoreFeature(x *Feature) {}
+
+func (a *closeRepo) StoreFeatureEvent(x *FeatureEvent) {}
+
+func (a *closeRepo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	return []*ProjectStatus{}, nil
+}
+
+func (a *closeRepo) StoreFeatureComment(x *FeatureComment) {}
+
+func (a *closeRepo) FindFeatureWatchersByFeature(workspaceID string, featureID string) ([]*Member, error) {
+	return []*Member{
+		{ID: "m-ann", AccountID: "a-ann", Email: "ann@example.com"},
+		{ID: "m-bob", AccountID: "a-bob", Email: "bob@example.com"},
+		{ID: "m-cy", AccountID: "a-cy", Email: "cy@example.com"},
+	}, nil
+}
+
+func (a *closeRepo) FindProjectsWithAutoClose() ([]*Project, error) {
+	return []*Project{{WorkspaceID: "ws", ID: "p1", AutoCloseDays: 30}}, nil
+}
+
+func (a *closeRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	return []*Feature{a.feature}, nil
+}
+
+func (a *closeRepo) FindFeatureCommentsByProject(workspaceID string, projectID string) ([]*FeatureComment, error) {
+	return []*FeatureComment{}, nil
+}
+
+func (a *closeRepo) Begin(ctx context.Context, opts *sql.TxOptions) error { return nil }
+
+func (a *closeRepo) Commit() error { return nil }
+
+func (a *closeRepo) Rollback() error { return nil }
+
+// Over the cap, notifications are stored for the digest instead of sent
+func (a *closeRepo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	return 1, nil
+}
+
+func notified(emails []*NotificationEmail) string {
+	x := []string{}
+	for _, n := range emails {
+		x = append(x, n.AccountID)
+	}
+	return strings.Join(x, ",")
+}
+
+func TestCloseFeatureNotifiesWatchers(t *testing.T) {
+	repo := &closeRepo{feature: &Feature{WorkspaceID: "ws", ID: "f1", MilestoneID: "m1", Title: "Pay by card", Status: "OPEN", Estimate: 1}}
+	s := memberService(repo, "EDITOR")
+	s.SetConfig(Configuration{DailyNotificationCap: 1})
+	s.SetAccountObject(&Account{ID: "a-ann", Name: "ann"})
+	s.SetWorkspaceObject(&Workspace{ID: "ws", Name: "acme"})
+
+	if _, err := s.CloseFeature("f1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if x := notified(repo.emails); x != "a-bob,a-cy" {
+		t.Error("the watchers but not the closer should be notified", x)
+	}
+	for _, n := range repo.emails {
+		if !strings.Contains(n.Body, "Pay by card") || !strings.Contains(n.Body, "ann closed the card") {
+			t.Error("the notification should name the card and who closed it", n.Body)
+		}
+	}
+}
+
+func TestCloseStaleFeaturesNotifiesWatchers(t *testing.T) {
+	now := time.Now().UTC()
+	repo := &closeRepo{feature: &Feature{WorkspaceID: "ws", ID: "f1", MilestoneID: "m1", Title: "Pay by card", Status: "OPEN", LastModified: now.AddDate(0, 0, -60)}}
+	s := &service{}
+	s.SetConfig(Configuration{DailyNotificationCap: 1})
+	s.SetRepoObject(repo)
+
+	if n := s.CloseStaleFeatures(now); n != 1 {
+		t.Fatal("the stale feature should be closed", n)
+	}
+
+	if x := notified(repo.emails); x != "a-ann,a-bob,a-cy" {
+		t.Error("every watcher should be notified", x)
+	}
+	if n := repo.emails[0]; !strings.Contains(n.Body, "closed the card after 30 days without activity") {
+		t.Error("the notification should say why the card was closed", n.Body)
+	}
+}
+
+// staleRepo holds projects p1, p2 and p3 with two stale features each. Storing "f-p2-b" fails.
+// Notifications stored in a transaction are kept when it commits.
+type staleRepo struct {
+	closeRepo
+	s       *service
+	open    bool
+	pending []*NotificationEmail
+	queued  []int
+}
+
+func (a *staleRepo) Begin(ctx context.Context, opts *sql.TxOptions) error {
+	a.open = true
+	return nil
+}
+
+func (a *staleRepo) Commit() error {
+	if a.open {
+		a.emails = append(a.emails, a.pending...)
+		a.queued = append(a.queued, len(a.s.afterCommit))
+	}
+	a.open, a.pending = false, nil
+	return nil
+}
+
+func (a *staleRepo) Rollback() error {
+	a.open, a.pending = false, nil
+	return nil
+}
+
+func (a *staleRepo) StoreNotificationEmail(x *NotificationEmail) { a.pending = append(a.pending, x) }
+
+func (a *staleRepo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	return 0, nil
+}
+
+func (a *staleRepo) FindProjectsWithAutoClose() ([]*Project, error) {
+	return []*Project{{WorkspaceID: "ws", ID: "p1", AutoCloseDays: 30}, {WorkspaceID: "ws", ID: "p2", AutoCloseDays: 30}, {WorkspaceID: "ws", ID: "p3", AutoCloseDays: 30}}, nil
+}
+
+func (a *staleRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	old := time.Now().UTC().AddDate(0, 0, -60)
+	return []*Feature{
+		{WorkspaceID: workspaceID, ID: "f-" + projectID + "-a", Title: "Card", Status: "OPEN", LastModified: old},
+		{WorkspaceID: workspaceID, ID: "f-" + projectID + "-b", Title: "Card", Status: "OPEN", LastModified: old},
+	}, nil
+}
+
+func (a *staleRepo) StoreFeature(x *Feature) {
+	if x.ID == "f-p2-b" {
+		panic("connection reset")
+	}
+}
+
+func TestCloseStaleFeaturesPerProject(t *testing.T) {
+	s := &service{}
+	repo := &staleRepo{s: s}
+	s.SetConfig(Configuration{DailyNotificationCap: 10, SMTPServer: "127.0.0.1", SMTPPort: "1"})
+	s.SetRepoObject(repo)
+
+	if n := s.CloseStaleFeatures(time.Now().UTC()); n != 4 {
+		t.Fatal("the features of the projects that did not fail should be closed", n)
+	}
+
+	if len(repo.emails) != 12 {
+		t.Error("the notifications of the failed project should be rolled back", len(repo.emails))
+	}
+	if len(repo.queued) != 2 || repo.queued[0] != 6 || repo.queued[1] != 6 {
+		t.Error("each project should send its notifications once it has committed", repo.queued)
+	}
+	if len(s.afterCommit) != 0 || repo.open {
+		t.Error("nothing should be left queued or open", len(s.afterCommit), repo.open)
+	}
+}
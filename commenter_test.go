@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+)
+
+// commentRepo accepts comments on feature "f1"
+type commentRepo struct {
+	Repository
+	comments []*FeatureComment
+}
+
+func (a *commentRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	if id != "f1" {
+		return nil, errNotFound
+	}
+	return &Feature{WorkspaceID: workspaceID, ID: id, MilestoneID: "m1"}, nil
+}
+
+func (a *commentRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *commentRepo) StoreFeatureComment(x *FeatureComment) {
+	a.comments = append(a.comments, x)
+}
+
+func (a *commentRepo) StoreFeatureCommentOwner(x *FeatureCommentOwner) {}
+
+func (a *commentRepo) StoreFeatureWatcher(x *FeatureWatcher) {}
+
+func (a *commentRepo) FindFeatureWatchersByFeature(workspaceID string, featureID string) ([]*Member, error) {
+	return []*Member{}, nil
+}
+
+func TestCommenterRole(t *testing.T) {
+	repo := &commentRepo{}
+
+	serve := func(level string, path string, body string) int {
+		s := memberService(repo, level)
+		w := serveWith(s, "/v1/", workspaceAPI, jsonRequest("POST", path, body))
+		return w.Code
+	}
+
+	comment := `{"featureId": "f1", "post": "Looks good"}`
+	if code := serve("COMMENTER", "/v1/featurecomments/c1", comment); code != 200 || len(repo.comments) != 1 {
+		t.Error("commenter should be able to comment", code)
+	}
+	if code := serve("VIEWER", "/v1/featurecomments/c2", comment); code != 401 || len(repo.comments) != 1 {
+		t.Error("viewer should not be able to comment", code)
+	}
+	if code := serve("COMMENTER", "/v1/features/f2", `{"milestoneId": "m1", "subWorkflowId": "sw1", "title": "New"}`); code != 401 {
+		t.Error("commenter should not be able to create features", code)
+	}
+
+	if !levelIsValid("COMMENTER") || isEditor("COMMENTER") {
+		t.Error("commenter should be a valid level that takes no seat")
+	}
+}
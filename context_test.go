@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+// contextRepo holds one feature with its parents in workspace "ws"
+type contextRepo struct {
+	Repository
+}
+
+func (contextRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	if workspaceID != "ws" || id != "f1" {
+		return nil, errNotFound
+	}
+	return &Feature{WorkspaceID: "ws", ID: "f1", MilestoneID: "m1", SubWorkflowID: "sw1", StatusID: "st1", Description: "secret", Annotations: "bug"}, nil
+}
+
+func (contextRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1", Description: "secret"}, nil
+}
+
+func (contextRepo) GetSubWorkflow(workspaceID string, id string) (*SubWorkflow, error) {
+	return &SubWorkflow{WorkspaceID: workspaceID, ID: id, WorkflowID: "w1"}, nil
+}
+
+func (contextRepo) GetWorkflow(workspaceID string, id string) (*Workflow, error) {
+	return &Workflow{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (contextRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	return &Project{WorkspaceID: workspaceID, ID: id}, nil
+}
+
+func (contextRepo) GetProjectStatus(workspaceID string, id string) (*ProjectStatus, error) {
+	return &ProjectStatus{WorkspaceID: workspaceID, ID: id, ProjectID: "p1", Title: "Review"}, nil
+}
+
+func (contextRepo) CountFeatureCommentsByFeature(workspaceID string, id string) (int, error) {
+	return 3, nil
+}
+
+func (contextRepo) FindWatchedFeatureIDsByProject(workspaceID string, projectID string, memberID string) ([]string, error) {
+	return []string{"f1"}, nil
+}
+
+func (contextRepo) FindFeatureReferencesByProject(workspaceID string, projectID string) ([]*FeatureReference, error) {
+	return []*FeatureReference{{FeatureID: "f1", ID: "r1", URL: "https://example.com/spec"}}, nil
+}
+
+func (contextRepo) FindTimeEntriesByProject(workspaceID string, projectID string) ([]*TimeEntry, error) {
+	return []*TimeEntry{}, nil
+}
+
+func (contextRepo) FindFavoriteProjectIDsByMember(workspaceID string, memberID string) ([]string, error) {
+	return []string{}, nil
+}
+
+func TestGetFeatureContext(t *testing.T) {
+	s := NewFeatmapService()
+	s.SetRepoObject(contextRepo{})
+	s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "ann"})
+
+	x, err := s.GetFeatureContext("f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Milestone.ID != "m1" || x.SubWorkflow.ID != "sw1" || x.Workflow.ID != "w1" || x.Project.ID != "p1" {
+		t.Error("context chain should lead from the feature up to its project", x)
+	}
+	if x.Status.Title != "Review" || x.CommentCount != 3 || !x.Feature.Watching || x.Feature.Annotations != "bug" {
+		t.Error("feature details are missing", x)
+	}
+	if len(x.Feature.References) != 1 || x.Feature.References[0].ID != "r1" {
+		t.Error("feature should embed its references", x.Feature.References)
+	}
+
+	redactFeatureContext(x, "comments,descriptions")
+	if x.CommentCount != 0 || x.Feature.Description != "" || x.Milestone.Description != "" {
+		t.Error("viewer redactions should apply", x)
+	}
+
+	s.SetMemberObject(&Member{WorkspaceID: "other", ID: "bob"})
+	if _, err := s.GetFeatureContext("f1"); err == nil {
+		t.Error("features of another workspace should not be found")
+	}
+}
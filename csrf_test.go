@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/jwtauth"
+)
+
+func serveCSRF(config Configuration, req *http.Request) int {
+	auth := jwtauth.New("HS256", []byte(config.JWTSecret), nil)
+	h := jwtauth.Verifier(auth)(CSRF(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestCSRF(t *testing.T) {
+	config := Configuration{JWTSecret: "secret", CSRFProtection: true}
+
+	req := httptest.NewRequest("POST", "/v1/projects", nil)
+	req.AddCookie(&http.Cookie{Name: "jwt", Value: "session"})
+	if serveCSRF(config, req) != 403 {
+		t.Error("cookie mutation without token should be rejected")
+	}
+
+	req.Header.Set(csrfHeaderName, csrfToken("secret", "other"))
+	if serveCSRF(config, req) != 403 {
+		t.Error("token for another session should be rejected")
+	}
+
+	req.Header.Set(csrfHeaderName, csrfToken("secret", "session"))
+	if serveCSRF(config, req) != 200 {
+		t.Error("cookie mutation with valid token should pass")
+	}
+
+	_, bearer, _ := jwtauth.New("HS256", []byte("secret"), nil).Encode(jwt.MapClaims{"id": "a1"})
+	req = httptest.NewRequest("POST", "/v1/projects", nil)
+	req.AddCookie(&http.Cookie{Name: "jwt", Value: "session"})
+	req.Header.Set("Authorization", "BEARER "+bearer)
+	if serveCSRF(config, req) != 200 {
+		t.Error("bearer auth should be exempt")
+	}
+
+	for _, header := range []string{"Basic YTpi", "BEARER session", "BEARER " + bearer + "x"} {
+		req = httptest.NewRequest("POST", "/v1/projects", nil)
+		req.AddCookie(&http.Cookie{Name: "jwt", Value: "session"})
+		req.Header.Set("Authorization", header)
+		if serveCSRF(config, req) != 403 {
+			t.Error("a header that does not authenticate should not skip the check", header)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "/v1/projects", nil)
+	req.AddCookie(&http.Cookie{Name: "jwt", Value: "session"})
+	if serveCSRF(config, req) != 200 {
+		t.Error("safe methods should pass")
+	}
+
+	req = httptest.NewRequest("POST", "/v1/projects", nil)
+	req.AddCookie(&http.Cookie{Name: "jwt", Value: "session"})
+	if serveCSRF(Configuration{JWTSecret: "secret"}, req) != 200 {
+		t.Error("disabled protection should pass")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// unknownFieldError is returned by the strict decoder for a field the request type does not have
+type unknownFieldError struct {
+	Field string
+}
+
+func (e *unknownFieldError) Error() string { return "unknown field: " + e.Field }
+
+// decodeStrict is used as render.Decode when strictJson is set. JSON bodies with
+// fields the request type does not know are rejected instead of silently ignored.
+func decodeStrict(r *http.Request, v interface{}) error {
+	if render.GetRequestContentType(r) != render.ContentTypeJSON {
+		return render.DefaultDecoder(r, v)
+	}
+
+	defer io.Copy(ioutil.Discard, r.Body)
+
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	err := d.Decode(v)
+	if err != nil && strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return &unknownFieldError{Field: strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)}
+	}
+	return err
+}
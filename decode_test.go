@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/render"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	defer func(d func(r *http.Request, v interface{}) error) { render.Decode = d }(render.Decode)
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		s := memberService(&placementRepo{project: &Project{WorkspaceID: "ws", ID: "p1"}}, "EDITOR")
+		return serveWith(s, "/v1/", workspaceAPI, jsonRequest("POST", "/v1/features/f1", body))
+	}
+
+	typo := `{"projectId": "p1", "title": "Quick", "titel": "Quick"}`
+
+	render.Decode = decodeStrict
+	if w := serve(typo); w.Code != 422 || !strings.Contains(w.Body.String(), "titel") {
+		t.Error("an unknown field should be rejected by name when strict", w.Code, w.Body.String())
+	}
+	if w := serve(`{"projectId": "p1", "title": "Quick"}`); w.Code != 200 {
+		t.Error("a known body should be accepted when strict", w.Code, w.Body.String())
+	}
+
+	render.Decode = render.DefaultDecoder
+	if w := serve(typo); w.Code != 200 {
+		t.Error("an unknown field should be ignored when lenient", w.Code, w.Body.String())
+	}
+}
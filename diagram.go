@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// projectDiagram is the story map of a project laid out for export as a diagram: one group per
+// milestone, holding a node per subworkflow with cards in it and the cards below it
+type projectDiagram struct {
+	Title        string
+	Milestones   []*diagramMilestone
+	Dependencies []*diagramDependency
+}
+
+type diagramMilestone struct {
+	ID           string
+	Title        string
+	SubWorkflows []*diagramSubWorkflow
+}
+
+type diagramSubWorkflow struct {
+	ID       string
+	Title    string
+	Features []*diagramFeature
+}
+
+type diagramFeature struct {
+	ID    string
+	Title string
+}
+
+// diagramDependency is a reference from one card to another card of the same project
+type diagramDependency struct {
+	From  string
+	To    string
+	Label string
+}
+
+func (s *service) GetProjectDiagram(id string) (*projectDiagram, error) {
+	p, err := s.r.GetProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, errors.New("project not found")
+	}
+
+	milestones, err := s.r.FindMilestonesByProject(p.WorkspaceID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	workflows, err := s.r.FindWorkflowsByProject(p.WorkspaceID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	subworkflows, err := s.r.FindSubWorkflowsByProject(p.WorkspaceID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	features, err := s.r.FindFeaturesByProject(p.WorkspaceID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	references, err := s.r.FindFeatureReferencesByProject(p.WorkspaceID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildProjectDiagram(p, milestones, workflows, subworkflows, features, references), nil
+}
+
+// buildProjectDiagram orders everything by rank, so the same story map always gives the same
+// diagram. Node ids are numbered in that order instead of using the ids of the cards.
+func buildProjectDiagram(p *Project, mm []*Milestone, ww []*Workflow, ss []*SubWorkflow, ff []*Feature, rr []*FeatureReference) *projectDiagram {
+	mm = append([]*Milestone{}, mm...)
+	sort.SliceStable(mm, func(i, k int) bool { return mm[i].Rank < mm[k].Rank })
+
+	workflowRank := map[string]string{}
+	for _, w := range ww {
+		workflowRank[w.ID] = w.Rank
+	}
+	ss = append([]*SubWorkflow{}, ss...)
+	sort.SliceStable(ss, func(i, k int) bool {
+		if a, b := workflowRank[ss[i].WorkflowID], workflowRank[ss[k].WorkflowID]; a != b {
+			return a < b
+		}
+		return ss[i].Rank < ss[k].Rank
+	})
+
+	ff = append([]*Feature{}, ff...)
+	sort.SliceStable(ff, func(i, k int) bool { return ff[i].Rank < ff[k].Rank })
+	cells := map[string][]*Feature{}
+	for _, f := range ff {
+		cells[f.MilestoneID+"/"+f.SubWorkflowID] = append(cells[f.MilestoneID+"/"+f.SubWorkflowID], f)
+	}
+
+	d := &projectDiagram{Title: p.Title, Milestones: []*diagramMilestone{}, Dependencies: []*diagramDependency{}}
+	nodes := map[string]string{}
+	for i, m := range mm {
+		dm := &diagramMilestone{ID: fmt.Sprintf("m%d", i+1), Title: m.Title, SubWorkflows: []*diagramSubWorkflow{}}
+		for _, sw := range ss {
+			cell := cells[m.ID+"/"+sw.ID]
+			if len(cell) == 0 {
+				continue
+			}
+			ds := &diagramSubWorkflow{ID: fmt.Sprintf("%ss%d", dm.ID, len(dm.SubWorkflows)+1), Title: sw.Title}
+			for _, f := range cell {
+				nodes[f.ID] = fmt.Sprintf("f%d", len(nodes)+1)
+				ds.Features = append(ds.Features, &diagramFeature{ID: nodes[f.ID], Title: f.Title})
+			}
+			dm.SubWorkflows = append(dm.SubWorkflows, ds)
+		}
+		d.Milestones = append(d.Milestones, dm)
+	}
+
+	for _, f := range ff {
+		for _, r := range rr {
+			if r.FeatureID != f.ID {
+				continue
+			}
+			from, ok := nodes[f.ID]
+			to, isCard := nodes[referencedFeature(r.URL, p.ID)]
+			if ok && isCard && from != to {
+				d.Dependencies = append(d.Dependencies, &diagramDependency{From: from, To: to, Label: r.Label})
+			}
+		}
+	}
+
+	return d
+}
+
+// referencedFeature returns the id of the card a reference links to, if it is a link to a card
+// of the project, as in the emails: <site>/<workspace>/projects/<project>/f/<feature>
+func referencedFeature(link string, projectID string) string {
+	x := strings.Split(strings.TrimRight(strings.SplitN(link, "?", 2)[0], "/"), "/")
+	n := len(x)
+	if n < 4 || x[n-4] != "projects" || x[n-3] != projectID || x[n-2] != "f" {
+		return ""
+	}
+	return x[n-1]
+}
+
+// mermaidLabel escapes a label for a quoted Mermaid string, using its entity codes
+var mermaidLabel = strings.NewReplacer(
+	"#", "#35;",
+	`"`, "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+	"`", "#96;",
+	"\r\n", " ",
+	"\n", " ",
+	"\r", " ",
+)
+
+func (d *projectDiagram) mermaid() string {
+	var b strings.Builder
+	label := func(s string) string { return `"` + mermaidLabel.Replace(s) + `"` }
+
+	b.WriteString("flowchart TB\n")
+	b.WriteString("  %% " + mermaidLabel.Replace(d.Title) + "\n")
+	for _, m := range d.Milestones {
+		fmt.Fprintf(&b, "  subgraph %s[%s]\n", m.ID, label(m.Title))
+		for _, s := range m.SubWorkflows {
+			fmt.Fprintf(&b, "    %s[%s]\n", s.ID, label(s.Title))
+			for _, f := range s.Features {
+				fmt.Fprintf(&b, "    %s(%s)\n", f.ID, label(f.Title))
+				fmt.Fprintf(&b, "    %s --> %s\n", s.ID, f.ID)
+			}
+		}
+		b.WriteString("  end\n")
+	}
+	for _, x := range d.Dependencies {
+		if x.Label == "" {
+			fmt.Fprintf(&b, "  %s -.-> %s\n", x.From, x.To)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -.->|%s| %s\n", x.From, label(x.Label), x.To)
+	}
+
+	return b.String()
+}
+
+// dotLabel escapes a label for a quoted Graphviz string
+var dotLabel = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func (d *projectDiagram) dot() string {
+	var b strings.Builder
+	label := func(s string) string { return `"` + dotLabel.Replace(s) + `"` }
+
+	fmt.Fprintf(&b, "digraph %s {\n", label(d.Title))
+	b.WriteString("  node [shape=box];\n")
+	for _, m := range d.Milestones {
+		fmt.Fprintf(&b, "  subgraph cluster_%s {\n", m.ID)
+		fmt.Fprintf(&b, "    label=%s;\n", label(m.Title))
+		for _, s := range m.SubWorkflows {
+			fmt.Fprintf(&b, "    %s [label=%s, style=bold];\n", s.ID, label(s.Title))
+			for _, f := range s.Features {
+				fmt.Fprintf(&b, "    %s [label=%s, style=rounded];\n", f.ID, label(f.Title))
+				fmt.Fprintf(&b, "    %s -> %s;\n", s.ID, f.ID)
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	for _, x := range d.Dependencies {
+		if x.Label == "" {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed];\n", x.From, x.To)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -> %s [style=dashed, label=%s];\n", x.From, x.To, label(x.Label))
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
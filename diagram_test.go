@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func diagramFixture() *projectDiagram {
+	p := &Project{ID: "p1", Title: "Shop"}
+	mm := []*Milestone{
+		{ID: "mb", Title: "Later", Rank: "b"},
+		{ID: "ma", Title: "MVP", Rank: "a"},
+	}
+	ww := []*Workflow{{ID: "w1", Rank: "a"}}
+	ss := []*SubWorkflow{
+		{ID: "s2", WorkflowID: "w1", Title: "Pay", Rank: "b"},
+		{ID: "s1", WorkflowID: "w1", Title: "Browse", Rank: "a"},
+	}
+	ff := []*Feature{
+		{ID: "f-pay", MilestoneID: "ma", SubWorkflowID: "s2", Title: `Pay by "card"`, Rank: "a"},
+		{ID: "f-list", MilestoneID: "ma", SubWorkflowID: "s1", Title: "List #1 <items>", Rank: "a"},
+		{ID: "f-search", MilestoneID: "mb", SubWorkflowID: "s1", Title: "Search", Rank: "a"},
+	}
+	rr := []*FeatureReference{
+		{FeatureID: "f-pay", Label: "needs", URL: "https://app.featmap.com/acme/projects/p1/f/f-list"},
+		{FeatureID: "f-pay", Label: "elsewhere", URL: "https://app.featmap.com/acme/projects/p2/f/f-x"},
+		{FeatureID: "f-search", Label: "docs", URL: "https://example.com/search"},
+	}
+	return buildProjectDiagram(p, mm, ww, ss, ff, rr)
+}
+
+func TestProjectDiagramMermaid(t *testing.T) {
+	got := diagramFixture().mermaid()
+
+	want := []string{
+		"flowchart TB\n",
+		`  subgraph m1["MVP"]` + "\n",
+		`    m1s1["Browse"]` + "\n",
+		`    f1("List #35;1 #lt;items#gt;")` + "\n",
+		"    m1s1 --> f1\n",
+		`    m1s2["Pay"]` + "\n",
+		`    f2("Pay by #quot;card#quot;")` + "\n",
+		"    m1s2 --> f2\n",
+		`  subgraph m2["Later"]` + "\n",
+		`    f3("Search")` + "\n",
+		`  f2 -.->|"needs"| f1` + "\n",
+	}
+	for _, x := range want {
+		if !strings.Contains(got, x) {
+			t.Errorf("missing %q in\n%s", x, got)
+		}
+	}
+
+	if strings.Count(got, "-.->") != 1 {
+		t.Error("only references to cards of the project are dependencies", got)
+	}
+	if strings.Index(got, "MVP") > strings.Index(got, "Later") {
+		t.Error("milestones not in rank order")
+	}
+}
+
+func TestProjectDiagramDeterministic(t *testing.T) {
+	if diagramFixture().mermaid() != diagramFixture().mermaid() || diagramFixture().dot() != diagramFixture().dot() {
+		t.Error("diagram differs between runs")
+	}
+}
+
+func TestProjectDiagramDot(t *testing.T) {
+	got := diagramFixture().dot()
+
+	want := []string{
+		`digraph "Shop" {`,
+		"  subgraph cluster_m1 {\n",
+		`    f2 [label="Pay by \"card\"", style=rounded];`,
+		"    m1s2 -> f2;\n",
+		`  f2 -> f1 [style=dashed, label="needs"];`,
+	}
+	for _, x := range want {
+		if !strings.Contains(got, x) {
+			t.Errorf("missing %q in\n%s", x, got)
+		}
+	}
+}
+
+func TestReferencedFeature(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"https://app.featmap.com/acme/projects/p1/f/f1", "f1"},
+		{"https://app.featmap.com/acme/projects/p1/f/f1/?x=1", "f1"},
+		{"https://app.featmap.com/acme/projects/p2/f/f1", ""},
+		{"https://app.featmap.com/acme/projects/p1", ""},
+		{"f1", ""},
+	}
+	for _, x := range tests {
+		if got := referencedFeature(x.link, "p1"); got != x.want {
+			t.Errorf("referencedFeature(%q) = %q, want %q", x.link, got, x.want)
+		}
+	}
+}
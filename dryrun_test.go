@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// markdownDryRunRepo is importRepo that undoes what was stored since the savepoint
+type markdownDryRunRepo struct {
+	importRepo
+	saved map[string]importRepo
+}
+
+func copyImportRepo(x importRepo) importRepo {
+	c := importRepo{projects: map[string]*Project{}, milestones: map[string]*Milestone{}, subWorkflows: map[string]*SubWorkflow{}, features: map[string]*Feature{}}
+	for k, v := range x.projects {
+		c.projects[k] = v
+	}
+	for k, v := range x.milestones {
+		c.milestones[k] = v
+	}
+	for k, v := range x.subWorkflows {
+		c.subWorkflows[k] = v
+	}
+	for k, v := range x.features {
+		c.features[k] = v
+	}
+	c.statuses = append(c.statuses, x.statuses...)
+	c.order = append(c.order, x.order...)
+	return c
+}
+
+func (a *markdownDryRunRepo) Savepoint(name string) {
+	if a.saved == nil {
+		a.saved = map[string]importRepo{}
+	}
+	a.saved[name] = copyImportRepo(a.importRepo)
+}
+
+func (a *markdownDryRunRepo) RollbackToSavepoint(name string) {
+	a.importRepo = copyImportRepo(a.saved[name])
+}
+
+// jsonDryRunRepo is portableRepo that undoes what was stored since the savepoint
+type jsonDryRunRepo struct {
+	portableRepo
+	saved portableRepo
+}
+
+func (a *jsonDryRunRepo) Savepoint(name string) { a.saved = a.portableRepo }
+
+func (a *jsonDryRunRepo) RollbackToSavepoint(name string) { a.portableRepo = a.saved }
+
+func serveImport(repo Repository, path string, body string) *httptest.ResponseRecorder {
+	s := memberService(repo, "EDITOR")
+	return serveWith(s, "/v1/", workspaceAPI, jsonRequest("POST", path, body))
+}
+
+func TestMarkdownImportDryRun(t *testing.T) {
+	repo := &markdownDryRunRepo{importRepo: copyImportRepo(importRepo{})}
+	body := `{"title": "Shop", "markdown": "# MVP\n- Buy\n  - Pay\n  - Refund\n# Later\n- Buy\n  - Discounts\n"}`
+
+	w := serveImport(repo, "/v1/import/markdown?dryRun=true", body)
+	if w.Code != 200 {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	res := importResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.DryRun || res.Project.Title != "Shop" || res.Summary != (importSummary{Milestones: 2, SubWorkflows: 1, Features: 3}) {
+		t.Error("a dry run should return what would be created", w.Body.String())
+	}
+	if len(res.Outline.Milestones) != 2 {
+		t.Error("a dry run should return the outline", w.Body.String())
+	}
+	if len(repo.projects) != 0 || len(repo.milestones) != 0 || len(repo.features) != 0 || len(repo.statuses) != 0 {
+		t.Error("a dry run should store nothing", len(repo.projects), len(repo.milestones), len(repo.features))
+	}
+
+	if w := serveImport(repo, "/v1/import/markdown?dryRun=true", `{"title": "Shop", "markdown": "no headings"}`); w.Code != 400 {
+		t.Error("a dry run should reject what the import rejects", w.Code)
+	}
+
+	w = serveImport(repo, "/v1/import/markdown", body)
+	stored := importResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &stored); err != nil || w.Code != 200 {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	if stored.DryRun || stored.Summary != res.Summary || len(repo.projects) != 1 || len(repo.features) != 3 {
+		t.Error("the import should store what the dry run returned", w.Body.String())
+	}
+}
+
+func TestJSONImportDryRun(t *testing.T) {
+	repo := &jsonDryRunRepo{}
+	body := `{"export": ` + olderExport + `}`
+
+	w := serveImport(repo, "/v1/import/json?dryRun=true", body)
+	if w.Code != 200 {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	res := struct {
+		Project  *Project `json:"project"`
+		Unmapped []string `json:"unmapped"`
+		DryRun   bool     `json:"dryRun"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.DryRun || res.Project.Title != "Shop (2)" || len(res.Unmapped) == 0 {
+		t.Error("a dry run should return the project and the report", w.Body.String())
+	}
+	if len(repo.projects) != 0 || len(repo.milestones) != 0 || len(repo.features) != 0 || len(repo.comments) != 0 {
+		t.Error("a dry run should store nothing", len(repo.projects), len(repo.milestones), len(repo.features))
+	}
+
+	if w := serveImport(repo, "/v1/import/json", body); w.Code != 200 || len(repo.features) != 3 {
+		t.Error("the import should store the features", w.Code, len(repo.features))
+	}
+}
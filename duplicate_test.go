@@ -0,0 +1,111 @@
+package main
+
+import "testing"
+
+// duplicateRepo holds one cell of features and their comments in memory
+type duplicateRepo struct {
+	Repository
+	features []*Feature
+	comments []*FeatureComment
+	owners   []*FeatureCommentOwner
+}
+
+func (a *duplicateRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	for _, f := range a.features {
+		if f.ID == id {
+			c := *f
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *duplicateRepo) FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, mid string, swid string) ([]*Feature, error) {
+	return a.features, nil
+}
+
+func (a *duplicateRepo) StoreFeature(x *Feature) {
+	a.features = append(a.features, x)
+}
+
+func (a *duplicateRepo) StoreFeatureEvent(x *FeatureEvent) {}
+
+func (a *duplicateRepo) FindFeatureCommentsByFeature(workspaceID string, featureID string) ([]*FeatureComment, error) {
+	x := []*FeatureComment{}
+	for _, c := range a.comments {
+		if c.FeatureID == featureID {
+			cc := *c
+			x = append(x, &cc)
+		}
+	}
+	return x, nil
+}
+
+func (a *duplicateRepo) StoreFeatureComment(x *FeatureComment) {
+	a.comments = append(a.comments, x)
+}
+
+func (a *duplicateRepo) GetFeatureCommentOwnerByFeatureComment(workspaceID string, id string) (*FeatureCommentOwner, error) {
+	for _, o := range a.owners {
+		if o.FeatureCommentID == id {
+			c := *o
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *duplicateRepo) StoreFeatureCommentOwner(x *FeatureCommentOwner) {
+	a.owners = append(a.owners, x)
+}
+
+func TestDuplicateFeature(t *testing.T) {
+	for _, c := range []struct {
+		annotations bool
+		comments    bool
+	}{{false, false}, {true, false}, {false, true}, {true, true}} {
+		repo := &duplicateRepo{
+			features: []*Feature{
+				{ID: "f1", Rank: "b", Title: "Login", Description: "Form", Annotations: "RISKY", Estimate: 3, Status: "OPEN"},
+				{ID: "f2", Rank: "c", Title: "Logout"},
+			},
+			comments: []*FeatureComment{{ID: "c1", FeatureID: "f1", Post: "why?"}},
+			owners:   []*FeatureCommentOwner{{ID: "o1", FeatureCommentID: "c1", MemberID: "bob"}},
+		}
+		s := memberService(repo, "EDITOR")
+
+		x, err := s.DuplicateFeature("f1", "copy", c.annotations, c.comments)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if x.ID != "copy" || x.Title != "Login" || x.Description != "Form" || x.Estimate != 3 || x.CreatedByName != "ann" {
+			t.Error("copy should keep the content of the original", x)
+		}
+		if !(x.Rank > "b" && x.Rank < "c") {
+			t.Error("copy should be placed right after the original", x.Rank)
+		}
+		if (x.Annotations == "RISKY") != c.annotations {
+			t.Error("annotations should only be copied when asked for", c, x.Annotations)
+		}
+
+		cc, _ := repo.FindFeatureCommentsByFeature("ws", "copy")
+		if (len(cc) == 1) != c.comments || (c.comments && (cc[0].ID == "c1" || cc[0].Post != "why?")) {
+			t.Error("comments should only be copied when asked for", c, cc)
+		}
+		if c.comments {
+			if o, _ := repo.GetFeatureCommentOwnerByFeatureComment("ws", cc[0].ID); o == nil || o.MemberID != "bob" {
+				t.Error("copied comments should keep their owner")
+			}
+		}
+		if len(repo.comments) != map[bool]int{false: 1, true: 2}[c.comments] {
+			t.Error("original comments should be left alone")
+		}
+	}
+
+	repo := &duplicateRepo{features: []*Feature{{ID: "f1"}}}
+	s := memberService(repo, "EDITOR")
+	if _, err := s.DuplicateFeature("f1", "f1", false, false); err == nil {
+		t.Error("existing id should be rejected")
+	}
+}
@@ -21,16 +21,10 @@ type welcome struct {
 func WelcomeBody(w welcome) (string, error) {
 
 	data, err := tmpl.Asset("tmpl/welcome.tmpl")
-	t, err := template.New("").Parse(string(data))
 	if err != nil {
 		return "", err
 	}
-
-	buf := new(bytes.Buffer)
-	if err = t.Execute(buf, w); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
+	return executeEmailTemplate(string(data), w)
 }
 
 type emailBody struct {
@@ -43,23 +37,40 @@ type emailBody struct {
 func ChangeEmailBody(w emailBody) (string, error) {
 
 	data, err := tmpl.Asset("tmpl/email.tmpl")
-	t, err := template.New("").Parse(string(data))
+	if err != nil {
+		return "", err
+	}
+	return executeEmailTemplate(string(data), w)
+}
+
+// executeEmailTemplate renders the source of an email template with data
+func executeEmailTemplate(source string, data interface{}) (string, error) {
+	t, err := template.New("").Parse(source)
 	if err != nil {
 		return "", err
 	}
 
 	buf := new(bytes.Buffer)
-	if err = t.Execute(buf, w); err != nil {
+	if err = t.Execute(buf, data); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
-func (s *service) SendEmail(smtpServer string, smtpPort string, smtpUser string, smtpPass string, from string, recipient string, subject string, body string) error {
+// emailMessage returns the headers and body of an email, with the request ID when sent during a request
+func (s *service) emailMessage(from string, recipient string, subject string, body string) []byte {
 	date := time.Now().Format(time.RFC1123)
+	headers := "From: " + from + "\r\nTo: " + recipient + "\r\nSubject: " + subject + "\r\nDate: " + date + "\r\n"
+	if s.requestID != "" {
+		headers += s.config.RequestIDHeader + ": " + s.requestID + "\r\n"
+	}
+	return []byte(headers + "\r\n" + body)
+}
+
+func (s *service) SendEmail(smtpServer string, smtpPort string, smtpUser string, smtpPass string, from string, recipient string, subject string, body string) error {
 	err := smtp.SendMail(smtpServer+":"+smtpPort,
 		smtp.PlainAuth("", smtpUser, smtpPass, smtpServer),
-		from, []string{recipient}, []byte("From: "+from+"\r\nTo: "+recipient+"\r\nSubject: "+subject+"\r\nDate: "+date+"\r\n\r\n"+body))
+		from, []string{recipient}, s.emailMessage(from, recipient, subject, body))
 	if err != nil {
 		log.Printf("smtp error: %s", err)
 		return err
@@ -78,17 +89,62 @@ type resetPasswordBody struct {
 func ResetPasswordBody(w resetPasswordBody) (string, error) {
 
 	data, err := tmpl.Asset("tmpl/reset.tmpl")
-	t, err := template.New("").Parse(string(data))
+	if err != nil {
+		return "", err
+	}
+	return executeEmailTemplate(string(data), w)
+}
 
+type watchBody struct {
+	AppSiteURL    string
+	WorkspaceName string
+	ProjectID     string
+	FeatureID     string
+	FeatureTitle  string
+	Actor         string
+	Action        string
+	Post          string
+}
+
+func watchNotificationBody(w watchBody) (string, error) {
+	data, err := tmpl.Asset("tmpl/watch.tmpl")
 	if err != nil {
 		return "", err
 	}
+	return executeEmailTemplate(string(data), w)
+}
 
-	buf := new(bytes.Buffer)
-	if err = t.Execute(buf, w); err != nil {
+type accessRequestBody struct {
+	AppSiteURL    string
+	WorkspaceName string
+	ProjectID     string
+	ProjectTitle  string
+	Requester     string
+	Level         string
+}
+
+func accessRequestNotificationBody(w accessRequestBody) (string, error) {
+	data, err := tmpl.Asset("tmpl/access.tmpl")
+	if err != nil {
 		return "", err
 	}
-	return buf.String(), nil
+	return executeEmailTemplate(string(data), w)
+}
+
+type shareLinkBody struct {
+	AppSiteURL    string
+	WorkspaceName string
+	ProjectID     string
+	ProjectTitle  string
+	Days          int
+}
+
+func shareLinkRevokedBody(w shareLinkBody) (string, error) {
+	data, err := tmpl.Asset("tmpl/sharelink.tmpl")
+	if err != nil {
+		return "", err
+	}
+	return executeEmailTemplate(string(data), w)
 }
 
 // InviteStruct ...
@@ -103,14 +159,24 @@ type InviteStruct struct {
 
 func inviteBody(w InviteStruct) (string, error) {
 	data, err := tmpl.Asset("tmpl/invite.tmpl")
-	t, err := template.New("").Parse(string(data))
 	if err != nil {
 		return "", err
 	}
+	return executeEmailTemplate(string(data), w)
+}
 
-	buf := new(bytes.Buffer)
-	if err = t.Execute(buf, w); err != nil {
+func digestBody(items []*NotificationEmail) (string, error) {
+	data, err := tmpl.Asset("tmpl/digest.tmpl")
+	if err != nil {
 		return "", err
 	}
-	return buf.String(), nil
+	return executeEmailTemplate(string(data), items)
+}
+
+func batchBody(items []*NotificationEmail) (string, error) {
+	data, err := tmpl.Asset("tmpl/batch.tmpl")
+	if err != nil {
+		return "", err
+	}
+	return executeEmailTemplate(string(data), items)
 }
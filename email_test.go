@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// notificationRepo keeps notification emails in memory
+type notificationRepo struct {
+	Repository
+	emails []*NotificationEmail
+}
+
+func (a *notificationRepo) StoreNotificationEmail(x *NotificationEmail) {
+	a.emails = append(a.emails, x)
+}
+
+func (a *notificationRepo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	n := 0
+	for _, x := range a.emails {
+		if x.AccountID == accountID && !x.CreatedAt.Before(t) && !x.Digest {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestNotificationCap(t *testing.T) {
+	repo := &notificationRepo{}
+
+	s := &service{}
+	s.SetConfig(Configuration{DailyNotificationCap: 2})
+	s.SetRepoObject(repo)
+
+	sent := []string{}
+	send := func(n *NotificationEmail) { sent = append(sent, n.Subject) }
+
+	for _, subject := range []string{"one", "two", "three", "four"} {
+		s.notify(&NotificationEmail{AccountID: "ann", Email: "ann@example.com", Subject: subject}, send)
+	}
+	s.notify(&NotificationEmail{AccountID: "bob", Email: "bob@example.com", Subject: "other"}, send)
+
+	if len(sent) != 3 || sent[0] != "one" || sent[1] != "two" || sent[2] != "other" {
+		t.Error("only notifications within the cap should be sent", sent)
+	}
+
+	digested := 0
+	for _, x := range repo.emails {
+		if x.Digest {
+			digested++
+		}
+	}
+	if digested != 2 {
+		t.Error("notifications over the cap should be kept for the digest", digested)
+	}
+
+	body, err := digestBody(repo.emails[2:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "three") || !strings.Contains(body, "four") {
+		t.Error("digest should list the notifications over the cap", body)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"html/template"
+	"sort"
+	"text/template/parse"
+	"time"
+
+	"github.com/amborle/featmap/tmpl"
+	"github.com/pkg/errors"
+)
+
+// emailTemplate is an email the service sends, with the sample data it is previewed with and the
+// placeholders it cannot do without, such as the link in it
+type emailTemplate struct {
+	file     string
+	required []string
+	sample   func(appSiteURL string, workspace string) interface{}
+}
+
+var emailTemplates = map[string]emailTemplate{
+	"welcome": {"tmpl/welcome.tmpl", []string{"AppSiteURL", "Key"}, func(u string, ws string) interface{} {
+		return welcome{AppSiteURL: u, Email: "ann@example.com", Workspace: ws, Key: "sample-key"}
+	}},
+	"change-email": {"tmpl/email.tmpl", []string{"AppSiteURL", "Key"}, func(u string, ws string) interface{} {
+		return emailBody{AppSiteURL: u, Email: "ann@example.com", Key: "sample-key"}
+	}},
+	"reset-password": {"tmpl/reset.tmpl", []string{"AppSiteURL", "Key"}, func(u string, ws string) interface{} {
+		return resetPasswordBody{AppSiteURL: u, Email: "ann@example.com", Key: "sample-key"}
+	}},
+	"invite": {"tmpl/invite.tmpl", []string{"AppSiteURL", "Code"}, func(u string, ws string) interface{} {
+		return InviteStruct{AppSiteURL: u, Email: "bob@example.com", WorkspaceName: ws, Code: "sample-code", InvitedBy: "Ann", InvitedByEmail: "ann@example.com"}
+	}},
+	"watch": {"tmpl/watch.tmpl", []string{"AppSiteURL", "FeatureID"}, func(u string, ws string) interface{} {
+		return watchBody{AppSiteURL: u, WorkspaceName: ws, ProjectID: "sample-project", FeatureID: "sample-feature", FeatureTitle: "Sign in with email", Actor: "Ann", Action: "commented", Post: "Looks good to me."}
+	}},
+	"access-request": {"tmpl/access.tmpl", []string{"AppSiteURL", "ProjectID"}, func(u string, ws string) interface{} {
+		return accessRequestBody{AppSiteURL: u, WorkspaceName: ws, ProjectID: "sample-project", ProjectTitle: "Roadmap", Requester: "Bob", Level: "editor"}
+	}},
+	"share-link-revoked": {"tmpl/sharelink.tmpl", []string{"AppSiteURL", "ProjectID"}, func(u string, ws string) interface{} {
+		return shareLinkBody{AppSiteURL: u, WorkspaceName: ws, ProjectID: "sample-project", ProjectTitle: "Roadmap", Days: 30}
+	}},
+	"digest": {"tmpl/digest.tmpl", []string{"Body"}, func(u string, ws string) interface{} {
+		return []*NotificationEmail{{Subject: "Featmap: Ann commented", Body: "Ann commented on \"Sign in with email\".", CreatedAt: time.Now().UTC()}}
+	}},
+	"batch": {"tmpl/batch.tmpl", []string{"Body"}, func(u string, ws string) interface{} {
+		return []*NotificationEmail{{Subject: "Featmap: Sign in with email", Body: "Ann commented on \"Sign in with email\".", CreatedAt: time.Now().UTC()}}
+	}},
+}
+
+type emailPreview struct {
+	Type    string   `json:"type"`
+	Body    string   `json:"body"`
+	Missing []string `json:"missingPlaceholders"`
+}
+
+// templateFields returns the fields a template uses, such as AppSiteURL for {{.AppSiteURL}}
+func templateFields(t *template.Template) map[string]bool {
+	x := map[string]bool{}
+
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, c := range n.Nodes {
+					walk(c)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, c := range n.Cmds {
+					walk(c)
+				}
+			}
+		case *parse.CommandNode:
+			for _, a := range n.Args {
+				walk(a)
+			}
+		case *parse.FieldNode:
+			for _, f := range n.Ident {
+				x[f] = true
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+
+	if t.Tree != nil {
+		walk(t.Tree.Root)
+	}
+	return x
+}
+
+// PreviewEmail renders an email template with sample data without sending it. The source is the
+// built in template unless one is given, and the required placeholders it lacks are listed.
+func (s *service) PreviewEmail(kind string, source string) (*emailPreview, error) {
+	e, ok := emailTemplates[kind]
+	if !ok {
+		return nil, errors.New("unknown email type")
+	}
+
+	if source == "" {
+		data, err := tmpl.Asset(e.file)
+		if err != nil {
+			return nil, err
+		}
+		source = string(data)
+	}
+
+	t, err := template.New("").Parse(source)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid template")
+	}
+
+	fields := templateFields(t)
+	missing := []string{}
+	for _, f := range e.required {
+		if !fields[f] {
+			missing = append(missing, f)
+		}
+	}
+	sort.Strings(missing)
+
+	body, err := executeEmailTemplate(source, e.sample(s.config.AppSiteURL, s.GetWorkspaceByContext().Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid template")
+	}
+
+	return &emailPreview{Type: kind, Body: body, Missing: missing}, nil
+}
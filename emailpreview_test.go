@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+type previewRepo struct {
+	Repository
+}
+
+func (previewRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Name: "acme"}, nil
+}
+
+func TestPreviewEmail(t *testing.T) {
+	s := memberService(previewRepo{}, "ADMIN")
+	s.SetConfig(Configuration{AppSiteURL: "https://featmap.example.com"})
+
+	x, err := s.PreviewEmail("invite", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(x.Body, "https://featmap.example.com") || !strings.Contains(x.Body, "sample-code") || len(x.Missing) != 0 {
+		t.Error("the built in template should render with sample data", x)
+	}
+
+	x, err = s.PreviewEmail("invite", "{{.InvitedBy}} invited you to {{.WorkspaceName}}.{{if .Email}} Welcome!{{end}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Body != "Ann invited you to acme. Welcome!" {
+		t.Error("a draft template should render with sample data", x.Body)
+	}
+	if len(x.Missing) != 2 || x.Missing[0] != "AppSiteURL" || x.Missing[1] != "Code" {
+		t.Error("missing required placeholders should be flagged", x.Missing)
+	}
+
+	if _, err := s.PreviewEmail("invite", "{{.Nope}}"); err == nil {
+		t.Error("a template using unknown fields should be rejected")
+	}
+	if _, err := s.PreviewEmail("newsletter", ""); err == nil {
+		t.Error("unknown email types should be rejected")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// trackBlocked keeps BlockedSince in step with the BLOCKED annotation of a feature. Removing the
+// annotation also resets the escalation, so a feature blocked again is escalated again.
+func trackBlocked(f *Feature, now time.Time) {
+	blocked := stringInSlice("BLOCKED", strings.Split(f.Annotations, ","))
+	switch {
+	case blocked && f.BlockedSince == nil:
+		f.BlockedSince = &now
+	case !blocked:
+		f.BlockedSince = nil
+		f.BlockedEscalated = false
+	}
+}
+
+// EscalateBlockedFeatures tells the watchers of open features blocked for longer than
+// EscalateBlockedAfterHours, once per feature until it is unblocked. It runs outside of a request.
+func (s *service) EscalateBlockedFeatures(now time.Time) int {
+	hours := s.config.EscalateBlockedAfterHours
+	if hours <= 0 {
+		return 0
+	}
+
+	ff, err := s.r.FindFeaturesBlockedBefore(now.Add(-time.Duration(hours) * time.Hour))
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
+	for _, f := range ff {
+		f.BlockedEscalated = true
+		s.r.StoreFeature(f)
+		s.escalate(f, hours)
+	}
+
+	return len(ff)
+}
+
+func (s *service) escalate(f *Feature, hours int) {
+	ws, err := s.r.GetWorkspace(f.WorkspaceID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	m, err := s.r.GetMilestone(f.WorkspaceID, f.MilestoneID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	watchers, err := s.r.FindFeatureWatchersByFeature(f.WorkspaceID, f.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, w := range watchers {
+		body, err := watchNotificationBody(watchBody{
+			AppSiteURL:    s.config.AppSiteURL,
+			WorkspaceName: ws.Name,
+			ProjectID:     m.ProjectID,
+			FeatureID:     f.ID,
+			FeatureTitle:  f.Title,
+			Actor:         systemName,
+			Action:        "escalated a blocker",
+			Post:          fmt.Sprintf("The card has been blocked for more than %d hours.", hours),
+		})
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		s.notify(&NotificationEmail{AccountID: w.AccountID, Email: w.Email, Subject: "Featmap: " + f.Title + " is blocked", Body: body}, s.sendNotificationEmail)
+	}
+}
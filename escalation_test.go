@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// escalationRepo holds features in memory, each watched by one member
+type escalationRepo struct {
+	notificationRepo
+	features []*Feature
+}
+
+func (a *escalationRepo) FindFeaturesBlockedBefore(t time.Time) ([]*Feature, error) {
+	x := []*Feature{}
+	for _, f := range a.features {
+		if f.BlockedSince != nil && f.BlockedSince.Before(t) && !f.BlockedEscalated && f.Status == "OPEN" {
+			x = append(x, f)
+		}
+	}
+	return x, nil
+}
+
+func (a *escalationRepo) StoreFeature(x *Feature) {}
+
+func (a *escalationRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Name: "acme"}, nil
+}
+
+func (a *escalationRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *escalationRepo) FindFeatureWatchersByFeature(workspaceID string, featureID string) ([]*Member, error) {
+	return []*Member{{ID: "m-" + featureID, AccountID: "a-" + featureID, Email: featureID + "@example.com"}}, nil
+}
+
+// Over the cap, notifications are stored for the digest instead of sent
+func (a *escalationRepo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	return 1, nil
+}
+
+func TestTrackBlocked(t *testing.T) {
+	now := time.Now().UTC()
+	f := &Feature{Annotations: "RISKY,BLOCKED"}
+
+	trackBlocked(f, now)
+	if f.BlockedSince == nil || !f.BlockedSince.Equal(now) {
+		t.Error("blocked feature should be tracked from now")
+	}
+	trackBlocked(f, now.Add(time.Hour))
+	if !f.BlockedSince.Equal(now) {
+		t.Error("blocked feature should keep the time it was first blocked")
+	}
+
+	f.BlockedEscalated = true
+	f.Annotations = "RISKY"
+	trackBlocked(f, now)
+	if f.BlockedSince != nil || f.BlockedEscalated {
+		t.Error("unblocked feature should be reset")
+	}
+}
+
+func TestEscalateBlockedFeatures(t *testing.T) {
+	now := time.Now().UTC()
+	long, recent := now.Add(-72*time.Hour), now.Add(-time.Hour)
+	repo := &escalationRepo{features: []*Feature{
+		{WorkspaceID: "ws", ID: "long", Status: "OPEN", Annotations: "BLOCKED", BlockedSince: &long},
+		{WorkspaceID: "ws", ID: "recent", Status: "OPEN", Annotations: "BLOCKED", BlockedSince: &recent},
+		{WorkspaceID: "ws", ID: "closed", Status: "CLOSED", Annotations: "BLOCKED", BlockedSince: &long},
+		{WorkspaceID: "ws", ID: "unblocked", Status: "OPEN"},
+	}}
+
+	s := &service{}
+	s.SetRepoObject(repo)
+
+	if n := s.EscalateBlockedFeatures(now); n != 0 {
+		t.Error("escalation should be off unless configured")
+	}
+
+	s.SetConfig(Configuration{EscalateBlockedAfterHours: 48, DailyNotificationCap: 1})
+	if n := s.EscalateBlockedFeatures(now); n != 1 || len(repo.emails) != 1 || repo.emails[0].Email != "long@example.com" {
+		t.Error("only the long blocked card should be escalated", n, repo.emails)
+	}
+	if n := s.EscalateBlockedFeatures(now.Add(time.Hour)); n != 0 || len(repo.emails) != 1 {
+		t.Error("a card should be escalated only once")
+	}
+
+	f := repo.features[0]
+	f.Annotations = ""
+	trackBlocked(f, now)
+	f.Annotations = "BLOCKED"
+	trackBlocked(f, now.Add(-49*time.Hour))
+	if n := s.EscalateBlockedFeatures(now); n != 1 || len(repo.emails) != 2 {
+		t.Error("a card blocked again should be escalated again", n)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import "testing"
+
+// estimateRepo is newFeatureRepo whose features have no watchers
+type estimateRepo struct {
+	newFeatureRepo
+}
+
+func (a *estimateRepo) FindFeatureWatchersByFeature(workspaceID string, featureID string) ([]*Member, error) {
+	return []*Member{}, nil
+}
+
+// boundsRepo holds one feature in memory
+type boundsRepo struct {
+	Repository
+	feature *Feature
+}
+
+func (a *boundsRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	c := *a.feature
+	return &c, nil
+}
+
+func (a *boundsRepo) StoreFeature(x *Feature) {
+	a.feature = x
+}
+
+func (a *boundsRepo) StoreFeatureEvent(x *FeatureEvent) {}
+
+func TestEstimateBounds(t *testing.T) {
+	repo := &boundsRepo{feature: &Feature{ID: "f1", Estimate: 5}}
+	s := memberService(repo, "EDITOR")
+
+	if _, err := s.UpdateEstimateOnFeature("f1", 1000000000); err != errEstimateOutOfRange || repo.feature.Estimate != 5 {
+		t.Error("estimate above the default maximum should be rejected", err)
+	}
+	if f, err := s.UpdateEstimateOnFeature("f1", 999); err != nil || f.Estimate != 999 {
+		t.Error("estimate within the default bounds should be accepted", err)
+	}
+
+	s.SetConfig(Configuration{MinEstimate: 1, MaxEstimate: 100})
+	if _, err := s.UpdateEstimateOnFeature("f1", 101); err != errEstimateOutOfRange {
+		t.Error("estimate above the configured maximum should be rejected", err)
+	}
+	if _, err := s.UpdateEstimateOnFeature("f1", 0); err != nil {
+		t.Error("no estimate should always be accepted", err)
+	}
+
+	s.SetWorkspaceObject(&Workspace{ID: "ws", MinEstimate: 2, MaxEstimate: 20})
+	if _, err := s.UpdateEstimateOnFeature("f1", 21); err != errEstimateOutOfRange {
+		t.Error("estimate above the workspace maximum should be rejected", err)
+	}
+	if _, err := s.UpdateEstimateOnFeature("f1", 1); err != errEstimateOutOfRange {
+		t.Error("estimate below the workspace minimum should be rejected", err)
+	}
+	if f, err := s.UpdateEstimateOnFeature("f1", 13); err != nil || f.Estimate != 13 {
+		t.Error("estimate within the workspace bounds should be accepted", err)
+	}
+}
+
+func TestProjectEstimate(t *testing.T) {
+	repo := &estimateRepo{newFeatureRepo{favoriteRepo: favoriteRepo{projects: []*Project{{ID: "p1", DefaultEstimate: 3}}}, features: map[string]*Feature{}}}
+
+	s := memberService(repo, "EDITOR")
+
+	estimate := func(n int) *int { return &n }
+
+	if f, err := s.CreateFeatureWithID("f1", "sw1", "m1", "Login", nil); err != nil || f.Estimate != 3 {
+		t.Error("a feature without an estimate should get the default", f, err)
+	}
+	if f, err := s.CreateFeatureWithID("f2", "sw1", "m1", "Logout", estimate(0)); err != nil || f.Estimate != 0 {
+		t.Error("an estimate of 0 should be kept", f, err)
+	}
+	if f, err := s.CreateFeatureWithID("f3", "sw1", "m1", "Sign up", estimate(5)); err != nil || f.Estimate != 5 {
+		t.Error("an estimate should be kept", f, err)
+	}
+	if _, err := s.CreateFeatureWithID("f4", "sw1", "m1", "Reset", estimate(-1)); err != errEstimateOutOfRange {
+		t.Error("a negative estimate should be refused", err)
+	}
+
+	repo.projects[0] = &Project{ID: "p1", RequireEstimate: true}
+	if _, err := s.CreateFeatureWithID("f4", "sw1", "m1", "Reset", nil); err != errEstimateRequired {
+		t.Error("a feature should not be created without a required estimate", err)
+	}
+	if _, err := s.CreateFeatureWithID("f4", "sw1", "m1", "Reset", estimate(0)); err != errEstimateRequired {
+		t.Error("an estimate of 0 should not count as a required estimate", err)
+	}
+	if _, err := s.CloseFeature("f2"); err != errEstimateRequired {
+		t.Error("a feature without a required estimate should not be closed", err)
+	}
+	if f, err := s.CloseFeature("f3"); err != nil || f.Status != "CLOSED" {
+		t.Error("a feature with an estimate should be closed", f, err)
+	}
+}
+
+func TestCloseWithDefaultEstimate(t *testing.T) {
+	repo := &statusRepo{
+		project:  &Project{ID: "p1", RequireEstimate: true, DefaultEstimate: 3},
+		statuses: []*ProjectStatus{{ProjectID: "p1", ID: "s-open"}, {ProjectID: "p1", ID: "s-done", Closed: true}},
+		features: []*Feature{{WorkspaceID: "ws", ID: "f1", MilestoneID: "m1", Status: "OPEN", StatusID: "s-open"}},
+	}
+	s := memberService(repo, "EDITOR")
+
+	if _, err := s.CloseFeature("f1"); err != errEstimateRequired {
+		t.Error("the default estimate should not count for a feature without one", err)
+	}
+	if _, err := s.SetStatusOnFeature("f1", "s-done"); err != errEstimateRequired {
+		t.Error("the default estimate should not count for a feature moved to a closed status", err)
+	}
+
+	repo.features[0].Estimate = 2
+	if f, err := s.CloseFeature("f1"); err != nil || f.Status != "CLOSED" {
+		t.Error("a feature with an estimate should be closed", f, err)
+	}
+}
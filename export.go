@@ -0,0 +1,117 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// accountExport is the personal data tied to an account, for data subject access requests.
+// It holds only what the account itself created or chose, never other members' data.
+type accountExport struct {
+	ExportedAt    time.Time            `json:"exportedAt"`
+	Account       *Account             `json:"account"`
+	Memberships   []*membershipExport  `json:"memberships"`
+	Notifications []*NotificationEmail `json:"notifications"`
+	AdminActions  []*AdminAction       `json:"adminActions"`
+}
+
+type membershipExport struct {
+	Workspace        string            `json:"workspace"`
+	Member           *Member           `json:"member"`
+	Comments         []*FeatureComment `json:"comments"`
+	Watching         []*FeatureWatcher `json:"watching"`
+	FavoriteProjects []string          `json:"favoriteProjects"`
+}
+
+func (s *service) ExportAccountData() (*accountExport, error) {
+	if s.Acc == nil {
+		return nil, errors.New("not allowed")
+	}
+
+	x := &accountExport{ExportedAt: time.Now().UTC(), Account: s.Acc, Memberships: []*membershipExport{}}
+
+	mm, err := s.r.GetMembersByAccount(s.Acc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range mm {
+		ws, err := s.r.GetWorkspace(m.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+
+		me := &membershipExport{Workspace: ws.Name, Member: m}
+
+		if me.Comments, err = s.r.FindFeatureCommentsByMember(m.WorkspaceID, m.ID); err != nil {
+			return nil, err
+		}
+		for _, c := range me.Comments {
+			c.MemberID = m.ID
+		}
+		if me.Watching, err = s.r.FindFeatureWatchersByMember(m.WorkspaceID, m.ID); err != nil {
+			return nil, err
+		}
+		if me.FavoriteProjects, err = s.r.FindFavoriteProjectIDsByMember(m.WorkspaceID, m.ID); err != nil {
+			return nil, err
+		}
+
+		x.Memberships = append(x.Memberships, me)
+	}
+
+	if x.Notifications, err = s.r.FindNotificationEmailsByAccount(s.Acc.ID); err != nil {
+		return nil, err
+	}
+	if x.AdminActions, err = s.r.FindAdminActionsByAccount(s.Acc.ID); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+// writeAccountExport streams an export as a zip file with one JSON file per part, and one folder
+// per workspace the account is a member of
+func writeAccountExport(w io.Writer, x *accountExport) error {
+	z := zip.NewWriter(w)
+
+	add := func(name string, v interface{}) error {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: x.ExportedAt})
+		if err != nil {
+			return err
+		}
+		e := json.NewEncoder(f)
+		e.SetIndent("", "  ")
+		return e.Encode(v)
+	}
+
+	if err := add("account.json", x.Account); err != nil {
+		return err
+	}
+	for _, m := range x.Memberships {
+		dir := "workspaces/" + m.Workspace + "/"
+		if err := add(dir+"membership.json", m.Member); err != nil {
+			return err
+		}
+		if err := add(dir+"comments.json", m.Comments); err != nil {
+			return err
+		}
+		if err := add(dir+"watching.json", m.Watching); err != nil {
+			return err
+		}
+		if err := add(dir+"favorite-projects.json", m.FavoriteProjects); err != nil {
+			return err
+		}
+	}
+	if err := add("notifications.json", x.Notifications); err != nil {
+		return err
+	}
+	if err := add("admin-actions.json", x.AdminActions); err != nil {
+		return err
+	}
+
+	return z.Close()
+}
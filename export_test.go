@@ -0,0 +1,98 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// exportRepo holds comments by ann and bob in workspace "ws"
+type exportRepo struct {
+	Repository
+	comments map[string][]*FeatureComment
+}
+
+func (a *exportRepo) GetMembersByAccount(id string) ([]*Member, error) {
+	return []*Member{{WorkspaceID: "ws", ID: "m-" + id, AccountID: id, Level: "EDITOR"}}, nil
+}
+
+func (a *exportRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Name: "acme"}, nil
+}
+
+func (a *exportRepo) FindFeatureCommentsByMember(workspaceID string, memberID string) ([]*FeatureComment, error) {
+	return a.comments[memberID], nil
+}
+
+func (a *exportRepo) FindFeatureWatchersByMember(workspaceID string, memberID string) ([]*FeatureWatcher, error) {
+	return []*FeatureWatcher{{WorkspaceID: workspaceID, FeatureID: "f1", MemberID: memberID}}, nil
+}
+
+func (a *exportRepo) FindFavoriteProjectIDsByMember(workspaceID string, memberID string) ([]string, error) {
+	return []string{"p1"}, nil
+}
+
+func (a *exportRepo) FindNotificationEmailsByAccount(accountID string) ([]*NotificationEmail, error) {
+	return []*NotificationEmail{}, nil
+}
+
+func (a *exportRepo) FindAdminActionsByAccount(accountID string) ([]*AdminAction, error) {
+	return []*AdminAction{}, nil
+}
+
+func TestExportAccountData(t *testing.T) {
+	repo := &exportRepo{comments: map[string][]*FeatureComment{
+		"m-ann": {{WorkspaceID: "ws", ID: "c1", FeatureID: "f1", Post: "ann's comment"}},
+		"m-bob": {{WorkspaceID: "ws", ID: "c2", FeatureID: "f1", Post: "bob's private note"}},
+	}}
+
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+	s.SetAccountObject(&Account{ID: "ann", Name: "Ann", Email: "ann@example.com", Password: "hash"})
+
+	x, err := s.ExportAccountData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(x.Memberships) != 1 || x.Memberships[0].Workspace != "acme" || len(x.Memberships[0].Comments) != 1 {
+		t.Fatal("export should hold the membership and its comments", x)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeAccountExport(buf, x); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	for _, f := range z.File {
+		rc, _ := f.Open()
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	if !strings.Contains(files["account.json"], "ann@example.com") || strings.Contains(files["account.json"], "hash") {
+		t.Error("profile should be exported without secrets", files["account.json"])
+	}
+	if !strings.Contains(files["workspaces/acme/comments.json"], "ann's comment") {
+		t.Error("export should contain the account's comments")
+	}
+	if !strings.Contains(files["workspaces/acme/watching.json"], "f1") || files["workspaces/acme/membership.json"] == "" {
+		t.Error("export should contain the membership and watched cards")
+	}
+	for name, content := range files {
+		if strings.Contains(content, "bob") {
+			t.Error("export should not contain other users' data", name)
+		}
+	}
+
+	if _, err := NewFeatmapService().ExportAccountData(); err == nil {
+		t.Error("export needs an account")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+// memberService is the service of a request by ann, account a1, who is member m-ann of workspace
+// "ws" at level. The workspace has an active subscription.
+func memberService(repo Repository, level string) Service {
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+	s.SetAccountObject(&Account{ID: "a1", Name: "ann"})
+	s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "m-ann", Level: level})
+	s.SetWorkspaceObject(&Workspace{ID: "ws"})
+	s.SetSubscriptionObject(&Subscription{WorkspaceID: "ws", Status: "active"})
+	return s
+}
+
+// withService puts s in the context of req, as the User middleware does
+func withService(req *http.Request, s Service) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), contextKey, &Env{Service: s}))
+}
+
+// serveWith serves req from api mounted at pattern, behind middlewares, with s as the service
+func serveWith(s Service, pattern string, api func(r chi.Router), req *http.Request, middlewares ...func(http.Handler) http.Handler) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, withService(r, s))
+		})
+	})
+	r.Use(middlewares...)
+	r.Route(pattern, api)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// jsonRequest is a request with body sent as JSON
+func jsonRequest(method string, path string, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
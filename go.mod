@@ -12,6 +12,7 @@ require (
 	github.com/go-chi/render v1.0.1
 	github.com/golang-migrate/migrate/v4 v4.13.0
 	github.com/jmoiron/sqlx v1.2.0
+	github.com/lib/pq v1.3.0
 	github.com/pkg/errors v0.9.1
 	github.com/satori/go.uuid v1.2.0
 	github.com/stripe/stripe-go v70.15.0+incompatible
@@ -0,0 +1,144 @@
+package main
+
+import "testing"
+
+// goalRepo keeps goals and their milestone links in memory
+type goalRepo struct {
+	Repository
+	milestones []*Milestone
+	goals      map[string]*Goal
+	links      []*GoalMilestone
+}
+
+func (a *goalRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	if id != "p1" {
+		return nil, errNotFound
+	}
+	return &Project{WorkspaceID: workspaceID, ID: id}, nil
+}
+
+func (a *goalRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	for _, m := range a.milestones {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *goalRepo) FindMilestonesByProject(workspaceID string, projectID string) ([]*Milestone, error) {
+	return a.milestones, nil
+}
+
+func (a *goalRepo) GetGoal(workspaceID string, id string) (*Goal, error) {
+	if g, ok := a.goals[id]; ok {
+		c := *g
+		return &c, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *goalRepo) StoreGoal(x *Goal) {
+	a.goals[x.ID] = x
+}
+
+func (a *goalRepo) DeleteGoal(workspaceID string, id string) {
+	delete(a.goals, id)
+}
+
+func (a *goalRepo) StoreGoalMilestone(x *GoalMilestone) {
+	a.links = append(a.links, x)
+}
+
+func (a *goalRepo) DeleteGoalMilestone(workspaceID string, goalID string, milestoneID string) {
+	x := []*GoalMilestone{}
+	for _, l := range a.links {
+		if l.GoalID != goalID || l.MilestoneID != milestoneID {
+			x = append(x, l)
+		}
+	}
+	a.links = x
+}
+
+func (a *goalRepo) FindGoalMilestonesByProject(workspaceID string, projectID string) ([]*GoalMilestone, error) {
+	return a.links, nil
+}
+
+func TestGoals(t *testing.T) {
+	repo := &goalRepo{
+		milestones: []*Milestone{{ID: "m1", ProjectID: "p1"}, {ID: "m2", ProjectID: "p1"}, {ID: "other", ProjectID: "p2"}},
+		goals:      map[string]*Goal{},
+	}
+	s := memberService(repo, "EDITOR")
+
+	if _, err := s.CreateGoalWithID("g1", "p1", "Grow", "", "31-12-2026"); err == nil {
+		t.Error("invalid target date should be rejected")
+	}
+	if _, err := s.CreateGoalWithID("g1", "p2", "Grow", "", ""); err == nil {
+		t.Error("unknown project should be rejected")
+	}
+	g, err := s.CreateGoalWithID("g1", "p1", " Grow ", "More users", "2026-12-31")
+	if err != nil || g.Title != "Grow" || g.CreatedByName != "ann" {
+		t.Error("goal should be created", g, err)
+	}
+	if _, err := s.CreateGoalWithID("g1", "p1", "Grow", "", ""); err == nil {
+		t.Error("duplicate goal should be rejected")
+	}
+
+	if g, err = s.UpdateGoal("g1", "p1", "Retain", "", ""); err != nil || g.Title != "Retain" || g.TargetDate != "" {
+		t.Error("goal should be updated", g, err)
+	}
+	if _, err := s.UpdateGoal("g1", "p2", "Retain", "", ""); err == nil {
+		t.Error("goal should not be reachable through another project")
+	}
+
+	if err := s.LinkGoalToMilestone("g1", "p1", "m2"); err != nil {
+		t.Error(err)
+	}
+	if err := s.LinkGoalToMilestone("g1", "p1", "other"); err == nil {
+		t.Error("milestone of another project should be rejected")
+	}
+
+	mm := s.GetMilestonesByProject("p1")
+	if len(mm[0].GoalIDs) != 0 || len(mm[1].GoalIDs) != 1 || mm[1].GoalIDs[0] != "g1" {
+		t.Error("milestones should list their linked goals", mm[0].GoalIDs, mm[1].GoalIDs)
+	}
+
+	if err := s.UnlinkGoalFromMilestone("g1", "p1", "m2"); err != nil || len(repo.links) != 0 {
+		t.Error("link should be removed", err)
+	}
+
+	if err := s.DeleteGoal("g1", "p1"); err != nil || len(repo.goals) != 0 {
+		t.Error("goal should be deleted", err)
+	}
+	if err := s.DeleteGoal("g1", "p1"); err == nil {
+		t.Error("deleting a missing goal should fail")
+	}
+}
+
+func TestFilterProjectByGoal(t *testing.T) {
+	x := &projectResponse{
+		Milestones: []*Milestone{{ID: "m1", GoalIDs: []string{"g1"}}, {ID: "m2", GoalIDs: []string{"g2"}}, {ID: "m3", GoalIDs: []string{}}},
+		Features:   []*Feature{{ID: "f1", MilestoneID: "m1"}, {ID: "f2", MilestoneID: "m2"}, {ID: "f3", MilestoneID: "m3"}},
+		FeatureComments: []*FeatureComment{
+			{ID: "c1", FeatureID: "f1"},
+			{ID: "c2", FeatureID: "f2"},
+		},
+		Workflows: []*Workflow{{ID: "w1"}},
+	}
+
+	filterProjectByGoal(x, "g1")
+
+	if len(x.Milestones) != 1 || x.Milestones[0].ID != "m1" {
+		t.Error("only milestones linked to the goal should remain", x.Milestones)
+	}
+	if len(x.Features) != 1 || x.Features[0].ID != "f1" {
+		t.Error("only features in linked milestones should remain", x.Features)
+	}
+	if len(x.FeatureComments) != 1 || x.FeatureComments[0].ID != "c1" {
+		t.Error("only comments on remaining features should remain", x.FeatureComments)
+	}
+	if len(x.Workflows) != 1 {
+		t.Error("workflows should be kept")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// featureHistoryEntry is one event of a feature, with what changed since the one before
+type featureHistoryEntry struct {
+	Changes       []string  `json:"changes"`
+	Title         string    `json:"title"`
+	MilestoneID   string    `json:"milestoneId"`
+	Status        string    `json:"status"`
+	Estimate      int       `json:"estimate"`
+	CreatedAt     time.Time `json:"createdAt"`
+	CreatedByName string    `json:"createdByName"`
+}
+
+// featureHistory tells what changed between each event and the one before. Events only record
+// the title, milestone, status and estimate, so other edits show up as "updated".
+func featureHistory(ee []*FeatureEvent) []*featureHistoryEntry {
+	x := []*featureHistoryEntry{}
+
+	var prev *FeatureEvent
+	for _, e := range ee {
+		changes := []string{}
+		switch {
+		case prev == nil:
+			changes = append(changes, "created")
+		case e.Status == "DELETED":
+			changes = append(changes, "deleted")
+		default:
+			// Events from before titles were recorded have none
+			if e.Title != prev.Title && prev.Title != "" {
+				changes = append(changes, "title")
+			}
+			if e.MilestoneID != prev.MilestoneID {
+				changes = append(changes, "milestone")
+			}
+			if e.Status != prev.Status {
+				changes = append(changes, "status")
+			}
+			if e.Estimate != prev.Estimate {
+				changes = append(changes, "estimate")
+			}
+			if len(changes) == 0 {
+				changes = append(changes, "updated")
+			}
+		}
+
+		x = append(x, &featureHistoryEntry{
+			Changes:       changes,
+			Title:         e.Title,
+			MilestoneID:   e.MilestoneID,
+			Status:        e.Status,
+			Estimate:      e.Estimate,
+			CreatedAt:     e.CreatedAt,
+			CreatedByName: e.CreatedByName,
+		})
+		prev = e
+	}
+
+	return x
+}
+
+// GetFeatureHistory returns a page of the history of a feature, oldest first
+func (s *service) GetFeatureHistory(id string, offset int, limit int) ([]*featureHistoryEntry, error) {
+	if offset < 0 || limit <= 0 || limit > 200 {
+		return nil, errors.New("invalid page")
+	}
+
+	if _, err := s.r.GetFeature(s.Member.WorkspaceID, id); err != nil {
+		return nil, errors.New("feature not found")
+	}
+
+	// The event before the page is read as well, to tell what the first one changed
+	from, n := offset, limit
+	if offset > 0 {
+		from, n = offset-1, limit+1
+	}
+	ee, err := s.r.FindFeatureEventsByFeature(s.Member.WorkspaceID, id, from, n)
+	if err != nil {
+		return nil, err
+	}
+
+	x := featureHistory(ee)
+	if offset > 0 && len(x) > 0 {
+		x = x[1:]
+	}
+	return x, nil
+}
+
+func redactFeatureHistory(hh []*featureHistoryEntry, redactions string) {
+	if !stringInSlice("estimates", strings.Split(redactions, ",")) {
+		return
+	}
+
+	for _, h := range hh {
+		h.Estimate = 0
+		changes := []string{}
+		for _, c := range h.Changes {
+			if c != "estimate" {
+				changes = append(changes, c)
+			}
+		}
+		if len(changes) == 0 {
+			changes = append(changes, "updated")
+		}
+		h.Changes = changes
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+)
+
+// historyRepo keeps features and their events in memory
+type historyRepo struct {
+	Repository
+	features map[string]*Feature
+	events   []*FeatureEvent
+}
+
+func (a *historyRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	f, ok := a.features[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	c := *f
+	return &c, nil
+}
+
+func (a *historyRepo) StoreFeature(x *Feature) {
+	a.features[x.ID] = x
+}
+
+func (a *historyRepo) StoreFeatureEvent(x *FeatureEvent) {
+	a.events = append(a.events, x)
+}
+
+func (a *historyRepo) FindFeatureEventsByFeature(workspaceID string, featureID string, offset int, limit int) ([]*FeatureEvent, error) {
+	x := []*FeatureEvent{}
+	for _, e := range a.events {
+		if e.FeatureID == featureID {
+			x = append(x, e)
+		}
+	}
+	if offset >= len(x) {
+		return []*FeatureEvent{}, nil
+	}
+	x = x[offset:]
+	if len(x) > limit {
+		x = x[:limit]
+	}
+	return x, nil
+}
+
+func TestFeatureHistory(t *testing.T) {
+	repo := &historyRepo{features: map[string]*Feature{
+		"f1": {WorkspaceID: "ws", ID: "f1", MilestoneID: "m1", Title: "Login", Status: "OPEN"},
+		"f2": {WorkspaceID: "ws", ID: "f2", MilestoneID: "m1", Title: "Logout", Status: "OPEN"},
+	}}
+	for _, f := range repo.features {
+		f.LastModifiedByName = "Ann"
+		repo.events = append(repo.events, &FeatureEvent{FeatureID: f.ID, MilestoneID: f.MilestoneID, Status: f.Status, Title: f.Title, CreatedByName: "Ann"})
+	}
+
+	s := memberService(repo, "EDITOR")
+	s.SetAccountObject(&Account{Name: "Bob"})
+
+	if _, err := s.RenameFeature("f1", "Sign in"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RenameFeature("f2", "Sign out"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateFeatureDescription("f1", "With email"); err != nil {
+		t.Fatal(err)
+	}
+
+	hh, err := s.GetFeatureHistory("f1", 0, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hh) != 3 {
+		t.Fatal("the history should hold the creation and both edits of the feature only", len(hh))
+	}
+	if hh[0].Changes[0] != "created" || hh[1].Changes[0] != "title" || hh[1].Title != "Sign in" || hh[2].Changes[0] != "updated" {
+		t.Error("the edits should be in order", hh[0].Changes, hh[1].Changes, hh[2].Changes)
+	}
+	if hh[0].CreatedByName != "Ann" || hh[1].CreatedByName != "Bob" {
+		t.Error("each entry should name who made the change", hh[0].CreatedByName, hh[1].CreatedByName)
+	}
+
+	if hh, _ := s.GetFeatureHistory("f1", 1, 1); len(hh) != 1 || hh[0].Title != "Sign in" || hh[0].Changes[0] != "title" {
+		t.Error("the history should be paginated", hh)
+	}
+	if hh, _ := s.GetFeatureHistory("f1", 2, 5); len(hh) != 1 || hh[0].Changes[0] != "updated" {
+		t.Error("a page should tell what its first entry changed", hh)
+	}
+	if hh, err := s.GetFeatureHistory("f1", 3, 5); err != nil || len(hh) != 0 {
+		t.Error("a page past the end should be empty", hh, err)
+	}
+	if _, err := s.GetFeatureHistory("f3", 0, 50); err == nil {
+		t.Error("the history of a feature outside the workspace should not be found")
+	}
+}
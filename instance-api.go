@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+func instanceAPI(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Get("/info", getInstanceInfo)
+	})
+}
+
+func getInstanceInfo(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Mode      string `json:"mode"`
+		Telemetry bool   `json:"telemetry"`
+	}
+
+	c := GetEnv(r).Service.GetConfig()
+
+	render.JSON(w, r, response{
+		Mode:      c.Mode,
+		Telemetry: c.Telemetry,
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// inviteRepo holds the invites of workspace "ws"
+type inviteRepo struct {
+	Repository
+	invites []*Invite
+}
+
+func (a *inviteRepo) GetInviteByCode(code string) (*Invite, error) {
+	for _, x := range a.invites {
+		if x.Code == code {
+			return x, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *inviteRepo) GetInvite(wsid string, id string) (*Invite, error) {
+	for _, x := range a.invites {
+		if x.ID == id {
+			return x, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *inviteRepo) RenewInvite(wsid string, id string, t time.Time) {
+	for _, x := range a.invites {
+		if x.ID == id {
+			x.SentAt = t
+		}
+	}
+}
+
+func (a *inviteRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Name: "acme"}, nil
+}
+
+func TestCheckInvite(t *testing.T) {
+	now := time.Now().UTC()
+	invite := func(id string, sent time.Time) *Invite {
+		return &Invite{WorkspaceID: "ws", ID: id, Email: "bob@example.com", Level: "EDITOR", Code: "code-" + id,
+			CreatedBy: "m-ann", CreatedByName: "ann", CreatedByEmail: "ann@example.com", WorkspaceName: "acme", CreatedAt: sent, SentAt: sent}
+	}
+	repo := &inviteRepo{invites: []*Invite{invite("i1", now.AddDate(0, 0, -2)), invite("i2", now.AddDate(0, 0, -10))}}
+
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{InviteTTLDays: 7})
+	s.SetRepoObject(repo)
+
+	serve := func(code string) *httptest.ResponseRecorder {
+		return serveWith(s, "/v1/link", linkAPI, httptest.NewRequest("GET", "/v1/link/invite/"+code, nil))
+	}
+
+	w := serve("code-i1")
+	if w.Code != 200 || w.Body.String() != `{"workspaceName":"acme","invitedBy":"ann","level":"EDITOR"}`+"\n" {
+		t.Error("a valid invite should show the workspace, inviter and level only", w.Code, w.Body.String())
+	}
+	if w := serve("code-i2"); w.Code != 410 {
+		t.Error("an expired invite should be gone", w.Code)
+	}
+	if w := serve("unknown"); w.Code != 404 {
+		t.Error("an unknown invite should not be found", w.Code)
+	}
+
+	// Resending restarts the time to accept, but not when the invite was created
+	s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "m-ann", Level: "ADMIN"})
+	if err := s.SendInvitationMail("i2"); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve("code-i2"); w.Code != 200 {
+		t.Error("a resent invite should be valid again", w.Code)
+	}
+	if x := repo.invites[1]; !x.CreatedAt.Equal(now.AddDate(0, 0, -10)) || !x.SentAt.After(x.CreatedAt) {
+		t.Error("resending should keep when the invite was created", x.CreatedAt, x.SentAt)
+	}
+}
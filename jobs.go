@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const systemName = "Featmap"
+
+// defaultJobInterval is how often a job runs unless jobIntervalsMinutes says otherwise
+const defaultJobInterval = time.Hour
+
+// defaultJobIntervals are the jobs that run more often by default, as their results are waited for
+var defaultJobIntervals = map[string]time.Duration{
+	"send-notification-batches": time.Minute,
+	"report-usage":              24 * time.Hour,
+}
+
+// scheduledJob is a background job and the state of its runs
+type scheduledJob struct {
+	Name         string        `json:"name"`
+	Interval     time.Duration `json:"-"`
+	Running      bool          `json:"running"`
+	LastRun      *time.Time    `json:"lastRun"`
+	LastDuration string        `json:"lastDuration,omitempty"`
+	NextRun      time.Time     `json:"nextRun"`
+	Skipped      int           `json:"skipped"`
+	run          func()
+}
+
+// scheduler starts each job when it is due. A job that is still running when it is due again
+// is skipped, so a slow run never piles up behind itself.
+type scheduler struct {
+	mu   sync.Mutex
+	jobs []*scheduledJob
+	wg   sync.WaitGroup
+}
+
+// jobs is the scheduler of this instance
+var jobs *scheduler
+
+func newScheduler() *scheduler {
+	return &scheduler{}
+}
+
+func (x *scheduler) add(name string, interval time.Duration, now time.Time, run func()) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.jobs = append(x.jobs, &scheduledJob{Name: name, Interval: interval, NextRun: now.Add(interval), run: run})
+}
+
+// tick starts the jobs that are due at now
+func (x *scheduler) tick(now time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for _, j := range x.jobs {
+		if now.Before(j.NextRun) {
+			continue
+		}
+		for !now.Before(j.NextRun) {
+			j.NextRun = j.NextRun.Add(j.Interval)
+		}
+
+		if j.Running {
+			j.Skipped++
+			log.Printf("job %s skipped, previous run still going", j.Name)
+			continue
+		}
+
+		j.Running = true
+		x.wg.Add(1)
+		go x.start(j, now)
+	}
+}
+
+func (x *scheduler) start(j *scheduledJob, at time.Time) {
+	defer x.wg.Done()
+	started := time.Now()
+	j.run()
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	j.Running = false
+	j.LastRun = &at
+	j.LastDuration = time.Since(started).Round(time.Millisecond).String()
+}
+
+// status returns a copy of the state of the jobs, ordered by name
+func (x *scheduler) status() []scheduledJob {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	ss := []scheduledJob{}
+	for _, j := range x.jobs {
+		ss = append(ss, *j)
+	}
+	sort.Slice(ss, func(i, k int) bool { return ss[i].Name < ss[k].Name })
+	return ss
+}
+
+// jobInterval returns the configured interval of a job
+func jobInterval(c Configuration, name string) time.Duration {
+	if m := c.JobIntervalsMinutes[name]; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	if d, ok := defaultJobIntervals[name]; ok {
+		return d
+	}
+	return defaultJobInterval
+}
+
+// loop checks which jobs are due on every tick
+func (x *scheduler) loop(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for t := range ticker.C {
+		x.tick(t.UTC())
+	}
+}
+
+// scheduleJobs registers the background jobs
+func scheduleJobs(db *sqlx.DB, c Configuration) *scheduler {
+	now := time.Now().UTC()
+	s := newScheduler()
+
+	add := func(name string, f func(s Service)) {
+		s.add(name, jobInterval(c, name), now, func() { runJob(db, c, name, f) })
+	}
+
+	add("close-stale-features", func(s Service) {
+		if n := s.CloseStaleFeatures(time.Now().UTC()); n > 0 {
+			log.Printf("closed %d stale features", n)
+		}
+	})
+	add("send-notification-digests", func(s Service) {
+		if n := s.SendNotificationDigests(time.Now().UTC()); n > 0 {
+			log.Printf("sent %d notification digests", n)
+		}
+	})
+	add("send-notification-batches", func(s Service) {
+		if n := s.SendNotificationBatches(time.Now().UTC()); n > 0 {
+			log.Printf("sent %d notification batches", n)
+		}
+	})
+	add("reclaim-inactive-seats", func(s Service) {
+		if n := s.ReclaimInactiveSeats(time.Now().UTC()); n > 0 {
+			log.Printf("reclaimed %d inactive seats", n)
+		}
+	})
+	add("purge-unverified-accounts", func(s Service) {
+		if n := s.PurgeUnverifiedAccounts(time.Now().UTC()); n > 0 {
+			log.Printf("purged %d unverified accounts", n)
+		}
+	})
+	add("escalate-blocked-features", func(s Service) {
+		if n := s.EscalateBlockedFeatures(time.Now().UTC()); n > 0 {
+			log.Printf("escalated %d blocked features", n)
+		}
+	})
+	add("revoke-stale-share-links", func(s Service) {
+		if n := s.RevokeStaleShareLinks(time.Now().UTC()); n > 0 {
+			log.Printf("revoked %d stale share links", n)
+		}
+	})
+	add("purge-project-archives", func(s Service) {
+		s.PurgeProjectArchives(time.Now().UTC())
+	})
+	add("merge-duplicate-accounts", func(s Service) {
+		if n := s.MergeDuplicateAccounts(); n > 0 {
+			log.Printf("merged %d duplicate accounts", n)
+		}
+	})
+	add("report-usage", func(s Service) {
+		s.ReportUsage(time.Now().UTC())
+	})
+
+	return s
+}
+
+// runJob runs f in its own transaction, with a service that has no account or member. What f
+// queued with AfterCommit runs once the transaction has committed. f may commit and begin
+// transactions of its own through the repository.
+func runJob(db *sqlx.DB, c Configuration, name string, f func(s Service)) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("job %s failed: %v", name, p)
+		}
+	}()
+
+	s := NewFeatmapService()
+	s.SetConfig(c)
+
+	repo := NewFeatmapRepository(db)
+	if err := repo.Begin(context.Background(), nil); err != nil {
+		log.Printf("job %s failed: %v", name, err)
+		return
+	}
+	repo.LogSlowQueries(slowQueryThreshold(c), "job:"+name)
+	s.SetRepoObject(repo)
+
+	err := repoDo(repo, func() { f(s) })
+	if err != nil {
+		log.Printf("job %s failed: %v", name, err)
+	}
+	s.RunAfterCommit(err == nil)
+}
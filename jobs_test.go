@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newScheduler()
+
+	var runs int32
+	release := make(chan bool)
+	s.add("slow", time.Hour, start, func() {
+		atomic.AddInt32(&runs, 1)
+		<-release
+	})
+
+	s.tick(start.Add(59 * time.Minute))
+	if atomic.LoadInt32(&runs) != 0 {
+		t.Error("job should not run before it is due")
+	}
+
+	s.tick(start.Add(time.Hour))
+	s.tick(start.Add(2 * time.Hour))
+	release <- true
+	s.wg.Wait()
+
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Error("job should not overlap itself", n)
+	}
+	st := s.status()[0]
+	if st.Skipped != 1 || st.Running || !st.LastRun.Equal(start.Add(time.Hour)) || !st.NextRun.Equal(start.Add(3*time.Hour)) {
+		t.Error("unexpected job status", st)
+	}
+
+	go func() { release <- true }()
+	s.tick(start.Add(3 * time.Hour))
+	s.wg.Wait()
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Error("job should run again once the previous run is done", n)
+	}
+}
+
+func TestJobInterval(t *testing.T) {
+	c := Configuration{JobIntervalsMinutes: map[string]int{"close-stale-features": 15}}
+	if jobInterval(c, "close-stale-features") != 15*time.Minute || jobInterval(c, "purge-unverified-accounts") != time.Hour {
+		t.Error("unexpected job intervals")
+	}
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/amborle/featmap/lexorank"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// exportDocument is a project export as made by GET /projects/{ID} or a project archive,
+// possibly by another instance. Exports carry no version, so for every record it keeps which
+// fields were there: a field an older version did not have yet gets the value of a new record.
+type exportDocument struct {
+	projectResponse
+	fields   map[string][]map[string]bool
+	unmapped map[string]bool
+}
+
+// exportReport tells how an export was carried over
+type exportReport struct {
+	Summary   importSummary `json:"summary"`
+	Defaulted []string      `json:"defaulted"`
+	Unmapped  []string      `json:"unmapped"`
+}
+
+// exportFields gives the fields this version writes for a record of the kind of v
+func exportFields(v interface{}) map[string]bool {
+	data, _ := json.Marshal(v)
+	m := map[string]json.RawMessage{}
+	_ = json.Unmarshal(data, &m)
+	x := map[string]bool{}
+	for k := range m {
+		x[k] = true
+	}
+	return x
+}
+
+var exportSections = map[string]interface{}{
+	"project":          &Project{},
+	"milestones":       &Milestone{},
+	"workflows":        &Workflow{},
+	"subWorkflows":     &SubWorkflow{},
+	"features":         &Feature{},
+	"featureComments":  &FeatureComment{},
+	"personas":         &Persona{},
+	"workflowPersonas": &WorkflowPersona{},
+	"statuses":         &ProjectStatus{},
+	"goals":            &Goal{},
+}
+
+// parseExport reads an export. Sections and fields this version does not know are noted as
+// unmapped, and so are related projects, which live on the instance the export came from.
+func parseExport(data []byte) (*exportDocument, error) {
+	d := &exportDocument{fields: map[string][]map[string]bool{}, unmapped: map[string]bool{}}
+	if err := json.Unmarshal(data, &d.projectResponse); err != nil {
+		return nil, errors.New("invalid export")
+	}
+	if d.Project == nil {
+		return nil, errors.New("export has no project")
+	}
+
+	top := map[string]json.RawMessage{}
+	_ = json.Unmarshal(data, &top)
+	for section, raw := range top {
+		v, ok := exportSections[section]
+		if !ok {
+			if section != "related" || len(d.Related) > 0 {
+				d.unmapped[section] = true
+			}
+			continue
+		}
+
+		records := []map[string]json.RawMessage{}
+		if section == "project" {
+			records = append(records, map[string]json.RawMessage{})
+			_ = json.Unmarshal(raw, &records[0])
+		} else {
+			_ = json.Unmarshal(raw, &records)
+		}
+
+		known := exportFields(v)
+		for _, r := range records {
+			fields := map[string]bool{}
+			for k := range r {
+				fields[k] = true
+				if !known[k] {
+					d.unmapped[section+"."+k] = true
+				}
+			}
+			d.fields[section] = append(d.fields[section], fields)
+		}
+	}
+
+	return d, nil
+}
+
+// exportImport is an import of an export on its way into the workspace
+type exportImport struct {
+	d         *exportDocument
+	defaulted map[string]bool
+}
+
+// missing tells whether a field of a record has to be defaulted, because the export did not
+// have it or its value is not valid here
+func (im *exportImport) missing(section string, i int, field string, valid bool) bool {
+	fields := im.d.fields[section]
+	if i < len(fields) && fields[i][field] && valid {
+		return false
+	}
+	im.defaulted[section+"."+field] = true
+	return true
+}
+
+// exportRanks keeps the ranks of the records in each scope, unless one of them lacks a rank:
+// then that scope is ranked anew in the order of the export
+func exportRanks(scopes []string, ranks []string) []string {
+	missing := map[string]bool{}
+	count := map[string]int{}
+	for i, scope := range scopes {
+		count[scope]++
+		if ranks[i] == "" {
+			missing[scope] = true
+		}
+	}
+
+	spread := map[string][]string{}
+	for scope := range missing {
+		spread[scope] = lexorank.Spread(count[scope])
+	}
+
+	x := make([]string, len(ranks))
+	seen := map[string]int{}
+	for i, scope := range scopes {
+		x[i] = ranks[i]
+		if missing[scope] {
+			x[i] = spread[scope][seen[scope]]
+		}
+		seen[scope]++
+	}
+	return x
+}
+
+// ImportExport creates a new project from an export with new ids for everything in it. Like
+// ImportOutline everything is validated before anything is stored.
+func (s *service) ImportExport(title string, d *exportDocument) (*Project, *exportReport, error) {
+	if title == "" {
+		title = d.Project.Title
+	}
+	title, err := s.importProjectTitle(title)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workflows := map[string]bool{}
+	for _, x := range d.Workflows {
+		if _, err := validateTitle(x.Title); err != nil {
+			return nil, nil, errors.Wrap(err, "workflow")
+		}
+		workflows[x.ID] = true
+	}
+	subWorkflows := map[string]bool{}
+	for _, x := range d.SubWorkflows {
+		if _, err := validateTitle(x.Title); err != nil {
+			return nil, nil, errors.Wrap(err, "subworkflow")
+		}
+		if !workflows[x.WorkflowID] {
+			return nil, nil, errors.New("subworkflow refers to a missing workflow")
+		}
+		subWorkflows[x.ID] = true
+	}
+	milestones := map[string]bool{}
+	for _, x := range d.Milestones {
+		if _, err := validateTitle(x.Title); err != nil {
+			return nil, nil, errors.Wrap(err, "milestone")
+		}
+		milestones[x.ID] = true
+	}
+	cells := map[string]int{}
+	for _, x := range d.Features {
+		if _, err := validateTitle(x.Title); err != nil {
+			return nil, nil, errors.Wrap(err, "feature")
+		}
+		if !milestones[x.MilestoneID] || !subWorkflows[x.SubWorkflowID] {
+			return nil, nil, errors.New("feature refers to a missing milestone or subworkflow")
+		}
+		cells[x.MilestoneID+"/"+x.SubWorkflowID]++
+		if s.featureCapExceeded(cells[x.MilestoneID+"/"+x.SubWorkflowID]) {
+			return nil, nil, errors.New("too many features")
+		}
+	}
+
+	im := &exportImport{d: d, defaulted: map[string]bool{}}
+	ws := s.Member.WorkspaceID
+	t := time.Now().UTC()
+	newID := func() string { return uuid.Must(uuid.NewV4(), nil).String() }
+	ids := map[string]string{}
+
+	p, err := s.CreateProjectWithID(newID(), title)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.Description = d.Project.Description
+	p.Annotations = d.Project.Annotations
+	p.AutoCloseDays = d.Project.AutoCloseDays
+	p.RequireEstimate = d.Project.RequireEstimate
+	p.DefaultEstimate = d.Project.DefaultEstimate
+	p.DefaultAnnotations = d.Project.DefaultAnnotations
+	if im.missing("project", 0, "defaultEstimate", s.checkEstimate(p.DefaultEstimate) == nil) {
+		p.DefaultEstimate = 0
+	}
+	s.r.StoreProject(p)
+
+	scopes, ranks := []string{}, []string{}
+	for _, x := range d.Statuses {
+		scopes, ranks = append(scopes, ""), append(ranks, x.Rank)
+	}
+	ranks = exportRanks(scopes, ranks)
+	seeded, _ := s.r.FindProjectStatusesByProject(ws, p.ID)
+	for _, y := range seeded {
+		// The export brings its own statuses of this kind
+		for _, x := range d.Statuses {
+			if x.Closed == y.Closed {
+				s.r.DeleteProjectStatus(ws, y.ID, "")
+				break
+			}
+		}
+	}
+	closed := map[string]bool{}
+	for i, x := range d.Statuses {
+		ids[x.ID] = newID()
+		closed[ids[x.ID]] = x.Closed
+		x.WorkspaceID, x.ProjectID, x.ID = ws, p.ID, ids[x.ID]
+		im.missing("statuses", i, "rank", x.Rank != "")
+		x.Rank = ranks[i]
+		if im.missing("statuses", i, "createdAt", !x.CreatedAt.IsZero()) {
+			x.CreatedAt = t
+		}
+		s.r.StoreProjectStatus(x)
+	}
+
+	scopes, ranks = []string{}, []string{}
+	for _, x := range d.Milestones {
+		scopes, ranks = append(scopes, ""), append(ranks, x.Rank)
+	}
+	ranks = exportRanks(scopes, ranks)
+	goalMilestones := map[string][]string{}
+	for i, x := range d.Milestones {
+		ids[x.ID] = newID()
+		x.WorkspaceID, x.ProjectID, x.ID = ws, p.ID, ids[x.ID]
+		im.missing("milestones", i, "rank", x.Rank != "")
+		x.Rank = ranks[i]
+		if im.missing("milestones", i, "status", x.Status == "OPEN" || x.Status == "CLOSED") {
+			x.Status = "OPEN"
+		}
+		if im.missing("milestones", i, "color", colorIsValid(x.Color)) {
+			x.Color = "WHITE"
+		}
+		if im.missing("milestones", i, "icon", iconIsValid(x.Icon)) {
+			x.Icon = ""
+		}
+		for _, g := range x.GoalIDs {
+			goalMilestones[g] = append(goalMilestones[g], x.ID)
+		}
+		x.GoalIDs = nil
+		x.CreatedAt, x.CreatedByName = t, s.Acc.Name
+		x.LastModified, x.LastModifiedByName = t, s.Acc.Name
+		s.r.StoreMilestone(x)
+	}
+
+	scopes, ranks = []string{}, []string{}
+	for _, x := range d.Workflows {
+		scopes, ranks = append(scopes, ""), append(ranks, x.Rank)
+	}
+	ranks = exportRanks(scopes, ranks)
+	for i, x := range d.Workflows {
+		ids[x.ID] = newID()
+		x.WorkspaceID, x.ProjectID, x.ID = ws, p.ID, ids[x.ID]
+		im.missing("workflows", i, "rank", x.Rank != "")
+		x.Rank = ranks[i]
+		if im.missing("workflows", i, "status", x.Status == "OPEN" || x.Status == "CLOSED") {
+			x.Status = "OPEN"
+		}
+		if im.missing("workflows", i, "color", colorIsValid(x.Color)) {
+			x.Color = "WHITE"
+		}
+		x.CreatedAt, x.CreatedByName = t, s.Acc.Name
+		x.LastModified, x.LastModifiedByName = t, s.Acc.Name
+		s.r.StoreWorkflow(x)
+	}
+
+	scopes, ranks = []string{}, []string{}
+	for _, x := range d.SubWorkflows {
+		scopes, ranks = append(scopes, x.WorkflowID), append(ranks, x.Rank)
+	}
+	ranks = exportRanks(scopes, ranks)
+	for i, x := range d.SubWorkflows {
+		ids[x.ID] = newID()
+		x.WorkspaceID, x.WorkflowID, x.ID = ws, ids[x.WorkflowID], ids[x.ID]
+		im.missing("subWorkflows", i, "rank", x.Rank != "")
+		x.Rank = ranks[i]
+		if im.missing("subWorkflows", i, "status", x.Status == "OPEN" || x.Status == "CLOSED") {
+			x.Status = "OPEN"
+		}
+		if im.missing("subWorkflows", i, "color", colorIsValid(x.Color)) {
+			x.Color = "WHITE"
+		}
+		x.CreatedAt, x.CreatedByName = t, s.Acc.Name
+		x.LastModified, x.LastModifiedByName = t, s.Acc.Name
+		s.r.StoreSubWorkflow(x)
+	}
+
+	p.DefaultMilestoneID = ids[d.Project.DefaultMilestoneID]
+	p.DefaultSubWorkflowID = ids[d.Project.DefaultSubWorkflowID]
+	s.r.StoreProject(p)
+
+	scopes, ranks = []string{}, []string{}
+	for _, x := range d.Features {
+		scopes, ranks = append(scopes, x.MilestoneID+"/"+x.SubWorkflowID), append(ranks, x.Rank)
+	}
+	ranks = exportRanks(scopes, ranks)
+	for i, x := range d.Features {
+		references := x.References
+		ids[x.ID] = newID()
+		x.WorkspaceID, x.ID = ws, ids[x.ID]
+		x.MilestoneID, x.SubWorkflowID = ids[x.MilestoneID], ids[x.SubWorkflowID]
+		im.missing("features", i, "rank", x.Rank != "")
+		x.Rank = ranks[i]
+		if im.missing("features", i, "status", x.Status == "OPEN" || x.Status == "CLOSED") {
+			x.Status = "OPEN"
+		}
+		im.missing("features", i, "statusId", x.StatusID == "" || ids[x.StatusID] != "")
+		if c, ok := closed[ids[x.StatusID]]; ok {
+			x.StatusID, x.Status = ids[x.StatusID], statusFromClosed(c)
+		} else {
+			x.StatusID = s.firstStatus(ws, p.ID, x.Status == "CLOSED")
+		}
+		if im.missing("features", i, "color", colorIsValid(x.Color)) {
+			x.Color = "WHITE"
+		}
+		if im.missing("features", i, "icon", iconIsValid(x.Icon)) {
+			x.Icon = ""
+		}
+		if im.missing("features", i, "annotations", areAnnotationsValid(x.Annotations)) {
+			x.Annotations = p.DefaultAnnotations
+		}
+		if im.missing("features", i, "estimate", s.checkEstimate(x.Estimate) == nil) {
+			x.Estimate = p.DefaultEstimate
+		}
+		if im.missing("features", i, "progress", x.Progress >= 0 && x.Progress <= 100) {
+			x.Progress = 0
+		}
+		x.BlockedEscalated, x.Watching, x.References = false, false, nil
+		x.CreatedAt, x.CreatedByName = t, s.Acc.Name
+		x.LastModified, x.LastModifiedByName = t, s.Acc.Name
+		s.r.StoreFeature(x)
+
+		for _, ref := range references {
+			ref.WorkspaceID, ref.ProjectID, ref.FeatureID, ref.ID = ws, p.ID, x.ID, newID()
+			if ref.CreatedAt.IsZero() {
+				ref.CreatedAt = t
+			}
+			s.r.StoreFeatureReference(ref)
+		}
+	}
+
+	for i, x := range d.FeatureComments {
+		if ids[x.FeatureID] == "" {
+			continue
+		}
+		x.WorkspaceID, x.ProjectID, x.FeatureID, x.ID = ws, p.ID, ids[x.FeatureID], newID()
+		x.MemberID = ""
+		if im.missing("featureComments", i, "createdAt", !x.CreatedAt.IsZero()) {
+			x.CreatedAt = t
+		}
+		if im.missing("featureComments", i, "lastModified", !x.LastModified.IsZero()) {
+			x.LastModified = x.CreatedAt
+		}
+		s.r.StoreFeatureComment(x)
+	}
+
+	for i, x := range d.Personas {
+		ids[x.ID] = newID()
+		x.WorkspaceID, x.ProjectID, x.ID = ws, p.ID, ids[x.ID]
+		if im.missing("personas", i, "createdAt", !x.CreatedAt.IsZero()) {
+			x.CreatedAt = t
+		}
+		s.r.StorePersona(x)
+	}
+
+	for _, x := range d.WorkflowPersonas {
+		if ids[x.WorkflowID] == "" || ids[x.PersonaID] == "" {
+			continue
+		}
+		x.WorkspaceID, x.ProjectID, x.ID = ws, p.ID, newID()
+		x.WorkflowID, x.PersonaID = ids[x.WorkflowID], ids[x.PersonaID]
+		s.r.StoreWorkflowPersona(x)
+	}
+
+	for _, x := range d.Goals {
+		oldID := x.ID
+		x.WorkspaceID, x.ProjectID, x.ID = ws, p.ID, newID()
+		x.CreatedAt, x.CreatedByName = t, s.Acc.Name
+		x.LastModified, x.LastModifiedByName = t, s.Acc.Name
+		s.r.StoreGoal(x)
+		for _, m := range goalMilestones[oldID] {
+			s.r.StoreGoalMilestone(&GoalMilestone{WorkspaceID: ws, ProjectID: p.ID, GoalID: x.ID, MilestoneID: m})
+		}
+	}
+
+	report := &exportReport{
+		Summary:   importSummary{Milestones: len(d.Milestones), SubWorkflows: len(d.SubWorkflows), Features: len(d.Features)},
+		Defaulted: []string{},
+		Unmapped:  []string{},
+	}
+	for k := range im.defaulted {
+		report.Defaulted = append(report.Defaulted, k)
+	}
+	for k := range d.unmapped {
+		report.Unmapped = append(report.Unmapped, k)
+	}
+	sort.Strings(report.Defaulted)
+	sort.Strings(report.Unmapped)
+
+	return p, report, nil
+}
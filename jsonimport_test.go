@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// portableRepo keeps everything an import stores
+type portableRepo struct {
+	Repository
+	projects     []*Project
+	milestones   []*Milestone
+	workflows    []*Workflow
+	subWorkflows []*SubWorkflow
+	features     []*Feature
+	comments     []*FeatureComment
+	statuses     []*ProjectStatus
+	goals        []*GoalMilestone
+}
+
+func (a *portableRepo) FindProjectsByWorkspace(workspaceID string) ([]*Project, error) {
+	return []*Project{{Title: "Shop"}}, nil
+}
+
+func (a *portableRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	return nil, errNotFound
+}
+
+func (a *portableRepo) StoreProject(x *Project) { a.projects = append(a.projects, x) }
+
+func (a *portableRepo) StoreMilestone(x *Milestone) { a.milestones = append(a.milestones, x) }
+
+func (a *portableRepo) StoreWorkflow(x *Workflow) { a.workflows = append(a.workflows, x) }
+
+func (a *portableRepo) StoreSubWorkflow(x *SubWorkflow) { a.subWorkflows = append(a.subWorkflows, x) }
+
+func (a *portableRepo) StoreFeature(x *Feature) { a.features = append(a.features, x) }
+
+func (a *portableRepo) StoreFeatureComment(x *FeatureComment) { a.comments = append(a.comments, x) }
+
+func (a *portableRepo) StoreProjectStatus(x *ProjectStatus) { a.statuses = append(a.statuses, x) }
+
+func (a *portableRepo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	return a.statuses, nil
+}
+
+func (a *portableRepo) DeleteProjectStatus(workspaceID string, id string, replacementID string) {
+	for i, x := range a.statuses {
+		if x.ID == id {
+			a.statuses = append(a.statuses[:i], a.statuses[i+1:]...)
+			return
+		}
+	}
+}
+
+func (a *portableRepo) StoreGoal(x *Goal) {}
+
+func (a *portableRepo) StoreGoalMilestone(x *GoalMilestone) { a.goals = append(a.goals, x) }
+
+// olderExport is an export from before colors, annotations, estimates, statuses and ranks
+// on features, with a field and a section this version does not know
+const olderExport = `{
+	"project": {"id": "p1", "title": "Shop", "description": "The shop"},
+	"milestones": [
+		{"id": "m1", "title": "MVP", "rank": "b", "status": "OPEN"},
+		{"id": "m2", "title": "Later", "rank": "c", "status": "CLOSED"}
+	],
+	"workflows": [{"id": "w1", "title": "Buy", "rank": "b"}],
+	"subWorkflows": [{"id": "s1", "workflowId": "w1", "title": "Check out", "rank": "b"}],
+	"features": [
+		{"id": "f1", "milestoneId": "m1", "subWorkflowId": "s1", "title": "Pay", "status": "OPEN", "owner": "bob"},
+		{"id": "f2", "milestoneId": "m1", "subWorkflowId": "s1", "title": "Refund", "status": "CLOSED"},
+		{"id": "f3", "milestoneId": "m2", "subWorkflowId": "s1", "title": "Discounts", "status": "OPEN"}
+	],
+	"featureComments": [{"id": "c1", "featureId": "f1", "post": "Cards only?", "createdByName": "bob"}],
+	"tags": []
+}`
+
+func TestImportOlderExport(t *testing.T) {
+	repo := &portableRepo{}
+	s := memberService(repo, "EDITOR")
+
+	d, err := parseExport([]byte(olderExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, report, err := s.ImportExport("", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.ID == "p1" || p.Title != "Shop (2)" || p.Description != "The shop" || p.WorkspaceID != "ws" {
+		t.Error("should create a new project from the export", p)
+	}
+	if len(repo.milestones) != 2 || len(repo.workflows) != 1 || len(repo.subWorkflows) != 1 || len(repo.features) != 3 || len(repo.comments) != 1 {
+		t.Fatal("should import everything in the export")
+	}
+
+	ids := map[string]string{}
+	for _, m := range repo.milestones {
+		ids[m.Title] = m.ID
+		if m.ProjectID != p.ID || m.ID == "m1" || m.ID == "m2" || m.Color != "WHITE" {
+			t.Error("milestone should be remapped with the default color", m)
+		}
+	}
+	if repo.milestones[1].Status != "CLOSED" || repo.milestones[0].Rank != "b" {
+		t.Error("fields in the export should be kept", repo.milestones)
+	}
+	if sw := repo.subWorkflows[0]; sw.WorkflowID != repo.workflows[0].ID || sw.Color != "WHITE" || sw.Status != "OPEN" {
+		t.Error("subworkflow should be remapped with defaults", sw)
+	}
+
+	if len(repo.statuses) != 2 || repo.statuses[0].Closed || !repo.statuses[1].Closed {
+		t.Fatal("the project should get the default statuses", repo.statuses)
+	}
+	seeded := map[string]string{"OPEN": repo.statuses[0].ID, "CLOSED": repo.statuses[1].ID}
+	for _, f := range repo.features {
+		if f.ID == "f1" || f.SubWorkflowID != repo.subWorkflows[0].ID || f.Color != "WHITE" || f.Estimate != 0 || f.Rank == "" {
+			t.Error("feature should be remapped with defaults", f)
+		}
+		if f.StatusID != seeded[f.Status] {
+			t.Error("feature should get the default status of its kind", f)
+		}
+	}
+	if f := repo.features[0]; f.MilestoneID != ids["MVP"] || repo.features[2].MilestoneID != ids["Later"] {
+		t.Error("features should stay in their milestones", f)
+	}
+	if repo.features[0].Rank >= repo.features[1].Rank {
+		t.Error("features should be ranked in the order of the export")
+	}
+	if repo.features[1].Status != "CLOSED" {
+		t.Error("feature status should be kept")
+	}
+	if c := repo.comments[0]; c.FeatureID != repo.features[0].ID || c.ProjectID != p.ID || c.CreatedAt.IsZero() || c.CreatedByName != "bob" {
+		t.Error("comment should follow its feature", c)
+	}
+
+	if strings.Join(report.Unmapped, ",") != "features.owner,tags" {
+		t.Error("unknown fields should be reported", report.Unmapped)
+	}
+	defaulted := strings.Join(report.Defaulted, ",")
+	for _, f := range []string{"features.color", "features.rank", "features.estimate", "milestones.color", "workflows.status"} {
+		if !strings.Contains(defaulted, f) {
+			t.Error("defaulted field should be reported", f, defaulted)
+		}
+	}
+	if strings.Contains(defaulted, "milestones.rank") {
+		t.Error("ranks in the export should not be reported", defaulted)
+	}
+	if report.Summary.Features != 3 {
+		t.Error("wrong summary", report.Summary)
+	}
+}
+
+func TestImportExportRejects(t *testing.T) {
+	s := memberService(&portableRepo{}, "EDITOR")
+
+	if _, err := parseExport([]byte(`{"milestones": []}`)); err == nil {
+		t.Error("an export without a project should be rejected")
+	}
+
+	d, err := parseExport([]byte(`{"project": {"title": "X"}, "milestones": [{"id": "m1", "title": "M"}], "features": [{"id": "f1", "milestoneId": "m1", "subWorkflowId": "gone", "title": "F"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.ImportExport("", d); err == nil {
+		t.Error("a feature outside the export should be rejected")
+	}
+}
+
+func TestImportJSONHandler(t *testing.T) {
+	repo := &portableRepo{}
+	s := memberService(repo, "EDITOR")
+
+	body, _ := json.Marshal(map[string]interface{}{"title": "Moved", "export": json.RawMessage(olderExport)})
+	w := serveWith(s, "/v1/", workspaceAPI, jsonRequest("POST", "/v1/import/json", string(body)))
+	if w.Code != 200 {
+		t.Fatal("import failed", w.Code, w.Body.String())
+	}
+
+	resp := struct {
+		Project  *Project `json:"project"`
+		Unmapped []string `json:"unmapped"`
+	}{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Project == nil || resp.Project.Title != "Moved" || len(resp.Unmapped) != 2 {
+		t.Error("should respond with the project and what was not mapped", w.Body.String())
+	}
+}
@@ -56,3 +56,32 @@ func getChar(s string, i int, defaultChar byte) byte {
 	}
 	return s[i]
 }
+
+// Spread returns n evenly spaced ranks of equal length, in order. They stay below maxChar and
+// none of them ends with minChar, so there is always room to rank before, between and after them.
+func Spread(n int) []string {
+	const base = int(maxChar-minChar) + 1
+
+	width, size := 1, base
+	for size-size/base < 2*(n+1) {
+		width++
+		size *= base
+	}
+	step := (size - size/base) / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		v := (i + 1) * step
+		if v%base == 0 {
+			v++
+		}
+
+		b := make([]byte, width)
+		for k := width - 1; k >= 0; k-- {
+			b[k] = minChar + byte(v%base)
+			v /= base
+		}
+		ranks[i] = string(b)
+	}
+	return ranks
+}
@@ -10,3 +10,31 @@ func TestSimple(t *testing.T) {
 		t.Error() // to indicate test failed
 	}
 }
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 12, 25, 26, 300, 1000} {
+		ranks := Spread(n)
+		if len(ranks) != n {
+			t.Error("wrong number of ranks", n)
+			continue
+		}
+
+		prev := ""
+		for i, r := range ranks {
+			if r <= prev || len(r) != len(ranks[0]) || r[len(r)-1] == minChar {
+				t.Error("ranks should be increasing, of equal length and not end with the min char", n, i, r)
+			}
+			if _, ok := Rank(prev, r); !ok {
+				t.Error("there should be room before", r)
+			}
+			prev = r
+		}
+		if _, ok := Rank(prev, ""); n > 0 && !ok {
+			t.Error("there should be room after the last rank", prev)
+		}
+	}
+
+	if r := Spread(1); r[0] != "m" {
+		t.Error("a single rank should sit in the middle", r)
+	}
+}
@@ -11,6 +11,8 @@ import (
 func linkAPI(r chi.Router) {
 	r.Group(func(r chi.Router) {
 
+		r.Get("/invite/{CODE}", checkInvite)
+
 		r.Route("/{LINK}",
 			func(r chi.Router) {
 				r.Get("/", getLink)
@@ -25,7 +27,7 @@ func getLink(w http.ResponseWriter, r *http.Request) {
 
 	project, err := s.GetProjectByExternalLink(link)
 
-	if err != nil {
+	if err != nil || project.ExternalLinkRevokedAt != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(errors.New("not found")))
 		return
 	}
@@ -48,7 +50,7 @@ func getLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !ws.AllowExternalSharing {
+	if ws.Suspended || !ws.AllowExternalSharing {
 		_ = render.Render(w, r, ErrInvalidRequest(errors.New("not allowed")))
 		return
 	}
@@ -59,5 +61,36 @@ func getLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if redactsFor("") {
+		redactProjectResponse(extended, ws.ViewerRedactions)
+	}
+	s.RecordShareLinkView(project)
+
 	render.JSON(w, r, extended)
 }
+
+// checkInvite shows what an invite is for without accepting it
+func checkInvite(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "CODE")
+
+	invite, err := GetEnv(r).Service.GetInvite(code)
+	if err == errInviteExpired {
+		_ = render.Render(w, r, ErrGone(err))
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrNotFound(errors.New("not found")))
+		return
+	}
+
+	type response struct {
+		WorkspaceName string `json:"workspaceName"`
+		InvitedBy     string `json:"invitedBy"`
+		Level         string `json:"level"`
+	}
+	render.JSON(w, r, response{
+		WorkspaceName: invite.WorkspaceName,
+		InvitedBy:     invite.CreatedByName,
+		Level:         invite.Level,
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultLocale = "en"
+
+// messages translates error messages, keyed by the message the service returns. Some of those are
+// codes the frontend switches on, so the message itself is never changed and the translation is
+// sent next to it.
+var messages = map[string]map[string]string{
+	"en": {
+		"name_invalid":      "The name is not valid.",
+		"password_invalid":  "The password is not valid.",
+		"email_taken":       "The email is already taken.",
+		"workspace_invalid": "The workspace name is not valid.",
+		"workspace_taken":   "The workspace name is already taken.",
+		"post_too_long":     "The comment is too long.",
+	},
+	"sv": {
+		"name_invalid":        "Namnet är inte giltigt.",
+		"password_invalid":    "Lösenordet är inte giltigt.",
+		"email_taken":         "E-postadressen används redan.",
+		"workspace_invalid":   "Namnet på arbetsytan är inte giltigt.",
+		"workspace_taken":     "Namnet på arbetsytan används redan.",
+		"post_too_long":       "Kommentaren är för lång.",
+		"already exists":      "Finns redan.",
+		"not found":           "Hittades inte.",
+		"not allowed":         "Inte tillåtet.",
+		"feature not found":   "Kortet hittades inte.",
+		"milestone not found": "Milstolpen hittades inte.",
+		"project not found":   "Projektet hittades inte.",
+		"invalid color":       "Ogiltig färg.",
+		"invalid annotation":  "Ogiltig markering.",
+		"invalid icon":        "Ogiltig ikon.",
+		"invalid url":         "Ogiltig webbadress.",
+		"title too long":      "Titeln är för lång.",
+		"title too short":     "Titeln är för kort.",
+		"too many features":   "För många kort.",
+		"estimate required":   "En uppskattning krävs.",
+		"subscription exceeded - please contact the owner of the workspace": "Prenumerationen räcker inte till, kontakta ägaren av arbetsytan.",
+	},
+	"de": {
+		"name_invalid":        "Der Name ist ungültig.",
+		"password_invalid":    "Das Passwort ist ungültig.",
+		"email_taken":         "Die E-Mail-Adresse wird bereits verwendet.",
+		"workspace_invalid":   "Der Name des Arbeitsbereichs ist ungültig.",
+		"workspace_taken":     "Der Name des Arbeitsbereichs wird bereits verwendet.",
+		"post_too_long":       "Der Kommentar ist zu lang.",
+		"already exists":      "Existiert bereits.",
+		"not found":           "Nicht gefunden.",
+		"not allowed":         "Nicht erlaubt.",
+		"feature not found":   "Karte nicht gefunden.",
+		"milestone not found": "Meilenstein nicht gefunden.",
+		"project not found":   "Projekt nicht gefunden.",
+		"invalid color":       "Ungültige Farbe.",
+		"invalid annotation":  "Ungültige Markierung.",
+		"invalid icon":        "Ungültiges Symbol.",
+		"invalid url":         "Ungültige URL.",
+		"title too long":      "Der Titel ist zu lang.",
+		"title too short":     "Der Titel ist zu kurz.",
+		"too many features":   "Zu viele Karten.",
+		"estimate required":   "Eine Schätzung ist erforderlich.",
+		"subscription exceeded - please contact the owner of the workspace": "Das Abonnement reicht nicht aus, bitte wenden Sie sich an den Eigentümer des Arbeitsbereichs.",
+	},
+}
+
+func localeIsValid(locale string) bool {
+	_, ok := messages[locale]
+	return ok
+}
+
+// localize translates a message, falling back to the default locale and then to the message itself
+func localize(locale string, message string) string {
+	if x, ok := messages[locale][message]; ok {
+		return x
+	}
+	if x, ok := messages[defaultLocale][message]; ok {
+		return x
+	}
+	return message
+}
+
+// requestLocale picks the locale of a request: the one set on the account, else the best match of
+// the Accept-Language header, else the default
+func requestLocale(r *http.Request) string {
+	if env := GetEnv(r); env != nil && env.Service != nil {
+		if a := env.Service.GetAccountObject(); a != nil && localeIsValid(a.Locale) {
+			return a.Locale
+		}
+	}
+	return negotiateLocale(r.Header.Get("Accept-Language"))
+}
+
+// negotiateLocale returns the supported locale the Accept-Language header prefers the most
+func negotiateLocale(header string) string {
+	type tag struct {
+		locale string
+		q      float64
+	}
+
+	tags := []tag{}
+	for _, part := range strings.Split(header, ",") {
+		x := strings.Split(strings.TrimSpace(part), ";")
+		q := 1.0
+		for _, p := range x[1:] {
+			if v := strings.TrimSpace(p); strings.HasPrefix(v, "q=") {
+				if f, err := strconv.ParseFloat(v[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+		locale := strings.ToLower(strings.SplitN(strings.TrimSpace(x[0]), "-", 2)[0])
+		if q > 0 && localeIsValid(locale) {
+			tags = append(tags, tag{locale, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, k int) bool { return tags[i].q > tags[k].q })
+	if len(tags) > 0 {
+		return tags[0].locale
+	}
+	return defaultLocale
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/pkg/errors"
+)
+
+func TestNegotiateLocale(t *testing.T) {
+	for header, locale := range map[string]string{
+		"":                          "en",
+		"sv-SE,sv;q=0.9,en;q=0.8":   "sv",
+		"fr-FR, de;q=0.5, en;q=0.7": "en",
+		"de-CH":                     "de",
+		"sv;q=0, de":                "de",
+		"*":                         "en",
+	} {
+		if x := negotiateLocale(header); x != locale {
+			t.Error("unexpected locale for", header, x)
+		}
+	}
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	respond := func(acceptLanguage string, a *Account) (string, string) {
+		req := httptest.NewRequest("POST", "/v1/account/nameupdate", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		if a != nil {
+			s := NewFeatmapService()
+			s.SetAccountObject(a)
+			req = withService(req, s)
+		}
+
+		w := httptest.NewRecorder()
+		_ = render.Render(w, req, ErrInvalidRequest(errors.New("name_invalid")))
+
+		var x map[string]string
+		_ = json.Unmarshal(w.Body.Bytes(), &x)
+		if x["message"] != "name_invalid" || w.Code != 400 {
+			t.Error("message should be left as is", x)
+		}
+		return x["localizedMessage"], w.Header().Get("Content-Language")
+	}
+
+	en, lang := respond("en-US", nil)
+	if en != "The name is not valid." || lang != "en" {
+		t.Error("english message expected", en, lang)
+	}
+	sv, lang := respond("sv-SE,sv;q=0.9", nil)
+	if sv != "Namnet är inte giltigt." || lang != "sv" {
+		t.Error("swedish message expected", sv, lang)
+	}
+	de, _ := respond("de", nil)
+	if de == en || de == sv {
+		t.Error("german message expected", de)
+	}
+	if x, _ := respond("fr", nil); x != en {
+		t.Error("unsupported locale should fall back to the default", x)
+	}
+	if x, _ := respond("sv", &Account{Locale: "de"}); x != de {
+		t.Error("account locale should win over the header", x)
+	}
+	if localize("de", "no such message") != "no such message" {
+		t.Error("messages without translation should be kept")
+	}
+}
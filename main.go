@@ -19,6 +19,7 @@ import (
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
 	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	bindata "github.com/golang-migrate/migrate/v4/source/go_bindata"
@@ -27,44 +28,97 @@ import (
 
 // Configuration ...
 type Configuration struct {
-	Environment         string `json:"environment"`
-	Mode                string `json:"mode"`
-	AppSiteURL          string `json:"appSiteURL"`
-	DbConnectionString  string `json:"dbConnectionString"`
-	JWTSecret           string `json:"jwtSecret"`
-	Port                string `json:"port"`
-	EmailFrom           string `json:"emailFrom"`
-	SMTPServer          string `json:"smtpServer"`
-	SMTPPort            string `json:"smtpPort"`
-	SMTPUser            string `json:"smtpUser"`
-	SMTPPass            string `json:"smtpPass"`
-	StripeKey           string `json:"stripeKey"`
-	StripeWebhookSecret string `json:"stripeWebhookSecret"`
-	StripeBasicPlan     string `json:"stripeBasicPlan"`
-	StripeProPlan       string `json:"stripeProPlan"`
+	Environment                 string              `json:"environment"`
+	Mode                        string              `json:"mode"`
+	AppSiteURL                  string              `json:"appSiteURL"`
+	DbConnectionString          string              `json:"dbConnectionString"`
+	JWTSecret                   string              `json:"jwtSecret"`
+	Port                        string              `json:"port"`
+	EmailFrom                   string              `json:"emailFrom"`
+	SMTPServer                  string              `json:"smtpServer"`
+	SMTPPort                    string              `json:"smtpPort"`
+	SMTPUser                    string              `json:"smtpUser"`
+	SMTPPass                    string              `json:"smtpPass"`
+	StripeKey                   string              `json:"stripeKey"`
+	StripeWebhookSecret         string              `json:"stripeWebhookSecret"`
+	StripeBasicPlan             string              `json:"stripeBasicPlan"`
+	StripeProPlan               string              `json:"stripeProPlan"`
+	RequestIDHeader             string              `json:"requestIdHeader"`
+	MaxFeaturesPerCell          int                 `json:"maxFeaturesPerCell"`
+	Telemetry                   bool                `json:"telemetry"`
+	TelemetryURL                string              `json:"telemetryUrl"`
+	CSRFProtection              bool                `json:"csrfProtection"`
+	MaxWorkspacesPerAccount     int                 `json:"maxWorkspacesPerAccount"`
+	WorkspaceLimitExemptTiers   []string            `json:"workspaceLimitExemptTiers"`
+	StrictJSON                  bool                `json:"strictJson"`
+	InviteTTLDays               int                 `json:"inviteTtlDays"`
+	SuperuserEmails             []string            `json:"superuserEmails"`
+	ImportTitleCollision        string              `json:"importTitleCollision"`
+	SlowQueryThresholdMs        int                 `json:"slowQueryThresholdMs"`
+	PurgeUnverifiedAfterDays    int                 `json:"purgeUnverifiedAfterDays"`
+	PurgeUnverifiedDryRun       bool                `json:"purgeUnverifiedDryRun"`
+	JobIntervalsMinutes         map[string]int      `json:"jobIntervalsMinutes"`
+	MaxReferencesPerFeature     int                 `json:"maxReferencesPerFeature"`
+	EscalateBlockedAfterHours   int                 `json:"escalateBlockedAfterHours"`
+	DailyNotificationCap        int                 `json:"dailyNotificationCap"`
+	ReclaimSeatsAfterDays       int                 `json:"reclaimSeatsAfterDays"`
+	ReclaimSeatsAuto            bool                `json:"reclaimSeatsAuto"`
+	MergeDuplicateAccountsAuto  bool                `json:"mergeDuplicateAccountsAuto"`
+	RevokeShareLinksAfterDays   int                 `json:"revokeShareLinksAfterDays"`
+	ProjectArchiveRetentionDays int                 `json:"projectArchiveRetentionDays"`
+	AutosaveDedupWindowMs       int                 `json:"autosaveDedupWindowMs"`
+	RatePlans                   map[string]RatePlan `json:"ratePlans"`
+	RateLimitExemptCIDRs        []string            `json:"rateLimitExemptCidrs"`
+	TrustedProxyCIDRs           []string            `json:"trustedProxyCidrs"`
+	MinEstimate                 int                 `json:"minEstimate"`
+	MaxEstimate                 int                 `json:"maxEstimate"`
+	AggregateCacheTTLSeconds    int                 `json:"aggregateCacheTtlSeconds"`
+	BootstrapEmail              string              `json:"bootstrapEmail"`
+	BootstrapPassword           string              `json:"bootstrapPassword"`
+	BootstrapWorkspace          string              `json:"bootstrapWorkspace"`
 }
 
 func main() {
 	r := chi.NewRouter()
 
+	config, err := readConfiguration()
+	if err != nil {
+		log.Fatalln("no conf.json found")
+	}
+
+	if trustedProxies, err = parseCIDRs(config.TrustedProxyCIDRs); err != nil {
+		log.Fatalln(err)
+	}
+	if rateLimitExempt, err = parseCIDRs(config.RateLimitExemptCIDRs); err != nil {
+		log.Fatalln(err)
+	}
+
+	if config.Telemetry {
+		if config.TelemetryURL == "" {
+			log.Fatalln("telemetry needs a telemetryUrl")
+		}
+		telemetry = newHTTPSink(config.TelemetryURL)
+	}
+
 	// A good base middleware stack
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(RealIP(trustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	// r.Use(middleware.SetHeader("Content-Type", "application/json"))
 
-	config, err := readConfiguration()
-	if err != nil {
-		log.Fatalln("no conf.json found")
+	middleware.RequestIDHeader = config.RequestIDHeader
+
+	if config.StrictJSON {
+		render.Decode = decodeStrict
 	}
 
 	// CORS
 	corsConfiguration := cors.New(cors.Options{
 		AllowedOrigins:   []string{config.AppSiteURL, "http://localhost:3000"}, // localhost is for development work
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Workspace", "X-CSRF-Token"},
-		ExposedHeaders:   []string{""},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Workspace", "X-CSRF-Token", "Prefer", config.RequestIDHeader},
+		ExposedHeaders:   []string{"ETag", "Preference-Applied", config.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
@@ -99,12 +153,21 @@ func main() {
 
 	m.Up()
 
+	if config.BootstrapEmail != "" {
+		runJob(db, config, "bootstrap", func(s Service) { s.Bootstrap() })
+	}
+
+	jobs = scheduleJobs(db, config)
+	go jobs.loop(time.Minute)
+
 	// Create JWTAuth object
 	auth := jwtauth.New("HS256", []byte(config.JWTSecret), nil)
 
 	r.Use(jwtauth.Verifier(auth))
+	r.Use(CSRF(config))
 	r.Use(ContextSkeleton(config))
 
+	r.Use(InvalidateAggregates())
 	r.Use(Transaction(db))
 	r.Use(Auth(auth))
 
@@ -120,6 +183,8 @@ func main() {
 	r.Route("/v1/users", usersAPI)               // Nothing is needed
 	r.Route("/v1/link", linkAPI)                 // Nothing is needed
 	r.Route("/v1/subscription", subscriptionAPI) // Nothing is needed
+	r.Route("/v1/instance", instanceAPI)         // Nothing is needed
+	r.Route("/v1/admin", adminAPI)               // Superuser needed
 
 	r.Route("/v1/account", accountAPI) // Account needed
 	r.Route("/v1/", workspaceAPI)      // Account + workspace is needed
@@ -162,6 +227,21 @@ func readConfiguration() (Configuration, error) {
 		configuration.SMTPPort = "587"
 	}
 
+	if emails := os.Getenv("FEATMAP_SUPERUSER_EMAILS"); emails != "" {
+		configuration.SuperuserEmails = strings.Split(emails, ",")
+	}
+
+	if email := os.Getenv("FEATMAP_BOOTSTRAP_EMAIL"); email != "" {
+		configuration.BootstrapEmail = email
+	}
+	if password := os.Getenv("FEATMAP_BOOTSTRAP_PASSWORD"); password != "" {
+		configuration.BootstrapPassword = password
+	}
+
+	if configuration.RequestIDHeader == "" {
+		configuration.RequestIDHeader = "X-Request-ID"
+	}
+
 	return configuration, err
 }
 
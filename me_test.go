@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// meRepo holds the memberships of account "a1"
+type meRepo struct {
+	Repository
+	memberships []*Membership
+}
+
+func (a *meRepo) FindMembershipsByAccount(id string) ([]*Membership, error) {
+	if id != "a1" {
+		return []*Membership{}, nil
+	}
+	return a.memberships, nil
+}
+
+func TestGetMe(t *testing.T) {
+	repo := &meRepo{memberships: []*Membership{
+		{MemberID: "m1", WorkspaceID: "w1", WorkspaceName: "acme", Level: "OWNER"},
+		{MemberID: "m2", WorkspaceID: "w2", WorkspaceName: "globex", Level: "COMMENTER"},
+	}}
+
+	serve := func(accountID string) map[string]json.RawMessage {
+		s := NewFeatmapService()
+		s.SetConfig(Configuration{Mode: "selfhosted", CSRFProtection: true})
+		s.SetRepoObject(repo)
+		s.SetAccountObject(&Account{ID: accountID, Name: "ann", Email: "ann@example.com"})
+
+		w := serveWith(s, "/v1/account", accountAPI, httptest.NewRequest("GET", "/v1/account/me", nil))
+		if w.Code != 200 {
+			t.Fatal(w.Code, w.Body.String())
+		}
+		res := map[string]json.RawMessage{}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := serve("a1")
+	acc := &Account{}
+	if err := json.Unmarshal(res["account"], acc); err != nil || acc.ID != "a1" || acc.Email != "ann@example.com" {
+		t.Error("the account should be returned", string(res["account"]))
+	}
+	mm := []*Membership{}
+	if err := json.Unmarshal(res["memberships"], &mm); err != nil || len(mm) != 2 {
+		t.Fatal("every membership should be returned", string(res["memberships"]))
+	}
+	if mm[0].WorkspaceName != "acme" || mm[0].Level != "OWNER" || mm[1].WorkspaceID != "w2" || mm[1].Level != "COMMENTER" {
+		t.Error("memberships should have their workspace and role", string(res["memberships"]))
+	}
+	if string(res["flags"]) != `{"mode":"selfhosted","csrfProtection":true}` {
+		t.Error("the flags should be returned", string(res["flags"]))
+	}
+
+	if res := serve("a2"); string(res["memberships"]) != "[]" {
+		t.Error("an account without workspaces should get an empty list", string(res["memberships"]))
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// memberLevels orders the levels of a member, from lowest to highest
+var memberLevels = map[string]int{"VIEWER": 0, "COMMENTER": 1, "EDITOR": 2, "ADMIN": 3, "OWNER": 4}
+
+// rememberedLevel is the level of a member, or the one it had for an inactive member
+func rememberedLevel(m *Member) string {
+	if m.Level == "INACTIVE" {
+		return m.InactiveLevel
+	}
+	return m.Level
+}
+
+// mergeLevel gives into the higher of the remembered levels of the two members. It stays inactive
+// only if the higher level is that of an inactive member, and is active when both are equal.
+func mergeLevel(into *Member, from *Member) bool {
+	a, b := memberLevels[rememberedLevel(into)], memberLevels[rememberedLevel(from)]
+	if a > b || (a == b && (into.Level != "INACTIVE" || from.Level == "INACTIVE")) {
+		return false
+	}
+	into.Level, into.InactiveLevel = from.Level, from.InactiveLevel
+	return true
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// duplicateAccounts groups the accounts whose emails only differ in case or surrounding space,
+// each group ordered from the earliest created
+func duplicateAccounts(aa []*Account) [][]*Account {
+	byEmail := map[string][]*Account{}
+	emails := []string{}
+	for _, a := range aa {
+		e := normalizeEmail(a.Email)
+		if _, ok := byEmail[e]; !ok {
+			emails = append(emails, e)
+		}
+		byEmail[e] = append(byEmail[e], a)
+	}
+
+	x := [][]*Account{}
+	for _, e := range emails {
+		if g := byEmail[e]; len(g) > 1 {
+			sort.SliceStable(g, func(i, k int) bool { return g[i].CreatedAt.Before(g[k].CreatedAt) })
+			x = append(x, g)
+		}
+	}
+	return x
+}
+
+func (s *service) AdminGetDuplicateAccounts() ([][]*Account, error) {
+	s.auditAdminAction("LIST_DUPLICATE_ACCOUNTS", "")
+
+	aa, err := s.r.FindAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	return duplicateAccounts(aa), nil
+}
+
+// errUnverifiedCanonical is returned when the account to keep has not confirmed its email. Anyone
+// can sign up with an email they do not own, so such an account must never take over the others.
+var errUnverifiedCanonical = errors.New("account to keep has not confirmed its email")
+
+// AdminMergeAccounts merges the accounts of an email into one. The earliest created account is
+// kept unless canonicalID names another one of them. The kept account must have confirmed its
+// email.
+func (s *service) AdminMergeAccounts(email string, canonicalID string) (*Account, error) {
+	aa, err := s.r.FindAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range duplicateAccounts(aa) {
+		if normalizeEmail(g[0].Email) != normalizeEmail(email) {
+			continue
+		}
+
+		into := g[0]
+		if canonicalID != "" {
+			into = nil
+			for _, a := range g {
+				if a.ID == canonicalID {
+					into = a
+				}
+			}
+			if into == nil {
+				return nil, errors.New("account not found")
+			}
+		}
+		if !into.EmailConfirmed {
+			return nil, errUnverifiedCanonical
+		}
+
+		s.mergeAccounts(into, g)
+		s.auditAdminAction("MERGE_ACCOUNTS", into.ID)
+		return into, nil
+	}
+
+	return nil, errors.New("no duplicates found")
+}
+
+// MergeDuplicateAccounts merges every group of duplicate accounts into its earliest created
+// account when the automatic mode is enabled. Groups whose earliest account has not confirmed its
+// email are left for an admin. It runs outside of a request.
+func (s *service) MergeDuplicateAccounts() int {
+	if !s.config.MergeDuplicateAccountsAuto {
+		return 0
+	}
+
+	aa, err := s.r.FindAllAccounts()
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
+	n := 0
+	for _, g := range duplicateAccounts(aa) {
+		if !g[0].EmailConfirmed {
+			log.Printf("not merging into unconfirmed account %s", g[0].ID)
+			continue
+		}
+		s.mergeAccounts(g[0], g)
+		log.Printf("merged %d accounts into %s", len(g)-1, g[0].ID)
+		n += len(g) - 1
+	}
+	return n
+}
+
+// mergeAccounts moves the memberships, comments, watches, favorites and notifications of the
+// accounts into one and deletes the others. In a workspace where both accounts are members, the
+// higher level is kept.
+func (s *service) mergeAccounts(into *Account, accounts []*Account) {
+	for _, from := range accounts {
+		if from.ID == into.ID {
+			continue
+		}
+
+		mm, err := s.r.GetMembersByAccount(from.ID)
+		if err != nil {
+			log.Println(err)
+		}
+		for _, m := range mm {
+			existing, _ := s.r.GetMemberByAccountAndWorkspace(into.ID, m.WorkspaceID)
+			if existing == nil {
+				s.r.ReassignMember(m.WorkspaceID, m.ID, into.ID)
+				continue
+			}
+			if mergeLevel(existing, m) {
+				s.r.StoreMember(existing)
+			}
+			s.r.MergeMember(m.WorkspaceID, m.ID, existing.ID)
+		}
+
+		s.r.ReassignAccount(from.ID, into.ID)
+		s.r.DeleteAccount(from.ID)
+	}
+
+	into.Email = normalizeEmail(into.Email)
+	s.r.StoreAccount(into)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mergeRepo keeps accounts, members, comment owners, time entries and notifications in memory
+type mergeRepo struct {
+	Repository
+	accounts      []*Account
+	members       []*Member
+	commentOwners map[string]string
+	timeEntries   map[string]string
+	notifications map[string]string
+	deleted       []string
+}
+
+func (a *mergeRepo) FindAllAccounts() ([]*Account, error) {
+	return a.accounts, nil
+}
+
+func (a *mergeRepo) GetMembersByAccount(id string) ([]*Member, error) {
+	x := []*Member{}
+	for _, m := range a.members {
+		if m.AccountID == id {
+			x = append(x, m)
+		}
+	}
+	return x, nil
+}
+
+func (a *mergeRepo) GetMemberByAccountAndWorkspace(accountID string, workspaceID string) (*Member, error) {
+	for _, m := range a.members {
+		if m.AccountID == accountID && m.WorkspaceID == workspaceID {
+			return m, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *mergeRepo) StoreMember(x *Member) {}
+
+func (a *mergeRepo) ReassignMember(workspaceID string, memberID string, accountID string) {
+	for _, m := range a.members {
+		if m.ID == memberID {
+			m.AccountID = accountID
+		}
+	}
+}
+
+func (a *mergeRepo) MergeMember(workspaceID string, fromID string, intoID string) {
+	for c, m := range a.commentOwners {
+		if m == fromID {
+			a.commentOwners[c] = intoID
+		}
+	}
+	for e, m := range a.timeEntries {
+		if m == fromID {
+			a.timeEntries[e] = intoID
+		}
+	}
+	x := []*Member{}
+	for _, m := range a.members {
+		if m.ID != fromID {
+			x = append(x, m)
+		}
+	}
+	a.members = x
+}
+
+func (a *mergeRepo) ReassignAccount(fromID string, intoID string) {
+	for n, acc := range a.notifications {
+		if acc == fromID {
+			a.notifications[n] = intoID
+		}
+	}
+}
+
+func (a *mergeRepo) DeleteAccount(id string) {
+	a.deleted = append(a.deleted, id)
+}
+
+func (a *mergeRepo) StoreAccount(x *Account) {}
+
+func (a *mergeRepo) StoreAdminAction(x *AdminAction) {}
+
+func TestMergeDuplicateAccounts(t *testing.T) {
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &mergeRepo{
+		accounts: []*Account{
+			{ID: "late", Email: " Ann@Example.com", CreatedAt: day.AddDate(0, 0, 2)},
+			{ID: "early", Email: "ann@example.com", CreatedAt: day, EmailConfirmed: true},
+			{ID: "bob", Email: "bob@example.com", CreatedAt: day},
+		},
+		members: []*Member{
+			{WorkspaceID: "ws1", ID: "m-early", AccountID: "early", Level: "VIEWER"},
+			{WorkspaceID: "ws1", ID: "m-late", AccountID: "late", Level: "EDITOR"},
+			{WorkspaceID: "ws2", ID: "m-late2", AccountID: "late", Level: "ADMIN"},
+		},
+		commentOwners: map[string]string{"c1": "m-late", "c2": "m-early"},
+		timeEntries:   map[string]string{"e1": "m-late", "e2": "m-late2"},
+		notifications: map[string]string{"n1": "late"},
+	}
+
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+	s.SetAccountObject(&Account{ID: "root", Email: "root@example.com"})
+
+	gg, err := s.AdminGetDuplicateAccounts()
+	if err != nil || len(gg) != 1 || len(gg[0]) != 2 || gg[0][0].ID != "early" {
+		t.Fatal("accounts with the same normalized email should be found, earliest first", gg, err)
+	}
+
+	if _, err := s.AdminMergeAccounts("ann@example.com", "bob"); err == nil {
+		t.Error("the canonical account should have the email")
+	}
+
+	a, err := s.AdminMergeAccounts("ANN@example.com", "")
+	if err != nil || a.ID != "early" {
+		t.Fatal("the earliest created account should be kept", a, err)
+	}
+	if len(repo.deleted) != 1 || repo.deleted[0] != "late" {
+		t.Error("the other account should be deleted", repo.deleted)
+	}
+	if !a.EmailConfirmed || a.Email != "ann@example.com" {
+		t.Error("the kept account should keep its confirmation and get the normalized email", a)
+	}
+
+	if len(repo.members) != 2 {
+		t.Fatal("members in the same workspace should be merged", repo.members)
+	}
+	for _, m := range repo.members {
+		if m.AccountID != "early" {
+			t.Error("memberships should move to the kept account", m)
+		}
+		if m.WorkspaceID == "ws1" && m.Level != "EDITOR" {
+			t.Error("the higher level should be kept", m.Level)
+		}
+	}
+	if repo.commentOwners["c1"] != "m-early" || repo.commentOwners["c2"] != "m-early" {
+		t.Error("comments should move to the kept member", repo.commentOwners)
+	}
+	if repo.timeEntries["e1"] != "m-early" || repo.timeEntries["e2"] != "m-late2" {
+		t.Error("time entries should move to the kept member", repo.timeEntries)
+	}
+	if repo.notifications["n1"] != "early" {
+		t.Error("notifications should move to the kept account", repo.notifications)
+	}
+}
+
+func TestMergeLevel(t *testing.T) {
+	for _, c := range []struct {
+		into, from, level, inactiveLevel string
+	}{
+		{"VIEWER", "EDITOR", "EDITOR", ""},
+		{"ADMIN", "EDITOR", "ADMIN", ""},
+		{"INACTIVE/EDITOR", "VIEWER", "INACTIVE", "EDITOR"},
+		{"VIEWER", "INACTIVE/ADMIN", "INACTIVE", "ADMIN"},
+		{"INACTIVE/EDITOR", "INACTIVE/ADMIN", "INACTIVE", "ADMIN"},
+		{"INACTIVE/ADMIN", "INACTIVE/EDITOR", "INACTIVE", "ADMIN"},
+		{"INACTIVE/EDITOR", "EDITOR", "EDITOR", ""},
+		{"EDITOR", "INACTIVE/EDITOR", "EDITOR", ""},
+		{"INACTIVE/EDITOR", "ADMIN", "ADMIN", ""},
+	} {
+		member := func(level string) *Member {
+			x := strings.Split(level, "/")
+			m := &Member{Level: x[0]}
+			if len(x) > 1 {
+				m.InactiveLevel = x[1]
+			}
+			return m
+		}
+		into := member(c.into)
+		mergeLevel(into, member(c.from))
+		if into.Level != c.level || into.InactiveLevel != c.inactiveLevel {
+			t.Error("merging", c.from, "into", c.into, "should give", c.level, c.inactiveLevel, "got", into.Level, into.InactiveLevel)
+		}
+	}
+}
+
+func TestMergeNeverKeepsUnconfirmedAccount(t *testing.T) {
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &mergeRepo{
+		accounts: []*Account{
+			{ID: "squatter", Email: "ann@example.com", CreatedAt: day},
+			{ID: "ann", Email: "Ann@example.com", CreatedAt: day.AddDate(0, 0, 2), EmailConfirmed: true},
+		},
+		members: []*Member{{WorkspaceID: "ws1", ID: "m-ann", AccountID: "ann", Level: "OWNER"}},
+	}
+
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{MergeDuplicateAccountsAuto: true})
+	s.SetRepoObject(repo)
+	s.SetAccountObject(&Account{ID: "root", Email: "root@example.com"})
+
+	if n := s.MergeDuplicateAccounts(); n != 0 || len(repo.deleted) != 0 {
+		t.Error("an unconfirmed earliest account should not take over a confirmed one", n, repo.deleted)
+	}
+	if _, err := s.AdminMergeAccounts("ann@example.com", ""); err != errUnverifiedCanonical {
+		t.Error("an admin should not keep an unconfirmed account", err)
+	}
+	if _, err := s.AdminMergeAccounts("ann@example.com", "squatter"); err != errUnverifiedCanonical {
+		t.Error("an admin should not choose an unconfirmed account", err)
+	}
+	if repo.members[0].AccountID != "ann" {
+		t.Error("the membership should stay with the confirmed account", repo.members[0])
+	}
+
+	a, err := s.AdminMergeAccounts("ann@example.com", "ann")
+	if err != nil || a.ID != "ann" || len(repo.deleted) != 1 || repo.deleted[0] != "squatter" {
+		t.Error("the confirmed account should be kept when chosen", a, err, repo.deleted)
+	}
+}
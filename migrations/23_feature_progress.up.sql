@@ -0,0 +1 @@
+ALTER TABLE public.features ADD progress int NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE public.projects ADD auto_close_days int NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+CREATE TABLE public.feature_watchers (
+	workspace_id uuid NOT NULL,
+	feature_id uuid NOT NULL,
+	member_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	created_at timestamptz NOT NULL,
+	CONSTRAINT feature_watchers_pk PRIMARY KEY (workspace_id, feature_id, member_id)
+);
+CREATE INDEX feature_watchers_workspace_id_idx ON public.feature_watchers USING btree (workspace_id, project_id, member_id);
+
+ALTER TABLE public.feature_watchers ADD CONSTRAINT feature_watchers_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.feature_watchers ADD CONSTRAINT feature_watchers_fk_1 FOREIGN KEY (workspace_id, feature_id) REFERENCES features(workspace_id, id) ON DELETE CASCADE;
+ALTER TABLE public.feature_watchers ADD CONSTRAINT feature_watchers_fk_2 FOREIGN KEY (workspace_id, member_id) REFERENCES members(workspace_id, id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE public.projects ADD require_estimate bool NOT NULL DEFAULT false;
+ALTER TABLE public.projects ADD default_estimate int NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+CREATE TABLE public.feature_events (
+	workspace_id uuid NOT NULL,
+	id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	feature_id uuid NOT NULL,
+	milestone_id uuid NOT NULL,
+	status varchar NOT NULL,
+	estimate int NOT NULL,
+	created_at timestamptz NOT NULL,
+	CONSTRAINT feature_events_pk PRIMARY KEY (workspace_id, id)
+);
+CREATE INDEX feature_events_workspace_id_idx ON public.feature_events USING btree (workspace_id, feature_id, created_at);
+CREATE INDEX feature_events_workspace_id_idx2 ON public.feature_events USING btree (workspace_id, milestone_id);
+
+ALTER TABLE public.feature_events ADD CONSTRAINT feature_events_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.feature_events ADD CONSTRAINT feature_events_fk_1 FOREIGN KEY (workspace_id, project_id) REFERENCES projects(workspace_id, id) ON DELETE CASCADE;
+
+-- Existing features start out with their current state
+INSERT INTO public.feature_events (workspace_id, id, project_id, feature_id, milestone_id, status, estimate, created_at)
+SELECT f.workspace_id, md5(random()::text || f.id::text)::uuid, m.project_id, f.id, f.milestone_id, f.status, f.estimate, f.created_at
+FROM public.features f INNER JOIN public.milestones m ON f.workspace_id = m.workspace_id AND f.milestone_id = m.id;
@@ -0,0 +1 @@
+ALTER TABLE public.workspaces ADD viewer_redactions varchar NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+CREATE INDEX projects_last_modified_idx ON public.projects USING btree (workspace_id, last_modified);
+CREATE INDEX milestones_last_modified_idx ON public.milestones USING btree (workspace_id, last_modified);
+CREATE INDEX workflows_last_modified_idx ON public.workflows USING btree (workspace_id, last_modified);
+CREATE INDEX subworkflows_last_modified_idx ON public.subworkflows USING btree (workspace_id, last_modified);
+CREATE INDEX features_last_modified_idx ON public.features USING btree (workspace_id, last_modified);
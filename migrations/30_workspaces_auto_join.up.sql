@@ -0,0 +1,2 @@
+ALTER TABLE public.workspaces ADD auto_join_domains varchar NOT NULL DEFAULT '';
+ALTER TABLE public.workspaces ADD auto_join_level varchar NOT NULL DEFAULT 'VIEWER';
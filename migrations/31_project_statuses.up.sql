@@ -0,0 +1,17 @@
+CREATE TABLE public.project_statuses (
+	workspace_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	id uuid NOT NULL,
+	title varchar NOT NULL,
+	"rank" varchar NOT NULL,
+	closed bool NOT NULL,
+	created_at timestamptz NOT NULL,
+	CONSTRAINT project_statuses_pk PRIMARY KEY (workspace_id, id)
+);
+CREATE INDEX project_statuses_workspace_id_idx ON public.project_statuses USING btree (workspace_id, project_id);
+
+ALTER TABLE public.project_statuses ADD CONSTRAINT project_statuses_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.project_statuses ADD CONSTRAINT project_statuses_fk_1 FOREIGN KEY (workspace_id, project_id) REFERENCES projects(workspace_id, id) ON DELETE CASCADE;
+
+-- An empty status_id means the built-in open or closed status given by status
+ALTER TABLE public.features ADD status_id varchar NOT NULL DEFAULT '';
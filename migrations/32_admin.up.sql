@@ -0,0 +1,12 @@
+ALTER TABLE public.workspaces ADD suspended bool NOT NULL DEFAULT false;
+
+CREATE TABLE public.admin_actions (
+	id uuid NOT NULL,
+	account_id uuid NOT NULL,
+	account_email varchar NOT NULL,
+	"action" varchar NOT NULL,
+	target varchar NOT NULL,
+	created_at timestamptz NOT NULL,
+	CONSTRAINT admin_actions_pk PRIMARY KEY (id)
+);
+CREATE INDEX admin_actions_created_at_idx ON public.admin_actions USING btree (created_at);
@@ -0,0 +1,12 @@
+CREATE TABLE public.project_favorites (
+	workspace_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	member_id uuid NOT NULL,
+	created_at timestamptz NOT NULL,
+	CONSTRAINT project_favorites_pk PRIMARY KEY (workspace_id, project_id, member_id)
+);
+CREATE INDEX project_favorites_member_id_idx ON public.project_favorites USING btree (workspace_id, member_id);
+
+ALTER TABLE public.project_favorites ADD CONSTRAINT project_favorites_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.project_favorites ADD CONSTRAINT project_favorites_fk_1 FOREIGN KEY (workspace_id, project_id) REFERENCES projects(workspace_id, id) ON DELETE CASCADE;
+ALTER TABLE public.project_favorites ADD CONSTRAINT project_favorites_fk_2 FOREIGN KEY (workspace_id, member_id) REFERENCES members(workspace_id, id) ON DELETE CASCADE;
@@ -0,0 +1,13 @@
+CREATE TABLE public.project_links (
+	workspace_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	related_project_id uuid NOT NULL,
+	created_at timestamptz NOT NULL,
+	CONSTRAINT project_links_pk PRIMARY KEY (workspace_id, project_id, related_project_id),
+	CONSTRAINT project_links_ordered CHECK (project_id < related_project_id)
+);
+CREATE INDEX project_links_related_project_id_idx ON public.project_links USING btree (workspace_id, related_project_id);
+
+ALTER TABLE public.project_links ADD CONSTRAINT project_links_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.project_links ADD CONSTRAINT project_links_fk_1 FOREIGN KEY (workspace_id, project_id) REFERENCES projects(workspace_id, id) ON DELETE CASCADE;
+ALTER TABLE public.project_links ADD CONSTRAINT project_links_fk_2 FOREIGN KEY (workspace_id, related_project_id) REFERENCES projects(workspace_id, id) ON DELETE CASCADE;
@@ -0,0 +1,13 @@
+CREATE TABLE public.notification_emails (
+	id uuid NOT NULL,
+	account_id uuid NOT NULL,
+	email varchar NOT NULL,
+	subject varchar NOT NULL,
+	body text NOT NULL,
+	digest bool NOT NULL,
+	created_at timestamptz NOT NULL,
+	CONSTRAINT notification_emails_pk PRIMARY KEY (id)
+);
+CREATE INDEX notification_emails_account_id_idx ON public.notification_emails USING btree (account_id, created_at);
+
+ALTER TABLE public.notification_emails ADD CONSTRAINT notification_emails_fk FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE;
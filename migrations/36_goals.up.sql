@@ -0,0 +1,29 @@
+CREATE TABLE public.goals (
+	workspace_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	id uuid NOT NULL,
+	title varchar NOT NULL,
+	description text NOT NULL,
+	target_date varchar NOT NULL,
+	created_at timestamptz NOT NULL,
+	created_by_name varchar NOT NULL,
+	last_modified timestamptz NOT NULL,
+	last_modified_by_name varchar NOT NULL,
+	CONSTRAINT goals_pk PRIMARY KEY (workspace_id, id)
+);
+CREATE INDEX goals_project_id_idx ON public.goals USING btree (workspace_id, project_id);
+
+ALTER TABLE public.goals ADD CONSTRAINT goals_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.goals ADD CONSTRAINT goals_fk_1 FOREIGN KEY (workspace_id, project_id) REFERENCES projects(workspace_id, id) ON DELETE CASCADE;
+
+CREATE TABLE public.goal_milestones (
+	workspace_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	goal_id uuid NOT NULL,
+	milestone_id uuid NOT NULL,
+	CONSTRAINT goal_milestones_pk PRIMARY KEY (workspace_id, goal_id, milestone_id)
+);
+CREATE INDEX goal_milestones_project_id_idx ON public.goal_milestones USING btree (workspace_id, project_id);
+
+ALTER TABLE public.goal_milestones ADD CONSTRAINT goal_milestones_fk FOREIGN KEY (workspace_id, goal_id) REFERENCES goals(workspace_id, id) ON DELETE CASCADE;
+ALTER TABLE public.goal_milestones ADD CONSTRAINT goal_milestones_fk_1 FOREIGN KEY (workspace_id, milestone_id) REFERENCES milestones(workspace_id, id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+-- Level to restore when an INACTIVE member returns
+ALTER TABLE public.members ADD inactive_level varchar NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE public.features ADD icon varchar NOT NULL DEFAULT '';
+ALTER TABLE public.milestones ADD icon varchar NOT NULL DEFAULT '';
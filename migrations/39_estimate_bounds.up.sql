@@ -0,0 +1,8 @@
+ALTER TABLE public.workspaces ADD min_estimate int NOT NULL DEFAULT 0;
+ALTER TABLE public.workspaces ADD max_estimate int NOT NULL DEFAULT 0;
+
+-- Clamp estimates entered before they were bounded to the default range
+UPDATE public.features SET estimate = 999 WHERE estimate > 999;
+UPDATE public.features SET estimate = 0 WHERE estimate < 0;
+UPDATE public.projects SET default_estimate = 999 WHERE default_estimate > 999;
+UPDATE public.projects SET default_estimate = 0 WHERE default_estimate < 0;
@@ -0,0 +1,15 @@
+CREATE TABLE public.feature_references (
+	workspace_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	feature_id uuid NOT NULL,
+	id uuid NOT NULL,
+	label varchar NOT NULL,
+	url varchar NOT NULL,
+	created_at timestamptz NOT NULL,
+	created_by_name varchar NOT NULL,
+	CONSTRAINT feature_references_pk PRIMARY KEY (workspace_id, id)
+);
+CREATE INDEX feature_references_project_id_idx ON public.feature_references USING btree (workspace_id, project_id);
+
+ALTER TABLE public.feature_references ADD CONSTRAINT feature_references_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.feature_references ADD CONSTRAINT feature_references_fk_1 FOREIGN KEY (workspace_id, feature_id) REFERENCES features(workspace_id, id) ON DELETE CASCADE;
@@ -0,0 +1,7 @@
+-- When the BLOCKED annotation was put on a feature, and if its watchers were told it stayed blocked
+ALTER TABLE public.features ADD blocked_since timestamptz NULL;
+ALTER TABLE public.features ADD blocked_escalated boolean NOT NULL DEFAULT false;
+
+UPDATE public.features SET blocked_since = last_modified WHERE 'BLOCKED' = ANY(string_to_array(annotations, ','));
+
+CREATE INDEX features_blocked_since_idx ON public.features USING btree (blocked_since) WHERE NOT blocked_escalated;
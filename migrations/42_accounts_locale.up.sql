@@ -0,0 +1,2 @@
+-- Locale chosen by the account, overriding the Accept-Language header. Empty follows the header.
+ALTER TABLE public.accounts ADD locale varchar NOT NULL DEFAULT '';
@@ -0,0 +1,19 @@
+CREATE TABLE public.access_requests (
+	workspace_id uuid NOT NULL,
+	id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	member_id uuid NOT NULL,
+	"level" varchar NOT NULL,
+	status varchar NOT NULL,
+	created_at timestamptz NOT NULL,
+	decided_at timestamptz NULL,
+	decided_by_name varchar NOT NULL DEFAULT '',
+	CONSTRAINT access_requests_pk PRIMARY KEY (workspace_id, id)
+);
+CREATE INDEX access_requests_project_id_idx ON public.access_requests USING btree (workspace_id, project_id);
+-- A member has at most one pending request
+CREATE UNIQUE INDEX access_requests_pending_idx ON public.access_requests USING btree (workspace_id, member_id) WHERE status = 'PENDING';
+
+ALTER TABLE public.access_requests ADD CONSTRAINT access_requests_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.access_requests ADD CONSTRAINT access_requests_fk_1 FOREIGN KEY (workspace_id, project_id) REFERENCES projects(workspace_id, id) ON DELETE CASCADE;
+ALTER TABLE public.access_requests ADD CONSTRAINT access_requests_fk_2 FOREIGN KEY (workspace_id, member_id) REFERENCES members(workspace_id, id) ON DELETE CASCADE;
@@ -0,0 +1,8 @@
+-- Who shared the link of a project and when, when it was last viewed and if it was revoked
+ALTER TABLE public.projects ADD external_link_created_at timestamptz NULL;
+ALTER TABLE public.projects ADD external_link_created_by varchar NOT NULL DEFAULT '';
+ALTER TABLE public.projects ADD external_link_viewed_at timestamptz NULL;
+ALTER TABLE public.projects ADD external_link_revoked_at timestamptz NULL;
+
+UPDATE public.projects SET external_link_created_at = created_at;
+ALTER TABLE public.projects ALTER COLUMN external_link_created_at SET NOT NULL;
@@ -0,0 +1,6 @@
+-- Settings given to every workspace the account creates, unless overridden when creating it
+ALTER TABLE public.accounts ADD default_allow_external_sharing boolean NOT NULL DEFAULT true;
+ALTER TABLE public.accounts ADD default_viewer_redactions varchar NOT NULL DEFAULT '';
+ALTER TABLE public.accounts ADD default_auto_join_level varchar NOT NULL DEFAULT 'VIEWER';
+ALTER TABLE public.accounts ADD default_min_estimate integer NOT NULL DEFAULT 0;
+ALTER TABLE public.accounts ADD default_max_estimate integer NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- Workspaces can keep an export of every project deleted, for a while
+ALTER TABLE public.workspaces ADD archive_deleted_projects boolean NOT NULL DEFAULT false;
+
+CREATE TABLE public.project_archives (
+	workspace_id uuid NOT NULL,
+	id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	project_title varchar NOT NULL,
+	created_at timestamptz NOT NULL,
+	created_by_name varchar NOT NULL,
+	"size" integer NOT NULL,
+	"data" bytea NOT NULL,
+	CONSTRAINT project_archives_pk PRIMARY KEY (workspace_id, id)
+);
+CREATE INDEX project_archives_created_at_idx ON public.project_archives USING btree (created_at);
+
+ALTER TABLE public.project_archives ADD CONSTRAINT project_archives_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
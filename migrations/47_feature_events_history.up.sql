@@ -0,0 +1,3 @@
+-- Who caused an event and the title at the time, to show the history of a feature
+ALTER TABLE public.feature_events ADD title varchar NOT NULL DEFAULT '';
+ALTER TABLE public.feature_events ADD created_by_name varchar NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Annotations every new feature of the project starts with. Empty turns it off.
+ALTER TABLE public.projects ADD default_annotations varchar NOT NULL DEFAULT '';
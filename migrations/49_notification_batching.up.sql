@@ -0,0 +1,7 @@
+-- Workspaces can batch the notifications on a card per type of event, for example comments=5
+ALTER TABLE public.workspaces ADD notification_batch_windows varchar NOT NULL DEFAULT '';
+
+-- A notification held back for a batch has batch_until set, until the batch is sent
+ALTER TABLE public.notification_emails ADD batch_key varchar NOT NULL DEFAULT '';
+ALTER TABLE public.notification_emails ADD batch_until timestamptz NULL;
+CREATE INDEX notification_emails_batch_idx ON public.notification_emails USING btree (account_id, batch_key, created_at);
@@ -0,0 +1,19 @@
+CREATE TABLE public.time_entries (
+	workspace_id uuid NOT NULL,
+	project_id uuid NOT NULL,
+	feature_id uuid NOT NULL,
+	id uuid NOT NULL,
+	member_id uuid NOT NULL,
+	minutes int4 NOT NULL,
+	note varchar NOT NULL,
+	created_at timestamptz NOT NULL,
+	created_by_name varchar NOT NULL,
+	last_modified timestamptz NOT NULL,
+	CONSTRAINT time_entries_pk PRIMARY KEY (workspace_id, id),
+	CONSTRAINT time_entries_minutes_check CHECK (minutes > 0)
+);
+CREATE INDEX time_entries_project_id_idx ON public.time_entries USING btree (workspace_id, project_id);
+CREATE INDEX time_entries_feature_id_idx ON public.time_entries USING btree (workspace_id, feature_id);
+
+ALTER TABLE public.time_entries ADD CONSTRAINT time_entries_fk FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE public.time_entries ADD CONSTRAINT time_entries_fk_1 FOREIGN KEY (workspace_id, feature_id) REFERENCES features(workspace_id, id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+-- Where a feature created without a milestone or a subworkflow goes. Empty falls back to the first.
+ALTER TABLE public.projects ADD default_milestone_id varchar NOT NULL DEFAULT '';
+ALTER TABLE public.projects ADD default_subworkflow_id varchar NOT NULL DEFAULT '';
@@ -0,0 +1,20 @@
+-- Every project gets an open and a closed status it does not have yet, and features without a
+-- custom status move to the first status of their project that matches their open or closed state
+INSERT INTO public.project_statuses (workspace_id, project_id, id, title, "rank", closed, created_at)
+SELECT p.workspace_id, p.id, md5(random()::text || clock_timestamp()::text)::uuid, 'Open', coalesce((SELECT max(s."rank") FROM public.project_statuses s WHERE s.workspace_id = p.workspace_id AND s.project_id = p.id), '') || 'n', false, now()
+FROM public.projects p
+WHERE NOT EXISTS (SELECT 1 FROM public.project_statuses s WHERE s.workspace_id = p.workspace_id AND s.project_id = p.id AND NOT s.closed);
+
+INSERT INTO public.project_statuses (workspace_id, project_id, id, title, "rank", closed, created_at)
+SELECT p.workspace_id, p.id, md5(random()::text || clock_timestamp()::text)::uuid, 'Closed', coalesce((SELECT max(s."rank") FROM public.project_statuses s WHERE s.workspace_id = p.workspace_id AND s.project_id = p.id), '') || 'n', true, now()
+FROM public.projects p
+WHERE NOT EXISTS (SELECT 1 FROM public.project_statuses s WHERE s.workspace_id = p.workspace_id AND s.project_id = p.id AND s.closed);
+
+UPDATE public.features f SET status_id = (
+	SELECT s.id::varchar
+	FROM public.project_statuses s
+	JOIN public.milestones m ON m.workspace_id = s.workspace_id AND m.project_id = s.project_id
+	WHERE m.workspace_id = f.workspace_id AND m.id = f.milestone_id AND s.closed = (f.status = 'CLOSED')
+	ORDER BY s."rank"
+	LIMIT 1)
+WHERE f.status_id = '';
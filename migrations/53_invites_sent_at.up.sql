@@ -0,0 +1,4 @@
+-- When an invite was last sent. Resending restarts the time it can be accepted, created_at stays.
+ALTER TABLE public.invites ADD sent_at timestamptz NULL;
+UPDATE public.invites SET sent_at = created_at;
+ALTER TABLE public.invites ALTER COLUMN sent_at SET NOT NULL;
@@ -0,0 +1,2 @@
+-- The time zone the days of a workspace are counted in, for example in burndowns. Empty is UTC.
+ALTER TABLE public.workspaces ADD timezone varchar NOT NULL DEFAULT '';
@@ -4,13 +4,22 @@ import "time"
 
 // Workspace ...
 type Workspace struct {
-	ID                   string    `db:"id" json:"id"`
-	Name                 string    `db:"name" json:"name"`
-	CreatedAt            time.Time `db:"created_at" json:"createdAt"`
-	AllowExternalSharing bool      `db:"allow_external_sharing" json:"allowExternalSharing"`
-	ExternalCustomerID   string    `db:"external_customer_id" json:"-"`
-	EUVAT                string    `db:"eu_vat" json:"euVat"`
-	ExternalBillingEmail string    `db:"external_billing_email" json:"externalBillingEmail"`
+	ID                       string    `db:"id" json:"id"`
+	Name                     string    `db:"name" json:"name"`
+	CreatedAt                time.Time `db:"created_at" json:"createdAt"`
+	AllowExternalSharing     bool      `db:"allow_external_sharing" json:"allowExternalSharing"`
+	ExternalCustomerID       string    `db:"external_customer_id" json:"-"`
+	EUVAT                    string    `db:"eu_vat" json:"euVat"`
+	ExternalBillingEmail     string    `db:"external_billing_email" json:"externalBillingEmail"`
+	ViewerRedactions         string    `db:"viewer_redactions" json:"viewerRedactions"`
+	AutoJoinDomains          string    `db:"auto_join_domains" json:"autoJoinDomains"`
+	AutoJoinLevel            string    `db:"auto_join_level" json:"autoJoinLevel"`
+	Suspended                bool      `db:"suspended" json:"suspended"`
+	MinEstimate              int       `db:"min_estimate" json:"minEstimate"`
+	MaxEstimate              int       `db:"max_estimate" json:"maxEstimate"`
+	ArchiveDeletedProjects   bool      `db:"archive_deleted_projects" json:"archiveDeletedProjects"`
+	NotificationBatchWindows string    `db:"notification_batch_windows" json:"notificationBatchWindows"`
+	Timezone                 string    `db:"timezone" json:"timezone"`
 }
 
 // Account ...
@@ -26,6 +35,13 @@ type Account struct {
 	EmailConfirmationPending bool      `db:"email_confirmation_pending" json:"emailConfirmationPending"`
 	PasswordResetKey         string    `db:"password_reset_key" json:"-"`
 	LatestActivity           time.Time `db:"latest_activity" json:"-"`
+	Locale                   string    `db:"locale" json:"locale"`
+
+	DefaultAllowExternalSharing bool   `db:"default_allow_external_sharing" json:"defaultAllowExternalSharing"`
+	DefaultViewerRedactions     string `db:"default_viewer_redactions" json:"defaultViewerRedactions"`
+	DefaultAutoJoinLevel        string `db:"default_auto_join_level" json:"defaultAutoJoinLevel"`
+	DefaultMinEstimate          int    `db:"default_min_estimate" json:"defaultMinEstimate"`
+	DefaultMaxEstimate          int    `db:"default_max_estimate" json:"defaultMaxEstimate"`
 }
 
 // Subscription ...
@@ -49,13 +65,35 @@ type Subscription struct {
 
 // Member ...
 type Member struct {
-	ID          string    `db:"id" json:"id"`
-	WorkspaceID string    `db:"workspace_id" json:"workspaceId"`
-	AccountID   string    `db:"account_id" json:"accountId"`
-	Level       string    `db:"level" json:"level"`
-	Name        string    `db:"name" json:"name"`   // Joined in
-	Email       string    `db:"email" json:"email"` // Joined in
-	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+	ID            string    `db:"id" json:"id"`
+	WorkspaceID   string    `db:"workspace_id" json:"workspaceId"`
+	AccountID     string    `db:"account_id" json:"accountId"`
+	Level         string    `db:"level" json:"level"`
+	InactiveLevel string    `db:"inactive_level" json:"inactiveLevel,omitempty"`
+	Name          string    `db:"name" json:"name"`   // Joined in
+	Email         string    `db:"email" json:"email"` // Joined in
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+}
+
+// AccessRequest is a member asking the admins for a higher level, from a project they want to work on
+type AccessRequest struct {
+	WorkspaceID   string     `db:"workspace_id" json:"workspaceId"`
+	ID            string     `db:"id" json:"id"`
+	ProjectID     string     `db:"project_id" json:"projectId"`
+	MemberID      string     `db:"member_id" json:"memberId"`
+	Level         string     `db:"level" json:"level"`
+	Status        string     `db:"status" json:"status"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	DecidedAt     *time.Time `db:"decided_at" json:"decidedAt"`
+	DecidedByName string     `db:"decided_by_name" json:"decidedByName"`
+}
+
+// Membership is a member joined with its workspace
+type Membership struct {
+	MemberID      string `db:"member_id" json:"memberId"`
+	WorkspaceID   string `db:"workspace_id" json:"workspaceId"`
+	WorkspaceName string `db:"workspace_name" json:"workspaceName"`
+	Level         string `db:"level" json:"level"`
 }
 
 // Invite ...
@@ -70,20 +108,33 @@ type Invite struct {
 	CreatedAt      time.Time `db:"created_at" json:"createdAt"`
 	CreatedByEmail string    `db:"created_by_email" json:"createdByEmail"`
 	WorkspaceName  string    `db:"workspace_name" json:"workspaceName"`
+	SentAt         time.Time `db:"sent_at" json:"sentAt"`
 }
 
 // Project ...
 type Project struct {
-	WorkspaceID        string    `db:"workspace_id" json:"workspaceId"`
-	ID                 string    `db:"id" json:"id"`
-	Title              string    `db:"title" json:"title"`
-	Description        string    `db:"description" json:"description"`
-	CreatedByName      string    `db:"created_by_name" json:"createdByName"`
-	CreatedAt          time.Time `db:"created_at" json:"createdAt"`
-	LastModified       time.Time `db:"last_modified" json:"lastModified"`
-	LastModifiedByName string    `db:"last_modified_by_name" json:"lastModifiedByName"`
-	ExternalLink       string    `db:"external_link" json:"externalLink"`
-	Annotations        string    `db:"annotations" json:"annotations"`
+	WorkspaceID          string    `db:"workspace_id" json:"workspaceId"`
+	ID                   string    `db:"id" json:"id"`
+	Title                string    `db:"title" json:"title"`
+	Description          string    `db:"description" json:"description"`
+	CreatedByName        string    `db:"created_by_name" json:"createdByName"`
+	CreatedAt            time.Time `db:"created_at" json:"createdAt"`
+	LastModified         time.Time `db:"last_modified" json:"lastModified"`
+	LastModifiedByName   string    `db:"last_modified_by_name" json:"lastModifiedByName"`
+	ExternalLink         string    `db:"external_link" json:"externalLink"`
+	Annotations          string    `db:"annotations" json:"annotations"`
+	AutoCloseDays        int       `db:"auto_close_days" json:"autoCloseDays"`
+	RequireEstimate      bool      `db:"require_estimate" json:"requireEstimate"`
+	DefaultEstimate      int       `db:"default_estimate" json:"defaultEstimate"`
+	DefaultAnnotations   string    `db:"default_annotations" json:"defaultAnnotations"`
+	DefaultMilestoneID   string    `db:"default_milestone_id" json:"defaultMilestoneId"`
+	DefaultSubWorkflowID string    `db:"default_subworkflow_id" json:"defaultSubWorkflowId"`
+	Favorited            bool      `db:"-" json:"favorited"`
+
+	ExternalLinkCreatedAt time.Time  `db:"external_link_created_at" json:"externalLinkCreatedAt"`
+	ExternalLinkCreatedBy string     `db:"external_link_created_by" json:"-"`
+	ExternalLinkViewedAt  *time.Time `db:"external_link_viewed_at" json:"externalLinkViewedAt"`
+	ExternalLinkRevokedAt *time.Time `db:"external_link_revoked_at" json:"externalLinkRevokedAt"`
 }
 
 // Milestone ...
@@ -101,6 +152,8 @@ type Milestone struct {
 	LastModifiedByName string    `db:"last_modified_by_name" json:"lastModifiedByName"`
 	Color              string    `db:"color" json:"color"`
 	Annotations        string    `db:"annotations" json:"annotations"`
+	Icon               string    `db:"icon" json:"icon"`
+	GoalIDs            []string  `db:"-" json:"goalIds"`
 }
 
 // Workflow ...
@@ -139,21 +192,188 @@ type SubWorkflow struct {
 
 // Feature ...
 type Feature struct {
+	WorkspaceID        string              `db:"workspace_id" json:"workspaceId"`
+	SubWorkflowID      string              `db:"subworkflow_id" json:"subWorkflowId"`
+	MilestoneID        string              `db:"milestone_id" json:"milestoneId"`
+	ID                 string              `db:"id" json:"id"`
+	Title              string              `db:"title" json:"title"`
+	Rank               string              `db:"rank" json:"rank"`
+	Description        string              `db:"description" json:"description"`
+	Status             string              `db:"status" json:"status"`
+	CreatedByName      string              `db:"created_by_name" json:"createdByName"`
+	CreatedAt          time.Time           `db:"created_at" json:"createdAt"`
+	LastModified       time.Time           `db:"last_modified" json:"lastModified"`
+	LastModifiedByName string              `db:"last_modified_by_name" json:"lastModifiedByName"`
+	Color              string              `db:"color" json:"color"`
+	Annotations        string              `db:"annotations" json:"annotations"`
+	Estimate           int                 `db:"estimate" json:"estimate"`
+	Progress           int                 `db:"progress" json:"progress"`
+	StatusID           string              `db:"status_id" json:"statusId"`
+	Icon               string              `db:"icon" json:"icon"`
+	BlockedSince       *time.Time          `db:"blocked_since" json:"blockedSince"`
+	BlockedEscalated   bool                `db:"blocked_escalated" json:"-"`
+	Watching           bool                `db:"-" json:"watching"`
+	References         []*FeatureReference `db:"-" json:"references"`
+	LoggedMinutes      int                 `db:"-" json:"loggedMinutes"`
+}
+
+// ProjectStatus is a custom feature status. Status on the feature follows its closed flag.
+type ProjectStatus struct {
+	WorkspaceID string    `db:"workspace_id" json:"workspaceId"`
+	ProjectID   string    `db:"project_id" json:"projectId"`
+	ID          string    `db:"id" json:"id"`
+	Title       string    `db:"title" json:"title"`
+	Rank        string    `db:"rank" json:"rank"`
+	Closed      bool      `db:"closed" json:"closed"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+// FeatureEvent is a snapshot of the reportable state of a feature after a change
+type FeatureEvent struct {
+	WorkspaceID   string    `db:"workspace_id" json:"workspaceId"`
+	ID            string    `db:"id" json:"id"`
+	ProjectID     string    `db:"project_id" json:"projectId"`
+	FeatureID     string    `db:"feature_id" json:"featureId"`
+	MilestoneID   string    `db:"milestone_id" json:"milestoneId"`
+	Status        string    `db:"status" json:"status"`
+	Estimate      int       `db:"estimate" json:"estimate"`
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+	Title         string    `db:"title" json:"title"`
+	CreatedByName string    `db:"created_by_name" json:"createdByName"`
+}
+
+// RecentChange is any project item, tagged with its type, that was modified recently
+type RecentChange struct {
+	Type               string    `db:"type" json:"type"`
+	ID                 string    `db:"id" json:"id"`
+	Title              string    `db:"title" json:"title"`
+	ProjectID          string    `db:"project_id" json:"projectId"`
+	ProjectTitle       string    `db:"project_title" json:"projectTitle"`
+	LastModified       time.Time `db:"last_modified" json:"lastModified"`
+	LastModifiedByName string    `db:"last_modified_by_name" json:"lastModifiedByName"`
+}
+
+// Breadcrumb is the path from a project down to a single entity within it
+type Breadcrumb struct {
+	Type             string `db:"type" json:"type"`
+	ProjectID        string `db:"project_id" json:"projectId"`
+	ProjectTitle     string `db:"project_title" json:"projectTitle"`
+	MilestoneID      string `db:"milestone_id" json:"milestoneId,omitempty"`
+	MilestoneTitle   string `db:"milestone_title" json:"milestoneTitle,omitempty"`
+	WorkflowID       string `db:"workflow_id" json:"workflowId,omitempty"`
+	WorkflowTitle    string `db:"workflow_title" json:"workflowTitle,omitempty"`
+	SubWorkflowID    string `db:"subworkflow_id" json:"subWorkflowId,omitempty"`
+	SubWorkflowTitle string `db:"subworkflow_title" json:"subWorkflowTitle,omitempty"`
+	FeatureID        string `db:"feature_id" json:"featureId,omitempty"`
+	FeatureTitle     string `db:"feature_title" json:"featureTitle,omitempty"`
+}
+
+// AnnotationCount is the number of items of one type carrying an annotation
+type AnnotationCount struct {
+	Name     string    `db:"name"`
+	Type     string    `db:"type"`
+	Count    int       `db:"count"`
+	LastUsed time.Time `db:"last_used"`
+}
+
+// AnnotationUsage ...
+type AnnotationUsage struct {
+	Name         string     `json:"name"`
+	Projects     int        `json:"projects"`
+	Milestones   int        `json:"milestones"`
+	Workflows    int        `json:"workflows"`
+	SubWorkflows int        `json:"subWorkflows"`
+	Features     int        `json:"features"`
+	Total        int        `json:"total"`
+	LastUsed     *time.Time `json:"lastUsed"`
+}
+
+// ProjectFavorite ...
+type ProjectFavorite struct {
+	WorkspaceID string    `db:"workspace_id" json:"workspaceId"`
+	ProjectID   string    `db:"project_id" json:"projectId"`
+	MemberID    string    `db:"member_id" json:"memberId"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+// ProjectLink relates two projects of a workspace. ProjectID is the lower of the two IDs so a
+// pair is only stored once, whichever side it was linked from.
+type ProjectLink struct {
+	WorkspaceID      string    `db:"workspace_id" json:"workspaceId"`
+	ProjectID        string    `db:"project_id" json:"projectId"`
+	RelatedProjectID string    `db:"related_project_id" json:"relatedProjectId"`
+	CreatedAt        time.Time `db:"created_at" json:"createdAt"`
+}
+
+// NotificationEmail is a notification to an account. It is kept for the day to count against the
+// daily cap, or until the digest is sent when it went over the cap.
+type NotificationEmail struct {
+	ID         string     `db:"id" json:"id"`
+	AccountID  string     `db:"account_id" json:"accountId"`
+	Email      string     `db:"email" json:"email"`
+	Subject    string     `db:"subject" json:"subject"`
+	Body       string     `db:"body" json:"body"`
+	Digest     bool       `db:"digest" json:"digest"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+	BatchKey   string     `db:"batch_key" json:"-"`
+	BatchUntil *time.Time `db:"batch_until" json:"-"`
+}
+
+// Goal is an objective of a project that milestones can be linked to
+type Goal struct {
 	WorkspaceID        string    `db:"workspace_id" json:"workspaceId"`
-	SubWorkflowID      string    `db:"subworkflow_id" json:"subWorkflowId"`
-	MilestoneID        string    `db:"milestone_id" json:"milestoneId"`
+	ProjectID          string    `db:"project_id" json:"projectId"`
 	ID                 string    `db:"id" json:"id"`
 	Title              string    `db:"title" json:"title"`
-	Rank               string    `db:"rank" json:"rank"`
 	Description        string    `db:"description" json:"description"`
-	Status             string    `db:"status" json:"status"`
-	CreatedByName      string    `db:"created_by_name" json:"createdByName"`
+	TargetDate         string    `db:"target_date" json:"targetDate"`
 	CreatedAt          time.Time `db:"created_at" json:"createdAt"`
+	CreatedByName      string    `db:"created_by_name" json:"createdByName"`
 	LastModified       time.Time `db:"last_modified" json:"lastModified"`
 	LastModifiedByName string    `db:"last_modified_by_name" json:"lastModifiedByName"`
-	Color              string    `db:"color" json:"color"`
-	Annotations        string    `db:"annotations" json:"annotations"`
-	Estimate           int       `db:"estimate" json:"estimate"`
+}
+
+// GoalMilestone ...
+type GoalMilestone struct {
+	WorkspaceID string `db:"workspace_id" json:"workspaceId"`
+	ProjectID   string `db:"project_id" json:"projectId"`
+	GoalID      string `db:"goal_id" json:"goalId"`
+	MilestoneID string `db:"milestone_id" json:"milestoneId"`
+}
+
+// FeatureWatcher ...
+type FeatureWatcher struct {
+	WorkspaceID string    `db:"workspace_id" json:"workspaceId"`
+	FeatureID   string    `db:"feature_id" json:"featureId"`
+	MemberID    string    `db:"member_id" json:"memberId"`
+	ProjectID   string    `db:"project_id" json:"projectId"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+// FeatureReference is a labeled link from a feature to an external resource
+type FeatureReference struct {
+	WorkspaceID   string    `db:"workspace_id" json:"workspaceId"`
+	ProjectID     string    `db:"project_id" json:"projectId"`
+	FeatureID     string    `db:"feature_id" json:"featureId"`
+	ID            string    `db:"id" json:"id"`
+	Label         string    `db:"label" json:"label"`
+	URL           string    `db:"url" json:"url"`
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+	CreatedByName string    `db:"created_by_name" json:"createdByName"`
+}
+
+// TimeEntry is time a member logged against a feature
+type TimeEntry struct {
+	WorkspaceID   string    `db:"workspace_id" json:"workspaceId"`
+	ProjectID     string    `db:"project_id" json:"projectId"`
+	FeatureID     string    `db:"feature_id" json:"featureId"`
+	ID            string    `db:"id" json:"id"`
+	MemberID      string    `db:"member_id" json:"memberId"`
+	Minutes       int       `db:"minutes" json:"minutes"`
+	Note          string    `db:"note" json:"note"`
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+	CreatedByName string    `db:"created_by_name" json:"createdByName"`
+	LastModified  time.Time `db:"last_modified" json:"lastModified"`
 }
 
 // FeatureComment ...
@@ -198,3 +418,35 @@ type WorkflowPersona struct {
 	ID          string `db:"id" json:"id"`
 	PersonaID   string `db:"persona_id" json:"personaId"`
 }
+
+// AdminAction records an action taken through the instance admin API
+type AdminAction struct {
+	ID           string    `db:"id" json:"id"`
+	AccountID    string    `db:"account_id" json:"accountId"`
+	AccountEmail string    `db:"account_email" json:"accountEmail"`
+	Action       string    `db:"action" json:"action"`
+	Target       string    `db:"target" json:"target"`
+	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
+}
+
+// ProjectArchive is an export of a project taken right before it was deleted
+type ProjectArchive struct {
+	WorkspaceID   string    `db:"workspace_id" json:"workspaceId"`
+	ID            string    `db:"id" json:"id"`
+	ProjectID     string    `db:"project_id" json:"projectId"`
+	ProjectTitle  string    `db:"project_title" json:"projectTitle"`
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+	CreatedByName string    `db:"created_by_name" json:"createdByName"`
+	Size          int       `db:"size" json:"size"`
+	Data          []byte    `db:"data" json:"-"`
+}
+
+// InstanceStats ...
+type InstanceStats struct {
+	Workspaces          int `db:"workspaces" json:"workspaces"`
+	SuspendedWorkspaces int `db:"suspended_workspaces" json:"suspendedWorkspaces"`
+	Accounts            int `db:"accounts" json:"accounts"`
+	ActiveAccounts      int `db:"active_accounts" json:"activeAccounts"`
+	Projects            int `db:"projects" json:"projects"`
+	Features            int `db:"features" json:"features"`
+}
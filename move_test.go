@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+// deletedParentRepo behaves as if another user deleted everything but the item being moved
+type deletedParentRepo struct {
+	Repository
+}
+
+var errNotFound = errors.New("not found")
+
+func (deletedParentRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	return &Feature{WorkspaceID: workspaceID, ID: id}, nil
+}
+
+func (deletedParentRepo) GetSubWorkflow(workspaceID string, id string) (*SubWorkflow, error) {
+	if id == "sw1" {
+		return &SubWorkflow{WorkspaceID: workspaceID, ID: id}, nil
+	}
+	return nil, errNotFound
+}
+
+func (deletedParentRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	if id == "m1" {
+		return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+	}
+	return nil, errNotFound
+}
+
+func (deletedParentRepo) GetWorkflow(workspaceID string, id string) (*Workflow, error) {
+	return nil, errNotFound
+}
+
+func (deletedParentRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	return nil, errNotFound
+}
+
+func TestMoveIntoDeletedParent(t *testing.T) {
+	s := memberService(deletedParentRepo{}, "EDITOR")
+
+	if _, err := s.MoveFeature("f1", "deleted", "sw1", 0); err != errMoveTargetGone {
+		t.Error("moving a feature into a deleted milestone should conflict", err)
+	}
+	if _, err := s.MoveFeature("f1", "m1", "deleted", 0); err != errMoveTargetGone {
+		t.Error("moving a feature into a deleted subworkflow should conflict", err)
+	}
+	if _, err := s.MoveSubWorkflow("sw1", "deleted", 0); err != errMoveTargetGone {
+		t.Error("moving a subworkflow into a deleted workflow should conflict", err)
+	}
+	if _, err := s.MoveMilestone("m1", 0); err != errMoveTargetGone {
+		t.Error("moving a milestone in a deleted project should conflict", err)
+	}
+}
+
+// milestoneRepo holds the milestones of one project in memory
+type milestoneRepo struct {
+	Repository
+	milestones []*Milestone
+}
+
+func (a *milestoneRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	for _, m := range a.milestones {
+		if m.ID == id {
+			c := *m
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *milestoneRepo) FindMilestonesByProject(workspaceID string, projectID string) ([]*Milestone, error) {
+	x := []*Milestone{}
+	for _, m := range a.milestones {
+		c := *m
+		x = append(x, &c)
+	}
+	sort.Slice(x, func(i, j int) bool { return x[i].Rank < x[j].Rank })
+	return x, nil
+}
+
+func (a *milestoneRepo) StoreMilestone(x *Milestone) {
+	for i, m := range a.milestones {
+		if m.ID == x.ID {
+			a.milestones[i] = x
+		}
+	}
+}
+
+func TestShiftMilestone(t *testing.T) {
+	repo := &milestoneRepo{milestones: []*Milestone{{ID: "a", Rank: "b"}, {ID: "b", Rank: "d"}, {ID: "c", Rank: "f"}}}
+
+	s := memberService(repo, "EDITOR")
+
+	order := func() string {
+		mm, _ := repo.FindMilestonesByProject("ws", "p")
+		x := ""
+		for _, m := range mm {
+			x += m.ID
+		}
+		return x
+	}
+
+	mm, err := s.ShiftMilestone("b", -1)
+	if err != nil || len(mm) != 2 || order() != "bac" {
+		t.Error("middle milestone should move up", order(), err)
+	}
+
+	mm, err = s.ShiftMilestone("b", -1)
+	if err != nil || len(mm) != 0 || order() != "bac" {
+		t.Error("first milestone moving up should be a no-op", order(), err)
+	}
+
+	if _, err := s.ShiftMilestone("c", 1); err != nil || order() != "bac" {
+		t.Error("last milestone moving down should be a no-op", order(), err)
+	}
+}
+
+// bulkMoveRepo holds the features of one project with two milestones in memory
+type bulkMoveRepo struct {
+	Repository
+	features []*Feature
+}
+
+func (a *bulkMoveRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	if id == "m1" || id == "m2" {
+		return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *bulkMoveRepo) FindMilestonesByProject(workspaceID string, projectID string) ([]*Milestone, error) {
+	return []*Milestone{{ID: "m1", ProjectID: "p1"}, {ID: "m2", ProjectID: "p1"}}, nil
+}
+
+func (a *bulkMoveRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	for _, f := range a.features {
+		if f.ID == id {
+			c := *f
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *bulkMoveRepo) FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, milestoneID string, subWorkflowID string) ([]*Feature, error) {
+	x := []*Feature{}
+	for _, f := range a.features {
+		if f.MilestoneID == milestoneID && f.SubWorkflowID == subWorkflowID {
+			c := *f
+			x = append(x, &c)
+		}
+	}
+	sort.Slice(x, func(i, j int) bool { return x[i].Rank < x[j].Rank })
+	return x, nil
+}
+
+func (a *bulkMoveRepo) StoreFeature(x *Feature) {
+	for i, f := range a.features {
+		if f.ID == x.ID {
+			a.features[i] = x
+		}
+	}
+}
+
+func (a *bulkMoveRepo) StoreFeatureEvent(x *FeatureEvent) {}
+
+func (a *bulkMoveRepo) GetSubWorkflow(workspaceID string, id string) (*SubWorkflow, error) {
+	if id == "other-sw" {
+		return &SubWorkflow{WorkspaceID: workspaceID, ID: id, WorkflowID: "other-wf"}, nil
+	}
+	return &SubWorkflow{WorkspaceID: workspaceID, ID: id, WorkflowID: "wf"}, nil
+}
+
+func (a *bulkMoveRepo) GetWorkflow(workspaceID string, id string) (*Workflow, error) {
+	if id == "other-wf" {
+		return &Workflow{WorkspaceID: workspaceID, ID: id, ProjectID: "p2"}, nil
+	}
+	return &Workflow{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func TestBulkMoveFeatures(t *testing.T) {
+	repo := &bulkMoveRepo{features: []*Feature{
+		{ID: "a", MilestoneID: "m1", SubWorkflowID: "sw", Rank: "b"},
+		{ID: "b", MilestoneID: "m1", SubWorkflowID: "sw", Rank: "d"},
+		{ID: "c", MilestoneID: "m1", SubWorkflowID: "sw", Rank: "f"},
+		{ID: "d", MilestoneID: "m2", SubWorkflowID: "sw", Rank: "m"},
+		{ID: "x", MilestoneID: "other", SubWorkflowID: "sw", Rank: "b"},
+	}}
+
+	s := memberService(repo, "EDITOR")
+
+	if _, err := s.BulkMoveFeatures("p1", []string{"a", "x"}, "m2", ""); err == nil {
+		t.Error("moving a feature of another project should fail")
+	}
+	if _, err := s.BulkMoveFeatures("p1", []string{"a"}, "deleted", ""); err != errMoveTargetGone {
+		t.Error("moving into a deleted milestone should conflict", err)
+	}
+	if f, _ := repo.GetFeature("ws", "a"); f.MilestoneID != "m1" {
+		t.Error("a failed move should not move anything")
+	}
+
+	ff, err := s.BulkMoveFeatures("p1", []string{"c", "a", "c"}, "m2", "")
+	if err != nil || len(ff) != 2 {
+		t.Fatal("moving two features should succeed", err)
+	}
+
+	x, _ := repo.FindFeaturesByMilestoneAndSubWorkflow("ws", "m2", "sw")
+	order := ""
+	for _, f := range x {
+		order += f.ID
+	}
+	if order != "dca" {
+		t.Error("moved features should be appended in the order given", order)
+	}
+	if ff[0].Rank != x[1].Rank || ff[1].Rank != x[2].Rank {
+		t.Error("the new ranks should be returned")
+	}
+}
+
+func TestBulkMoveColumns(t *testing.T) {
+	repo := &bulkMoveRepo{features: []*Feature{
+		{ID: "a1", MilestoneID: "m1", SubWorkflowID: "a", Rank: "b"},
+		{ID: "a2", MilestoneID: "m1", SubWorkflowID: "a", Rank: "d"},
+		{ID: "b1", MilestoneID: "m1", SubWorkflowID: "b", Rank: "b"},
+		{ID: "c1", MilestoneID: "m1", SubWorkflowID: "c", Rank: "b"},
+		{ID: "a3", MilestoneID: "m2", SubWorkflowID: "a", Rank: "m"},
+	}}
+
+	s := memberService(repo, "EDITOR")
+
+	if _, err := s.BulkMoveColumns("p1", []string{"a", "other-sw"}, "m1", "m2"); err == nil {
+		t.Error("moving a subworkflow of another project should fail")
+	}
+	if f, _ := repo.GetFeature("ws", "a1"); f.MilestoneID != "m1" {
+		t.Error("a failed move should not move anything")
+	}
+
+	ff, err := s.BulkMoveColumns("p1", []string{"a", "b"}, "m1", "m2")
+	if err != nil || len(ff) != 3 {
+		t.Fatal("moving two subworkflows should move their three features", err, len(ff))
+	}
+
+	x, _ := repo.FindFeaturesByMilestoneAndSubWorkflow("ws", "m2", "a")
+	order := ""
+	for _, f := range x {
+		order += f.ID
+	}
+	if order != "a3a1a2" {
+		t.Error("the features should follow those already in the milestone, in their order", order)
+	}
+	if f, _ := repo.GetFeature("ws", "b1"); f.MilestoneID != "m2" || f.SubWorkflowID != "b" {
+		t.Error("features should keep their subworkflow", f)
+	}
+	if f, _ := repo.GetFeature("ws", "c1"); f.MilestoneID != "m1" {
+		t.Error("other subworkflows should stay", f)
+	}
+
+	if ff, err := s.BulkMoveColumns("p1", []string{"c"}, "m2", "m1"); err != nil || len(ff) != 0 {
+		t.Error("moving an empty subworkflow should do nothing", err)
+	}
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/jwtauth"
 	"github.com/go-chi/render"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
@@ -35,6 +43,11 @@ func ContextSkeleton(c Configuration) func(next http.Handler) http.Handler {
 
 			s := NewFeatmapService()
 			s.SetConfig(c)
+
+			requestID := middleware.GetReqID(r.Context())
+			s.SetRequestID(requestID)
+			w.Header().Set(c.RequestIDHeader, requestID)
+
 			ctx := context.WithValue(r.Context(), contextKey, &Env{Service: s})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
@@ -42,26 +55,134 @@ func ContextSkeleton(c Configuration) func(next http.Handler) http.Handler {
 	}
 }
 
-// Transaction ...
+// Transaction runs the request in a transaction of its repository, committed at the end unless
+// the request panics. What the request queued with AfterCommit runs once it has committed.
 func Transaction(db *sqlx.DB) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 
 			s := GetEnv(r).Service
 
-			_ = txnDo(db, func(tx *sqlx.Tx) error {
-				repo := NewFeatmapRepository(db)
-				repo.SetTx(tx)
-				s.SetRepoObject(repo)
-				next.ServeHTTP(w, r)
-				return nil
-			})
+			repo := NewFeatmapRepository(db)
+			if err := repo.Begin(context.Background(), nil); err != nil {
+				log.Println(err)
+				http.Error(w, http.StatusText(500), 500)
+				return
+			}
+			repo.LogSlowQueries(slowQueryThreshold(s.GetConfig()), s.GetRequestID())
+			s.SetRepoObject(repo)
 
+			err := repoDo(repo, func() { next.ServeHTTP(w, r) })
+			if err != nil {
+				log.Println(err)
+			}
+			s.RunAfterCommit(err == nil)
 		}
 		return http.HandlerFunc(fn)
 	}
 }
 
+const serializableAttempts = 3
+
+// isSerializationFailure reports whether Postgres failed err with serialization_failure or
+// deadlock_detected, after which the transaction can be retried
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// bufferedResponse holds a response until the transaction behind it has committed
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
+
+func (b *bufferedResponse) flush(w http.ResponseWriter) {
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(b.status)
+	_, _ = w.Write(b.body.Bytes())
+}
+
+// Serializable commits what the middleware did in the request transaction and begins it again at
+// SERIALIZABLE for the rest of the request, which is retried when Postgres reports a
+// serialization failure. What an attempt queued with AfterCommit runs only if it committed.
+func Serializable() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			s := GetEnv(r).Service
+			repo := s.GetRepoObject()
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, http.StatusText(400), 400)
+				return
+			}
+
+			if err := repo.Commit(); err != nil {
+				log.Println(err)
+				http.Error(w, http.StatusText(500), 500)
+				return
+			}
+			s.RunAfterCommit(true)
+
+			for attempt := 1; ; attempt++ {
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+				buf := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+
+				err := serializableAttempt(r.Context(), repo, func() { next.ServeHTTP(buf, r) })
+				s.RunAfterCommit(err == nil)
+				if isSerializationFailure(err) && attempt < serializableAttempts {
+					continue
+				}
+				if isSerializationFailure(err) {
+					http.Error(w, http.StatusText(409), 409)
+					return
+				}
+				if err != nil {
+					log.Println(err)
+					http.Error(w, http.StatusText(500), 500)
+					return
+				}
+
+				buf.flush(w)
+				return
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func serializableAttempt(ctx context.Context, repo Repository, f func()) (err error) {
+	if err := repo.Begin(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = repo.Rollback()
+			if perr, ok := p.(error); ok && isSerializationFailure(perr) {
+				err = perr
+				return
+			}
+			panic(p)
+		}
+	}()
+
+	f()
+
+	return repo.Commit()
+}
+
 // Auth ...
 func Auth(auth *jwtauth.JWTAuth) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -105,6 +226,10 @@ func User() func(next http.Handler) http.Handler {
 						http.Error(w, http.StatusText(401), 401)
 						return
 					}
+					if ws.Suspended {
+						http.Error(w, http.StatusText(403), 403)
+						return
+					}
 					s.SetWorkspaceObject(ws)
 
 					sub := s.GetSubscriptionByWorkspace(member.WorkspaceID)
@@ -113,6 +238,13 @@ func User() func(next http.Handler) http.Handler {
 						return
 					}
 					s.SetSubscriptionObject(sub)
+
+					if member.Level == "INACTIVE" {
+						if err := s.ReactivateMember(); err != nil {
+							http.Error(w, http.StatusText(403), 403)
+							return
+						}
+					}
 				}
 			}
 
@@ -183,6 +315,21 @@ func RequireAccount() func(next http.Handler) http.Handler {
 	}
 }
 
+// RequireSuperuser ...
+func RequireSuperuser() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+
+			if !GetEnv(r).Service.IsSuperuser() {
+				http.Error(w, http.StatusText(403), 403)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
 // RequireEditor ...
 func RequireEditor() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -198,6 +345,21 @@ func RequireEditor() func(next http.Handler) http.Handler {
 	}
 }
 
+// RequireCommenter lets commenters and editors through
+func RequireCommenter() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+
+			if !canComment(GetEnv(r).Service.GetMemberObject().Level) {
+				http.Error(w, http.StatusText(401), 401)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
 // RequireSubscription  ...
 func RequireSubscription() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -231,6 +393,39 @@ func subscriptionIsActive(s *Subscription) bool {
 	return false
 }
 
+// RealIP sets the remote address of a request to that of the client, as told by the proxy headers
+// when the request comes through one of the trusted proxies
+func RealIP(trusted []*net.IPNet) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			r.RemoteAddr = clientAddr(trusted, r)
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RateLimit limits the requests of a workspace according to the rate plan of its subscription tier.
+// Requests from the exempt ranges are not limited, but they still have to be authenticated.
+func RateLimit() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			s := GetEnv(r).Service
+			sub := s.GetSubscriptionObject()
+
+			if sub != nil && !inNets(rateLimitExempt, r.RemoteAddr) {
+				if plan, ok := ratePlan(s.GetConfig(), sub.Level); ok && !limiter.allow(sub.WorkspaceID, plan, time.Now()) {
+					w.Header().Set("Retry-After", strconv.Itoa(int(60/float64(plan.RequestsPerMinute))+1))
+					http.Error(w, http.StatusText(429), 429)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
 // RequireTrialOrPro  ...
 func RequireTrialOrPro() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// outline is a project parsed from an import, before anything is stored
+type outline struct {
+	Milestones []*outlineMilestone `json:"milestones"`
+}
+
+type outlineMilestone struct {
+	Title   string           `json:"title"`
+	Columns []*outlineColumn `json:"columns"`
+}
+
+// outlineColumn becomes a subworkflow, its cards become features in the milestone
+type outlineColumn struct {
+	Title string   `json:"title"`
+	Cards []string `json:"cards"`
+}
+
+type importSummary struct {
+	Milestones   int `json:"milestones"`
+	SubWorkflows int `json:"subWorkflows"`
+	Features     int `json:"features"`
+}
+
+var errTitleExists = errors.New("a project with this title already exists")
+
+// importTitle resolves a collision between an imported project title and the existing ones
+// according to strategy, which is suffix (the default), keep or reject.
+func importTitle(title string, existing []string, strategy string) (string, error) {
+	taken := map[string]bool{}
+	for _, t := range existing {
+		taken[strings.ToLower(t)] = true
+	}
+	if !taken[strings.ToLower(title)] {
+		return title, nil
+	}
+
+	switch strategy {
+	case "keep":
+		return title, nil
+	case "reject":
+		return "", errTitleExists
+	}
+
+	for n := 2; ; n++ {
+		x := fmt.Sprintf("%s (%d)", title, n)
+		if !taken[strings.ToLower(x)] {
+			return x, nil
+		}
+	}
+}
+
+func (o *outline) summary() importSummary {
+	x := importSummary{Milestones: len(o.Milestones)}
+	columns := map[string]bool{}
+	for _, m := range o.Milestones {
+		for _, c := range m.Columns {
+			columns[c.Title] = true
+			x.Features += len(c.Cards)
+		}
+	}
+	x.SubWorkflows = len(columns)
+	return x
+}
+
+// indentation returns the width of the leading whitespace, counting a tab as four spaces
+func indentation(line string) int {
+	n := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func bulletText(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	for _, b := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, b) {
+			return strings.TrimSpace(line[len(b):]), true
+		}
+	}
+	return "", false
+}
+
+// parseMarkdownOutline reads headings as milestones, top-level bullets as subworkflows
+// and nested bullets, at any depth, as features. The first bullet under a heading sets
+// the top-level indentation, so outlines indented with a mix of tabs and spaces still work.
+func parseMarkdownOutline(md string) (*outline, error) {
+	o := &outline{}
+
+	var milestone *outlineMilestone
+	var column *outlineColumn
+	topIndent := -1
+
+	for i, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			title := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			if title == "" {
+				return nil, fmt.Errorf("line %d: heading without a title", n)
+			}
+			milestone = &outlineMilestone{Title: title}
+			o.Milestones = append(o.Milestones, milestone)
+			column = nil
+			topIndent = -1
+			continue
+		}
+
+		text, ok := bulletText(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a heading or a list item", n)
+		}
+		if text == "" {
+			return nil, fmt.Errorf("line %d: list item without a title", n)
+		}
+		if milestone == nil {
+			return nil, fmt.Errorf("line %d: list item before the first heading", n)
+		}
+
+		indent := indentation(line)
+		if topIndent < 0 || indent <= topIndent {
+			topIndent = indent
+			column = &outlineColumn{Title: text}
+			milestone.Columns = append(milestone.Columns, column)
+			continue
+		}
+
+		column.Cards = append(column.Cards, text)
+	}
+
+	if len(o.Milestones) == 0 {
+		return nil, fmt.Errorf("no headings found")
+	}
+
+	return o, nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+const sampleOutline = `# MVP
+- Sign up
+  - Email form
+  - Verify email
+- Search
+	- Free text
+
+## Later
+* Sign up
+    * Social login
+        * Deeply nested
+`
+
+func TestParseMarkdownOutline(t *testing.T) {
+	o, err := parseMarkdownOutline(sampleOutline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(o.Milestones) != 2 || o.Milestones[0].Title != "MVP" || o.Milestones[1].Title != "Later" {
+		t.Fatal("wrong milestones")
+	}
+
+	mvp := o.Milestones[0]
+	if len(mvp.Columns) != 2 || mvp.Columns[0].Title != "Sign up" || mvp.Columns[1].Title != "Search" {
+		t.Error("wrong columns in first milestone")
+	}
+	if len(mvp.Columns[0].Cards) != 2 || mvp.Columns[0].Cards[1] != "Verify email" {
+		t.Error("wrong cards in first column")
+	}
+	if len(mvp.Columns[1].Cards) != 1 {
+		t.Error("tab indented card missing")
+	}
+
+	later := o.Milestones[1]
+	if len(later.Columns) != 1 || len(later.Columns[0].Cards) != 2 {
+		t.Error("deeply nested bullets should become cards")
+	}
+
+	s := o.summary()
+	if s.Milestones != 2 || s.SubWorkflows != 2 || s.Features != 5 {
+		t.Error("wrong summary", s)
+	}
+}
+
+func TestParseMarkdownOutlineErrors(t *testing.T) {
+	for _, md := range []string{
+		"",
+		"- Orphan",
+		"# MVP\nJust a paragraph",
+		"#\n- Item",
+	} {
+		if _, err := parseMarkdownOutline(md); err == nil {
+			t.Error("expected error for", md)
+		}
+	}
+
+	_, err := parseMarkdownOutline("# MVP\n- Item\nText")
+	if err == nil || err.Error() != "line 3: expected a heading or a list item" {
+		t.Error("error should name the line", err)
+	}
+}
+
+func TestImportTitle(t *testing.T) {
+	existing := []string{"Roadmap", "roadmap (2)", "Other"}
+
+	if x, _ := importTitle("New", existing, ""); x != "New" {
+		t.Error("unused title should be kept", x)
+	}
+	if x, _ := importTitle("Other", existing, "suffix"); x != "Other (2)" {
+		t.Error("duplicate title should get a suffix", x)
+	}
+	if x, _ := importTitle("Roadmap", existing, ""); x != "Roadmap (3)" {
+		t.Error("suffix should skip titles in use", x)
+	}
+	if x, _ := importTitle("Other", existing, "keep"); x != "Other" {
+		t.Error("keep should allow the duplicate", x)
+	}
+	if _, err := importTitle("other", existing, "reject"); err != errTitleExists {
+		t.Error("reject should fail on a duplicate")
+	}
+}
+
+// importRepo keeps an imported project in memory. Its finders return siblings newest first, so
+// the ranks made while appending come out of order.
+type importRepo struct {
+	Repository
+	projects     map[string]*Project
+	milestones   map[string]*Milestone
+	subWorkflows map[string]*SubWorkflow
+	features     map[string]*Feature
+	statuses     []*ProjectStatus
+	order        []string
+}
+
+// Savepoint and RollbackToSavepoint are left to markdownDryRunRepo
+func (a *importRepo) Savepoint(name string) {}
+
+func (a *importRepo) RollbackToSavepoint(name string) {}
+
+func (a *importRepo) FindProjectsByWorkspace(workspaceID string) ([]*Project, error) {
+	return []*Project{}, nil
+}
+
+func (a *importRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	if x, ok := a.projects[id]; ok {
+		return x, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *importRepo) StoreProject(x *Project) { a.projects[x.ID] = x }
+
+func (a *importRepo) GetWorkflow(workspaceID string, id string) (*Workflow, error) {
+	return nil, errNotFound
+}
+
+func (a *importRepo) FindWorkflowsByProject(workspaceID string, projectID string) ([]*Workflow, error) {
+	return []*Workflow{}, nil
+}
+
+func (a *importRepo) StoreWorkflow(x *Workflow) {}
+
+func (a *importRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	if x, ok := a.milestones[id]; ok {
+		return x, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *importRepo) FindMilestonesByProject(workspaceID string, projectID string) ([]*Milestone, error) {
+	x := []*Milestone{}
+	for i := len(a.order) - 1; i >= 0; i-- {
+		if m, ok := a.milestones[a.order[i]]; ok {
+			x = append(x, m)
+		}
+	}
+	return x, nil
+}
+
+func (a *importRepo) StoreMilestone(x *Milestone) {
+	if _, ok := a.milestones[x.ID]; !ok {
+		a.order = append(a.order, x.ID)
+	}
+	a.milestones[x.ID] = x
+}
+
+func (a *importRepo) GetSubWorkflow(workspaceID string, id string) (*SubWorkflow, error) {
+	return nil, errNotFound
+}
+
+func (a *importRepo) FindSubWorkflowsByWorkflow(workspaceID string, workflowID string) ([]*SubWorkflow, error) {
+	x := []*SubWorkflow{}
+	for i := len(a.order) - 1; i >= 0; i-- {
+		if sw, ok := a.subWorkflows[a.order[i]]; ok {
+			x = append(x, sw)
+		}
+	}
+	return x, nil
+}
+
+func (a *importRepo) StoreSubWorkflow(x *SubWorkflow) {
+	if _, ok := a.subWorkflows[x.ID]; !ok {
+		a.order = append(a.order, x.ID)
+	}
+	a.subWorkflows[x.ID] = x
+}
+
+func (a *importRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	return nil, errNotFound
+}
+
+func (a *importRepo) FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, mid string, swid string) ([]*Feature, error) {
+	x := []*Feature{}
+	for i := len(a.order) - 1; i >= 0; i-- {
+		if f, ok := a.features[a.order[i]]; ok && f.MilestoneID == mid && f.SubWorkflowID == swid {
+			x = append(x, f)
+		}
+	}
+	return x, nil
+}
+
+func (a *importRepo) StoreFeature(x *Feature) {
+	if _, ok := a.features[x.ID]; !ok {
+		a.order = append(a.order, x.ID)
+	}
+	a.features[x.ID] = x
+}
+
+func (a *importRepo) StoreFeatureEvent(x *FeatureEvent) {}
+
+func (a *importRepo) StoreProjectStatus(x *ProjectStatus) { a.statuses = append(a.statuses, x) }
+
+func (a *importRepo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	x := []*ProjectStatus{}
+	for _, y := range a.statuses {
+		if y.ProjectID == projectID {
+			x = append(x, y)
+		}
+	}
+	return x, nil
+}
+
+func TestImportOutlineRanks(t *testing.T) {
+	repo := &importRepo{projects: map[string]*Project{}, milestones: map[string]*Milestone{}, subWorkflows: map[string]*SubWorkflow{}, features: map[string]*Feature{}}
+	s := memberService(repo, "EDITOR")
+
+	o, err := parseMarkdownOutline("# One\n- A\n  - a1\n  - a2\n  - a3\n  - a4\n- B\n  - b1\n# Two\n- A\n  - a5\n# Three\n- C\n  - c1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ImportOutline("Imported", o); err != nil {
+		t.Fatal(err)
+	}
+
+	clean := func(what string, ranks []string) {
+		for i := range ranks {
+			if len(ranks[i]) != len(ranks[0]) || (i > 0 && ranks[i] <= ranks[i-1]) {
+				t.Error(what+" should have increasing ranks of equal length in outline order", ranks)
+				return
+			}
+		}
+	}
+
+	milestones := map[string]string{}
+	ranks := map[string][]string{}
+	for _, id := range repo.order {
+		if m, ok := repo.milestones[id]; ok {
+			milestones[m.ID] = m.Title
+			ranks["milestones"] = append(ranks["milestones"], m.Rank)
+		}
+		if sw, ok := repo.subWorkflows[id]; ok {
+			ranks["subworkflows"] = append(ranks["subworkflows"], sw.Rank)
+		}
+		if f, ok := repo.features[id]; ok {
+			ranks[milestones[f.MilestoneID]+"/"+f.SubWorkflowID] = append(ranks[milestones[f.MilestoneID]+"/"+f.SubWorkflowID], f.Rank)
+		}
+	}
+	if len(ranks["milestones"]) != 3 || len(ranks["subworkflows"]) != 3 || len(ranks) != 6 {
+		t.Fatal("outline should be imported", ranks)
+	}
+	for what, rr := range ranks {
+		clean(what, rr)
+	}
+}
+
+// brokenImportRepo fails to read milestones once a feature has been stored
+type brokenImportRepo struct {
+	markdownDryRunRepo
+}
+
+func (a *brokenImportRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	if len(a.features) > 0 {
+		return nil, errors.New("connection lost")
+	}
+	return a.markdownDryRunRepo.GetMilestone(workspaceID, id)
+}
+
+func TestImportOutlineUndoneOnError(t *testing.T) {
+	repo := &brokenImportRepo{markdownDryRunRepo{importRepo: copyImportRepo(importRepo{})}}
+	s := memberService(repo, "EDITOR")
+
+	o, err := parseMarkdownOutline("# One\n- A\n  - a1\n  - a2\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ImportOutline("Imported", o); err == nil {
+		t.Fatal("the import should fail")
+	}
+	if len(repo.projects) != 0 || len(repo.milestones) != 0 || len(repo.subWorkflows) != 0 || len(repo.features) != 0 || len(repo.statuses) != 0 {
+		t.Error("a failed import should leave nothing behind", len(repo.projects), len(repo.milestones), len(repo.features))
+	}
+}
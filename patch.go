@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// featurePatch holds the fields of a feature to change. A nil field is left as it is.
+type featurePatch struct {
+	Title       *string
+	Description *string
+	Color       *string
+	Icon        *string
+	Annotations *string
+	Estimate    *int
+	Progress    *int
+}
+
+// parseFeaturePatch reads the body of a PATCH on a feature. A field that is absent is left as it
+// is, while null resets it to the value a new feature has. The title cannot be reset.
+func parseFeaturePatch(m map[string]json.RawMessage) (featurePatch, error) {
+	p := featurePatch{}
+
+	for k, raw := range m {
+		var err error
+		switch k {
+		case "title":
+			if string(raw) == "null" {
+				return p, errors.New("title too short")
+			}
+			p.Title, err = patchString(raw, "")
+		case "description":
+			p.Description, err = patchString(raw, "")
+		case "color":
+			p.Color, err = patchString(raw, "WHITE")
+		case "icon":
+			p.Icon, err = patchString(raw, "")
+		case "annotations":
+			p.Annotations, err = patchString(raw, "")
+		case "estimate":
+			p.Estimate, err = patchInt(raw)
+		case "progress":
+			p.Progress, err = patchInt(raw)
+		default:
+			return p, &unknownFieldError{Field: k}
+		}
+		if err != nil {
+			return p, errors.New("invalid " + k)
+		}
+	}
+
+	return p, nil
+}
+
+func patchString(raw json.RawMessage, null string) (*string, error) {
+	x := null
+	if string(raw) != "null" {
+		if err := json.Unmarshal(raw, &x); err != nil {
+			return nil, err
+		}
+	}
+	return &x, nil
+}
+
+func patchInt(raw json.RawMessage) (*int, error) {
+	x := 0
+	if string(raw) != "null" {
+		if err := json.Unmarshal(raw, &x); err != nil {
+			return nil, err
+		}
+	}
+	return &x, nil
+}
+
+// PatchFeature changes only the fields set in the patch. Every field is checked before any is
+// changed, and the feature is stored once.
+func (s *service) PatchFeature(id string, p featurePatch) (*Feature, error) {
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, errors.New("feature not found")
+	}
+
+	if p.Title != nil {
+		title, err := validateTitle(*p.Title)
+		if err != nil {
+			return nil, err
+		}
+		p.Title = &title
+	}
+	if p.Color != nil && !colorIsValid(*p.Color) {
+		return nil, errors.New("invalid color")
+	}
+	if p.Icon != nil && !iconIsValid(*p.Icon) {
+		return nil, errors.New("invalid icon")
+	}
+	if p.Annotations != nil && !areAnnotationsValid(*p.Annotations) {
+		return nil, errors.New("invalid annotation")
+	}
+	if p.Estimate != nil {
+		if err := s.checkEstimate(*p.Estimate); err != nil {
+			return nil, err
+		}
+	}
+	if p.Progress != nil && (*p.Progress < 0 || *p.Progress > 100) {
+		return nil, errors.New("invalid progress")
+	}
+
+	if p.Title != nil {
+		f.Title = *p.Title
+	}
+	if p.Description != nil {
+		f.Description = *p.Description
+	}
+	if p.Color != nil {
+		f.Color = *p.Color
+	}
+	if p.Icon != nil {
+		f.Icon = *p.Icon
+	}
+	if p.Annotations != nil {
+		f.Annotations = *p.Annotations
+	}
+	if p.Estimate != nil {
+		f.Estimate = *p.Estimate
+	}
+	if p.Progress != nil {
+		f.Progress = *p.Progress
+	}
+
+	f.LastModifiedByName = s.Acc.Name
+	f.LastModified = time.Now().UTC()
+	trackBlocked(f, f.LastModified)
+
+	s.r.StoreFeature(f)
+	s.recordFeatureEvent(f, f.Status)
+
+	return f, nil
+}
+
+// ReplaceFeature sets all the fields a feature can be edited on. Those left empty get the value a
+// new feature has.
+func (s *service) ReplaceFeature(id string, title string, description string, color string, icon string, annotations string, estimate int, progress int) (*Feature, error) {
+	if color == "" {
+		color = "WHITE"
+	}
+	return s.PatchFeature(id, featurePatch{
+		Title:       &title,
+		Description: &description,
+		Color:       &color,
+		Icon:        &icon,
+		Annotations: &annotations,
+		Estimate:    &estimate,
+		Progress:    &progress,
+	})
+}
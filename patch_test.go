@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+)
+
+// patchRepo holds feature "f1" and counts how often it is stored
+type patchRepo struct {
+	Repository
+	feature *Feature
+	stores  int
+	events  int
+}
+
+func (a *patchRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	if id != a.feature.ID {
+		return nil, errNotFound
+	}
+	x := *a.feature
+	return &x, nil
+}
+
+func (a *patchRepo) StoreFeature(x *Feature) {
+	a.feature = x
+	a.stores++
+}
+
+func (a *patchRepo) StoreFeatureEvent(x *FeatureEvent) {
+	a.events++
+}
+
+func TestPatchFeature(t *testing.T) {
+	repo := &patchRepo{}
+
+	serve := func(method string, body string) int {
+		s := memberService(repo, "EDITOR")
+		w := serveWith(s, "/v1/", workspaceAPI, jsonRequest(method, "/v1/features/f1", body))
+		return w.Code
+	}
+
+	reset := func() {
+		repo.feature = &Feature{WorkspaceID: "ws", ID: "f1", Title: "Old", Description: "Keep me", Color: "RED", Estimate: 3}
+		repo.stores, repo.events = 0, 0
+	}
+
+	reset()
+	if code := serve("PATCH", `{"title": "New"}`); code != 200 {
+		t.Fatal("patch failed", code)
+	}
+	f := repo.feature
+	if f.Title != "New" || f.Description != "Keep me" || f.Color != "RED" || f.Estimate != 3 {
+		t.Error("a patch should only change the fields sent", f)
+	}
+	if repo.stores != 1 || repo.events != 1 {
+		t.Error("a patch should store the feature once", repo.stores, repo.events)
+	}
+
+	reset()
+	if code := serve("PUT", `{"title": "New"}`); code != 200 {
+		t.Fatal("put failed", code)
+	}
+	f = repo.feature
+	if f.Title != "New" || f.Description != "" || f.Color != "WHITE" || f.Estimate != 0 {
+		t.Error("a put should reset the fields not sent", f)
+	}
+
+	reset()
+	if code := serve("PATCH", `{"description": null, "estimate": 5}`); code != 200 {
+		t.Fatal("patch failed", code)
+	}
+	if f := repo.feature; f.Title != "Old" || f.Description != "" || f.Estimate != 5 {
+		t.Error("null should reset a field", f)
+	}
+
+	reset()
+	for _, body := range []string{`{"title": null}`, `{"color": "PLAID"}`, `{"estimate": "five"}`, `{"title": "New", "progress": 101}`} {
+		if code := serve("PATCH", body); code != 400 {
+			t.Error("patch should be rejected", body, code)
+		}
+	}
+	if code := serve("PATCH", `{"owner": "bob"}`); code != 422 {
+		t.Error("patch of an unknown field should be rejected", code)
+	}
+	if repo.stores != 0 || repo.feature.Title != "Old" {
+		t.Error("a rejected patch should change nothing", repo.feature)
+	}
+}
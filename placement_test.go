@@ -0,0 +1,139 @@
+package main
+
+import (
+	"testing"
+)
+
+// placementRepo holds project p1 with milestones m1, m2 and workflows w1, which is empty, and
+// w2 with subworkflows s1, s2. Milestone "other" and subworkflow "s-other" are in another project.
+type placementRepo struct {
+	Repository
+	project *Project
+	stored  []*Feature
+}
+
+func (a *placementRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	if id != a.project.ID {
+		return nil, errNotFound
+	}
+	return a.project, nil
+}
+
+func (a *placementRepo) StoreProject(x *Project) { a.project = x }
+
+func (a *placementRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	switch id {
+	case "m1", "m2":
+		return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+	case "other":
+		return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p2"}, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *placementRepo) FindMilestonesByProject(workspaceID string, projectID string) ([]*Milestone, error) {
+	return []*Milestone{{ID: "m1", ProjectID: "p1", Rank: "a"}, {ID: "m2", ProjectID: "p1", Rank: "b"}}, nil
+}
+
+func (a *placementRepo) GetWorkflow(workspaceID string, id string) (*Workflow, error) {
+	if id == "w-other" {
+		return &Workflow{ID: id, ProjectID: "p2"}, nil
+	}
+	return &Workflow{ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *placementRepo) FindWorkflowsByProject(workspaceID string, projectID string) ([]*Workflow, error) {
+	return []*Workflow{{ID: "w1", ProjectID: "p1", Rank: "a"}, {ID: "w2", ProjectID: "p1", Rank: "b"}}, nil
+}
+
+func (a *placementRepo) GetSubWorkflow(workspaceID string, id string) (*SubWorkflow, error) {
+	switch id {
+	case "s1", "s2":
+		return &SubWorkflow{ID: id, WorkflowID: "w2"}, nil
+	case "s-other":
+		return &SubWorkflow{ID: id, WorkflowID: "w-other"}, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *placementRepo) FindSubWorkflowsByWorkflow(workspaceID string, workflowID string) ([]*SubWorkflow, error) {
+	if workflowID != "w2" {
+		return []*SubWorkflow{}, nil
+	}
+	return []*SubWorkflow{{ID: "s1", WorkflowID: "w2", Rank: "a"}, {ID: "s2", WorkflowID: "w2", Rank: "b"}}, nil
+}
+
+func (a *placementRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	return nil, errNotFound
+}
+
+func (a *placementRepo) FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, mid string, swid string) ([]*Feature, error) {
+	return []*Feature{}, nil
+}
+
+func (a *placementRepo) StoreFeature(x *Feature) { a.stored = append(a.stored, x) }
+
+func (a *placementRepo) StoreFeatureEvent(x *FeatureEvent) {}
+
+func (a *placementRepo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	return []*ProjectStatus{}, nil
+}
+
+func TestDefaultPlacement(t *testing.T) {
+	repo := &placementRepo{project: &Project{WorkspaceID: "ws", ID: "p1"}}
+
+	serve := func(path string, body string) int {
+		s := memberService(repo, "EDITOR")
+		w := serveWith(s, "/v1/", workspaceAPI, jsonRequest("POST", path, body))
+		return w.Code
+	}
+
+	placed := func() string {
+		f := repo.stored[len(repo.stored)-1]
+		return f.MilestoneID + "/" + f.SubWorkflowID
+	}
+
+	if code := serve("/v1/features/f1", `{"projectId": "p1", "title": "Quick"}`); code != 200 || placed() != "m1/s1" {
+		t.Error("without defaults a feature should go to the first milestone and subworkflow", code)
+	}
+
+	if code := serve("/v1/projects/p1/settings/default-placement", `{"milestoneId": "m2", "subWorkflowId": "s2"}`); code != 200 {
+		t.Fatal("defaults should be set", code)
+	}
+	if code := serve("/v1/features/f2", `{"projectId": "p1", "title": "Quick"}`); code != 200 || placed() != "m2/s2" {
+		t.Error("a feature should go to the defaults", code, placed())
+	}
+	if code := serve("/v1/features/f3", `{"milestoneId": "m1", "title": "Quick"}`); code != 200 || placed() != "m1/s2" {
+		t.Error("only the missing subworkflow should be defaulted", code, placed())
+	}
+
+	for _, body := range []string{`{"milestoneId": "other"}`, `{"subWorkflowId": "s-other"}`, `{"milestoneId": "gone"}`} {
+		if code := serve("/v1/projects/p1/settings/default-placement", body); code != 400 {
+			t.Error("defaults outside the project should be rejected", body, code)
+		}
+	}
+
+	repo.project.DefaultMilestoneID = "gone"
+	if code := serve("/v1/features/f4", `{"projectId": "p1", "title": "Quick"}`); code != 200 || placed() != "m1/s2" {
+		t.Error("a default that is gone should fall back to the first milestone", code, placed())
+	}
+
+	if code := serve("/v1/features/f5", `{"title": "Quick"}`); code != 400 {
+		t.Error("a feature without a project should be rejected", code)
+	}
+
+	for _, body := range []string{
+		`{"projectId": "p1", "milestoneId": "other", "title": "Quick"}`,
+		`{"projectId": "p1", "subWorkflowId": "s-other", "title": "Quick"}`,
+		`{"projectId": "p1", "milestoneId": "m1", "subWorkflowId": "s-other", "title": "Quick"}`,
+		`{"projectId": "p1", "milestoneId": "other", "subWorkflowId": "s1", "title": "Quick"}`,
+		`{"milestoneId": "m1", "subWorkflowId": "s-other", "title": "Quick"}`,
+	} {
+		if code := serve("/v1/features/f6", body); code != 400 {
+			t.Error("a feature should not be placed outside its project", body, code)
+		}
+	}
+	if code := serve("/v1/features/f6", `{"milestoneId": "m2", "subWorkflowId": "s1", "title": "Quick"}`); code != 200 || placed() != "m2/s1" {
+		t.Error("a feature should be placed where it was asked", code, placed())
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import "testing"
+
+// favoriteRepo keeps projects and favorites in memory
+type favoriteRepo struct {
+	Repository
+	projects  []*Project
+	favorites map[string]map[string]bool
+}
+
+func (a *favoriteRepo) FindProjectsByWorkspace(workspaceID string) ([]*Project, error) {
+	x := []*Project{}
+	for _, p := range a.projects {
+		c := *p
+		x = append(x, &c)
+	}
+	return x, nil
+}
+
+func (a *favoriteRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	for _, p := range a.projects {
+		if p.ID == id {
+			c := *p
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *favoriteRepo) StoreProjectFavorite(x *ProjectFavorite) {
+	if a.favorites[x.MemberID] == nil {
+		a.favorites[x.MemberID] = map[string]bool{}
+	}
+	a.favorites[x.MemberID][x.ProjectID] = true
+}
+
+func (a *favoriteRepo) DeleteProjectFavorite(workspaceID string, projectID string, memberID string) {
+	delete(a.favorites[memberID], projectID)
+}
+
+func (a *favoriteRepo) FindFavoriteProjectIDsByMember(workspaceID string, memberID string) ([]string, error) {
+	x := []string{}
+	for id := range a.favorites[memberID] {
+		x = append(x, id)
+	}
+	return x, nil
+}
+
+func TestFavoriteProjects(t *testing.T) {
+	repo := &favoriteRepo{projects: []*Project{{ID: "p1"}, {ID: "p2"}}, favorites: map[string]map[string]bool{}}
+
+	member := func(id string) Service {
+		s := NewFeatmapService()
+		s.SetRepoObject(repo)
+		s.SetMemberObject(&Member{WorkspaceID: "ws", ID: id})
+		return s
+	}
+	ann, bob := member("ann"), member("bob")
+
+	if err := ann.FavoriteProject("p2"); err != nil {
+		t.Error(err)
+	}
+	if ann.FavoriteProject("unknown") == nil {
+		t.Error("favoriting an unknown project should fail")
+	}
+
+	pp := favoriteProjects(ann.GetProjects())
+	if len(pp) != 1 || pp[0].ID != "p2" || !pp[0].Favorited {
+		t.Error("filter should return the favorite of the member", pp)
+	}
+	if !ann.GetProject("p2").Favorited {
+		t.Error("project response should be marked as favorited")
+	}
+	if len(favoriteProjects(bob.GetProjects())) != 0 {
+		t.Error("favorites of other members should not be included")
+	}
+
+	if err := ann.UnfavoriteProject("p2"); err != nil {
+		t.Error(err)
+	}
+	if len(favoriteProjects(ann.GetProjects())) != 0 {
+		t.Error("unfavorited project should no longer be returned")
+	}
+}
+
+// linkRepo stores project links like the table does, one row per ordered pair
+type linkRepo struct {
+	favoriteRepo
+	links map[ProjectLink]bool
+}
+
+func (a *linkRepo) StoreProjectLink(x *ProjectLink) {
+	a.links[ProjectLink{WorkspaceID: x.WorkspaceID, ProjectID: x.ProjectID, RelatedProjectID: x.RelatedProjectID}] = true
+}
+
+func (a *linkRepo) DeleteProjectLink(workspaceID string, projectID string, relatedProjectID string) {
+	delete(a.links, ProjectLink{WorkspaceID: workspaceID, ProjectID: projectID, RelatedProjectID: relatedProjectID})
+}
+
+func (a *linkRepo) FindRelatedProjects(workspaceID string, projectID string) ([]*Project, error) {
+	x := []*Project{}
+	for l := range a.links {
+		if l.ProjectID == projectID {
+			x = append(x, &Project{ID: l.RelatedProjectID})
+		}
+		if l.RelatedProjectID == projectID {
+			x = append(x, &Project{ID: l.ProjectID})
+		}
+	}
+	return x, nil
+}
+
+func TestLinkProjects(t *testing.T) {
+	repo := &linkRepo{favoriteRepo: favoriteRepo{projects: []*Project{{ID: "p1"}, {ID: "p2"}}}, links: map[ProjectLink]bool{}}
+
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+	s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "ann"})
+
+	if s.LinkProjects("p1", "p1") == nil {
+		t.Error("self-link should be rejected")
+	}
+	if s.LinkProjects("p1", "unknown") == nil {
+		t.Error("link to an unknown project should be rejected")
+	}
+
+	if err := s.LinkProjects("p2", "p1"); err != nil {
+		t.Error(err)
+	}
+	if err := s.LinkProjects("p1", "p2"); err != nil {
+		t.Error(err)
+	}
+	if len(repo.links) != 1 {
+		t.Error("linking from either side should not duplicate the link", repo.links)
+	}
+
+	if pp := s.GetRelatedProjects("p1"); len(pp) != 1 || pp[0].ID != "p2" {
+		t.Error("related project should be visible from the first project", pp)
+	}
+	if pp := s.GetRelatedProjects("p2"); len(pp) != 1 || pp[0].ID != "p1" {
+		t.Error("related project should be visible from the second project", pp)
+	}
+
+	_ = s.UnlinkProjects("p2", "p1")
+	if len(s.GetRelatedProjects("p1")) != 0 {
+		t.Error("unlinked project should no longer be related")
+	}
+}
+
+// newFeatureRepo holds one project with one cell and the features created in it
+type newFeatureRepo struct {
+	favoriteRepo
+	features map[string]*Feature
+}
+
+func (a *newFeatureRepo) StoreProject(x *Project) {
+	a.projects[0] = x
+}
+
+func (a *newFeatureRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *newFeatureRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	if f, ok := a.features[id]; ok {
+		c := *f
+		return &c, nil
+	}
+	return nil, errNotFound
+}
+
+func (a *newFeatureRepo) FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, milestoneID string, subWorkflowID string) ([]*Feature, error) {
+	return []*Feature{}, nil
+}
+
+func (a *newFeatureRepo) StoreFeature(x *Feature) {
+	a.features[x.ID] = x
+}
+
+func (a *newFeatureRepo) StoreFeatureEvent(x *FeatureEvent) {}
+
+func (a *newFeatureRepo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	return []*ProjectStatus{}, nil
+}
+
+func TestDefaultAnnotations(t *testing.T) {
+	repo := &newFeatureRepo{favoriteRepo: favoriteRepo{projects: []*Project{{ID: "p1"}}}, features: map[string]*Feature{}}
+
+	s := memberService(repo, "EDITOR")
+
+	if _, err := s.UpdateDefaultAnnotationsOnProject("p1", "TRIAGE"); err == nil {
+		t.Error("default annotations should be valid annotations")
+	}
+
+	f, err := s.CreateFeatureWithID("f1", "sw1", "m1", "Login", nil)
+	if err != nil || f.Annotations != "" {
+		t.Error("features should start without annotations unless configured", f, err)
+	}
+
+	if _, err := s.UpdateDefaultAnnotationsOnProject("p1", "UNCLEAR,BLOCKED"); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = s.CreateFeatureWithID("f2", "sw1", "m1", "Logout", nil)
+	if err != nil || f.Annotations != "UNCLEAR,BLOCKED" || f.BlockedSince == nil {
+		t.Fatal("a new feature should get the default annotations", f, err)
+	}
+
+	f, err = s.UpdateAnnotationsOnFeature("f2", "")
+	if err != nil || f.Annotations != "" || repo.features["f2"].Annotations != "" {
+		t.Error("default annotations should be removable", f, err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// purgeRepo keeps accounts and their single-member workspaces in memory
+type purgeRepo struct {
+	Repository
+	accounts   map[string]*Account
+	workspaces map[string]bool
+	deleted    []string
+}
+
+func (a *purgeRepo) FindUnverifiedAccountsInactiveSince(t time.Time) ([]*Account, error) {
+	x := []*Account{}
+	for _, acc := range a.accounts {
+		if acc.CreatedAt.Before(t) && acc.LatestActivity.Before(t) {
+			x = append(x, acc)
+		}
+	}
+	return x, nil
+}
+
+func (a *purgeRepo) GetMembersByAccount(id string) ([]*Member, error) {
+	return []*Member{{WorkspaceID: "ws-" + id, AccountID: id, Level: "OWNER"}}, nil
+}
+
+func (a *purgeRepo) FindMembersByWorkspace(id string) ([]*Member, error) {
+	return []*Member{{WorkspaceID: id}}, nil
+}
+
+func (a *purgeRepo) FindProjectsByWorkspace(id string) ([]*Project, error) {
+	return []*Project{}, nil
+}
+
+func (a *purgeRepo) DeleteWorkspace(id string) {
+	delete(a.workspaces, id)
+}
+
+func (a *purgeRepo) DeleteAccount(id string) {
+	delete(a.accounts, id)
+	a.deleted = append(a.deleted, id)
+}
+
+func TestPurgeUnverifiedAccounts(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -60)
+
+	repo := &purgeRepo{
+		accounts: map[string]*Account{
+			"old":      {ID: "old", CreatedAt: old, LatestActivity: old},
+			"verified": {ID: "verified", CreatedAt: old, LatestActivity: old, EmailConfirmed: true},
+			"recent":   {ID: "recent", CreatedAt: now.AddDate(0, 0, -2), LatestActivity: now},
+		},
+		workspaces: map[string]bool{"ws-old": true, "ws-verified": true, "ws-recent": true},
+	}
+
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+
+	s.SetConfig(Configuration{PurgeUnverifiedAfterDays: 30, PurgeUnverifiedDryRun: true})
+	if n := s.PurgeUnverifiedAccounts(now); n != 1 || len(repo.deleted) != 0 {
+		t.Error("dry run should only count the old unverified account", n, repo.deleted)
+	}
+
+	s.SetConfig(Configuration{PurgeUnverifiedAfterDays: 30})
+	if n := s.PurgeUnverifiedAccounts(now); n != 1 || repo.accounts["old"] != nil || repo.workspaces["ws-old"] {
+		t.Error("old unverified account and its empty workspace should be purged", n)
+	}
+	if repo.accounts["verified"] == nil || repo.accounts["recent"] == nil || !repo.workspaces["ws-verified"] {
+		t.Error("verified and recent accounts should be retained")
+	}
+}
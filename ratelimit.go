@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RatePlan is the API throughput allowed to a workspace on a subscription tier
+type RatePlan struct {
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	Burst             int `json:"burst"`
+}
+
+func (p RatePlan) capacity() float64 {
+	if p.Burst > 0 {
+		return float64(p.Burst)
+	}
+	return float64(p.RequestsPerMinute)
+}
+
+// workspaceLimiter keeps a token bucket per workspace. The plan is passed on every request, so
+// a change of tier takes effect right away.
+type workspaceLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var limiter = newWorkspaceLimiter()
+
+func newWorkspaceLimiter() *workspaceLimiter {
+	return &workspaceLimiter{buckets: map[string]*bucket{}}
+}
+
+// allow takes a token from the bucket of the workspace, and tells if there was one
+func (l *workspaceLimiter) allow(workspaceID string, plan RatePlan, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c := plan.capacity()
+	b, ok := l.buckets[workspaceID]
+	if !ok {
+		b = &bucket{tokens: c, last: now}
+		l.buckets[workspaceID] = b
+	}
+
+	b.tokens += now.Sub(b.last).Minutes() * float64(plan.RequestsPerMinute)
+	if b.tokens > c {
+		b.tokens = c
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// ratePlan returns the plan of a subscription tier, if it is limited at all
+func ratePlan(c Configuration, tier string) (RatePlan, bool) {
+	p, ok := c.RatePlans[tier]
+	if !ok || p.RequestsPerMinute <= 0 {
+		return RatePlan{}, false
+	}
+	return p, true
+}
+
+// rateLimitExempt and trustedProxies are the parsed rateLimitExemptCidrs and trustedProxyCidrs,
+// set at startup
+var (
+	rateLimitExempt []*net.IPNet
+	trustedProxies  []*net.IPNet
+)
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	x := []*net.IPNet{}
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, err
+		}
+		x = append(x, n)
+	}
+	return x, nil
+}
+
+// inNets tells if addr, with or without a port, is in one of the ranges
+func inNets(nets []*net.IPNet, addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(strings.TrimSpace(host))
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr returns the address of the client of a request. The proxy headers are only believed
+// when the request comes from a trusted proxy, and X-Forwarded-For is read from the right, past
+// the trusted proxies that appended to it, since anything left of them is what the client sent.
+func clientAddr(trusted []*net.IPNet, r *http.Request) string {
+	if !inNets(trusted, r.RemoteAddr) {
+		return r.RemoteAddr
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if i == 0 || !inNets(trusted, hop) {
+				return hop
+			}
+		}
+	}
+	if xrip := strings.TrimSpace(r.Header.Get("X-Real-IP")); xrip != "" {
+		return xrip
+	}
+	return r.RemoteAddr
+}
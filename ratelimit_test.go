@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+func TestRatePlans(t *testing.T) {
+	c := Configuration{RatePlans: map[string]RatePlan{
+		"BASIC": {RequestsPerMinute: 60, Burst: 5},
+		"PRO":   {RequestsPerMinute: 600, Burst: 50},
+	}}
+	l := newWorkspaceLimiter()
+	now := time.Now()
+
+	allowed := func(workspaceID string, tier string) int {
+		plan, ok := ratePlan(c, tier)
+		if !ok {
+			return -1
+		}
+		n := 0
+		for i := 0; i < 100; i++ {
+			if l.allow(workspaceID, plan, now) {
+				n++
+			}
+		}
+		return n
+	}
+
+	if n := allowed("basic", "BASIC"); n != 5 {
+		t.Error("basic workspace should get its burst", n)
+	}
+	if n := allowed("pro", "PRO"); n != 50 {
+		t.Error("pro workspace should get a larger burst", n)
+	}
+	if n := allowed("trial", "TRIAL"); n != -1 {
+		t.Error("tiers without a plan should not be limited")
+	}
+
+	plan, _ := ratePlan(c, "BASIC")
+	if !l.allow("basic", plan, now.Add(time.Second)) || l.allow("basic", plan, now.Add(time.Second)) {
+		t.Error("the bucket should refill at the rate of the plan")
+	}
+
+	pro, _ := ratePlan(c, "PRO")
+	if !l.allow("basic", pro, now.Add(time.Minute)) {
+		t.Error("an upgraded workspace should get the new plan right away")
+	}
+}
+
+// okAPI answers a GET of / with 200
+func okAPI(r chi.Router) {
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func TestRateLimitExemptCIDRs(t *testing.T) {
+	limiter = newWorkspaceLimiter()
+	rateLimitExempt, _ = parseCIDRs([]string{"10.1.0.0/16"})
+	defer func() { rateLimitExempt = nil }()
+	proxies, _ := parseCIDRs([]string{"192.0.2.0/24"})
+
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{RatePlans: map[string]RatePlan{"BASIC": {RequestsPerMinute: 1, Burst: 1}}})
+	s.SetSubscriptionObject(&Subscription{WorkspaceID: "ws", Level: "BASIC"})
+
+	// httptest requests come from 192.0.2.1, the trusted proxy
+	serve := func(ip string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Forwarded-For", ip)
+		return serveWith(s, "/", okAPI, req, RealIP(proxies), RateLimit()).Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := serve("10.1.2.3"); code != 200 {
+			t.Error("an exempt address should not be throttled", code)
+		}
+	}
+	if serve("10.2.0.1") != 200 || serve("10.2.0.1") != 429 {
+		t.Error("an address outside the ranges should be throttled")
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	if _, err := parseCIDRs([]string{"10.0.0.0/8", "::1/128"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := parseCIDRs([]string{"10.0.0.1"}); err == nil {
+		t.Error("an address without a prefix length should be rejected")
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	proxies, _ := parseCIDRs([]string{"10.0.0.0/8"})
+
+	addr := func(remote string, xff string, xrip string) string {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remote
+		if xff != "" {
+			r.Header.Set("X-Forwarded-For", xff)
+		}
+		if xrip != "" {
+			r.Header.Set("X-Real-IP", xrip)
+		}
+		return clientAddr(proxies, r)
+	}
+
+	if x := addr("203.0.113.9:5000", "10.1.2.3", "10.1.2.3"); x != "203.0.113.9:5000" {
+		t.Error("the headers of a client that is not a trusted proxy should be ignored", x)
+	}
+	if x := addr("10.0.0.2:5000", "198.51.100.7", ""); x != "198.51.100.7" {
+		t.Error("the client behind a trusted proxy should be used", x)
+	}
+	if x := addr("10.0.0.2:5000", "10.1.2.3, 198.51.100.7, 10.0.0.3", ""); x != "198.51.100.7" {
+		t.Error("a forwarded address the client made up should be skipped", x)
+	}
+	if x := addr("10.0.0.2:5000", "10.0.0.4, 10.0.0.3", ""); x != "10.0.0.4" {
+		t.Error("the first hop should be used when every hop is a proxy", x)
+	}
+	if x := addr("10.0.0.2:5000", "", "198.51.100.7"); x != "198.51.100.7" {
+		t.Error("X-Real-IP should be used without X-Forwarded-For", x)
+	}
+	if x := addr("10.0.0.2:5000", "", ""); x != "10.0.0.2:5000" {
+		t.Error("a proxy without headers should be the client", x)
+	}
+}
+
+func TestSpoofedAddressIsRateLimited(t *testing.T) {
+	limiter = newWorkspaceLimiter()
+	rateLimitExempt, _ = parseCIDRs([]string{"10.1.0.0/16"})
+	defer func() { rateLimitExempt = nil }()
+
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{RatePlans: map[string]RatePlan{"BASIC": {RequestsPerMinute: 1, Burst: 1}}})
+	s.SetSubscriptionObject(&Subscription{WorkspaceID: "ws", Level: "BASIC"})
+
+	codes := []int{}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Forwarded-For", "10.1.2.3")
+		codes = append(codes, serveWith(s, "/", okAPI, req, RealIP(nil), RateLimit()).Code)
+	}
+	if codes[1] != 429 {
+		t.Error("a client should not get exempt by sending an exempt address", codes)
+	}
+}
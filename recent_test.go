@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+)
+
+// recentRepo answers like recentChangesQuery: changes of the workspace after since, newest first
+type recentRepo struct {
+	Repository
+	changes map[string][]*RecentChange
+}
+
+func (a *recentRepo) FindRecentChanges(workspaceID string, since time.Time, limit int) ([]*RecentChange, error) {
+	x := []*RecentChange{}
+	for _, c := range a.changes[workspaceID] {
+		if c.LastModified.After(since) {
+			x = append(x, c)
+		}
+	}
+	sort.Slice(x, func(i, j int) bool { return x[i].LastModified.After(x[j].LastModified) })
+	if len(x) > limit {
+		x = x[:limit]
+	}
+	return x, nil
+}
+
+func TestRecentChanges(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+	repo := &recentRepo{changes: map[string][]*RecentChange{
+		"ws": {
+			{Type: "project", ID: "p1", ProjectID: "p1", LastModified: ago(30)},
+			{Type: "feature", ID: "f1", ProjectID: "p1", LastModified: ago(2)},
+			{Type: "milestone", ID: "m1", ProjectID: "p1", LastModified: ago(24)},
+			{Type: "subworkflow", ID: "s1", ProjectID: "p1", LastModified: ago(5)},
+		},
+		"other": {{Type: "feature", ID: "f-other", ProjectID: "p-other", LastModified: ago(1)}},
+	}}
+
+	serve := func(query string) (int, []*RecentChange) {
+		s := memberService(repo, "VIEWER")
+		w := serveWith(s, "/v1/", workspaceAPI, httptest.NewRequest("GET", "/v1/recent?"+query, nil))
+		x := []*RecentChange{}
+		_ = json.Unmarshal(w.Body.Bytes(), &x)
+		return w.Code, x
+	}
+
+	ids := func(x []*RecentChange) string {
+		s := ""
+		for _, c := range x {
+			s += c.ID + " "
+		}
+		return s
+	}
+
+	code, x := serve("since=" + ago(24).Format(time.RFC3339))
+	if code != 200 || ids(x) != "f1 s1 " {
+		t.Error("only changes after the cutoff should appear, newest first", code, ids(x))
+	}
+	if x[0].Type != "feature" || x[0].ProjectID != "p1" {
+		t.Error("a change should have its type and project", x[0])
+	}
+
+	if code, x := serve("since=" + ago(48).Format(time.RFC3339) + "&limit=2"); code != 200 || ids(x) != "f1 s1 " {
+		t.Error("the newest changes should be returned up to the limit", code, ids(x))
+	}
+
+	for _, query := range []string{"", "since=yesterday", "since=" + ago(1).Format(time.RFC3339) + "&limit=0", "since=" + ago(1).Format(time.RFC3339) + "&limit=201"} {
+		if code, _ := serve(query); code != 400 {
+			t.Error("the query should be rejected", query, code)
+		}
+	}
+}
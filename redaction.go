@@ -0,0 +1,122 @@
+package main
+
+import "strings"
+
+// Fields a workspace can hide from viewers and on shared links
+var redactableFields = []string{"comments", "descriptions", "estimates"}
+
+// redactsFor tells if the redactions of the workspace apply to a reader at level. They apply to
+// everyone who cannot edit, including shared link readers, who have no level.
+func redactsFor(level string) bool {
+	return !isEditor(level)
+}
+
+func redactionsAreValid(redactions string) bool {
+	if redactions == "" {
+		return true
+	}
+	for _, x := range strings.Split(redactions, ",") {
+		if !stringInSlice(x, redactableFields) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringInSlice(a string, list []string) bool {
+	for _, b := range list {
+		if b == a {
+			return true
+		}
+	}
+	return false
+}
+
+func redactFeatures(ff []*Feature, redactions []string) {
+	for _, f := range ff {
+		if stringInSlice("descriptions", redactions) {
+			f.Description = ""
+		}
+		if stringInSlice("estimates", redactions) {
+			f.Estimate = 0
+		}
+	}
+}
+
+// redactProjectResponse removes the fields the workspace hides from lower-privilege readers
+func redactProjectResponse(x *projectResponse, redactions string) {
+	if redactions == "" {
+		return
+	}
+	rr := strings.Split(redactions, ",")
+
+	if stringInSlice("comments", rr) {
+		x.FeatureComments = []*FeatureComment{}
+	}
+	if stringInSlice("descriptions", rr) {
+		if x.Project != nil {
+			x.Project.Description = ""
+		}
+		for _, m := range x.Milestones {
+			m.Description = ""
+		}
+		for _, w := range x.Workflows {
+			w.Description = ""
+		}
+		for _, sw := range x.SubWorkflows {
+			sw.Description = ""
+		}
+	}
+	redactFeatures(x.Features, rr)
+}
+
+func redactMilestoneTree(x *milestoneTreeResponse, redactions string) {
+	if redactions == "" {
+		return
+	}
+	rr := strings.Split(redactions, ",")
+
+	if stringInSlice("comments", rr) {
+		x.FeatureComments = []*FeatureComment{}
+	}
+	if stringInSlice("descriptions", rr) {
+		x.Milestone.Description = ""
+	}
+	redactFeatures(x.Features, rr)
+}
+
+func redactFeatureContext(x *featureContextResponse, redactions string) {
+	if redactions == "" {
+		return
+	}
+	rr := strings.Split(redactions, ",")
+
+	if stringInSlice("comments", rr) {
+		x.CommentCount = 0
+	}
+	if stringInSlice("descriptions", rr) {
+		x.Milestone.Description = ""
+		x.SubWorkflow.Description = ""
+		x.Workflow.Description = ""
+		x.Project.Description = ""
+	}
+	redactFeatures([]*Feature{x.Feature}, rr)
+}
+
+func redactRollup(x *projectRollup, redactions string) {
+	if x == nil || !stringInSlice("estimates", strings.Split(redactions, ",")) {
+		return
+	}
+	for _, r := range append(x.Milestones, x.SubWorkflows...) {
+		r.Estimate = 0
+	}
+}
+
+func redactBurndown(pp []*burndownPoint, redactions string) {
+	if !stringInSlice("estimates", strings.Split(redactions, ",")) {
+		return
+	}
+	for _, p := range pp {
+		p.Remaining = 0
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// redactionRepo holds a project with feature f1, estimated at 3, in milestone m1
+type redactionRepo struct {
+	rollupRepo
+}
+
+func (a *redactionRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1", CreatedAt: time.Now().UTC().AddDate(0, 0, -2)}, nil
+}
+
+func (a *redactionRepo) FindFeatureEventsByMilestone(workspaceID string, milestoneID string) ([]*FeatureEvent, error) {
+	return []*FeatureEvent{{FeatureID: "f1", MilestoneID: milestoneID, Status: "OPEN", Estimate: a.estimate, CreatedAt: time.Now().UTC().AddDate(0, 0, -1)}}, nil
+}
+
+func TestAggregatesAreRedacted(t *testing.T) {
+	repo := &redactionRepo{rollupRepo{estimate: 3}}
+
+	serve := func(level string, path string) string {
+		s := memberService(repo, level)
+		s.SetConfig(Configuration{AggregateCacheTTLSeconds: -1})
+		s.SetWorkspaceObject(&Workspace{ID: "ws", ViewerRedactions: "estimates"})
+		w := serveWith(s, "/v1/", workspaceAPI, httptest.NewRequest("GET", path, nil))
+		if w.Code != 200 {
+			t.Fatal(path, w.Code)
+		}
+		return w.Body.String()
+	}
+
+	for _, c := range []struct {
+		path     string
+		estimate string
+	}{
+		{"/v1/projects/p1/rollup", `"estimate":3`},
+		{"/v1/milestones/m1/burndown", `"remaining":3`},
+	} {
+		if x := serve("ADMIN", c.path); !strings.Contains(x, c.estimate) {
+			t.Error("an admin should see the estimates", c.path, x)
+		}
+		for _, level := range []string{"VIEWER", "COMMENTER"} {
+			if x := serve(level, c.path); strings.Contains(x, c.estimate) {
+				t.Error("a reader who cannot edit should not see the estimates", level, c.path, x)
+			}
+		}
+	}
+}
+
+func TestRedactsFor(t *testing.T) {
+	for level, redacts := range map[string]bool{"": true, "VIEWER": true, "COMMENTER": true, "EDITOR": false, "ADMIN": false, "OWNER": false} {
+		if redactsFor(level) != redacts {
+			t.Error("wrong redaction for", level)
+		}
+	}
+}
+
+// describedRepo is archiveRepo whose project, milestone and feature have descriptions, and
+// whose feature has a comment. The project is shared at link "l1".
+type describedRepo struct {
+	archiveRepo
+}
+
+func (a *describedRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	return &Project{WorkspaceID: "ws", ID: "p1", Title: "Roadmap", Description: "secret plans", ExternalLink: "l1"}, nil
+}
+
+func (a *describedRepo) GetProjectByExternalLink(link string) (*Project, error) {
+	return a.GetProject("ws", "p1")
+}
+
+func (a *describedRepo) StoreProject(x *Project) {}
+
+func (a *describedRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, AllowExternalSharing: true, ViewerRedactions: "comments,descriptions"}, nil
+}
+
+func (a *describedRepo) FindSubscriptionsByWorkspace(workspaceID string) ([]*Subscription, error) {
+	return []*Subscription{{WorkspaceID: workspaceID, Level: "PRO", Status: "active"}}, nil
+}
+
+func (a *describedRepo) FindMilestonesByProject(workspaceID string, projectID string) ([]*Milestone, error) {
+	return []*Milestone{{WorkspaceID: workspaceID, ProjectID: projectID, ID: "m1", Title: "MVP", Description: "secret milestone"}}, nil
+}
+
+func (a *describedRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	return []*Feature{{WorkspaceID: workspaceID, ID: "f1", MilestoneID: "m1", Title: "Login", Description: "secret feature"}}, nil
+}
+
+func (a *describedRepo) FindFeatureCommentsByProject(workspaceID string, projectID string) ([]*FeatureComment, error) {
+	return []*FeatureComment{{WorkspaceID: workspaceID, ID: "c1", FeatureID: "f1", ProjectID: projectID, Post: "secret comment"}}, nil
+}
+
+func (a *describedRepo) FindFavoriteProjectIDsByMember(workspaceID string, memberID string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (a *describedRepo) FindGoalMilestonesByProject(workspaceID string, projectID string) ([]*GoalMilestone, error) {
+	return []*GoalMilestone{}, nil
+}
+
+func (a *describedRepo) FindWatchedFeatureIDsByProject(workspaceID string, projectID string, memberID string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (a *describedRepo) FindFeatureReferencesByProject(workspaceID string, projectID string) ([]*FeatureReference, error) {
+	return []*FeatureReference{}, nil
+}
+
+func (a *describedRepo) FindTimeEntriesByProject(workspaceID string, projectID string) ([]*TimeEntry, error) {
+	return []*TimeEntry{}, nil
+}
+
+func (a *describedRepo) FindFeatureCommentOwnersByProject(workspaceID string, projectID string) ([]*FeatureCommentOwner, error) {
+	return []*FeatureCommentOwner{}, nil
+}
+
+func (a *describedRepo) FindRelatedProjects(workspaceID string, projectID string) ([]*Project, error) {
+	return []*Project{}, nil
+}
+
+func (a *describedRepo) FindGoalsByProject(workspaceID string, projectID string) ([]*Goal, error) {
+	return []*Goal{}, nil
+}
+
+func TestProjectIsRedacted(t *testing.T) {
+	repo := &describedRepo{}
+	secrets := []string{"secret plans", "secret milestone", "secret feature", "secret comment"}
+
+	project := func(level string) string {
+		s := memberService(repo, level)
+		s.SetWorkspaceObject(&Workspace{ID: "ws", ViewerRedactions: "comments,descriptions"})
+		w := serveWith(s, "/v1/", workspaceAPI, httptest.NewRequest("GET", "/v1/projects/p1", nil))
+		if w.Code != 200 {
+			t.Fatal(level, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+
+	x := project("ADMIN")
+	for _, secret := range secrets {
+		if !strings.Contains(x, secret) {
+			t.Error("an admin should see the comments and descriptions", secret, x)
+		}
+	}
+	x = project("VIEWER")
+	for _, secret := range secrets {
+		if strings.Contains(x, secret) {
+			t.Error("a viewer should not see the comments and descriptions", secret, x)
+		}
+	}
+	if !strings.Contains(x, "Roadmap") || !strings.Contains(x, "Login") {
+		t.Error("a viewer should still see the project", x)
+	}
+
+	// Anyone with the share link reads it as a viewer
+	link := NewFeatmapService()
+	link.SetRepoObject(repo)
+	w := serveWith(link, "/v1/link", linkAPI, httptest.NewRequest("GET", "/v1/link/l1", nil))
+	if w.Code != 200 {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	for _, secret := range secrets {
+		if strings.Contains(w.Body.String(), secret) {
+			t.Error("a share link should not show the comments and descriptions", secret, w.Body.String())
+		}
+	}
+}
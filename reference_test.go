@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// referenceRepo holds the references of feature "f1" in memory
+type referenceRepo struct {
+	Repository
+	references []*FeatureReference
+}
+
+func (a *referenceRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	if id != "f1" {
+		return nil, errNotFound
+	}
+	return &Feature{WorkspaceID: workspaceID, ID: id, MilestoneID: "m1"}, nil
+}
+
+func (a *referenceRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *referenceRepo) StoreFeatureReference(x *FeatureReference) {
+	a.references = append(a.references, x)
+}
+
+func (a *referenceRepo) DeleteFeatureReference(workspaceID string, featureID string, id string) {
+	x := []*FeatureReference{}
+	for _, r := range a.references {
+		if !(r.FeatureID == featureID && r.ID == id) {
+			x = append(x, r)
+		}
+	}
+	a.references = x
+}
+
+func (a *referenceRepo) FindFeatureReferencesByFeature(workspaceID string, featureID string) ([]*FeatureReference, error) {
+	x := []*FeatureReference{}
+	for _, r := range a.references {
+		if r.FeatureID == featureID {
+			x = append(x, r)
+		}
+	}
+	return x, nil
+}
+
+func TestReferenceURLIsValid(t *testing.T) {
+	for x, valid := range map[string]bool{
+		"https://example.com/spec":          true,
+		"http://docs.example.com/a?b=c#d":   true,
+		"https://www.figma.com/file/abc123": true,
+		"example.com/spec":                  false,
+		"ftp://example.com/file":            false,
+		"javascript:alert(1)":               false,
+		"https://":                          false,
+		"":                                  false,
+	} {
+		if referenceURLIsValid(x) != valid {
+			t.Error("unexpected validation of", x)
+		}
+	}
+}
+
+func TestFeatureReferences(t *testing.T) {
+	repo := &referenceRepo{}
+	s := memberService(repo, "EDITOR")
+	s.SetConfig(Configuration{MaxReferencesPerFeature: 3})
+
+	x, err := s.AddFeatureReference("f1", "r1", " Spec ", "https://example.com/spec")
+	if err != nil || x.Label != "Spec" || x.ProjectID != "p1" || x.CreatedByName != "ann" || len(repo.references) != 1 {
+		t.Error("reference should be added", x, err)
+	}
+	if _, err := s.AddFeatureReference("f1", "r2", "Spec", "not a url"); err == nil {
+		t.Error("invalid url should be rejected")
+	}
+	if _, err := s.AddFeatureReference("f2", "r2", "Spec", "https://example.com/spec"); err == nil {
+		t.Error("missing feature should be rejected")
+	}
+
+	for i := 2; i <= 3; i++ {
+		if _, err := s.AddFeatureReference("f1", fmt.Sprint("r", i), "", "https://example.com/design"); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := s.AddFeatureReference("f1", "r4", "", "https://example.com/docs"); err == nil || len(repo.references) != 3 {
+		t.Error("references should be capped per feature")
+	}
+
+	if err := s.DeleteFeatureReference("f1", "r1"); err != nil || len(repo.references) != 2 {
+		t.Error("reference should be removed", err)
+	}
+	if _, err := s.AddFeatureReference("f1", "r4", "", "https://example.com/docs"); err != nil {
+		t.Error("removing a reference should free room for another", err)
+	}
+
+	if (&service{}).maxReferences() != defaultMaxReferences {
+		t.Error("cap should default without configuration")
+	}
+}
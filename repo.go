@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"log"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
@@ -12,27 +15,39 @@ type Repository interface {
 	DB() *sqlx.DB
 
 	SetTx(tx *sqlx.Tx)
+	Begin(ctx context.Context, opts *sql.TxOptions) error
+	Commit() error
+	Rollback() error
+	LogSlowQueries(threshold time.Duration, requestID string)
+	Savepoint(name string)
+	RollbackToSavepoint(name string)
 
 	StoreWorkspace(x *Workspace)
 	GetWorkspace(workspaceID string) (*Workspace, error)
 	GetWorkspacesByAccount(id string) ([]*Workspace, error)
+	FindWorkspacesByAutoJoinDomain(domain string) ([]*Workspace, error)
 	GetWorkspaceByName(name string) (*Workspace, error)
 	DeleteWorkspace(workspaceID string)
 
 	GetAccount(id string) (*Account, error)
 	GetAccountByEmail(email string) (*Account, error)
+	CountAccounts() (int, error)
 	GetAccountByConfirmationKey(key string) (*Account, error)
 	GetAccountByPasswordKey(key string) (*Account, error)
 	FindAccountsByWorkspace(id string) ([]*Account, error)
 	StoreAccount(x *Account)
 	DeleteAccount(accountID string)
+	FindUnverifiedAccountsInactiveSince(t time.Time) ([]*Account, error)
 
 	StoreMember(x *Member)
 	GetMember(workspaceID string, id string) (*Member, error)
+	GetMemberWithAccount(workspaceID string, id string) (*Member, error)
 	GetMemberByAccountAndWorkspace(accountID string, workspaceID string) (*Member, error)
 	GetMembersByAccount(id string) ([]*Member, error)
+	FindMembershipsByAccount(id string) ([]*Membership, error)
 	GetMemberByEmail(workspaceID string, email string) (*Member, error)
 	FindMembersByWorkspace(id string) ([]*Member, error)
+	FindSeatMembersInactiveSince(workspaceID string, t time.Time) ([]*Member, error)
 	DeleteMember(wsid string, id string)
 
 	StoreSubscription(z *Subscription)
@@ -42,6 +57,7 @@ type Repository interface {
 
 	StoreInvite(x *Invite)
 	DeleteInvite(wsid string, id string)
+	RenewInvite(wsid string, id string, t time.Time)
 	GetInviteByCode(code string) (*Invite, error)
 	GetInviteByEmail(wsid string, email string) (*Invite, error)
 	GetInvite(workspaceID string, id string) (*Invite, error)
@@ -50,6 +66,11 @@ type Repository interface {
 	GetProjectByExternalLink(link string) (*Project, error)
 	GetProject(workspaceID string, projectID string) (*Project, error)
 	FindProjectsByWorkspace(workspaceID string) ([]*Project, error)
+	FindProjectsWithAutoClose() ([]*Project, error)
+	FindProjectsWithExternalLinkIdleSince(t time.Time) ([]*Project, error)
+	FindRecentChanges(workspaceID string, since time.Time, limit int) ([]*RecentChange, error)
+	GetBreadcrumb(workspaceID string, kind string, id string) (*Breadcrumb, error)
+	CountAnnotations(workspaceID string) ([]*AnnotationCount, error)
 	StoreProject(x *Project)
 	DeleteProject(workspaceID string, projectID string)
 
@@ -71,12 +92,17 @@ type Repository interface {
 
 	GetFeature(workspaceID string, featureID string) (*Feature, error)
 	FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error)
+	FindFeaturesBlockedBefore(t time.Time) ([]*Feature, error)
 	FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, mid string, swid string) ([]*Feature, error)
+	FindFeaturesByMilestone(workspaceID string, milestoneID string) ([]*Feature, error)
 	StoreFeature(x *Feature)
 	DeleteFeature(workspaceID string, workflowID string)
 
 	GetFeatureComment(workspaceID string, ID string) (*FeatureComment, error)
 	FindFeatureCommentsByProject(workspaceID string, projectID string) ([]*FeatureComment, error)
+	FindFeatureCommentsByMilestone(workspaceID string, milestoneID string) ([]*FeatureComment, error)
+	CountFeatureCommentsByFeature(workspaceID string, featureID string) (int, error)
+	FindFeatureCommentsByFeature(workspaceID string, featureID string) ([]*FeatureComment, error)
 	StoreFeatureComment(x *FeatureComment)
 	DeleteFeatureComment(workspaceID string, commentID string)
 
@@ -85,6 +111,60 @@ type Repository interface {
 	StoreFeatureCommentOwner(x *FeatureCommentOwner)
 	GetFeatureCommentOwnerByFeatureComment(workspaceID string, ID string) (*FeatureCommentOwner, error)
 
+	StoreFeatureEvent(x *FeatureEvent)
+	FindFeatureEventsByMilestone(workspaceID string, milestoneID string) ([]*FeatureEvent, error)
+	FindFeatureEventsByFeature(workspaceID string, featureID string, offset int, limit int) ([]*FeatureEvent, error)
+
+	StoreProjectFavorite(x *ProjectFavorite)
+	DeleteProjectFavorite(workspaceID string, projectID string, memberID string)
+	FindFavoriteProjectIDsByMember(workspaceID string, memberID string) ([]string, error)
+
+	StoreProjectLink(x *ProjectLink)
+	DeleteProjectLink(workspaceID string, projectID string, relatedProjectID string)
+	FindRelatedProjects(workspaceID string, projectID string) ([]*Project, error)
+
+	StoreNotificationEmail(x *NotificationEmail)
+	CountNotificationEmailsSince(accountID string, t time.Time) (int, error)
+	FindDigestNotificationEmailsBefore(t time.Time) ([]*NotificationEmail, error)
+	DeleteNotificationEmailsBefore(t time.Time)
+	GetLatestNotificationEmailByBatchKey(accountID string, key string) (*NotificationEmail, error)
+	FindHeldNotificationEmailsBefore(t time.Time) ([]*NotificationEmail, error)
+	DeleteHeldNotificationEmailsBefore(t time.Time)
+
+	GetGoal(workspaceID string, id string) (*Goal, error)
+	FindGoalsByProject(workspaceID string, projectID string) ([]*Goal, error)
+	StoreGoal(x *Goal)
+	DeleteGoal(workspaceID string, id string)
+	StoreGoalMilestone(x *GoalMilestone)
+	DeleteGoalMilestone(workspaceID string, goalID string, milestoneID string)
+	FindGoalMilestonesByProject(workspaceID string, projectID string) ([]*GoalMilestone, error)
+
+	StoreFeatureWatcher(x *FeatureWatcher)
+	DeleteFeatureWatcher(workspaceID string, featureID string, memberID string)
+	FindFeatureWatchersByFeature(workspaceID string, featureID string) ([]*Member, error)
+	FindWatchedFeatureIDsByProject(workspaceID string, projectID string, memberID string) ([]string, error)
+
+	GetAccessRequest(workspaceID string, id string) (*AccessRequest, error)
+	GetPendingAccessRequestByMember(workspaceID string, memberID string) (*AccessRequest, error)
+	FindPendingAccessRequestsByProject(workspaceID string, projectID string) ([]*AccessRequest, error)
+	StoreAccessRequest(x *AccessRequest)
+
+	StoreFeatureReference(x *FeatureReference)
+	DeleteFeatureReference(workspaceID string, featureID string, id string)
+	FindFeatureReferencesByFeature(workspaceID string, featureID string) ([]*FeatureReference, error)
+	FindFeatureReferencesByProject(workspaceID string, projectID string) ([]*FeatureReference, error)
+	GetTimeEntry(workspaceID string, featureID string, id string) (*TimeEntry, error)
+	StoreTimeEntry(x *TimeEntry)
+	DeleteTimeEntry(workspaceID string, featureID string, id string)
+	FindTimeEntriesByFeature(workspaceID string, featureID string) ([]*TimeEntry, error)
+	FindTimeEntriesByProject(workspaceID string, projectID string) ([]*TimeEntry, error)
+
+	GetProjectStatus(workspaceID string, ID string) (*ProjectStatus, error)
+	FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error)
+	StoreProjectStatus(x *ProjectStatus)
+	DeleteProjectStatus(workspaceID string, id string, replacementID string)
+	SyncFeatureStatuses(workspaceID string, statusID string, status string)
+
 	GetPersona(workspaceID string, ID string) (*Persona, error)
 	FindPersonasByProject(workspaceID string, projectID string) ([]*Persona, error)
 	StorePersona(x *Persona)
@@ -94,11 +174,30 @@ type Repository interface {
 	FindWorkflowPersonasByProject(workspaceID string, projectID string) ([]*WorkflowPersona, error)
 	StoreWorkflowPersona(x *WorkflowPersona)
 	DeleteWorkflowPersona(workspaceID string, id string)
+
+	FindAllWorkspaces() ([]*Workspace, error)
+	FindAllAccounts() ([]*Account, error)
+	GetInstanceStats(activeSince time.Time) (*InstanceStats, error)
+	StoreAdminAction(x *AdminAction)
+	FindAdminActionsByAccount(accountID string) ([]*AdminAction, error)
+
+	FindFeatureCommentsByMember(workspaceID string, memberID string) ([]*FeatureComment, error)
+	FindFeatureWatchersByMember(workspaceID string, memberID string) ([]*FeatureWatcher, error)
+	FindNotificationEmailsByAccount(accountID string) ([]*NotificationEmail, error)
+
+	ReassignMember(workspaceID string, memberID string, accountID string)
+	MergeMember(workspaceID string, fromID string, intoID string)
+	ReassignAccount(fromID string, intoID string)
+
+	GetProjectArchive(workspaceID string, id string) (*ProjectArchive, error)
+	FindProjectArchivesByWorkspace(workspaceID string) ([]*ProjectArchive, error)
+	StoreProjectArchive(x *ProjectArchive)
+	DeleteProjectArchivesBefore(t time.Time)
 }
 
 type repo struct {
 	db *sqlx.DB
-	tx *sqlx.Tx
+	tx *slowQueryTx
 }
 
 type txnFunc func(*sqlx.Tx) error
@@ -133,7 +232,72 @@ func (a *repo) DB() *sqlx.DB {
 }
 
 func (a *repo) SetTx(tx *sqlx.Tx) {
-	a.tx = tx
+	a.tx = &slowQueryTx{Tx: tx}
+}
+
+// Begin starts a transaction of the repository, which must not have one open
+func (a *repo) Begin(ctx context.Context, opts *sql.TxOptions) error {
+	tx, err := a.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if a.tx == nil {
+		a.tx = &slowQueryTx{}
+	}
+	a.tx.Tx, a.tx.failure = tx, nil
+	return nil
+}
+
+// Commit commits the transaction of the repository, if one is open. After a serialization
+// failure the transaction is aborted, and Commit returns the failure rather than the error of
+// committing, so the caller knows to retry.
+func (a *repo) Commit() error {
+	if a.tx == nil || a.tx.Tx == nil {
+		return nil
+	}
+	tx, failure := a.tx.Tx, a.tx.failure
+	a.tx.Tx, a.tx.failure = nil, nil
+	if err := tx.Commit(); failure == nil {
+		return err
+	}
+	return failure
+}
+
+// Rollback rolls back the transaction of the repository, if one is open
+func (a *repo) Rollback() error {
+	if a.tx == nil || a.tx.Tx == nil {
+		return nil
+	}
+	tx := a.tx.Tx
+	a.tx.Tx, a.tx.failure = nil, nil
+	return tx.Rollback()
+}
+
+// repoDo runs f and commits the transaction the repository has open at the end, which may not be
+// the one it had at the start
+func repoDo(repo Repository, f func()) error {
+	defer func() {
+		if p := recover(); p != nil {
+			_ = repo.Rollback()
+			panic(p)
+		}
+	}()
+
+	f()
+	return repo.Commit()
+}
+
+func (a *repo) LogSlowQueries(threshold time.Duration, requestID string) {
+	a.tx.threshold = threshold
+	a.tx.requestID = requestID
+}
+
+func (a *repo) Savepoint(name string) {
+	a.tx.MustExec("SAVEPOINT " + name)
+}
+
+func (a *repo) RollbackToSavepoint(name string) {
+	a.tx.MustExec("ROLLBACK TO SAVEPOINT " + name)
 }
 
 // Workspaces
@@ -162,10 +326,18 @@ func (a *repo) GetWorkspacesByAccount(id string) ([]*Workspace, error) {
 	return workspaces, nil
 }
 
-const saveWorkspaceQuery = "INSERT INTO workspaces (id, name, created_at, allow_external_sharing, external_customer_id, eu_vat, external_billing_email) VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT (id) DO UPDATE SET allow_external_sharing = $4, external_customer_id = $5, eu_vat = $6, external_billing_email = $7"
+func (a *repo) FindWorkspacesByAutoJoinDomain(domain string) ([]*Workspace, error) {
+	var workspaces []*Workspace
+	if err := a.tx.Select(&workspaces, "SELECT * FROM workspaces w WHERE w.auto_join_domains <> '' AND ',' || w.auto_join_domains || ',' LIKE '%,' || $1 || ',%'", domain); err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+const saveWorkspaceQuery = "INSERT INTO workspaces (id, name, created_at, allow_external_sharing, external_customer_id, eu_vat, external_billing_email, viewer_redactions, auto_join_domains, auto_join_level, suspended, min_estimate, max_estimate, archive_deleted_projects, notification_batch_windows, timezone) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16) ON CONFLICT (id) DO UPDATE SET allow_external_sharing = $4, external_customer_id = $5, eu_vat = $6, external_billing_email = $7, viewer_redactions = $8, auto_join_domains = $9, auto_join_level = $10, suspended = $11, min_estimate = $12, max_estimate = $13, archive_deleted_projects = $14, notification_batch_windows = $15, timezone = $16"
 
 func (a *repo) StoreWorkspace(x *Workspace) {
-	a.tx.MustExec(saveWorkspaceQuery, x.ID, x.Name, x.CreatedAt, x.AllowExternalSharing, x.ExternalCustomerID, x.EUVAT, x.ExternalBillingEmail)
+	a.tx.MustExec(saveWorkspaceQuery, x.ID, x.Name, x.CreatedAt, x.AllowExternalSharing, x.ExternalCustomerID, x.EUVAT, x.ExternalBillingEmail, x.ViewerRedactions, x.AutoJoinDomains, x.AutoJoinLevel, x.Suspended, x.MinEstimate, x.MaxEstimate, x.ArchiveDeletedProjects, x.NotificationBatchWindows, x.Timezone)
 }
 
 func (a *repo) DeleteWorkspace(workspaceID string) {
@@ -191,6 +363,14 @@ func (a *repo) GetAccountByEmail(email string) (*Account, error) {
 	return acc, nil
 }
 
+func (a *repo) CountAccounts() (int, error) {
+	var n int
+	if err := a.tx.Get(&n, "SELECT count(*) FROM accounts"); err != nil {
+		return 0, errors.Wrap(err, "not found")
+	}
+	return n, nil
+}
+
 func (a *repo) GetAccountByConfirmationKey(key string) (*Account, error) {
 	acc := &Account{}
 	if err := a.tx.Get(acc, "SELECT * FROM accounts WHERE email_confirmation_key = $1", key); err != nil {
@@ -207,10 +387,10 @@ func (a *repo) GetAccountByPasswordKey(key string) (*Account, error) {
 	return acc, nil
 }
 
-const saveAccountQuery = "INSERT INTO accounts (id, email, password, created_at, email_confirmation_sent_to, email_confirmed, email_confirmation_key,email_confirmation_pending, password_reset_key, name) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) ON CONFLICT (id) DO UPDATE SET email = $2, password = $3, email_confirmation_sent_to = $5, email_confirmed = $6,email_confirmation_key = $7,email_confirmation_pending = $8, password_reset_key=$9, name=$10, latest_activity=$11"
+const saveAccountQuery = "INSERT INTO accounts (id, email, password, created_at, email_confirmation_sent_to, email_confirmed, email_confirmation_key,email_confirmation_pending, password_reset_key, name, locale, default_allow_external_sharing, default_viewer_redactions, default_auto_join_level, default_min_estimate, default_max_estimate) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$12,$13,$14,$15,$16,$17) ON CONFLICT (id) DO UPDATE SET email = $2, password = $3, email_confirmation_sent_to = $5, email_confirmed = $6,email_confirmation_key = $7,email_confirmation_pending = $8, password_reset_key=$9, name=$10, latest_activity=$11, locale=$12, default_allow_external_sharing=$13, default_viewer_redactions=$14, default_auto_join_level=$15, default_min_estimate=$16, default_max_estimate=$17"
 
 func (a *repo) StoreAccount(x *Account) {
-	a.tx.MustExec(saveAccountQuery, x.ID, x.Email, x.Password, x.CreatedAt, x.EmailConfirmationSentTo, x.EmailConfirmed, x.EmailConfirmationKey, x.EmailConfirmationPending, x.PasswordResetKey, x.Name, x.LatestActivity)
+	a.tx.MustExec(saveAccountQuery, x.ID, x.Email, x.Password, x.CreatedAt, x.EmailConfirmationSentTo, x.EmailConfirmed, x.EmailConfirmationKey, x.EmailConfirmationPending, x.PasswordResetKey, x.Name, x.LatestActivity, x.Locale, x.DefaultAllowExternalSharing, x.DefaultViewerRedactions, x.DefaultAutoJoinLevel, x.DefaultMinEstimate, x.DefaultMaxEstimate)
 
 }
 
@@ -226,12 +406,20 @@ func (a *repo) DeleteAccount(accountID string) {
 	a.tx.MustExec("DELETE FROM accounts WHERE id=$1", accountID)
 }
 
+func (a *repo) FindUnverifiedAccountsInactiveSince(t time.Time) ([]*Account, error) {
+	accounts := []*Account{}
+	if err := a.tx.Select(&accounts, "SELECT * FROM accounts WHERE NOT email_confirmed AND created_at < $1 AND latest_activity < $1", t); err != nil {
+		return nil, errors.Wrap(err, "accounts not found")
+	}
+	return accounts, nil
+}
+
 // Members
 
-const saveMemberQuery = "INSERT INTO members (id, workspace_id, account_id, level, created_at) VALUES ($1,$2,$3,$4,$5) ON CONFLICT (workspace_id, id) DO UPDATE SET level = $4"
+const saveMemberQuery = "INSERT INTO members (id, workspace_id, account_id, level, created_at, inactive_level) VALUES ($1,$2,$3,$4,$5,$6) ON CONFLICT (workspace_id, id) DO UPDATE SET level = $4, inactive_level = $6"
 
 func (a *repo) StoreMember(x *Member) {
-	a.tx.MustExec(saveMemberQuery, x.ID, x.WorkspaceID, x.AccountID, x.Level, x.CreatedAt)
+	a.tx.MustExec(saveMemberQuery, x.ID, x.WorkspaceID, x.AccountID, x.Level, x.CreatedAt, x.InactiveLevel)
 }
 
 func (a *repo) DeleteMember(wsid string, id string) {
@@ -255,6 +443,15 @@ func (a *repo) GetMember(workspaceID string, id string) (*Member, error) {
 	return member, nil
 }
 
+// GetMemberWithAccount is GetMember with the name and email of the account joined in
+func (a *repo) GetMemberWithAccount(workspaceID string, id string) (*Member, error) {
+	member := &Member{}
+	if err := a.tx.Get(member, "SELECT m.workspace_id, m.id, m.account_id, m.level, m.inactive_level, m.created_at, a.name, a.email FROM members m INNER JOIN accounts a ON m.account_id = a.id WHERE m.workspace_id = $1 AND m.id = $2", workspaceID, id); err != nil {
+		return nil, errors.Wrap(err, "member not found")
+	}
+	return member, nil
+}
+
 func (a *repo) GetMembersByAccount(id string) ([]*Member, error) {
 	var members []*Member
 	if err := a.tx.Select(&members, "SELECT * FROM members WHERE account_id = $1", id); err != nil {
@@ -263,6 +460,14 @@ func (a *repo) GetMembersByAccount(id string) ([]*Member, error) {
 	return members, nil
 }
 
+func (a *repo) FindMembershipsByAccount(id string) ([]*Membership, error) {
+	x := []*Membership{}
+	if err := a.tx.Select(&x, "SELECT m.id AS member_id, m.workspace_id, w.name AS workspace_name, m.level FROM members m INNER JOIN workspaces w ON m.workspace_id = w.id WHERE m.account_id = $1 ORDER BY w.name", id); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
 func (a *repo) GetMemberByEmail(workspaceID string, email string) (*Member, error) {
 	member := &Member{}
 	if err := a.tx.Get(member, "SELECT * FROM members m WHERE m.workspace_id = $1 AND m.account_id IN (SELECT id FROM accounts a WHERE a.email = $2) ", workspaceID, email); err != nil {
@@ -274,13 +479,22 @@ func (a *repo) GetMemberByEmail(workspaceID string, email string) (*Member, erro
 
 func (a *repo) FindMembersByWorkspace(id string) ([]*Member, error) {
 	x := []*Member{}
-	if err := a.tx.Select(&x, "SELECT m.workspace_id, m.id, m.account_id, m.level, m.created_at, a.name, a.email FROM members m INNER JOIN accounts a ON m.account_id = a.id WHERE m.workspace_id = $1 ORDER by m.created_at DESC ", id); err != nil {
+	if err := a.tx.Select(&x, "SELECT m.workspace_id, m.id, m.account_id, m.level, m.inactive_level, m.created_at, a.name, a.email FROM members m INNER JOIN accounts a ON m.account_id = a.id WHERE m.workspace_id = $1 ORDER by m.created_at DESC ", id); err != nil {
 		//if err := a.tx.Select(&x, "SELECT * FROM members m WHERE m.workspace_id = $1 ", id); err != nil {
 		return nil, err
 	}
 	return x, nil
 }
 
+// FindSeatMembersInactiveSince returns the editors and admins whose account has had no activity since t
+func (a *repo) FindSeatMembersInactiveSince(workspaceID string, t time.Time) ([]*Member, error) {
+	x := []*Member{}
+	if err := a.tx.Select(&x, "SELECT m.workspace_id, m.id, m.account_id, m.level, m.inactive_level, m.created_at, a.name, a.email FROM members m INNER JOIN accounts a ON m.account_id = a.id WHERE m.workspace_id = $1 AND m.level IN ('EDITOR', 'ADMIN') AND a.latest_activity < $2 ORDER BY a.latest_activity", workspaceID, t); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
 // Subscriptions
 
 const storeSubQuery = "INSERT INTO subscriptions (id, workspace_id,level, number_of_editors, from_date,expiration_date, created_by_name, created_at, last_modified, last_modified_by_name, status, external_customer_id, external_plan_id, external_subscription_id,external_subscription_item_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15) ON CONFLICT (workspace_id, id) DO UPDATE SET level = $3, number_of_editors = $4, from_date = $5,expiration_date = $6, created_by_name = $7, created_at = $8, last_modified = $9, last_modified_by_name = $10, status = $11, external_customer_id = $12, external_plan_id = $13,  external_subscription_id = $14, external_subscription_item_id = $15"
@@ -322,7 +536,11 @@ func (a *repo) FindSubscriptionByExternalID(externalSubID string) (*Subscription
 // INVITES
 
 func (a *repo) StoreInvite(x *Invite) {
-	a.tx.MustExec("INSERT INTO invites (workspace_id, id, email, level, code, created_by, created_by_name, created_at, created_by_email, workspace_name) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)", x.WorkspaceID, x.ID, x.Email, x.Level, x.Code, x.CreatedBy, x.CreatedByName, x.CreatedAt, x.CreatedByEmail, x.WorkspaceName)
+	a.tx.MustExec("INSERT INTO invites (workspace_id, id, email, level, code, created_by, created_by_name, created_at, created_by_email, workspace_name, sent_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)", x.WorkspaceID, x.ID, x.Email, x.Level, x.Code, x.CreatedBy, x.CreatedByName, x.CreatedAt, x.CreatedByEmail, x.WorkspaceName, x.SentAt)
+}
+
+func (a *repo) RenewInvite(wsid string, id string, t time.Time) {
+	a.tx.MustExec("UPDATE invites SET sent_at = $3 WHERE workspace_id = $1 AND id = $2", wsid, id, t)
 }
 
 func (a *repo) DeleteInvite(wsid string, id string) {
@@ -392,14 +610,123 @@ func (a *repo) FindProjectsByWorkspace(workspaceID string) ([]*Project, error) {
 	return x, nil
 }
 
+func (a *repo) FindProjectsWithAutoClose() ([]*Project, error) {
+	x := []*Project{}
+	err := a.tx.Select(&x, "SELECT * FROM projects WHERE auto_close_days > 0")
+	if err != nil {
+		return nil, errors.Wrap(err, "no projects found")
+	}
+	return x, nil
+}
+
+// FindProjectsWithExternalLinkIdleSince returns the projects in all workspaces whose share link is
+// not revoked and was neither created nor viewed since t
+func (a *repo) FindProjectsWithExternalLinkIdleSince(t time.Time) ([]*Project, error) {
+	x := []*Project{}
+	err := a.tx.Select(&x, "SELECT * FROM projects WHERE external_link_revoked_at IS NULL AND COALESCE(external_link_viewed_at, external_link_created_at) < $1 ORDER BY workspace_id", t)
+	if err != nil {
+		return nil, errors.Wrap(err, "no projects found")
+	}
+	return x, nil
+}
+
 func (a *repo) StoreProject(x *Project) {
-	a.tx.MustExec("INSERT INTO projects (workspace_id, id, title, created_at,created_by_name, description, last_modified, last_modified_by_name, external_link) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) ON CONFLICT (workspace_id, id) DO UPDATE SET title = $3, description = $6, last_modified = $7, last_modified_by_name = $8, external_link = $9", x.WorkspaceID, x.ID, x.Title, x.CreatedAt, x.CreatedByName, x.Description, x.LastModified, x.LastModifiedByName, x.ExternalLink)
+	a.tx.MustExec("INSERT INTO projects (workspace_id, id, title, created_at,created_by_name, description, last_modified, last_modified_by_name, external_link, auto_close_days, require_estimate, default_estimate, external_link_created_at, external_link_created_by, external_link_viewed_at, external_link_revoked_at, default_annotations, default_milestone_id, default_subworkflow_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19) ON CONFLICT (workspace_id, id) DO UPDATE SET title = $3, description = $6, last_modified = $7, last_modified_by_name = $8, external_link = $9, auto_close_days = $10, require_estimate = $11, default_estimate = $12, external_link_created_at = $13, external_link_created_by = $14, external_link_viewed_at = $15, external_link_revoked_at = $16, default_annotations = $17, default_milestone_id = $18, default_subworkflow_id = $19", x.WorkspaceID, x.ID, x.Title, x.CreatedAt, x.CreatedByName, x.Description, x.LastModified, x.LastModifiedByName, x.ExternalLink, x.AutoCloseDays, x.RequireEstimate, x.DefaultEstimate, x.ExternalLinkCreatedAt, x.ExternalLinkCreatedBy, x.ExternalLinkViewedAt, x.ExternalLinkRevokedAt, x.DefaultAnnotations, x.DefaultMilestoneID, x.DefaultSubWorkflowID)
 }
 
 func (a *repo) DeleteProject(workspaceID string, projectID string) {
 	a.tx.MustExec("DELETE FROM projects WHERE workspace_id=$1 AND id=$2", workspaceID, projectID)
 }
 
+const recentChangesQuery = `
+SELECT 'project' AS type, p.id, p.title, p.id AS project_id, p.title AS project_title, p.last_modified, p.last_modified_by_name
+FROM projects p WHERE p.workspace_id = $1 AND p.last_modified > $2
+UNION ALL
+SELECT 'milestone', m.id, m.title, p.id, p.title, m.last_modified, m.last_modified_by_name
+FROM milestones m INNER JOIN projects p ON m.workspace_id = p.workspace_id AND m.project_id = p.id WHERE m.workspace_id = $1 AND m.last_modified > $2
+UNION ALL
+SELECT 'workflow', w.id, w.title, p.id, p.title, w.last_modified, w.last_modified_by_name
+FROM workflows w INNER JOIN projects p ON w.workspace_id = p.workspace_id AND w.project_id = p.id WHERE w.workspace_id = $1 AND w.last_modified > $2
+UNION ALL
+SELECT 'subworkflow', sw.id, sw.title, p.id, p.title, sw.last_modified, sw.last_modified_by_name
+FROM subworkflows sw INNER JOIN workflows w ON sw.workspace_id = w.workspace_id AND sw.workflow_id = w.id INNER JOIN projects p ON w.workspace_id = p.workspace_id AND w.project_id = p.id WHERE sw.workspace_id = $1 AND sw.last_modified > $2
+UNION ALL
+SELECT 'feature', f.id, f.title, p.id, p.title, f.last_modified, f.last_modified_by_name
+FROM features f INNER JOIN milestones m ON f.workspace_id = m.workspace_id AND f.milestone_id = m.id INNER JOIN projects p ON m.workspace_id = p.workspace_id AND m.project_id = p.id WHERE f.workspace_id = $1 AND f.last_modified > $2
+ORDER BY last_modified DESC
+LIMIT $3`
+
+func (a *repo) FindRecentChanges(workspaceID string, since time.Time, limit int) ([]*RecentChange, error) {
+	x := []*RecentChange{}
+	if err := a.tx.Select(&x, recentChangesQuery, workspaceID, since, limit); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+const countAnnotationsQuery = `
+SELECT name, type, count(*) AS count, max(last_modified) AS last_used FROM (
+	SELECT unnest(string_to_array(annotations, ',')) AS name, 'project' AS type, last_modified FROM projects WHERE workspace_id = $1 AND annotations <> ''
+	UNION ALL
+	SELECT unnest(string_to_array(annotations, ',')), 'milestone', last_modified FROM milestones WHERE workspace_id = $1 AND annotations <> ''
+	UNION ALL
+	SELECT unnest(string_to_array(annotations, ',')), 'workflow', last_modified FROM workflows WHERE workspace_id = $1 AND annotations <> ''
+	UNION ALL
+	SELECT unnest(string_to_array(annotations, ',')), 'subworkflow', last_modified FROM subworkflows WHERE workspace_id = $1 AND annotations <> ''
+	UNION ALL
+	SELECT unnest(string_to_array(annotations, ',')), 'feature', last_modified FROM features WHERE workspace_id = $1 AND annotations <> ''
+) a
+GROUP BY name, type`
+
+func (a *repo) CountAnnotations(workspaceID string) ([]*AnnotationCount, error) {
+	x := []*AnnotationCount{}
+	if err := a.tx.Select(&x, countAnnotationsQuery, workspaceID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// breadcrumbQueries select the path to an entity of each type in a single query
+var breadcrumbQueries = map[string]string{
+	"project": `
+SELECT 'project' AS type, p.id AS project_id, p.title AS project_title,
+	'' AS milestone_id, '' AS milestone_title, '' AS workflow_id, '' AS workflow_title, '' AS subworkflow_id, '' AS subworkflow_title, '' AS feature_id, '' AS feature_title
+FROM projects p WHERE p.workspace_id = $1 AND p.id = $2`,
+	"milestone": `
+SELECT 'milestone' AS type, p.id AS project_id, p.title AS project_title,
+	m.id AS milestone_id, m.title AS milestone_title, '' AS workflow_id, '' AS workflow_title, '' AS subworkflow_id, '' AS subworkflow_title, '' AS feature_id, '' AS feature_title
+FROM milestones m INNER JOIN projects p ON m.workspace_id = p.workspace_id AND m.project_id = p.id WHERE m.workspace_id = $1 AND m.id = $2`,
+	"workflow": `
+SELECT 'workflow' AS type, p.id AS project_id, p.title AS project_title,
+	'' AS milestone_id, '' AS milestone_title, w.id AS workflow_id, w.title AS workflow_title, '' AS subworkflow_id, '' AS subworkflow_title, '' AS feature_id, '' AS feature_title
+FROM workflows w INNER JOIN projects p ON w.workspace_id = p.workspace_id AND w.project_id = p.id WHERE w.workspace_id = $1 AND w.id = $2`,
+	"subworkflow": `
+SELECT 'subworkflow' AS type, p.id AS project_id, p.title AS project_title,
+	'' AS milestone_id, '' AS milestone_title, w.id AS workflow_id, w.title AS workflow_title, sw.id AS subworkflow_id, sw.title AS subworkflow_title, '' AS feature_id, '' AS feature_title
+FROM subworkflows sw INNER JOIN workflows w ON sw.workspace_id = w.workspace_id AND sw.workflow_id = w.id INNER JOIN projects p ON w.workspace_id = p.workspace_id AND w.project_id = p.id WHERE sw.workspace_id = $1 AND sw.id = $2`,
+	"feature": `
+SELECT 'feature' AS type, p.id AS project_id, p.title AS project_title,
+	m.id AS milestone_id, m.title AS milestone_title, w.id AS workflow_id, w.title AS workflow_title, sw.id AS subworkflow_id, sw.title AS subworkflow_title, f.id AS feature_id, f.title AS feature_title
+FROM features f
+INNER JOIN milestones m ON f.workspace_id = m.workspace_id AND f.milestone_id = m.id
+INNER JOIN subworkflows sw ON f.workspace_id = sw.workspace_id AND f.subworkflow_id = sw.id
+INNER JOIN workflows w ON sw.workspace_id = w.workspace_id AND sw.workflow_id = w.id
+INNER JOIN projects p ON m.workspace_id = p.workspace_id AND m.project_id = p.id
+WHERE f.workspace_id = $1 AND f.id = $2`,
+}
+
+func (a *repo) GetBreadcrumb(workspaceID string, kind string, id string) (*Breadcrumb, error) {
+	query, ok := breadcrumbQueries[kind]
+	if !ok {
+		return nil, errors.New("unknown type")
+	}
+	x := &Breadcrumb{}
+	if err := a.tx.Get(x, query, workspaceID, id); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
 // Milestones
 
 func (a *repo) GetMilestone(workspaceID string, milestoneID string) (*Milestone, error) {
@@ -420,7 +747,7 @@ func (a *repo) FindMilestonesByProject(workspaceID string, projectID string) ([]
 }
 
 func (a *repo) StoreMilestone(x *Milestone) {
-	a.tx.MustExec("INSERT INTO milestones (workspace_id, project_id, id, rank, title, created_at,created_by_name, description, last_modified, last_modified_by_name,status, color, annotations) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10, $11, $12,$13) ON CONFLICT (workspace_id, id) DO UPDATE SET rank = $4, title = $5, description = $8, last_modified = $9, last_modified_by_name = $10, status = $11,color = $12, annotations = $13", x.WorkspaceID, x.ProjectID, x.ID, x.Rank, x.Title, x.CreatedAt, x.CreatedByName, x.Description, x.LastModified, x.LastModifiedByName, x.Status, x.Color, x.Annotations)
+	a.tx.MustExec("INSERT INTO milestones (workspace_id, project_id, id, rank, title, created_at,created_by_name, description, last_modified, last_modified_by_name,status, color, annotations, icon) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10, $11, $12,$13,$14) ON CONFLICT (workspace_id, id) DO UPDATE SET rank = $4, title = $5, description = $8, last_modified = $9, last_modified_by_name = $10, status = $11,color = $12, annotations = $13, icon = $14", x.WorkspaceID, x.ProjectID, x.ID, x.Rank, x.Title, x.CreatedAt, x.CreatedByName, x.Description, x.LastModified, x.LastModifiedByName, x.Status, x.Color, x.Annotations, x.Icon)
 }
 
 func (a *repo) DeleteMilestone(workspaceID string, milestoneID string) {
@@ -508,6 +835,15 @@ func (a *repo) FindFeaturesByProject(workspaceID string, projectID string) ([]*F
 	return x, nil
 }
 
+// FindFeaturesBlockedBefore returns the open features in all workspaces that were blocked before t and not escalated yet
+func (a *repo) FindFeaturesBlockedBefore(t time.Time) ([]*Feature, error) {
+	x := []*Feature{}
+	if err := a.tx.Select(&x, "SELECT * FROM features WHERE blocked_since < $1 AND NOT blocked_escalated AND status = 'OPEN' ORDER BY workspace_id, blocked_since", t); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
 func (a *repo) FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, mid string, swid string) ([]*Feature, error) {
 	x := []*Feature{}
 	err := a.tx.Select(&x, "SELECT * FROM features f WHERE f.workspace_id = $1 AND f.milestone_id = $2 AND f.subworkflow_id = $3 ORDER BY f.rank", workspaceID, mid, swid)
@@ -517,9 +853,18 @@ func (a *repo) FindFeaturesByMilestoneAndSubWorkflow(workspaceID string, mid str
 	return x, nil
 }
 
+func (a *repo) FindFeaturesByMilestone(workspaceID string, milestoneID string) ([]*Feature, error) {
+	x := []*Feature{}
+	err := a.tx.Select(&x, "SELECT * FROM features f WHERE f.workspace_id = $1 AND f.milestone_id = $2 ORDER BY f.rank", workspaceID, milestoneID)
+	if err != nil {
+		return nil, errors.Wrap(err, "no found")
+	}
+	return x, nil
+}
+
 func (a *repo) StoreFeature(x *Feature) {
-	a.tx.MustExec("INSERT INTO features (workspace_id, subworkflow_id, milestone_id, id, rank, title, created_at, description, created_by_name, last_modified,last_modified_by_name, status, color, annotations, estimate) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15) ON CONFLICT (workspace_id, id) DO UPDATE SET subworkflow_id = $2, milestone_id = $3,rank = $5, title = $6,  description = $8, last_modified = $10, last_modified_by_name = $11, status = $12, color = $13,  annotations = $14, estimate = $15",
-		x.WorkspaceID, x.SubWorkflowID, x.MilestoneID, x.ID, x.Rank, x.Title, x.CreatedAt, x.Description, x.CreatedByName, x.LastModified, x.LastModifiedByName, x.Status, x.Color, x.Annotations, x.Estimate)
+	a.tx.MustExec("INSERT INTO features (workspace_id, subworkflow_id, milestone_id, id, rank, title, created_at, description, created_by_name, last_modified,last_modified_by_name, status, color, annotations, estimate, progress, status_id, icon, blocked_since, blocked_escalated) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20) ON CONFLICT (workspace_id, id) DO UPDATE SET subworkflow_id = $2, milestone_id = $3,rank = $5, title = $6,  description = $8, last_modified = $10, last_modified_by_name = $11, status = $12, color = $13,  annotations = $14, estimate = $15, progress = $16, status_id = $17, icon = $18, blocked_since = $19, blocked_escalated = $20",
+		x.WorkspaceID, x.SubWorkflowID, x.MilestoneID, x.ID, x.Rank, x.Title, x.CreatedAt, x.Description, x.CreatedByName, x.LastModified, x.LastModifiedByName, x.Status, x.Color, x.Annotations, x.Estimate, x.Progress, x.StatusID, x.Icon, x.BlockedSince, x.BlockedEscalated)
 }
 
 func (a *repo) DeleteFeature(workspaceID string, featureID string) {
@@ -545,14 +890,31 @@ func (a *repo) FindFeatureCommentsByProject(workspaceID string, projectID string
 	return x, nil
 }
 
-func (a *repo) FindFeatureCommentsByFeature(workspaceID string, ID string) (*FeatureComment, error) {
-	x := &FeatureComment{}
-	if err := a.tx.Get(x, "SELECT * FROM feature_comments WHERE workspace_id = $1 AND feature_id = $2", workspaceID, ID); err != nil {
+func (a *repo) FindFeatureCommentsByMilestone(workspaceID string, milestoneID string) ([]*FeatureComment, error) {
+	x := []*FeatureComment{}
+	err := a.tx.Select(&x, "SELECT * FROM feature_comments c WHERE c.workspace_id = $1 AND c.feature_id IN (select f.id from features f where f.workspace_id = $1 and f.milestone_id = $2)", workspaceID, milestoneID)
+	if err != nil {
+		return nil, errors.Wrap(err, "no found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindFeatureCommentsByFeature(workspaceID string, featureID string) ([]*FeatureComment, error) {
+	x := []*FeatureComment{}
+	if err := a.tx.Select(&x, "SELECT * FROM feature_comments WHERE workspace_id = $1 AND feature_id = $2 ORDER BY created_at", workspaceID, featureID); err != nil {
 		return nil, errors.Wrap(err, "not found")
 	}
 	return x, nil
 }
 
+func (a *repo) CountFeatureCommentsByFeature(workspaceID string, featureID string) (int, error) {
+	var n int
+	if err := a.tx.Get(&n, "SELECT count(*) FROM feature_comments WHERE workspace_id = $1 AND feature_id = $2", workspaceID, featureID); err != nil {
+		return 0, errors.Wrap(err, "not found")
+	}
+	return n, nil
+}
+
 func (a *repo) StoreFeatureComment(x *FeatureComment) {
 	a.tx.MustExec("INSERT INTO feature_comments (workspace_id, id, project_id, feature_id, post, created_at, created_by_name, last_modified) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT (workspace_id, id) DO UPDATE SET post = $5, created_by_name = $7, last_modified = $8",
 		x.WorkspaceID, x.ID, x.ProjectID, x.FeatureID, x.Post, x.CreatedAt, x.CreatedByName, x.LastModified)
@@ -594,6 +956,318 @@ func (a *repo) StoreFeatureCommentOwner(x *FeatureCommentOwner) {
 		x.WorkspaceID, x.ID, x.FeatureCommentID, x.MemberID, x.ProjectID)
 }
 
+// Feature events
+
+// StoreFeatureEvent looks up the project through the milestone, so callers only need the feature
+func (a *repo) StoreFeatureEvent(x *FeatureEvent) {
+	a.tx.MustExec("INSERT INTO feature_events (workspace_id, id, project_id, feature_id, milestone_id, status, estimate, created_at, title, created_by_name) SELECT $1,$2,m.project_id,$3,$4,$5,$6,$7,$8,$9 FROM milestones m WHERE m.workspace_id = $1 AND m.id = $4",
+		x.WorkspaceID, x.ID, x.FeatureID, x.MilestoneID, x.Status, x.Estimate, x.CreatedAt, x.Title, x.CreatedByName)
+}
+
+// FindFeatureEventsByFeature returns a page of the events of a feature, oldest first
+func (a *repo) FindFeatureEventsByFeature(workspaceID string, featureID string, offset int, limit int) ([]*FeatureEvent, error) {
+	x := []*FeatureEvent{}
+	err := a.tx.Select(&x, "SELECT * FROM feature_events WHERE workspace_id = $1 AND feature_id = $2 ORDER BY created_at, id OFFSET $3 LIMIT $4", workspaceID, featureID, offset, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// FindFeatureEventsByMilestone returns all events of features that have been in the milestone at some point
+func (a *repo) FindFeatureEventsByMilestone(workspaceID string, milestoneID string) ([]*FeatureEvent, error) {
+	x := []*FeatureEvent{}
+	err := a.tx.Select(&x, "SELECT * FROM feature_events e WHERE e.workspace_id = $1 AND e.feature_id IN (select d.feature_id from feature_events d where d.workspace_id = $1 and d.milestone_id = $2) ORDER BY e.created_at", workspaceID, milestoneID)
+	if err != nil {
+		return nil, errors.Wrap(err, "no found")
+	}
+	return x, nil
+}
+
+// Project favorites
+
+func (a *repo) StoreProjectFavorite(x *ProjectFavorite) {
+	a.tx.MustExec("INSERT INTO project_favorites (workspace_id, project_id, member_id, created_at) VALUES ($1,$2,$3,$4) ON CONFLICT (workspace_id, project_id, member_id) DO NOTHING",
+		x.WorkspaceID, x.ProjectID, x.MemberID, x.CreatedAt)
+}
+
+func (a *repo) DeleteProjectFavorite(workspaceID string, projectID string, memberID string) {
+	a.tx.MustExec("DELETE FROM project_favorites WHERE workspace_id = $1 AND project_id = $2 AND member_id = $3", workspaceID, projectID, memberID)
+}
+
+func (a *repo) FindFavoriteProjectIDsByMember(workspaceID string, memberID string) ([]string, error) {
+	x := []string{}
+	if err := a.tx.Select(&x, "SELECT project_id FROM project_favorites WHERE workspace_id = $1 AND member_id = $2", workspaceID, memberID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// Project links
+
+func (a *repo) StoreProjectLink(x *ProjectLink) {
+	a.tx.MustExec("INSERT INTO project_links (workspace_id, project_id, related_project_id, created_at) VALUES ($1,$2,$3,$4) ON CONFLICT (workspace_id, project_id, related_project_id) DO NOTHING",
+		x.WorkspaceID, x.ProjectID, x.RelatedProjectID, x.CreatedAt)
+}
+
+func (a *repo) DeleteProjectLink(workspaceID string, projectID string, relatedProjectID string) {
+	a.tx.MustExec("DELETE FROM project_links WHERE workspace_id = $1 AND project_id = $2 AND related_project_id = $3", workspaceID, projectID, relatedProjectID)
+}
+
+func (a *repo) FindRelatedProjects(workspaceID string, projectID string) ([]*Project, error) {
+	x := []*Project{}
+	if err := a.tx.Select(&x, "SELECT p.* FROM projects p INNER JOIN project_links l ON p.workspace_id = l.workspace_id AND ((l.project_id = $2 AND p.id = l.related_project_id) OR (l.related_project_id = $2 AND p.id = l.project_id)) WHERE p.workspace_id = $1 ORDER BY p.title", workspaceID, projectID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// Notification emails
+
+func (a *repo) StoreNotificationEmail(x *NotificationEmail) {
+	a.tx.MustExec("INSERT INTO notification_emails (id, account_id, email, subject, body, digest, created_at, batch_key, batch_until) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)",
+		x.ID, x.AccountID, x.Email, x.Subject, x.Body, x.Digest, x.CreatedAt, x.BatchKey, x.BatchUntil)
+}
+
+func (a *repo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	var n int
+	if err := a.tx.Get(&n, "SELECT count(*) FROM notification_emails WHERE account_id = $1 AND created_at >= $2 AND NOT digest AND batch_until IS NULL", accountID, t); err != nil {
+		return 0, errors.Wrap(err, "not found")
+	}
+	return n, nil
+}
+
+func (a *repo) FindDigestNotificationEmailsBefore(t time.Time) ([]*NotificationEmail, error) {
+	x := []*NotificationEmail{}
+	if err := a.tx.Select(&x, "SELECT * FROM notification_emails WHERE digest AND created_at < $1 ORDER BY account_id, created_at", t); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) DeleteNotificationEmailsBefore(t time.Time) {
+	a.tx.MustExec("DELETE FROM notification_emails WHERE created_at < $1 AND batch_until IS NULL", t)
+}
+
+func (a *repo) GetLatestNotificationEmailByBatchKey(accountID string, key string) (*NotificationEmail, error) {
+	x := &NotificationEmail{}
+	if err := a.tx.Get(x, "SELECT * FROM notification_emails WHERE account_id = $1 AND batch_key = $2 AND batch_until IS NULL ORDER BY created_at DESC LIMIT 1", accountID, key); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindHeldNotificationEmailsBefore(t time.Time) ([]*NotificationEmail, error) {
+	x := []*NotificationEmail{}
+	if err := a.tx.Select(&x, "SELECT * FROM notification_emails WHERE batch_until <= $1 ORDER BY account_id, batch_key, created_at", t); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) DeleteHeldNotificationEmailsBefore(t time.Time) {
+	a.tx.MustExec("DELETE FROM notification_emails WHERE batch_until <= $1", t)
+}
+
+// Goals
+
+func (a *repo) GetGoal(workspaceID string, id string) (*Goal, error) {
+	x := &Goal{}
+	if err := a.tx.Get(x, "SELECT * FROM goals WHERE workspace_id = $1 AND id = $2", workspaceID, id); err != nil {
+		return nil, errors.Wrap(err, "goal not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindGoalsByProject(workspaceID string, projectID string) ([]*Goal, error) {
+	x := []*Goal{}
+	if err := a.tx.Select(&x, "SELECT * FROM goals WHERE workspace_id = $1 AND project_id = $2 ORDER BY target_date, created_at", workspaceID, projectID); err != nil {
+		return nil, errors.Wrap(err, "no goals found")
+	}
+	return x, nil
+}
+
+func (a *repo) StoreGoal(x *Goal) {
+	a.tx.MustExec("INSERT INTO goals (workspace_id, project_id, id, title, description, target_date, created_at, created_by_name, last_modified, last_modified_by_name) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) ON CONFLICT (workspace_id, id) DO UPDATE SET title = $4, description = $5, target_date = $6, last_modified = $9, last_modified_by_name = $10",
+		x.WorkspaceID, x.ProjectID, x.ID, x.Title, x.Description, x.TargetDate, x.CreatedAt, x.CreatedByName, x.LastModified, x.LastModifiedByName)
+}
+
+func (a *repo) DeleteGoal(workspaceID string, id string) {
+	a.tx.MustExec("DELETE FROM goals WHERE workspace_id = $1 AND id = $2", workspaceID, id)
+}
+
+func (a *repo) StoreGoalMilestone(x *GoalMilestone) {
+	a.tx.MustExec("INSERT INTO goal_milestones (workspace_id, project_id, goal_id, milestone_id) VALUES ($1,$2,$3,$4) ON CONFLICT (workspace_id, goal_id, milestone_id) DO NOTHING",
+		x.WorkspaceID, x.ProjectID, x.GoalID, x.MilestoneID)
+}
+
+func (a *repo) DeleteGoalMilestone(workspaceID string, goalID string, milestoneID string) {
+	a.tx.MustExec("DELETE FROM goal_milestones WHERE workspace_id = $1 AND goal_id = $2 AND milestone_id = $3", workspaceID, goalID, milestoneID)
+}
+
+func (a *repo) FindGoalMilestonesByProject(workspaceID string, projectID string) ([]*GoalMilestone, error) {
+	x := []*GoalMilestone{}
+	if err := a.tx.Select(&x, "SELECT * FROM goal_milestones WHERE workspace_id = $1 AND project_id = $2", workspaceID, projectID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// Feature watchers
+
+func (a *repo) StoreFeatureWatcher(x *FeatureWatcher) {
+	a.tx.MustExec("INSERT INTO feature_watchers (workspace_id, feature_id, member_id, project_id, created_at) VALUES ($1,$2,$3,$4,$5) ON CONFLICT (workspace_id, feature_id, member_id) DO NOTHING",
+		x.WorkspaceID, x.FeatureID, x.MemberID, x.ProjectID, x.CreatedAt)
+}
+
+func (a *repo) DeleteFeatureWatcher(workspaceID string, featureID string, memberID string) {
+	a.tx.MustExec("DELETE FROM feature_watchers WHERE workspace_id = $1 AND feature_id = $2 AND member_id = $3", workspaceID, featureID, memberID)
+}
+
+func (a *repo) FindFeatureWatchersByFeature(workspaceID string, featureID string) ([]*Member, error) {
+	x := []*Member{}
+	if err := a.tx.Select(&x, "SELECT m.workspace_id, m.id, m.account_id, m.level, m.created_at, a.name, a.email FROM feature_watchers w INNER JOIN members m ON w.workspace_id = m.workspace_id AND w.member_id = m.id INNER JOIN accounts a ON m.account_id = a.id WHERE w.workspace_id = $1 AND w.feature_id = $2", workspaceID, featureID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindWatchedFeatureIDsByProject(workspaceID string, projectID string, memberID string) ([]string, error) {
+	x := []string{}
+	if err := a.tx.Select(&x, "SELECT feature_id FROM feature_watchers WHERE workspace_id = $1 AND project_id = $2 AND member_id = $3", workspaceID, projectID, memberID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// Access requests
+
+func (a *repo) GetAccessRequest(workspaceID string, id string) (*AccessRequest, error) {
+	x := &AccessRequest{}
+	if err := a.tx.Get(x, "SELECT * FROM access_requests WHERE workspace_id = $1 AND id = $2", workspaceID, id); err != nil {
+		return nil, errors.Wrap(err, "access request not found")
+	}
+	return x, nil
+}
+
+func (a *repo) GetPendingAccessRequestByMember(workspaceID string, memberID string) (*AccessRequest, error) {
+	x := &AccessRequest{}
+	if err := a.tx.Get(x, "SELECT * FROM access_requests WHERE workspace_id = $1 AND member_id = $2 AND status = 'PENDING'", workspaceID, memberID); err != nil {
+		return nil, errors.Wrap(err, "access request not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindPendingAccessRequestsByProject(workspaceID string, projectID string) ([]*AccessRequest, error) {
+	x := []*AccessRequest{}
+	if err := a.tx.Select(&x, "SELECT * FROM access_requests WHERE workspace_id = $1 AND project_id = $2 AND status = 'PENDING' ORDER BY created_at", workspaceID, projectID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) StoreAccessRequest(x *AccessRequest) {
+	a.tx.MustExec("INSERT INTO access_requests (workspace_id, id, project_id, member_id, level, status, created_at, decided_at, decided_by_name) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) ON CONFLICT (workspace_id, id) DO UPDATE SET status = $6, decided_at = $8, decided_by_name = $9",
+		x.WorkspaceID, x.ID, x.ProjectID, x.MemberID, x.Level, x.Status, x.CreatedAt, x.DecidedAt, x.DecidedByName)
+}
+
+// Feature references
+
+func (a *repo) StoreFeatureReference(x *FeatureReference) {
+	a.tx.MustExec("INSERT INTO feature_references (workspace_id, project_id, feature_id, id, label, url, created_at, created_by_name) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT (workspace_id, id) DO UPDATE SET label = $5, url = $6",
+		x.WorkspaceID, x.ProjectID, x.FeatureID, x.ID, x.Label, x.URL, x.CreatedAt, x.CreatedByName)
+}
+
+func (a *repo) DeleteFeatureReference(workspaceID string, featureID string, id string) {
+	a.tx.MustExec("DELETE FROM feature_references WHERE workspace_id = $1 AND feature_id = $2 AND id = $3", workspaceID, featureID, id)
+}
+
+func (a *repo) FindFeatureReferencesByFeature(workspaceID string, featureID string) ([]*FeatureReference, error) {
+	x := []*FeatureReference{}
+	if err := a.tx.Select(&x, "SELECT * FROM feature_references WHERE workspace_id = $1 AND feature_id = $2 ORDER BY created_at", workspaceID, featureID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindFeatureReferencesByProject(workspaceID string, projectID string) ([]*FeatureReference, error) {
+	x := []*FeatureReference{}
+	if err := a.tx.Select(&x, "SELECT * FROM feature_references WHERE workspace_id = $1 AND project_id = $2 ORDER BY created_at", workspaceID, projectID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// Time entries
+
+func (a *repo) GetTimeEntry(workspaceID string, featureID string, id string) (*TimeEntry, error) {
+	x := &TimeEntry{}
+	if err := a.tx.Get(x, "SELECT * FROM time_entries WHERE workspace_id = $1 AND feature_id = $2 AND id = $3", workspaceID, featureID, id); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) StoreTimeEntry(x *TimeEntry) {
+	a.tx.MustExec("INSERT INTO time_entries (workspace_id, project_id, feature_id, id, member_id, minutes, note, created_at, created_by_name, last_modified) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) ON CONFLICT (workspace_id, id) DO UPDATE SET minutes = $6, note = $7, last_modified = $10",
+		x.WorkspaceID, x.ProjectID, x.FeatureID, x.ID, x.MemberID, x.Minutes, x.Note, x.CreatedAt, x.CreatedByName, x.LastModified)
+}
+
+func (a *repo) DeleteTimeEntry(workspaceID string, featureID string, id string) {
+	a.tx.MustExec("DELETE FROM time_entries WHERE workspace_id = $1 AND feature_id = $2 AND id = $3", workspaceID, featureID, id)
+}
+
+func (a *repo) FindTimeEntriesByFeature(workspaceID string, featureID string) ([]*TimeEntry, error) {
+	x := []*TimeEntry{}
+	if err := a.tx.Select(&x, "SELECT * FROM time_entries WHERE workspace_id = $1 AND feature_id = $2 ORDER BY created_at", workspaceID, featureID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindTimeEntriesByProject(workspaceID string, projectID string) ([]*TimeEntry, error) {
+	x := []*TimeEntry{}
+	if err := a.tx.Select(&x, "SELECT * FROM time_entries WHERE workspace_id = $1 AND project_id = $2 ORDER BY created_at", workspaceID, projectID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// Project statuses
+
+func (a *repo) GetProjectStatus(workspaceID string, ID string) (*ProjectStatus, error) {
+	x := &ProjectStatus{}
+	if err := a.tx.Get(x, "SELECT * FROM project_statuses WHERE workspace_id = $1 AND id = $2", workspaceID, ID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	x := []*ProjectStatus{}
+	err := a.tx.Select(&x, "SELECT * FROM project_statuses s WHERE s.workspace_id = $1 AND s.project_id = $2 ORDER BY s.rank", workspaceID, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "no found")
+	}
+	return x, nil
+}
+
+func (a *repo) StoreProjectStatus(x *ProjectStatus) {
+	a.tx.MustExec("INSERT INTO project_statuses (workspace_id, project_id, id, title, rank, closed, created_at) VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT (workspace_id, id) DO UPDATE SET title = $4, rank = $5, closed = $6",
+		x.WorkspaceID, x.ProjectID, x.ID, x.Title, x.Rank, x.Closed, x.CreatedAt)
+}
+
+// DeleteProjectStatus moves features with the status to the replacement and deletes it
+func (a *repo) DeleteProjectStatus(workspaceID string, id string, replacementID string) {
+	a.tx.MustExec("UPDATE features SET status_id = $3 WHERE workspace_id = $1 AND status_id = $2", workspaceID, id, replacementID)
+	a.tx.MustExec("DELETE FROM project_statuses WHERE workspace_id = $1 AND id = $2", workspaceID, id)
+}
+
+// SyncFeatureStatuses sets status on all features with the custom status after its closed flag changed
+func (a *repo) SyncFeatureStatuses(workspaceID string, statusID string, status string) {
+	a.tx.MustExec("UPDATE features SET status = $3 WHERE workspace_id = $1 AND status_id = $2", workspaceID, statusID, status)
+}
+
 // Personas
 
 func (a *repo) GetPersona(workspaceID string, ID string) (*Persona, error) {
@@ -649,3 +1323,128 @@ func (a *repo) StoreWorkflowPersona(x *WorkflowPersona) {
 func (a *repo) DeleteWorkflowPersona(workspaceID string, id string) {
 	a.tx.MustExec("DELETE FROM workflow_personas WHERE workspace_id=$1 AND id=$2", workspaceID, id)
 }
+
+// Instance admin
+
+func (a *repo) FindAllWorkspaces() ([]*Workspace, error) {
+	var workspaces []*Workspace
+	if err := a.tx.Select(&workspaces, "SELECT * FROM workspaces ORDER BY created_at"); err != nil {
+		return nil, errors.Wrap(err, "no workspaces found")
+	}
+	return workspaces, nil
+}
+
+func (a *repo) FindAllAccounts() ([]*Account, error) {
+	var accounts []*Account
+	if err := a.tx.Select(&accounts, "SELECT * FROM accounts ORDER BY created_at"); err != nil {
+		return nil, errors.Wrap(err, "no accounts found")
+	}
+	return accounts, nil
+}
+
+const instanceStatsQuery = `SELECT
+	(SELECT count(*) FROM workspaces) AS workspaces,
+	(SELECT count(*) FROM workspaces WHERE suspended) AS suspended_workspaces,
+	(SELECT count(*) FROM accounts) AS accounts,
+	(SELECT count(*) FROM accounts WHERE latest_activity >= $1) AS active_accounts,
+	(SELECT count(*) FROM projects) AS projects,
+	(SELECT count(*) FROM features) AS features`
+
+func (a *repo) GetInstanceStats(activeSince time.Time) (*InstanceStats, error) {
+	x := &InstanceStats{}
+	if err := a.tx.Get(x, instanceStatsQuery, activeSince); err != nil {
+		return nil, errors.Wrap(err, "stats not found")
+	}
+	return x, nil
+}
+
+func (a *repo) StoreAdminAction(x *AdminAction) {
+	a.tx.MustExec("INSERT INTO admin_actions (id, account_id, account_email, action, target, created_at) VALUES ($1,$2,$3,$4,$5,$6)", x.ID, x.AccountID, x.AccountEmail, x.Action, x.Target, x.CreatedAt)
+}
+
+func (a *repo) FindAdminActionsByAccount(accountID string) ([]*AdminAction, error) {
+	x := []*AdminAction{}
+	if err := a.tx.Select(&x, "SELECT * FROM admin_actions WHERE account_id = $1 ORDER BY created_at", accountID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// Personal data
+
+func (a *repo) FindFeatureCommentsByMember(workspaceID string, memberID string) ([]*FeatureComment, error) {
+	x := []*FeatureComment{}
+	if err := a.tx.Select(&x, "SELECT c.* FROM feature_comments c INNER JOIN feature_comment_owners o ON c.workspace_id = o.workspace_id AND c.id = o.feature_comment_id WHERE c.workspace_id = $1 AND o.member_id = $2 ORDER BY c.created_at", workspaceID, memberID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindFeatureWatchersByMember(workspaceID string, memberID string) ([]*FeatureWatcher, error) {
+	x := []*FeatureWatcher{}
+	if err := a.tx.Select(&x, "SELECT * FROM feature_watchers WHERE workspace_id = $1 AND member_id = $2 ORDER BY created_at", workspaceID, memberID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) FindNotificationEmailsByAccount(accountID string) ([]*NotificationEmail, error) {
+	x := []*NotificationEmail{}
+	if err := a.tx.Select(&x, "SELECT * FROM notification_emails WHERE account_id = $1 ORDER BY created_at", accountID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+// Merging accounts
+
+// ReassignMember makes the member belong to another account
+func (a *repo) ReassignMember(workspaceID string, memberID string, accountID string) {
+	a.tx.MustExec("UPDATE members SET account_id = $3 WHERE workspace_id = $1 AND id = $2", workspaceID, memberID, accountID)
+}
+
+// MergeMember moves what a member did onto another member of the same workspace and deletes it.
+// Watches and favorites both members had are dropped with the member, as are pending requests.
+func (a *repo) MergeMember(workspaceID string, fromID string, intoID string) {
+	a.tx.MustExec("UPDATE feature_comment_owners SET member_id = $3 WHERE workspace_id = $1 AND member_id = $2", workspaceID, fromID, intoID)
+	a.tx.MustExec("UPDATE feature_watchers w SET member_id = $3 WHERE w.workspace_id = $1 AND w.member_id = $2 AND NOT EXISTS (SELECT 1 FROM feature_watchers x WHERE x.workspace_id = $1 AND x.feature_id = w.feature_id AND x.member_id = $3)", workspaceID, fromID, intoID)
+	a.tx.MustExec("UPDATE project_favorites f SET member_id = $3 WHERE f.workspace_id = $1 AND f.member_id = $2 AND NOT EXISTS (SELECT 1 FROM project_favorites x WHERE x.workspace_id = $1 AND x.project_id = f.project_id AND x.member_id = $3)", workspaceID, fromID, intoID)
+	a.tx.MustExec("UPDATE access_requests SET member_id = $3 WHERE workspace_id = $1 AND member_id = $2 AND status <> 'PENDING'", workspaceID, fromID, intoID)
+	a.tx.MustExec("UPDATE projects SET external_link_created_by = $3 WHERE workspace_id = $1 AND external_link_created_by = $2", workspaceID, fromID, intoID)
+	a.tx.MustExec("UPDATE time_entries SET member_id = $3 WHERE workspace_id = $1 AND member_id = $2", workspaceID, fromID, intoID)
+	a.tx.MustExec("DELETE FROM members WHERE workspace_id = $1 AND id = $2", workspaceID, fromID)
+}
+
+// ReassignAccount moves the notifications and admin actions of an account onto another
+func (a *repo) ReassignAccount(fromID string, intoID string) {
+	a.tx.MustExec("UPDATE notification_emails SET account_id = $2 WHERE account_id = $1", fromID, intoID)
+	a.tx.MustExec("UPDATE admin_actions SET account_id = $2 WHERE account_id = $1", fromID, intoID)
+}
+
+// Project archives
+
+func (a *repo) GetProjectArchive(workspaceID string, id string) (*ProjectArchive, error) {
+	x := &ProjectArchive{}
+	if err := a.tx.Get(x, "SELECT * FROM project_archives WHERE workspace_id = $1 AND id = $2", workspaceID, id); err != nil {
+		return nil, errors.Wrap(err, "archive not found")
+	}
+	return x, nil
+}
+
+// FindProjectArchivesByWorkspace returns the archives of a workspace, newest first, without their data
+func (a *repo) FindProjectArchivesByWorkspace(workspaceID string) ([]*ProjectArchive, error) {
+	x := []*ProjectArchive{}
+	if err := a.tx.Select(&x, "SELECT workspace_id, id, project_id, project_title, created_at, created_by_name, size FROM project_archives WHERE workspace_id = $1 ORDER BY created_at DESC", workspaceID); err != nil {
+		return nil, errors.Wrap(err, "not found")
+	}
+	return x, nil
+}
+
+func (a *repo) StoreProjectArchive(x *ProjectArchive) {
+	a.tx.MustExec("INSERT INTO project_archives (workspace_id, id, project_id, project_title, created_at, created_by_name, size, data) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)", x.WorkspaceID, x.ID, x.ProjectID, x.ProjectTitle, x.CreatedAt, x.CreatedByName, x.Size, x.Data)
+}
+
+// DeleteProjectArchivesBefore deletes the archives of all workspaces taken before t
+func (a *repo) DeleteProjectArchivesBefore(t time.Time) {
+	a.tx.MustExec("DELETE FROM project_archives WHERE created_at < $1", t)
+}
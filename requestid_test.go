@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/stripe/stripe-go"
+)
+
+// subscriptionRepo stores the subscription it is given
+type subscriptionRepo struct {
+	Repository
+	stored *Subscription
+}
+
+func (a *subscriptionRepo) StoreSubscription(x *Subscription) { a.stored = x }
+
+func TestRequestIDPropagation(t *testing.T) {
+	forwarded := []string{}
+	stripeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = append(forwarded, r.Header.Get("X-Request-ID"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "sub_1", "object": "subscription", "status": "canceled"}`))
+	}))
+	defer stripeAPI.Close()
+
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{URL: stripeAPI.URL}))
+	defer stripe.SetBackend(stripe.APIBackend, nil)
+
+	cancel := func(w http.ResponseWriter, r *http.Request) {
+		s := GetEnv(r).Service
+		s.SetRepoObject(&subscriptionRepo{})
+		s.SetSubscriptionObject(&Subscription{ExternalSubscriptionID: "sub_1", Status: "active"})
+		if err := s.(*service).handleCancelSubscription(); err != nil {
+			t.Error(err)
+		}
+	}
+	h := middleware.RequestID(ContextSkeleton(Configuration{RequestIDHeader: "X-Request-ID"})(http.HandlerFunc(cancel)))
+
+	req := httptest.NewRequest("POST", "/v1/subscriptions/cancel", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Header().Get("X-Request-ID") != "req-1" {
+		t.Error("the inbound request ID should be returned", w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/v1/subscriptions/cancel", nil))
+	generated := w.Header().Get("X-Request-ID")
+	if generated == "" {
+		t.Error("a request without an ID should get one")
+	}
+
+	if len(forwarded) != 2 || forwarded[0] != "req-1" || forwarded[1] != generated {
+		t.Error("calls to Stripe should carry the request ID", forwarded)
+	}
+}
+
+func TestRequestIDInEmails(t *testing.T) {
+	s := &service{}
+	s.SetConfig(Configuration{RequestIDHeader: "X-Request-ID"})
+
+	if m := string(s.emailMessage("team@example.com", "ann@example.com", "Hello", "Hi")); strings.Contains(m, "X-Request-ID") {
+		t.Error("an email outside a request should not carry a request ID", m)
+	}
+
+	s.SetRequestID("req-1")
+	m := string(s.emailMessage("team@example.com", "ann@example.com", "Hello", "Hi"))
+	if !strings.Contains(m, "\r\nX-Request-ID: req-1\r\n") || !strings.HasSuffix(m, "\r\n\r\nHi") {
+		t.Error("an email should carry the request ID as a header", m)
+	}
+}
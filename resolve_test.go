@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+// breadcrumbRepo resolves features of a single workspace
+type breadcrumbRepo struct {
+	Repository
+	workspaceID string
+	features    map[string]*Breadcrumb
+}
+
+func (a *breadcrumbRepo) GetBreadcrumb(workspaceID string, kind string, id string) (*Breadcrumb, error) {
+	if x, ok := a.features[id]; ok && kind == "feature" && workspaceID == a.workspaceID {
+		return x, nil
+	}
+	return nil, errNotFound
+}
+
+func TestResolve(t *testing.T) {
+	id := "6f1c2c1e-8a52-4a8e-9b57-6f0ad0c4b2a1"
+	repo := &breadcrumbRepo{workspaceID: "ws", features: map[string]*Breadcrumb{
+		id: {Type: "feature", ProjectID: "p1", ProjectTitle: "Shop", MilestoneID: "m1", FeatureID: id, FeatureTitle: "Checkout"},
+	}}
+
+	member := func(workspaceID string) Service {
+		s := NewFeatmapService()
+		s.SetRepoObject(repo)
+		s.SetMemberObject(&Member{WorkspaceID: workspaceID})
+		return s
+	}
+
+	x, err := member("ws").Resolve("feature", id)
+	if err != nil || x.ProjectID != "p1" || x.MilestoneID != "m1" || x.FeatureTitle != "Checkout" {
+		t.Error("feature should resolve to its breadcrumb", x, err)
+	}
+
+	if _, err := member("other").Resolve("feature", id); err == nil || err == errUnresolvable {
+		t.Error("feature of another workspace should not be found", err)
+	}
+
+	if _, err := member("ws").Resolve("card", id); err != errUnresolvable {
+		t.Error("unknown type should be rejected", err)
+	}
+	if _, err := member("ws").Resolve("feature", "not-a-uuid"); err != errUnresolvable {
+		t.Error("malformed id should be rejected", err)
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/render"
 )
@@ -51,8 +54,87 @@ func (r *Response) JSON() []byte {
 	return res
 }
 
+// renderJSONWithETag renders v as JSON with an ETag, answering 304 if the client already has it
+func renderJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, http.StatusText(500), 500)
+		return
+	}
+
+	sum := sha1.Sum(b)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(b)
+}
+
+// reconcileFields are always part of a minimal update response
+var reconcileFields = []string{"id", "lastModified", "lastModifiedByName"}
+
+// wantsMinimal tells if the client asked for only the changed fields of an update
+func wantsMinimal(r *http.Request) bool {
+	return r.URL.Query().Get("fields") == "changed" || strings.Contains(r.Header.Get("Prefer"), "return=minimal")
+}
+
+// minimalRepresentation keeps the given JSON fields of v together with reconcileFields
+func minimalRepresentation(v interface{}, fields ...string) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	full := map[string]interface{}{}
+	if err := json.Unmarshal(b, &full); err != nil {
+		return nil, err
+	}
+
+	x := map[string]interface{}{}
+	for _, f := range append(fields, reconcileFields...) {
+		if val, ok := full[f]; ok {
+			x[f] = val
+		}
+	}
+	return x, nil
+}
+
+// renderUpdated renders an updated entity with the ETag of its full representation. Only the
+// changed fields are sent when the client asked for a minimal response.
+func renderUpdated(w http.ResponseWriter, r *http.Request, v interface{}, changed ...string) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, http.StatusText(500), 500)
+		return
+	}
+
+	sum := sha1.Sum(b)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+
+	if !wantsMinimal(r) {
+		render.JSON(w, r, v)
+		return
+	}
+
+	x, err := minimalRepresentation(v, changed...)
+	if err != nil {
+		http.Error(w, http.StatusText(500), 500)
+		return
+	}
+	w.Header().Set("Preference-Applied", "return=minimal")
+	render.JSON(w, r, x)
+}
+
 // ErrInvalidRequest ...
 func ErrInvalidRequest(err error) render.Renderer {
+	if _, ok := err.(*unknownFieldError); ok {
+		return ErrUnprocessable(err)
+	}
 	return &ErrResponse{
 		Err:            err,
 		HTTPStatusCode: 400,
@@ -61,6 +143,46 @@ func ErrInvalidRequest(err error) render.Renderer {
 	}
 }
 
+// ErrUnprocessable ...
+func ErrUnprocessable(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 422,
+		StatusText:     "",
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrNotFound ...
+func ErrNotFound(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 404,
+		StatusText:     "",
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrConflict ...
+func ErrConflict(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 409,
+		StatusText:     "",
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrGone ...
+func ErrGone(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 410,
+		StatusText:     "",
+		ErrorText:      err.Error(),
+	}
+}
+
 // ErrInternal ...
 
 // ErrResponse ...
@@ -68,13 +190,19 @@ type ErrResponse struct {
 	Err            error `json:"-"` // low-level runtime error
 	HTTPStatusCode int   `json:"-"` // http response status code
 
-	StatusText string `json:"status,omitempty"`  // user-level status message
-	AppCode    int64  `json:"code,omitempty"`    // application-specific error code
-	ErrorText  string `json:"message,omitempty"` // application-level error message, for debugging
+	StatusText string `json:"status,omitempty"`           // user-level status message
+	AppCode    int64  `json:"code,omitempty"`             // application-specific error code
+	ErrorText  string `json:"message,omitempty"`          // application-level error message, for debugging
+	Localized  string `json:"localizedMessage,omitempty"` // error message in the locale of the request
 }
 
 // Render ...
 func (e *ErrResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if e.ErrorText != "" {
+		locale := requestLocale(r)
+		e.Localized = localize(locale, e.ErrorText)
+		w.Header().Set("Content-Language", locale)
+	}
 	render.Status(r, e.HTTPStatusCode)
 	return nil
 }
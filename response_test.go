@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRenderUpdated(t *testing.T) {
+	f := &Feature{ID: "f1", Title: "Renamed", Description: "Long text", LastModified: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), LastModifiedByName: "Ann"}
+
+	full := httptest.NewRecorder()
+	renderUpdated(full, httptest.NewRequest("POST", "/features/f1/rename", nil), f, "title")
+
+	minimal := httptest.NewRecorder()
+	renderUpdated(minimal, httptest.NewRequest("POST", "/features/f1/rename?fields=changed", nil), f, "title")
+
+	if full.Header().Get("ETag") == "" || full.Header().Get("ETag") != minimal.Header().Get("ETag") {
+		t.Error("both responses should carry the ETag of the full feature")
+	}
+
+	var a, b map[string]interface{}
+	_ = json.Unmarshal(full.Body.Bytes(), &a)
+	_ = json.Unmarshal(minimal.Body.Bytes(), &b)
+
+	if a["description"] != "Long text" {
+		t.Error("full response should contain all fields")
+	}
+	if len(b) != 4 || b["title"] != "Renamed" || b["id"] != "f1" || b["lastModifiedByName"] != "Ann" || b["lastModified"] == nil {
+		t.Error("minimal response should only contain the title and the fields to reconcile", b)
+	}
+
+	req := httptest.NewRequest("POST", "/features/f1/rename", nil)
+	req.Header.Set("Prefer", "return=minimal")
+	if !wantsMinimal(req) {
+		t.Error("Prefer header should ask for a minimal response")
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+type rollup struct {
+	ID            string `json:"id"`
+	Features      int    `json:"features"`
+	Estimate      int    `json:"estimate"`
+	Progress      int    `json:"progress"`
+	LoggedMinutes int    `json:"loggedMinutes"`
+}
+
+type projectRollup struct {
+	Milestones   []*rollup `json:"milestones"`
+	SubWorkflows []*rollup `json:"subWorkflows"`
+}
+
+// rollupFeatures sums estimates and logged time and averages progress per milestone and per
+// subworkflow
+func rollupFeatures(ff []*Feature) *projectRollup {
+	milestones := map[string]*rollup{}
+	subWorkflows := map[string]*rollup{}
+	res := &projectRollup{Milestones: []*rollup{}, SubWorkflows: []*rollup{}}
+
+	progress := map[*rollup]int{}
+
+	add := func(m map[string]*rollup, list *[]*rollup, id string, f *Feature) {
+		x, ok := m[id]
+		if !ok {
+			x = &rollup{ID: id}
+			m[id] = x
+			*list = append(*list, x)
+		}
+		x.Features++
+		x.Estimate += f.Estimate
+		x.LoggedMinutes += f.LoggedMinutes
+		progress[x] += f.Progress
+	}
+
+	for _, f := range ff {
+		add(milestones, &res.Milestones, f.MilestoneID, f)
+		add(subWorkflows, &res.SubWorkflows, f.SubWorkflowID, f)
+	}
+
+	for x, p := range progress {
+		x.Progress = p / x.Features
+	}
+
+	return res
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRollupFeatures(t *testing.T) {
+	ff := []*Feature{
+		{ID: "1", MilestoneID: "m1", SubWorkflowID: "s1", Estimate: 3, Progress: 100},
+		{ID: "2", MilestoneID: "m1", SubWorkflowID: "s2", Estimate: 2, Progress: 50},
+		{ID: "3", MilestoneID: "m2", SubWorkflowID: "s1", Estimate: 5, Progress: 0},
+	}
+
+	got := rollupFeatures(ff)
+
+	if len(got.Milestones) != 2 || len(got.SubWorkflows) != 2 {
+		t.Fatal("wrong number of rollups")
+	}
+
+	m1 := got.Milestones[0]
+	if m1.ID != "m1" || m1.Features != 2 || m1.Estimate != 5 || m1.Progress != 75 {
+		t.Error("wrong milestone rollup", m1)
+	}
+
+	s1 := got.SubWorkflows[0]
+	if s1.ID != "s1" || s1.Features != 2 || s1.Estimate != 8 || s1.Progress != 50 {
+		t.Error("wrong subworkflow rollup", s1)
+	}
+}
+
+func TestRollupFeaturesEmpty(t *testing.T) {
+	got := rollupFeatures(nil)
+	if len(got.Milestones) != 0 || len(got.SubWorkflows) != 0 {
+		t.Error()
+	}
+}
+
+// brokenRollupRepo fails to read the features of a project
+type brokenRollupRepo struct {
+	rollupRepo
+}
+
+func (a *brokenRollupRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestRollupError(t *testing.T) {
+	aggregates = newMemoryCache()
+
+	s := memberService(&brokenRollupRepo{}, "EDITOR")
+
+	if x, err := s.GetRollupByProject("p1"); err == nil || x != nil {
+		t.Error("a failed read should be an error", x)
+	}
+
+	w := serveWith(s, "/v1/", workspaceAPI, httptest.NewRequest("GET", "/v1/projects/p1/rollup", nil))
+	if w.Code != 400 {
+		t.Error("a failed rollup should not answer an empty success", w.Code, w.Body.String())
+	}
+}
+
+func TestUpdateProgress(t *testing.T) {
+	repo := &newFeatureRepo{features: map[string]*Feature{"f1": {WorkspaceID: "ws", ID: "f1", Progress: 20}}}
+
+	s := memberService(repo, "EDITOR")
+
+	for _, progress := range []int{-1, 101} {
+		if _, err := s.UpdateProgressOnFeature("f1", progress); err == nil {
+			t.Error("progress outside 0 to 100 should be refused", progress)
+		}
+	}
+	if repo.features["f1"].Progress != 20 {
+		t.Error("refused progress should not be stored", repo.features["f1"].Progress)
+	}
+
+	for _, progress := range []int{0, 100} {
+		if f, err := s.UpdateProgressOnFeature("f1", progress); err != nil || repo.features["f1"].Progress != progress {
+			t.Error("progress from 0 to 100 should be stored", progress, f, err)
+		}
+	}
+}
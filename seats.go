@@ -0,0 +1,108 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Members with the INACTIVE level do not take up a seat. The level they had is kept in
+// InactiveLevel so it can be restored when they return.
+
+func (s *service) reclaimableMembers(workspaceID string, now time.Time) []*Member {
+	days := s.config.ReclaimSeatsAfterDays
+	if days <= 0 {
+		return []*Member{}
+	}
+
+	mm, err := s.r.FindSeatMembersInactiveSince(workspaceID, now.AddDate(0, 0, -days))
+	if err != nil {
+		log.Println(err)
+		return []*Member{}
+	}
+	return mm
+}
+
+func (s *service) deactivate(m *Member) {
+	m.InactiveLevel = m.Level
+	m.Level = "INACTIVE"
+	s.r.StoreMember(m)
+}
+
+// GetReclaimableMembers lists the members of the workspace whose seat can be reclaimed
+func (s *service) GetReclaimableMembers() []*Member {
+	return s.reclaimableMembers(s.Member.WorkspaceID, time.Now().UTC())
+}
+
+func (s *service) DeactivateMember(id string) (*Member, error) {
+	member, err := s.r.GetMember(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if member.ID == s.Member.ID {
+		return nil, errors.New("not allowed to deactivate own membership")
+	}
+
+	if !(member.Level == "EDITOR" || member.Level == "ADMIN") {
+		return nil, errors.New("only editors and admins can be deactivated")
+	}
+
+	s.deactivate(member)
+
+	return member, nil
+}
+
+// errNoFreeSeat is returned when an inactive member returns to a subscription without a free seat
+var errNoFreeSeat = errors.New("no free seat")
+
+// ReactivateMember restores the level of a returning inactive member. If the subscription has
+// no free seat left they stay inactive, with their level kept for when a seat frees up.
+func (s *service) ReactivateMember() error {
+	m := s.Member
+	if m.Level != "INACTIVE" {
+		return nil
+	}
+
+	level := m.InactiveLevel
+	if !levelIsValid(level) {
+		level = "VIEWER"
+	}
+	if isEditor(level) && (s.Subscription == nil || s.numberOfEditors() >= s.Subscription.NumberOfEditors) {
+		return errNoFreeSeat
+	}
+
+	m.Level = level
+	m.InactiveLevel = ""
+	s.r.StoreMember(m)
+
+	if s.Acc != nil {
+		s.UpdateLatestActivityNow()
+	}
+	return nil
+}
+
+// ReclaimInactiveSeats deactivates the reclaimable members of every workspace when the
+// automatic mode is enabled. It runs outside of a request.
+func (s *service) ReclaimInactiveSeats(now time.Time) int {
+	if !s.config.ReclaimSeatsAuto {
+		return 0
+	}
+
+	ww, err := s.r.FindAllWorkspaces()
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
+	n := 0
+	for _, w := range ww {
+		for _, m := range s.reclaimableMembers(w.ID, now) {
+			s.deactivate(m)
+			n++
+		}
+	}
+
+	return n
+}
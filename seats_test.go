@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// seatRepo keeps the members of a single workspace in memory
+type seatRepo struct {
+	Repository
+	members  []*Member
+	activity map[string]time.Time
+}
+
+func (a *seatRepo) FindMembersByWorkspace(id string) ([]*Member, error) {
+	return a.members, nil
+}
+
+func (a *seatRepo) GetMember(workspaceID string, id string) (*Member, error) {
+	for _, m := range a.members {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *seatRepo) StoreMember(x *Member) {}
+
+func (a *seatRepo) StoreAccount(x *Account) {
+	a.activity[x.ID] = x.LatestActivity
+}
+
+func (a *seatRepo) FindAllWorkspaces() ([]*Workspace, error) {
+	return []*Workspace{{ID: "ws"}}, nil
+}
+
+func (a *seatRepo) FindSeatMembersInactiveSince(workspaceID string, t time.Time) ([]*Member, error) {
+	x := []*Member{}
+	for _, m := range a.members {
+		if (m.Level == "EDITOR" || m.Level == "ADMIN") && a.activity[m.AccountID].Before(t) {
+			x = append(x, m)
+		}
+	}
+	return x, nil
+}
+
+func TestReclaimInactiveSeats(t *testing.T) {
+	now := time.Now().UTC()
+	repo := &seatRepo{
+		members: []*Member{
+			{ID: "owner", AccountID: "a-owner", WorkspaceID: "ws", Level: "OWNER"},
+			{ID: "bob", AccountID: "a-bob", WorkspaceID: "ws", Level: "EDITOR"},
+			{ID: "cat", AccountID: "a-cat", WorkspaceID: "ws", Level: "EDITOR"},
+		},
+		activity: map[string]time.Time{"a-owner": now, "a-bob": now.AddDate(0, 0, -100), "a-cat": now},
+	}
+	sub := &Subscription{NumberOfEditors: 3}
+
+	s := &service{}
+	s.SetRepoObject(repo)
+	s.SetConfig(Configuration{ReclaimSeatsAfterDays: 90})
+	s.SetMemberObject(repo.members[0])
+	s.SetSubscriptionObject(sub)
+
+	if mm := s.GetReclaimableMembers(); len(mm) != 1 || mm[0].ID != "bob" {
+		t.Error("only the inactive editor should be reclaimable", mm)
+	}
+	if n := s.ReclaimInactiveSeats(now); n != 0 {
+		t.Error("seats should not be reclaimed automatically unless enabled")
+	}
+	if _, err := s.DeactivateMember("owner"); err == nil {
+		t.Error("owner should not be deactivated")
+	}
+
+	before := s.numberOfEditors()
+	bob, err := s.DeactivateMember("bob")
+	if err != nil || bob.Level != "INACTIVE" || bob.InactiveLevel != "EDITOR" {
+		t.Error("bob should be inactive", bob, err)
+	}
+	if s.numberOfEditors() != before-1 {
+		t.Error("inactive member should free a seat")
+	}
+
+	returning := &service{}
+	returning.SetRepoObject(repo)
+	returning.SetMemberObject(bob)
+	returning.SetAccountObject(&Account{ID: "a-bob"})
+	returning.SetSubscriptionObject(sub)
+	if err := returning.ReactivateMember(); err != nil {
+		t.Fatal(err)
+	}
+	if bob.Level != "EDITOR" || bob.InactiveLevel != "" || !repo.activity["a-bob"].After(now) {
+		t.Error("returning member should get the level back", bob)
+	}
+
+	repo.activity["a-bob"] = now.AddDate(0, 0, -100)
+	s.SetConfig(Configuration{ReclaimSeatsAfterDays: 90, ReclaimSeatsAuto: true})
+	if n := s.ReclaimInactiveSeats(now); n != 1 || bob.Level != "INACTIVE" {
+		t.Error("automatic mode should deactivate the inactive member", n)
+	}
+
+	sub.NumberOfEditors = 2
+	if err := returning.ReactivateMember(); err != errNoFreeSeat {
+		t.Error("returning member should be refused without a free seat", err)
+	}
+	if bob.Level != "INACTIVE" || bob.InactiveLevel != "EDITOR" {
+		t.Error("returning member should stay inactive with the level kept without a free seat", bob)
+	}
+}
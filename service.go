@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -31,15 +35,20 @@ type Service interface {
 	SetAuth(x *jwtauth.JWTAuth)
 	SetWorkspaceObject(a *Workspace)
 	SetSubscriptionObject(x *Subscription)
+	SetRequestID(x string)
 	UpdateLatestActivityNow()
+	AfterCommit(f func())
+	RunAfterCommit(committed bool)
 
 	GetConfig() Configuration
 	GetDBObject() *sqlx.DB
 	GetRepoObject() Repository
+	DryRun(f func() error) error
 	GetMemberObject() *Member
 	GetAccountObject() *Account
 	GetWorkspaceObject() *Workspace
 	GetSubscriptionObject() *Subscription
+	GetRequestID() string
 
 	SendEmail(smtpServer string, smtpPort string, smtpUser string, smtpPass string, from string, recipient string, subject string, body string) error
 
@@ -47,8 +56,23 @@ type Service interface {
 	Login(email string, password string) (*Account, error)
 	Token(accountID string) string
 	DeleteAccount() error
-
-	CreateWorkspace(name string) (*Workspace, *Subscription, *Member, error)
+	PurgeUnverifiedAccounts(now time.Time) int
+	MergeDuplicateAccounts() int
+	ReportUsage(now time.Time) bool
+	AdminGetDuplicateAccounts() ([][]*Account, error)
+	AdminMergeAccounts(email string, canonicalID string) (*Account, error)
+	GetReclaimableMembers() []*Member
+	DeactivateMember(id string) (*Member, error)
+	ReactivateMember() error
+	ReclaimInactiveSeats(now time.Time) int
+	EscalateBlockedFeatures(now time.Time) int
+	SendNotificationDigests(now time.Time) int
+	SendNotificationBatches(now time.Time) int
+	Bootstrap() bool
+
+	CreateWorkspace(name string, settings workspaceSettings) (*Workspace, *Subscription, *Member, error)
+	UpdateWorkspaceDefaults(x workspaceSettings) (*Account, error)
+	CloneWorkspace(sourceID string, name string) (*Workspace, error)
 	GetWorkspace(id string) (*Workspace, error)
 	GetWorkspaceByContext() *Workspace
 	GetWorkspaces() []*Workspace
@@ -66,12 +90,15 @@ type Service interface {
 	ConfirmEmail(key string) error
 	UpdateEmail(email string) error
 	UpdateName(name string) error
+	UpdateLocale(locale string) error
+	ExportAccountData() (*accountExport, error)
 	ResendEmail() error
 	SendResetEmail(email string) error
 	SetPassword(password string, key string) error
 
 	GetMember(accountID string, workspaceID string) (*Member, error)
 	GetMembersByAccount() []*Member
+	GetMembershipsByAccount() []*Membership
 	GetMembers() []*Member
 	GetMembersByWorkspace(id string) []*Member
 	UpdateMemberLevel(memberID string, level string) (*Member, error)
@@ -80,6 +107,17 @@ type Service interface {
 	Leave() error
 
 	ChangeAllowExternalSharing(value bool) error
+	ChangeArchiveDeletedProjects(value bool) error
+	PreviewEmail(kind string, source string) (*emailPreview, error)
+	GetFeatureHistory(id string, offset int, limit int) ([]*featureHistoryEntry, error)
+	GetProjectArchives() []*ProjectArchive
+	GetProjectArchive(id string) (*ProjectArchive, error)
+	PurgeProjectArchives(now time.Time)
+	ChangeViewerRedactions(value string) error
+	ChangeTimezone(value string) error
+	ChangeNotificationBatchWindows(value string) error
+	ChangeAutoJoin(domains string, level string) error
+	ChangeEstimateBounds(min int, max int) error
 	ChangeGeneralInfo(EUVAT string, externalBillingEmail string) error
 
 	GetInvitesByWorkspace() []*Invite
@@ -90,23 +128,53 @@ type Service interface {
 	GetInvite(code string) (*Invite, error)
 
 	GetProjectByExternalLink(link string) (*Project, error)
+	RecordShareLinkView(p *Project)
+	GetShareLinks() []*shareLink
+	RenewShareLink(projectID string) (*Project, error)
+	RevokeStaleShareLinks(now time.Time) int
 	GetProjectExtendedByExternalLink(link string) (*projectResponse, error)
 	GetProject(id string) *Project
+	FavoriteProject(id string) error
+	LinkProjects(id string, relatedID string) error
+	GetGoalsByProject(projectID string) []*Goal
+	CreateGoalWithID(id string, projectID string, title string, description string, targetDate string) (*Goal, error)
+	UpdateGoal(id string, projectID string, title string, description string, targetDate string) (*Goal, error)
+	DeleteGoal(id string, projectID string) error
+	LinkGoalToMilestone(id string, projectID string, milestoneID string) error
+	UnlinkGoalFromMilestone(id string, projectID string, milestoneID string) error
+	UnlinkProjects(id string, relatedID string) error
+	GetRelatedProjects(id string) []*Project
+	UnfavoriteProject(id string) error
 	CreateProjectWithID(id string, title string) (*Project, error)
+	ImportOutline(title string, o *outline) (*Project, error)
+	ImportExport(title string, d *exportDocument) (*Project, *exportReport, error)
 	RenameProject(id string, title string) (*Project, error)
 	DeleteProject(id string) error
 	GetProjects() []*Project
+	GetRecentChanges(since time.Time, limit int) ([]*RecentChange, error)
+	Resolve(kind string, id string) (*Breadcrumb, error)
+	GetAnnotationUsage(sortByUsage bool) ([]*AnnotationUsage, error)
 	UpdateProjectDescription(id string, d string) (*Project, error)
+	UpdateAutoCloseOnProject(id string, days int) (*Project, error)
+	UpdateDefaultAnnotationsOnProject(id string, names string) (*Project, error)
+	UpdateDefaultPlacementOnProject(id string, milestoneID string, subWorkflowID string) (*Project, error)
+	FeaturePlacement(projectID string, milestoneID string, subWorkflowID string) (string, string, error)
+	UpdateEstimateSettingsOnProject(id string, require bool, defaultEstimate int) (*Project, error)
+	CloseStaleFeatures(now time.Time) int
 
 	CreateMilestoneWithID(id string, projectID string, title string) (*Milestone, error)
 	MoveMilestone(id string, index int) (*Milestone, error)
+	ShiftMilestone(id string, delta int) ([]*Milestone, error)
 	RenameMilestone(id string, title string) (*Milestone, error)
 	GetMilestonesByProject(id string) []*Milestone
+	GetMilestoneTree(id string) (*milestoneTreeResponse, error)
+	GetBurndownByMilestone(id string, from time.Time, to time.Time, loc *time.Location) ([]*burndownPoint, error)
 	DeleteMilestone(id string) error
 	UpdateMilestoneDescription(id string, d string) (*Milestone, error)
 	CloseMilestone(id string) (*Milestone, error)
 	OpenMilestone(id string) (*Milestone, error)
 	ChangeColorOnMilestone(id string, color string) (*Milestone, error)
+	ChangeIconOnMilestone(id string, icon string) (*Milestone, error)
 	UpdateAnnotationsOnMilestone(id string, names string) (*Milestone, error)
 
 	GetWorkflowsByProject(id string) []*Workflow
@@ -133,15 +201,41 @@ type Service interface {
 
 	GetFeaturesByProject(id string) []*Feature
 	MoveFeature(id string, toMilestoneID string, toSubWorkflowID string, index int) (*Feature, error)
-	CreateFeatureWithID(id string, subWorkflowID string, milestoneID string, title string) (*Feature, error)
+	CreateFeatureWithID(id string, subWorkflowID string, milestoneID string, title string, estimate *int) (*Feature, error)
+	CreateFeatures(milestoneID string, subWorkflowID string, features []*newFeature) ([]*Feature, error)
 	RenameFeature(id string, title string) (*Feature, error)
 	DeleteFeature(id string) error
 	UpdateFeatureDescription(id string, d string) (*Feature, error)
 	CloseFeature(id string) (*Feature, error)
 	OpenFeature(id string) (*Feature, error)
 	ChangeColorOnFeature(id string, color string) (*Feature, error)
+	PatchFeature(id string, p featurePatch) (*Feature, error)
+	ReplaceFeature(id string, title string, description string, color string, icon string, annotations string, estimate int, progress int) (*Feature, error)
+	ChangeIconOnFeature(id string, icon string) (*Feature, error)
+	DuplicateFeature(id string, newID string, copyAnnotations bool, copyComments bool) (*Feature, error)
 	UpdateAnnotationsOnFeature(id string, names string) (*Feature, error)
 	UpdateEstimateOnFeature(id string, estimate int) (*Feature, error)
+	UpdateProgressOnFeature(id string, progress int) (*Feature, error)
+	GetFeatureContext(id string) (*featureContextResponse, error)
+	BulkMoveFeatures(projectID string, ids []string, toMilestoneID string, toSubWorkflowID string) ([]*Feature, error)
+	BulkMoveColumns(projectID string, subWorkflowIDs []string, fromMilestoneID string, toMilestoneID string) ([]*Feature, error)
+	BulkAnnotateFeatures(projectID string, filter featureFilter, add []string, remove []string) (int, error)
+	WatchFeature(id string) error
+	UnwatchFeature(id string) error
+
+	RequestAccess(projectID string, id string, level string) (*AccessRequest, error)
+	GetAccessRequests(projectID string) []*AccessRequest
+	ApproveAccessRequest(projectID string, id string) (*AccessRequest, error)
+	DenyAccessRequest(projectID string, id string) (*AccessRequest, error)
+
+	AddFeatureReference(featureID string, id string, label string, link string) (*FeatureReference, error)
+	DeleteFeatureReference(featureID string, id string) error
+	GetTimeEntries(featureID string) ([]*TimeEntry, error)
+	LogTime(featureID string, id string, minutes int, note string) (*TimeEntry, error)
+	UpdateTimeEntry(featureID string, id string, minutes int, note string) (*TimeEntry, error)
+	DeleteTimeEntry(featureID string, id string) error
+	GetRollupByProject(id string) (*projectRollup, error)
+	GetProjectDiagram(id string) (*projectDiagram, error)
 
 	GetFeatureCommentsByProject(id string) []*FeatureComment
 	CreateFeatureCommentWithID(id string, featureID string, post string) (*FeatureComment, error)
@@ -149,6 +243,11 @@ type Service interface {
 	DeleteFeatureComment(id string) error
 
 	GetPersonasByProject(id string) []*Persona
+	GetProjectStatusesByProject(id string) []*ProjectStatus
+	CreateProjectStatusWithID(id string, projectID string, title string, closed bool) (*ProjectStatus, error)
+	UpdateProjectStatus(id string, title string, closed bool) (*ProjectStatus, error)
+	DeleteProjectStatus(id string) error
+	SetStatusOnFeature(id string, statusID string) (*Feature, error)
 	GetWorkflowPersonasByProject(id string) []*WorkflowPersona
 
 	CreateWorkflowPersonaWithID(id string, workflowID string, personaID string) (*WorkflowPersona, error)
@@ -157,6 +256,13 @@ type Service interface {
 	CreatePersonaWithID(id string, projectID string, avatar string, name string, role string, description string, workflowID string, workflowPersonaID string) (*Persona, error)
 	DeletePersona(id string) error
 	UpdatePersona(id string, avatar string, name string, role string, description string) (*Persona, error)
+
+	IsSuperuser() bool
+	AdminGetWorkspaces() ([]*Workspace, error)
+	AdminGetAccounts() ([]*Account, error)
+	AdminSetWorkspaceSuspended(id string, suspended bool) (*Workspace, error)
+	AdminResetPassword(accountID string) error
+	AdminGetStats() (*InstanceStats, error)
 }
 
 type service struct {
@@ -167,6 +273,8 @@ type service struct {
 	r            Repository
 	auth         *jwtauth.JWTAuth
 	ws           *Workspace
+	requestID    string
+	afterCommit  []func()
 }
 
 // NewFeatmapService ...
@@ -182,6 +290,23 @@ func (s *service) SetRepoObject(m Repository)            { s.r = m }
 func (s *service) SetAuth(x *jwtauth.JWTAuth)            { s.auth = x }
 func (s *service) SetWorkspaceObject(a *Workspace)       { s.ws = a }
 func (s *service) SetSubscriptionObject(x *Subscription) { s.Subscription = x }
+func (s *service) SetRequestID(x string)                 { s.requestID = x }
+
+// AfterCommit queues f to run once the transaction of the request or job has committed, so that a
+// retried or failed transaction does not send anything
+func (s *service) AfterCommit(f func()) { s.afterCommit = append(s.afterCommit, f) }
+
+// RunAfterCommit runs what was queued with AfterCommit if the transaction committed, and forgets it
+func (s *service) RunAfterCommit(committed bool) {
+	ff := s.afterCommit
+	s.afterCommit = nil
+	if !committed {
+		return
+	}
+	for _, f := range ff {
+		f()
+	}
+}
 
 func (s *service) GetConfig() Configuration             { return s.config }
 func (s *service) GetDBObject() *sqlx.DB                { return s.r.DB() }
@@ -190,6 +315,16 @@ func (s *service) GetAccountObject() *Account           { return s.Acc }
 func (s *service) GetSubscriptionObject() *Subscription { return s.Subscription }
 func (s *service) GetMemberObject() *Member             { return s.Member }
 func (s *service) GetWorkspaceObject() *Workspace       { return s.ws }
+func (s *service) GetRequestID() string                 { return s.requestID }
+
+// outboundHeaders returns the headers to attach to calls made to external services
+func (s *service) outboundHeaders() http.Header {
+	h := http.Header{}
+	if s.requestID != "" {
+		h.Set(s.config.RequestIDHeader, s.requestID)
+	}
+	return h
+}
 
 func (s *service) UpdateLatestActivityNow() {
 	acc := s.GetAccountObject()
@@ -198,6 +333,27 @@ func (s *service) UpdateLatestActivityNow() {
 }
 
 func (s *service) Register(workspaceName string, name string, email string, password string) (*Workspace, *Account, *Member, error) {
+	workspace, acc, member, err := s.signUp(workspaceName, name, email, password)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	body, err := WelcomeBody(welcome{s.config.AppSiteURL, acc.EmailConfirmationSentTo, workspace.Name, acc.EmailConfirmationKey})
+	if err != nil {
+		log.Println(err)
+		return nil, nil, nil, err
+	}
+
+	err = s.SendEmail(s.config.SMTPServer, s.config.SMTPPort, s.config.SMTPUser, s.config.SMTPPass, s.config.EmailFrom, acc.EmailConfirmationSentTo, "Welcome to Featmap!", body)
+	if err != nil {
+		log.Println("error sending mail")
+	}
+
+	return workspace, acc, member, nil
+}
+
+// signUp creates an account and a workspace it owns, without telling anyone
+func (s *service) signUp(workspaceName string, name string, email string, password string) (*Workspace, *Account, *Member, error) {
 
 	workspaceName = govalidator.Trim(workspaceName, "")
 	name = govalidator.Trim(name, "")
@@ -243,11 +399,15 @@ func (s *service) Register(workspaceName string, name string, email string, pass
 		Name:                 workspaceName,
 		CreatedAt:            t,
 		AllowExternalSharing: true,
+		AutoJoinLevel:        "VIEWER",
 		EUVAT:                "",
 		ExternalBillingEmail: email,
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	acc := &Account{
 		ID:                       uuid.Must(uuid.NewV4(), nil).String(),
 		Name:                     name,
@@ -259,6 +419,9 @@ func (s *service) Register(workspaceName string, name string, email string, pass
 		EmailConfirmationKey:     uuid.Must(uuid.NewV4(), nil).String(),
 		EmailConfirmationPending: true,
 		PasswordResetKey:         uuid.Must(uuid.NewV4(), nil).String(),
+
+		DefaultAllowExternalSharing: true,
+		DefaultAutoJoinLevel:        "VIEWER",
 	}
 
 	sub := &Subscription{
@@ -300,17 +463,6 @@ func (s *service) Register(workspaceName string, name string, email string, pass
 	s.SetSubscriptionObject(sub)
 	s.SetMemberObject(member)
 
-	body, err := WelcomeBody(welcome{s.config.AppSiteURL, acc.EmailConfirmationSentTo, workspace.Name, acc.EmailConfirmationKey})
-	if err != nil {
-		log.Println(err)
-		return nil, nil, nil, err
-	}
-
-	err = s.SendEmail(s.config.SMTPServer, s.config.SMTPPort, s.config.SMTPUser, s.config.SMTPPass, s.config.EmailFrom, acc.EmailConfirmationSentTo, "Welcome to Featmap!", body)
-	if err != nil {
-		log.Println("error sending mail")
-	}
-
 	return workspace, acc, member, nil
 }
 
@@ -327,6 +479,64 @@ func (s *service) DeleteAccount() error {
 	return nil
 }
 
+// PurgeUnverifiedAccounts deletes accounts that never confirmed their email and have been inactive
+// for the configured number of days, together with the empty workspaces they own. Accounts owning
+// a workspace with projects or other members are kept. It runs outside of a request.
+func (s *service) PurgeUnverifiedAccounts(now time.Time) int {
+	days := s.config.PurgeUnverifiedAfterDays
+	if days <= 0 {
+		return 0
+	}
+
+	aa, err := s.r.FindUnverifiedAccountsInactiveSince(now.AddDate(0, 0, -days))
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
+	n := 0
+	for _, a := range aa {
+		if a.EmailConfirmed {
+			continue
+		}
+
+		members, _ := s.r.GetMembersByAccount(a.ID)
+
+		owned := []string{}
+		keep := false
+		for _, m := range members {
+			if m.Level != "OWNER" {
+				continue
+			}
+			mm, _ := s.r.FindMembersByWorkspace(m.WorkspaceID)
+			pp, _ := s.r.FindProjectsByWorkspace(m.WorkspaceID)
+			if len(mm) > 1 || len(pp) > 0 {
+				keep = true
+				break
+			}
+			owned = append(owned, m.WorkspaceID)
+		}
+		if keep {
+			continue
+		}
+
+		if s.config.PurgeUnverifiedDryRun {
+			log.Printf("purge unverified: would delete account %s (%s) created %s and workspaces %v", a.ID, a.Email, a.CreatedAt.Format(time.RFC3339), owned)
+			n++
+			continue
+		}
+
+		for _, id := range owned {
+			s.r.DeleteWorkspace(id)
+		}
+		s.r.DeleteAccount(a.ID)
+		log.Printf("purge unverified: deleted account %s (%s) created %s and workspaces %v", a.ID, a.Email, a.CreatedAt.Format(time.RFC3339), owned)
+		n++
+	}
+
+	return n
+}
+
 func (s *service) Login(email string, password string) (*Account, error) {
 
 	acc, err := s.r.GetAccountByEmail(strings.ToLower(email))
@@ -387,9 +597,65 @@ func workspaceNameIsValid(name string) bool {
 	return !(len(name) < 2 || len(name) > 200 || !govalidator.IsAlphanumeric(name) || name == "account" || name == "link")
 }
 
-func (s *service) CreateWorkspace(name string) (*Workspace, *Subscription, *Member, error) {
+// workspaceLimitError is returned when an account already owns the maximum number of workspaces
+type workspaceLimitError struct {
+	Message string `json:"message"`
+	Current int    `json:"current"`
+	Allowed int    `json:"allowed"`
+}
+
+func (e *workspaceLimitError) Error() string { return e.Message }
+
+func workspaceLimitReached(owned int, allowed int, exempt bool) bool {
+	return allowed > 0 && !exempt && owned >= allowed
+}
+
+// checkWorkspaceLimit enforces maxWorkspacesPerAccount for the current account
+func (s *service) checkWorkspaceLimit() error {
+	if s.config.MaxWorkspacesPerAccount <= 0 {
+		return nil
+	}
+
+	members, err := s.r.GetMembersByAccount(s.Acc.ID)
+	if err != nil {
+		return err
+	}
+
+	owned := map[string]bool{}
+	for _, m := range members {
+		if m.Level == "OWNER" {
+			owned[m.WorkspaceID] = true
+		}
+	}
+
+	exempt := false
+	subs, _ := s.r.FindSubscriptionsByAccount(s.Acc.ID)
+	for _, sub := range subs {
+		if !owned[sub.WorkspaceID] {
+			continue
+		}
+		for _, tier := range s.config.WorkspaceLimitExemptTiers {
+			if sub.Level == tier {
+				exempt = true
+			}
+		}
+	}
+
+	if workspaceLimitReached(len(owned), s.config.MaxWorkspacesPerAccount, exempt) {
+		return &workspaceLimitError{Message: "workspace_limit_reached", Current: len(owned), Allowed: s.config.MaxWorkspacesPerAccount}
+	}
+	return nil
+}
+
+// CreateWorkspace creates a workspace owned by the account, with the workspace defaults of the
+// account overridden by settings
+func (s *service) CreateWorkspace(name string, settings workspaceSettings) (*Workspace, *Subscription, *Member, error) {
 	name = govalidator.Trim(name, "")
 
+	if err := s.checkWorkspaceLimit(); err != nil {
+		return nil, nil, nil, err
+	}
+
 	if !workspaceNameIsValid(name) {
 		return nil, nil, nil, errors.New("workspace_invalid")
 	}
@@ -405,9 +671,16 @@ func (s *service) CreateWorkspace(name string) (*Workspace, *Subscription, *Memb
 		Name:                 name,
 		CreatedAt:            t,
 		AllowExternalSharing: true,
+		AutoJoinLevel:        "VIEWER",
 		EUVAT:                "",
 		ExternalBillingEmail: s.Acc.Email,
 	}
+	applyAccountDefaults(s.Acc, workspace)
+	settings.applyTo(workspace)
+	if err := validateWorkspaceSettings(workspace); err != nil {
+		return nil, nil, nil, err
+	}
+
 	subscription := &Subscription{
 		ID:                 uuid.Must(uuid.NewV4(), nil).String(),
 		WorkspaceID:        workspace.ID,
@@ -436,6 +709,134 @@ func (s *service) CreateWorkspace(name string) (*Workspace, *Subscription, *Memb
 	return workspace, subscription, member, nil
 }
 
+// CloneWorkspace creates a new workspace with the settings and project structure of an
+// existing one. Members, features and comments are not copied.
+func (s *service) CloneWorkspace(sourceID string, name string) (*Workspace, error) {
+	m, err := s.r.GetMemberByAccountAndWorkspace(s.Acc.ID, sourceID)
+	if err != nil || !(m.Level == "ADMIN" || m.Level == "OWNER") {
+		return nil, errors.New("not allowed")
+	}
+
+	source, err := s.r.GetWorkspace(sourceID)
+	if err != nil {
+		return nil, errors.New("workspace not found")
+	}
+
+	// Everything is read before anything is stored, so a failed read leaves no half clone behind
+	pp, err := s.r.FindProjectsByWorkspace(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	contents := make([]*projectResponse, len(pp))
+	for i, p := range pp {
+		x := &projectResponse{Project: p}
+		if x.Milestones, err = s.r.FindMilestonesByProject(sourceID, p.ID); err != nil {
+			return nil, err
+		}
+		if x.Workflows, err = s.r.FindWorkflowsByProject(sourceID, p.ID); err != nil {
+			return nil, err
+		}
+		if x.SubWorkflows, err = s.r.FindSubWorkflowsByProject(sourceID, p.ID); err != nil {
+			return nil, err
+		}
+		if x.Personas, err = s.r.FindPersonasByProject(sourceID, p.ID); err != nil {
+			return nil, err
+		}
+		if x.WorkflowPersonas, err = s.r.FindWorkflowPersonasByProject(sourceID, p.ID); err != nil {
+			return nil, err
+		}
+		if x.Statuses, err = s.r.FindProjectStatusesByProject(sourceID, p.ID); err != nil {
+			return nil, err
+		}
+		contents[i] = x
+	}
+
+	workspace, _, _, err := s.CreateWorkspace(name, workspaceSettings{})
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.AllowExternalSharing = source.AllowExternalSharing
+	workspace.ViewerRedactions = source.ViewerRedactions
+	workspace.NotificationBatchWindows = source.NotificationBatchWindows
+	workspace.Timezone = source.Timezone
+	workspace.MinEstimate, workspace.MaxEstimate = source.MinEstimate, source.MaxEstimate
+	s.r.StoreWorkspace(workspace)
+
+	t := time.Now().UTC()
+	newID := func() string { return uuid.Must(uuid.NewV4(), nil).String() }
+
+	for _, c := range contents {
+		p := c.Project
+		milestones, workflows, subWorkflows := c.Milestones, c.Workflows, c.SubWorkflows
+		personas, workflowPersonas, statuses := c.Personas, c.WorkflowPersonas, c.Statuses
+
+		defaultMilestoneID, defaultSubWorkflowID := p.DefaultMilestoneID, p.DefaultSubWorkflowID
+		p.WorkspaceID = workspace.ID
+		p.ID = newID()
+		p.DefaultMilestoneID, p.DefaultSubWorkflowID = "", ""
+		p.ExternalLink = newID()
+		p.ExternalLinkCreatedAt, p.ExternalLinkCreatedBy = t, ""
+		p.ExternalLinkViewedAt, p.ExternalLinkRevokedAt = nil, nil
+		p.CreatedAt, p.CreatedByName = t, s.Acc.Name
+		p.LastModified, p.LastModifiedByName = t, s.Acc.Name
+		s.r.StoreProject(p)
+
+		milestoneIDs := map[string]string{}
+		for _, x := range milestones {
+			milestoneIDs[x.ID] = newID()
+			x.WorkspaceID, x.ProjectID, x.ID = workspace.ID, p.ID, milestoneIDs[x.ID]
+			x.CreatedAt, x.CreatedByName = t, s.Acc.Name
+			x.LastModified, x.LastModifiedByName = t, s.Acc.Name
+			s.r.StoreMilestone(x)
+		}
+
+		workflowIDs := map[string]string{}
+		for _, x := range workflows {
+			workflowIDs[x.ID] = newID()
+			x.WorkspaceID, x.ProjectID, x.ID = workspace.ID, p.ID, workflowIDs[x.ID]
+			x.CreatedAt, x.CreatedByName = t, s.Acc.Name
+			x.LastModified, x.LastModifiedByName = t, s.Acc.Name
+			s.r.StoreWorkflow(x)
+		}
+
+		subWorkflowIDs := map[string]string{}
+		for _, x := range subWorkflows {
+			subWorkflowIDs[x.ID] = newID()
+			x.WorkspaceID, x.WorkflowID, x.ID = workspace.ID, workflowIDs[x.WorkflowID], subWorkflowIDs[x.ID]
+			x.CreatedAt, x.CreatedByName = t, s.Acc.Name
+			x.LastModified, x.LastModifiedByName = t, s.Acc.Name
+			s.r.StoreSubWorkflow(x)
+		}
+
+		p.DefaultMilestoneID = milestoneIDs[defaultMilestoneID]
+		p.DefaultSubWorkflowID = subWorkflowIDs[defaultSubWorkflowID]
+		s.r.StoreProject(p)
+
+		personaIDs := map[string]string{}
+		for _, x := range personas {
+			personaIDs[x.ID] = newID()
+			x.WorkspaceID, x.ProjectID, x.ID = workspace.ID, p.ID, personaIDs[x.ID]
+			x.CreatedAt = t
+			s.r.StorePersona(x)
+		}
+
+		for _, x := range workflowPersonas {
+			x.WorkspaceID, x.ProjectID, x.ID = workspace.ID, p.ID, newID()
+			x.WorkflowID, x.PersonaID = workflowIDs[x.WorkflowID], personaIDs[x.PersonaID]
+			s.r.StoreWorkflowPersona(x)
+		}
+
+		for _, x := range statuses {
+			x.WorkspaceID, x.ProjectID, x.ID = workspace.ID, p.ID, newID()
+			x.CreatedAt = t
+			s.r.StoreProjectStatus(x)
+		}
+	}
+
+	return workspace, nil
+}
+
 func (s *service) GetWorkspace(id string) (*Workspace, error) {
 
 	workspace, err := s.r.GetWorkspace(id)
@@ -501,6 +902,7 @@ func (s *service) UpdateMemberLevel(memberID string, level string) (*Member, err
 	}
 
 	member.Level = level
+	member.InactiveLevel = ""
 
 	s.r.StoreMember(member)
 
@@ -544,6 +946,11 @@ func isEditor(level string) bool {
 	return level == "EDITOR" || level == "ADMIN" || level == "OWNER"
 }
 
+// canComment tells if a level may comment. Commenters do not take up a seat.
+func canComment(level string) bool {
+	return level == "COMMENTER" || isEditor(level)
+}
+
 func (s *service) CreateMember(workspaceID string, accountID string, level string) (*Member, error) {
 	sub := s.GetSubscriptionByWorkspace(workspaceID)
 
@@ -589,6 +996,15 @@ func (s *service) GetMember(accountID string, workspaceID string) (*Member, erro
 	return member, nil
 }
 
+func (s *service) GetMembershipsByAccount() []*Membership {
+	mm, err := s.r.FindMembershipsByAccount(s.Acc.ID)
+	if err != nil {
+		log.Println(err)
+		return []*Membership{}
+	}
+	return mm
+}
+
 func (s *service) GetMembersByAccount() []*Member {
 
 	members, err := s.r.GetMembersByAccount(s.Acc.ID)
@@ -629,6 +1045,86 @@ func (s *service) ChangeAllowExternalSharing(value bool) error {
 	return nil
 }
 
+func (s *service) ChangeViewerRedactions(value string) error {
+
+	if !redactionsAreValid(value) {
+		return errors.New("invalid redactions")
+	}
+
+	w := s.GetWorkspaceByContext()
+
+	w.ViewerRedactions = value
+
+	s.r.StoreWorkspace(w)
+
+	return nil
+}
+
+// ChangeTimezone sets the time zone the days of the workspace are counted in, an IANA name
+// such as Europe/Oslo. Empty is UTC.
+func (s *service) ChangeTimezone(value string) error {
+
+	if _, err := time.LoadLocation(value); err != nil {
+		return errors.New("invalid time zone")
+	}
+
+	w := s.GetWorkspaceByContext()
+
+	w.Timezone = value
+
+	s.r.StoreWorkspace(w)
+
+	return nil
+}
+
+func (s *service) ChangeAutoJoin(domains string, level string) error {
+
+	domains = strings.ToLower(strings.ReplaceAll(domains, " ", ""))
+	if domains != "" {
+		for _, d := range strings.Split(domains, ",") {
+			if !govalidator.IsDNSName(d) || !strings.Contains(d, ".") {
+				return errors.New("invalid domain")
+			}
+		}
+	}
+
+	if !(level == "VIEWER" || level == "COMMENTER" || level == "EDITOR") {
+		return errors.New("invalid level")
+	}
+
+	w := s.GetWorkspaceByContext()
+
+	w.AutoJoinDomains = domains
+	w.AutoJoinLevel = level
+
+	s.r.StoreWorkspace(w)
+
+	return nil
+}
+
+// autoJoinWorkspaces makes a verified account a member of the workspaces that auto-join its email domain
+func (s *service) autoJoinWorkspaces(a *Account) {
+	at := strings.LastIndex(a.Email, "@")
+	if !a.EmailConfirmed || at < 0 {
+		return
+	}
+
+	ww, err := s.r.FindWorkspacesByAutoJoinDomain(strings.ToLower(a.Email[at+1:]))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, w := range ww {
+		if m, _ := s.r.GetMemberByAccountAndWorkspace(a.ID, w.ID); m != nil {
+			continue
+		}
+		if _, err := s.CreateMember(w.ID, a.ID, w.AutoJoinLevel); err != nil {
+			log.Println("auto-join " + w.Name + ": " + err.Error())
+		}
+	}
+}
+
 func (s *service) ChangeGeneralInfo(EUVAT string, externalBillingInfo string) error {
 
 	w := s.GetWorkspaceByContext()
@@ -643,6 +1139,7 @@ func (s *service) ChangeGeneralInfo(EUVAT string, externalBillingInfo string) er
 	if len(w.ExternalCustomerID) > 0 {
 		params := &stripe.CustomerParams{}
 		params.Email = stripe.String(externalBillingInfo)
+		params.Headers = s.outboundHeaders()
 		_, err := customer.Update(w.ExternalCustomerID, params)
 		if err != nil {
 			return err
@@ -688,6 +1185,7 @@ func (s *service) CreateInvite(email string, level string) (*Invite, error) {
 		return nil, err
 	}
 
+	t := time.Now().UTC()
 	x := &Invite{
 		WorkspaceID:    s.Member.WorkspaceID,
 		ID:             uuid.Must(uuid.NewV4(), nil).String(),
@@ -696,9 +1194,10 @@ func (s *service) CreateInvite(email string, level string) (*Invite, error) {
 		Code:           uuid.Must(uuid.NewV4(), nil).String(),
 		CreatedBy:      s.Member.ID,
 		CreatedByName:  s.Acc.Name,
-		CreatedAt:      time.Now().UTC(),
+		CreatedAt:      t,
 		CreatedByEmail: s.Acc.Email,
 		WorkspaceName:  ws.Name,
+		SentAt:         t,
 	}
 
 	s.r.StoreInvite(x)
@@ -722,6 +1221,9 @@ func (s *service) SendInvitationMail(invitationID string) error {
 		return err
 	}
 
+	// Resending restarts the time the invite can be accepted
+	s.r.RenewInvite(invite.WorkspaceID, invite.ID, time.Now().UTC())
+
 	i := InviteStruct{
 		AppSiteURL:     s.config.AppSiteURL,
 		WorkspaceName:  ws.Name,
@@ -784,6 +1286,12 @@ func (s *service) GetInvitesByWorkspace() []*Invite {
 	return invites
 }
 
+var errInviteExpired = errors.New("invite expired")
+
+func (s *service) inviteHasExpired(invite *Invite) bool {
+	return s.config.InviteTTLDays > 0 && invite.SentAt.AddDate(0, 0, s.config.InviteTTLDays).Before(time.Now().UTC())
+}
+
 func (s *service) AcceptInvite(code string) error {
 	invite, err := s.r.GetInviteByCode(code)
 
@@ -791,6 +1299,10 @@ func (s *service) AcceptInvite(code string) error {
 		return errors.New("invite not found")
 	}
 
+	if s.inviteHasExpired(invite) {
+		return errInviteExpired
+	}
+
 	acc, err := s.r.GetAccountByEmail(invite.Email)
 	if err != nil {
 		return errors.New("Please create an account first  (using " + invite.Email + ") and then accept again.")
@@ -812,6 +1324,10 @@ func (s *service) GetInvite(code string) (*Invite, error) {
 		return nil, err
 	}
 
+	if s.inviteHasExpired(invite) {
+		return nil, errInviteExpired
+	}
+
 	return invite, nil
 }
 
@@ -823,10 +1339,52 @@ func (s *service) GetProject(id string) *Project {
 	pp, err := s.r.GetProject(s.Member.WorkspaceID, id)
 	if err != nil {
 		log.Println(err)
+		return nil
 	}
+	s.markFavorited(pp)
 	return pp
 }
 
+func (s *service) GetRecentChanges(since time.Time, limit int) ([]*RecentChange, error) {
+	if limit <= 0 || limit > 200 {
+		return nil, errors.New("invalid limit")
+	}
+	return s.r.FindRecentChanges(s.Member.WorkspaceID, since, limit)
+}
+
+// GetAnnotationUsage counts the items of the workspace carrying each annotation, unused ones included
+func (s *service) GetAnnotationUsage(sortByUsage bool) ([]*AnnotationUsage, error) {
+	cc, err := s.r.CountAnnotations(s.Member.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	x := annotationUsage(cc)
+	if sortByUsage {
+		sort.SliceStable(x, func(i, j int) bool { return x[i].Total > x[j].Total })
+	}
+	return x, nil
+}
+
+// errUnresolvable is returned for links that cannot name an entity of any kind
+var errUnresolvable = errors.New("invalid type or id")
+
+// Resolve validates a shared link and returns the path needed to navigate to it
+func (s *service) Resolve(kind string, id string) (*Breadcrumb, error) {
+	if _, ok := breadcrumbQueries[kind]; !ok {
+		return nil, errUnresolvable
+	}
+	if _, err := uuid.FromString(id); err != nil {
+		return nil, errUnresolvable
+	}
+
+	x, err := s.r.GetBreadcrumb(s.Member.WorkspaceID, kind, id)
+	if err != nil {
+		return nil, errors.New("not found")
+	}
+	return x, nil
+}
+
 func (s *service) GetProjectByExternalLink(link string) (*Project, error) {
 	return s.r.GetProjectByExternalLink(link)
 
@@ -837,7 +1395,11 @@ func (s *service) GetProjectExtendedByExternalLink(link string) (*projectRespons
 	if err != nil {
 		return nil, err
 	}
+	return s.projectContent(project)
+}
 
+// projectContent reads everything in a project, in the workspace of the project
+func (s *service) projectContent(project *Project) (*projectResponse, error) {
 	milestones, err := s.r.FindMilestonesByProject(project.WorkspaceID, project.ID)
 	if err != nil {
 		return nil, err
@@ -870,6 +1432,11 @@ func (s *service) GetProjectExtendedByExternalLink(link string) (*projectRespons
 		return nil, err
 	}
 
+	statuses, err := s.r.FindProjectStatusesByProject(project.WorkspaceID, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	resp := &projectResponse{
 		Project:          project,
 		Milestones:       milestones,
@@ -879,6 +1446,7 @@ func (s *service) GetProjectExtendedByExternalLink(link string) (*projectRespons
 		FeatureComments:  featureComments,
 		Personas:         personas,
 		WorkflowPersonas: workflowPersonas,
+		Statuses:         statuses,
 	}
 
 	return resp, nil
@@ -904,508 +1472,812 @@ func (s *service) CreateProjectWithID(id string, title string) (*Project, error)
 		CreatedByName: s.Acc.Name,
 		ExternalLink:  uuid.Must(uuid.NewV4(), nil).String(),
 	}
+	p.ExternalLinkCreatedAt = p.CreatedAt
+	p.ExternalLinkCreatedBy = s.Member.ID
 
 	p.LastModified = time.Now().UTC()
 	p.LastModifiedByName = s.Acc.Name
 
 	s.r.StoreProject(p)
+	s.createDefaultStatuses(p)
 
 	return p, nil
 }
 
-func (s *service) LoadSampleCards(pid string) error {
-	// wsid := s.Member.WorkspaceID
-	// accid := s.Acc.ID
-	// t := time.UTC()
-
-	// milestone1 := &Milestone{WorkspaceID: wsid, ProjectID: pid, ID: newUIID(), Title: "Proof-of-concept", Status: "OPEN",  }
-	return nil
-}
-
-func (s *service) RenameProject(id string, title string) (*Project, error) {
+// importProjectTitle validates the title of an imported project and resolves a collision
+// with the projects of the workspace
+func (s *service) importProjectTitle(title string) (string, error) {
 	title, err := validateTitle(title)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	p, err := s.r.GetProject(s.Member.WorkspaceID, id)
-	if err != nil {
-		return nil, err
+	pp, _ := s.r.FindProjectsByWorkspace(s.Member.WorkspaceID)
+	existing := make([]string, len(pp))
+	for i, p := range pp {
+		existing[i] = p.Title
 	}
-
-	p.Title = title
-	p.LastModified = time.Now().UTC()
-	p.LastModifiedByName = s.Acc.Name
-	s.r.StoreProject(p)
-	return p, nil
+	if title, err = importTitle(title, existing, s.config.ImportTitleCollision); err != nil {
+		return "", err
+	}
+	return validateTitle(title)
 }
 
-func (s *service) UpdateProjectDescription(id string, d string) (*Project, error) {
-	x, err := s.r.GetProject(s.Member.WorkspaceID, id)
+// ImportOutline creates a new project from an outline. The outline is validated before
+// anything is stored, and what was stored is undone when a later step fails, so a bad outline
+// does not leave a half imported project behind.
+func (s *service) ImportOutline(title string, o *outline) (*Project, error) {
+	title, err := s.importProjectTitle(title)
 	if err != nil {
 		return nil, err
 	}
 
-	x.Description = d
-	x.LastModified = time.Now().UTC()
-	x.LastModifiedByName = s.Acc.Name
-	s.r.StoreProject(x)
-
-	return x, nil
-}
-
-func (s *service) DeleteProject(id string) error {
-	s.r.DeleteProject(s.Member.WorkspaceID, id)
-	return nil
-}
-
-func (s *service) GetProjects() []*Project {
-	pp, err := s.r.FindProjectsByWorkspace(s.Member.WorkspaceID)
-	if err != nil {
-		log.Println(err)
+	for _, m := range o.Milestones {
+		if m.Title, err = validateTitle(m.Title); err != nil {
+			return nil, errors.Wrap(err, "milestone")
+		}
+		for _, c := range m.Columns {
+			if c.Title, err = validateTitle(c.Title); err != nil {
+				return nil, errors.Wrap(err, "subworkflow")
+			}
+			for i := range c.Cards {
+				if c.Cards[i], err = validateTitle(c.Cards[i]); err != nil {
+					return nil, errors.Wrap(err, "feature")
+				}
+			}
+		}
 	}
-	return pp
-}
 
-// Milestones
+	// Columns with the same title in different milestones share a subworkflow
+	cells := map[string]int{}
+	for _, m := range o.Milestones {
+		for _, c := range m.Columns {
+			cells[m.Title+"\n"+c.Title] += len(c.Cards)
+			if s.featureCapExceeded(cells[m.Title+"\n"+c.Title]) {
+				return nil, errors.New("too many features")
+			}
+		}
+	}
 
-func (s *service) CreateMilestoneWithID(id string, projectID string, title string) (*Milestone, error) {
-	title, err := validateTitle(title)
+	// The request transaction is committed even when the handler fails, so the import undoes
+	// itself, and drops what it queued to send
+	s.r.Savepoint("import_outline")
+	queued := len(s.afterCommit)
+	p, err := s.storeOutline(title, o)
 	if err != nil {
+		s.r.RollbackToSavepoint("import_outline")
+		s.afterCommit = s.afterCommit[:queued]
 		return nil, err
 	}
-
-	a, _ := s.r.GetMilestone(s.Member.WorkspaceID, id)
-	if a != nil {
-		return nil, errors.New("already exists")
-	}
-
-	mm, _ := s.r.FindMilestonesByProject(s.Member.WorkspaceID, projectID)
-
-	p := &Milestone{
-		WorkspaceID:   s.Member.WorkspaceID,
-		ProjectID:     projectID,
-		ID:            id,
-		Title:         title,
-		Status:        "OPEN",
-		Rank:          "",
-		CreatedAt:     time.Now().UTC(),
-		CreatedByName: s.Acc.Name,
-		Color:         "WHITE",
-	}
-
-	n := len(mm)
-	if n == 0 {
-		rank, _ := lexorank.Rank("", "")
-		p.Rank = rank
-	} else {
-		rank, _ := lexorank.Rank(mm[n-1].Rank, "")
-		p.Rank = rank
-	}
-
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
-	s.r.StoreMilestone(p)
-
 	return p, nil
 }
 
-func (s *service) MoveMilestone(id string, index int) (*Milestone, error) {
-
-	if index < 0 || index > 1000 {
-		return nil, errors.New("index invalid")
+// storeOutline stores the project of a validated outline
+func (s *service) storeOutline(title string, o *outline) (*Project, error) {
+	p, err := s.CreateProjectWithID(uuid.Must(uuid.NewV4(), nil).String(), title)
+	if err != nil {
+		return nil, err
 	}
 
-	m, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
+	wf, err := s.CreateWorkflowWithID(uuid.Must(uuid.NewV4(), nil).String(), p.ID, "Imported")
 	if err != nil {
 		return nil, err
 	}
 
-	mm, _ := s.r.FindMilestonesByProject(s.Member.WorkspaceID, m.ProjectID)
-
-	// Remove the item we are moving
-	mmf := []*Milestone{}
-	for _, x := range mm {
-		if x.ID != id {
-			mmf = append(mmf, x)
+	subWorkflows := map[string]*SubWorkflow{}
+	milestones := []*Milestone{}
+	columns := []*SubWorkflow{}
+	features := map[string][]*Feature{}
+	for _, m := range o.Milestones {
+		milestone, err := s.CreateMilestoneWithID(uuid.Must(uuid.NewV4(), nil).String(), p.ID, m.Title)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	var prevRank, nextRank string
-	length := len(mmf)
+		milestones = append(milestones, milestone)
+
+		for _, c := range m.Columns {
+			sw, ok := subWorkflows[c.Title]
+			if !ok {
+				sw, err = s.CreateSubWorkflowWithID(uuid.Must(uuid.NewV4(), nil).String(), wf.ID, c.Title)
+				if err != nil {
+					return nil, err
+				}
+				subWorkflows[c.Title] = sw
+				columns = append(columns, sw)
+			}
 
-	if length != 0 {
-		if (index - 1) >= 0 {
-			prevRank = mmf[index-1].Rank
+			for _, card := range c.Cards {
+				f, err := s.CreateFeatureWithID(uuid.Must(uuid.NewV4(), nil).String(), sw.ID, milestone.ID, card, nil)
+				if err != nil {
+					return nil, err
+				}
+				features[milestone.ID+"/"+sw.ID] = append(features[milestone.ID+"/"+sw.ID], f)
+			}
 		}
+	}
 
-		if index < length {
-			nextRank = mmf[index].Rank
+	// Appending one by one crowds the ranks at the end, so they are spread evenly in the
+	// order of the outline once everything is created
+	for i, r := range lexorank.Spread(len(milestones)) {
+		milestones[i].Rank = r
+		s.r.StoreMilestone(milestones[i])
+	}
+	for i, r := range lexorank.Spread(len(columns)) {
+		columns[i].Rank = r
+		s.r.StoreSubWorkflow(columns[i])
+	}
+	for _, ff := range features {
+		for i, r := range lexorank.Spread(len(ff)) {
+			ff[i].Rank = r
+			s.r.StoreFeature(ff[i])
 		}
 	}
 
-	rank, _ := lexorank.Rank(prevRank, nextRank)
+	return p, nil
+}
 
-	m.Rank = rank
-	m.LastModifiedByName = s.Acc.Name
-	m.LastModified = time.Now().UTC()
+// DryRun calls f and then undoes everything it stored in the request transaction
+func (s *service) DryRun(f func() error) error {
+	s.r.Savepoint("dry_run")
+	defer s.r.RollbackToSavepoint("dry_run")
 
-	s.r.StoreMilestone(m)
+	// Nothing of a dry run is sent either
+	queued := len(s.afterCommit)
+	defer func() { s.afterCommit = s.afterCommit[:queued] }()
 
-	return m, nil
+	return f()
 }
 
-func (s *service) RenameMilestone(id string, title string) (*Milestone, error) {
+func (s *service) LoadSampleCards(pid string) error {
+	// wsid := s.Member.WorkspaceID
+	// accid := s.Acc.ID
+	// t := time.UTC()
+
+	// milestone1 := &Milestone{WorkspaceID: wsid, ProjectID: pid, ID: newUIID(), Title: "Proof-of-concept", Status: "OPEN",  }
+	return nil
+}
 
+func (s *service) RenameProject(id string, title string) (*Project, error) {
 	title, err := validateTitle(title)
 	if err != nil {
 		return nil, err
 	}
 
-	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
+	p, err := s.r.GetProject(s.Member.WorkspaceID, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if s.autosaved("project", id, "title", p.Title, title) {
+		return p, nil
+	}
+
 	p.Title = title
-	p.LastModifiedByName = s.Acc.Name
 	p.LastModified = time.Now().UTC()
-
-	s.r.StoreMilestone(p)
-
+	p.LastModifiedByName = s.Acc.Name
+	s.r.StoreProject(p)
 	return p, nil
 }
 
-func (s *service) DeleteMilestone(id string) error {
-	s.r.DeleteMilestone(s.Member.WorkspaceID, id)
-	return nil
-}
-
-func (s *service) GetMilestonesByProject(id string) []*Milestone {
-	pp, err := s.r.FindMilestonesByProject(s.Member.WorkspaceID, id)
+func (s *service) UpdateProjectDescription(id string, d string) (*Project, error) {
+	x, err := s.r.GetProject(s.Member.WorkspaceID, id)
 	if err != nil {
-		log.Println(err)
+		return nil, err
 	}
-	return pp
-}
 
-func (s *service) UpdateMilestoneDescription(id string, d string) (*Milestone, error) {
-	x, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
-	if err != nil {
-		return nil, err
+	if s.autosaved("project", id, "description", x.Description, d) {
+		return x, nil
 	}
 
 	x.Description = d
 	x.LastModified = time.Now().UTC()
 	x.LastModifiedByName = s.Acc.Name
-	s.r.StoreMilestone(x)
+	s.r.StoreProject(x)
 
 	return x, nil
 }
 
-func (s *service) CloseMilestone(id string) (*Milestone, error) {
-	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
-	if p == nil {
-		return nil, err
+func (s *service) UpdateAutoCloseOnProject(id string, days int) (*Project, error) {
+	if days < 0 || days > 3650 {
+		return nil, errors.New("invalid number of days")
 	}
 
-	p.Status = "CLOSED"
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
+	x, err := s.r.GetProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
 
-	s.r.StoreMilestone(p)
+	x.AutoCloseDays = days
+	x.LastModified = time.Now().UTC()
+	x.LastModifiedByName = s.Acc.Name
+	s.r.StoreProject(x)
 
-	return p, nil
+	return x, nil
 }
 
-func (s *service) OpenMilestone(id string) (*Milestone, error) {
-	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
-	if p == nil {
-		return nil, err
-	}
+var errEstimateRequired = errors.New("estimate required")
 
-	p.Status = "OPEN"
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
+// errEstimateOutOfRange is returned for estimates outside the bounds of the workspace
+var errEstimateOutOfRange = errors.New("estimate out of range")
 
-	s.r.StoreMilestone(p)
+// errRepeatedFeatureID is returned when a batch of features names the same ID twice
+var errRepeatedFeatureID = errors.New("repeated feature id")
 
-	return p, nil
-}
+const maxEstimate = 999
 
-func (s *service) ChangeColorOnMilestone(id string, color string) (*Milestone, error) {
+// estimateBounds returns the bounds of an estimate, those of the workspace taking precedence
+// over the configured ones. A zero bound is not set.
+func (s *service) estimateBounds() (int, int) {
+	min, max := s.config.MinEstimate, s.config.MaxEstimate
+	if s.ws != nil && s.ws.MinEstimate > 0 {
+		min = s.ws.MinEstimate
+	}
+	if s.ws != nil && s.ws.MaxEstimate > 0 {
+		max = s.ws.MaxEstimate
+	}
+	if max <= 0 || max > maxEstimate {
+		max = maxEstimate
+	}
+	return min, max
+}
 
-	if !colorIsValid(color) {
-		return nil, errors.New("invalid color")
+// checkEstimate tells if an estimate is within bounds. Zero means no estimate and is always valid.
+func (s *service) checkEstimate(estimate int) error {
+	if estimate == 0 {
+		return nil
 	}
+	min, max := s.estimateBounds()
+	if estimate < 0 || estimate < min || estimate > max {
+		return errEstimateOutOfRange
+	}
+	return nil
+}
 
-	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
-	if p == nil {
-		return nil, err
+func (s *service) ChangeEstimateBounds(min int, max int) error {
+	if min < 0 || max < 0 || max > maxEstimate || (max > 0 && min > max) {
+		return errors.New("invalid bounds")
 	}
 
-	p.Color = color
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
+	w := s.GetWorkspaceByContext()
 
-	s.r.StoreMilestone(p)
+	w.MinEstimate = min
+	w.MaxEstimate = max
 
-	return p, nil
+	s.r.StoreWorkspace(w)
+
+	return nil
 }
 
-func (s *service) UpdateAnnotationsOnMilestone(id string, names string) (*Milestone, error) {
+func (s *service) UpdateEstimateSettingsOnProject(id string, require bool, defaultEstimate int) (*Project, error) {
+	if err := s.checkEstimate(defaultEstimate); err != nil {
+		return nil, err
+	}
 
-	f, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
-	if f == nil {
+	x, err := s.r.GetProject(s.Member.WorkspaceID, id)
+	if err != nil {
 		return nil, err
 	}
 
+	x.RequireEstimate = require
+	x.DefaultEstimate = defaultEstimate
+	x.LastModified = time.Now().UTC()
+	x.LastModifiedByName = s.Acc.Name
+	s.r.StoreProject(x)
+
+	return x, nil
+}
+
+func (s *service) UpdateDefaultAnnotationsOnProject(id string, names string) (*Project, error) {
 	if !areAnnotationsValid(names) {
 		return nil, errors.New("invalid annotation")
 	}
 
-	f.Annotations = names
-	f.LastModifiedByName = s.Acc.Name
-	f.LastModified = time.Now().UTC()
+	x, err := s.r.GetProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
 
-	s.r.StoreMilestone(f)
+	x.DefaultAnnotations = names
+	x.LastModified = time.Now().UTC()
+	x.LastModifiedByName = s.Acc.Name
+	s.r.StoreProject(x)
 
-	return f, nil
+	return x, nil
 }
 
-// Workflow
-
-func (s *service) CreateWorkflowWithID(id string, projectID string, title string) (*Workflow, error) {
-
-	title, err := validateTitle(title)
+// UpdateDefaultPlacementOnProject sets the milestone and subworkflow a feature created without
+// them goes to. An empty id unsets that default.
+func (s *service) UpdateDefaultPlacementOnProject(id string, milestoneID string, subWorkflowID string) (*Project, error) {
+	x, err := s.r.GetProject(s.Member.WorkspaceID, id)
 	if err != nil {
 		return nil, err
 	}
 
-	a, _ := s.r.GetWorkflow(s.Member.WorkspaceID, id)
-	if a != nil {
-		return nil, errors.New("already exists")
+	if milestoneID != "" && !s.milestoneInProject(x.ID, milestoneID) {
+		return nil, errors.New("milestone not in project")
+	}
+	if subWorkflowID != "" && !s.subWorkflowInProject(x.ID, subWorkflowID) {
+		return nil, errors.New("subworkflow not in project")
 	}
 
-	ww, _ := s.r.FindWorkflowsByProject(s.Member.WorkspaceID, projectID)
+	x.DefaultMilestoneID = milestoneID
+	x.DefaultSubWorkflowID = subWorkflowID
+	x.LastModified = time.Now().UTC()
+	x.LastModifiedByName = s.Acc.Name
+	s.r.StoreProject(x)
 
-	p := &Workflow{
-		WorkspaceID:   s.Member.WorkspaceID,
-		ProjectID:     projectID,
-		ID:            id,
-		Title:         title,
-		Rank:          "",
-		CreatedAt:     time.Now().UTC(),
-		CreatedByName: s.Acc.Name,
-		Color:         "WHITE",
-		Status:        "OPEN",
+	return x, nil
+}
+
+func (s *service) milestoneInProject(projectID string, id string) bool {
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
+	return err == nil && m.ProjectID == projectID
+}
+
+func (s *service) subWorkflowInProject(projectID string, id string) bool {
+	sw, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if err != nil {
+		return false
 	}
+	w, err := s.r.GetWorkflow(s.Member.WorkspaceID, sw.WorkflowID)
+	return err == nil && w.ProjectID == projectID
+}
 
-	n := len(ww)
-	if n == 0 {
-		rank, _ := lexorank.Rank("", "")
-		p.Rank = rank
-	} else {
-		rank, _ := lexorank.Rank(ww[n-1].Rank, "")
-		p.Rank = rank
+// FeaturePlacement fills in the milestone and subworkflow a new feature was created without.
+// The defaults of the project are used while they are still in it, else the first milestone
+// and the first subworkflow of the first workflow that has one. Those given must be in the project,
+// which is the one of the milestone when no project is given.
+func (s *service) FeaturePlacement(projectID string, milestoneID string, subWorkflowID string) (string, string, error) {
+	if projectID == "" && milestoneID != "" {
+		if m, err := s.r.GetMilestone(s.Member.WorkspaceID, milestoneID); err == nil {
+			projectID = m.ProjectID
+		}
 	}
 
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
+	if milestoneID != "" && !s.milestoneInProject(projectID, milestoneID) {
+		return "", "", errors.New("milestone not in project")
+	}
+	if subWorkflowID != "" && !s.subWorkflowInProject(projectID, subWorkflowID) {
+		return "", "", errors.New("subworkflow not in project")
+	}
+	if milestoneID != "" && subWorkflowID != "" {
+		return milestoneID, subWorkflowID, nil
+	}
 
-	s.r.StoreWorkflow(p)
+	p, err := s.r.GetProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		return "", "", errors.New("project not found")
+	}
 
-	return p, nil
+	if milestoneID == "" {
+		if p.DefaultMilestoneID != "" && s.milestoneInProject(p.ID, p.DefaultMilestoneID) {
+			milestoneID = p.DefaultMilestoneID
+		} else if mm, _ := s.r.FindMilestonesByProject(s.Member.WorkspaceID, p.ID); len(mm) > 0 {
+			milestoneID = mm[0].ID
+		} else {
+			return "", "", errors.New("project has no milestones")
+		}
+	}
+
+	if subWorkflowID == "" {
+		if p.DefaultSubWorkflowID != "" && s.subWorkflowInProject(p.ID, p.DefaultSubWorkflowID) {
+			subWorkflowID = p.DefaultSubWorkflowID
+		} else {
+			ww, _ := s.r.FindWorkflowsByProject(s.Member.WorkspaceID, p.ID)
+			for _, w := range ww {
+				if sws, _ := s.r.FindSubWorkflowsByWorkflow(s.Member.WorkspaceID, w.ID); len(sws) > 0 {
+					subWorkflowID = sws[0].ID
+					break
+				}
+			}
+			if subWorkflowID == "" {
+				return "", "", errors.New("project has no subworkflows")
+			}
+		}
+	}
+
+	return milestoneID, subWorkflowID, nil
 }
 
-func (s *service) MoveWorkflow(id string, index int) (*Workflow, error) {
+// defaultAnnotations returns the annotations new features of the project start with
+func (s *service) defaultAnnotations(projectID string) string {
+	p, err := s.r.GetProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		return ""
+	}
+	return p.DefaultAnnotations
+}
 
-	if index < 0 || index > 1000 {
-		return nil, errors.New("index invalid")
+// projectEstimate applies the project's default when no estimate is given and enforces
+// require_estimate. An explicit 0 is kept, but does not count as an estimate.
+func (s *service) projectEstimate(projectID string, estimate *int) (int, error) {
+	if estimate != nil {
+		if err := s.checkEstimate(*estimate); err != nil {
+			return 0, err
+		}
 	}
 
-	m, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+	p, err := s.r.GetProject(s.Member.WorkspaceID, projectID)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	mm, _ := s.r.FindWorkflowsByProject(s.Member.WorkspaceID, m.ProjectID)
+	e := p.DefaultEstimate
+	if estimate != nil {
+		e = *estimate
+	}
+	if e == 0 && p.RequireEstimate {
+		return 0, errEstimateRequired
+	}
+	return e, nil
+}
 
-	// Remove the item we are moving
-	mmf := []*Workflow{}
-	for _, x := range mm {
-		if x.ID != id {
-			mmf = append(mmf, x)
+// CloseStaleFeatures closes open features without activity for the number of days set on their project.
+// It runs outside of a request and therefore works across all workspaces.
+func (s *service) CloseStaleFeatures(now time.Time) int {
+	pp, err := s.r.FindProjectsWithAutoClose()
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
+	n := 0
+	for _, p := range pp {
+		// A project that fails is rolled back on its own and the others still close
+		closed := 0
+		err := s.inOwnTransaction(func() { closed = s.closeStaleFeaturesOf(p, now) })
+		if err != nil {
+			log.Printf("closing stale features of project %s: %v", p.ID, err)
+			continue
 		}
+		n += closed
 	}
 
-	var prevRank, nextRank string
-	length := len(mmf)
+	return n
+}
 
-	if length != 0 {
-		if (index - 1) >= 0 {
-			prevRank = mmf[index-1].Rank
+// inOwnTransaction commits what the repository has open and runs f in a transaction of its own.
+// What f queued with AfterCommit runs once f has committed. No transaction is open afterwards.
+func (s *service) inOwnTransaction(f func()) (err error) {
+	if err := s.r.Commit(); err != nil {
+		return err
+	}
+	s.RunAfterCommit(true)
+
+	if err := s.r.Begin(context.Background(), nil); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = s.r.Rollback()
+			err = fmt.Errorf("%v", p)
 		}
+		s.RunAfterCommit(err == nil)
+	}()
 
-		if index < length {
-			nextRank = mmf[index].Rank
+	f()
+	return s.r.Commit()
+}
+
+// closeStaleFeaturesOf closes the open features of a project without activity for its AutoCloseDays
+func (s *service) closeStaleFeaturesOf(p *Project, now time.Time) int {
+	ff, err := s.r.FindFeaturesByProject(p.WorkspaceID, p.ID)
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
+	cc, _ := s.r.FindFeatureCommentsByProject(p.WorkspaceID, p.ID)
+
+	// A comment counts as activity on its feature
+	latest := map[string]time.Time{}
+	for _, c := range cc {
+		if c.LastModified.After(latest[c.FeatureID]) {
+			latest[c.FeatureID] = c.LastModified
 		}
 	}
 
-	rank, _ := lexorank.Rank(prevRank, nextRank)
+	ws, err := s.r.GetWorkspace(p.WorkspaceID)
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
 
-	m.Rank = rank
-	m.LastModifiedByName = s.Acc.Name
-	m.LastModified = time.Now().UTC()
+	n := 0
+	cutoff := now.AddDate(0, 0, -p.AutoCloseDays)
+	for _, f := range ff {
+		if f.Status != "OPEN" || f.LastModified.After(cutoff) || latest[f.ID].After(cutoff) {
+			continue
+		}
 
-	s.r.StoreWorkflow(m)
+		f.Status = "CLOSED"
+		f.StatusID = s.firstStatus(p.WorkspaceID, p.ID, true)
+		f.LastModifiedByName = systemName
+		f.LastModified = now
+		s.r.StoreFeature(f)
+		s.recordFeatureEvent(f, f.Status)
+
+		s.r.StoreFeatureComment(&FeatureComment{
+			WorkspaceID:   p.WorkspaceID,
+			ID:            uuid.Must(uuid.NewV4(), nil).String(),
+			FeatureID:     f.ID,
+			ProjectID:     p.ID,
+			Post:          fmt.Sprintf("Automatically closed after %d days without activity.", p.AutoCloseDays),
+			CreatedAt:     now,
+			CreatedByName: systemName,
+			LastModified:  now,
+		})
+		s.notifyFeatureWatchers(ws, "", systemName, f, p.ID, "status", fmt.Sprintf("closed the card after %d days without activity", p.AutoCloseDays), "")
+		n++
+	}
 
-	return m, nil
+	return n
 }
 
-func (s *service) RenameWorkflow(id string, title string) (*Workflow, error) {
+func (s *service) DeleteProject(id string) error {
+	if ws := s.GetWorkspaceByContext(); ws != nil && ws.ArchiveDeletedProjects {
+		if err := s.archiveProject(id); err != nil {
+			return err
+		}
+	}
+	s.r.DeleteProject(s.Member.WorkspaceID, id)
+	return nil
+}
 
-	title, err := validateTitle(title)
+func (s *service) GetProjects() []*Project {
+	pp, err := s.r.FindProjectsByWorkspace(s.Member.WorkspaceID)
 	if err != nil {
-		return nil, err
+		log.Println(err)
 	}
+	s.markFavorited(pp...)
+	return pp
+}
 
-	p, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+func (s *service) FavoriteProject(id string) error {
+	p, err := s.r.GetProject(s.Member.WorkspaceID, id)
 	if err != nil {
-		return nil, err
+		return errors.New("project not found")
 	}
 
-	p.Title = title
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
-
-	s.r.StoreWorkflow(p)
+	s.r.StoreProjectFavorite(&ProjectFavorite{
+		WorkspaceID: s.Member.WorkspaceID,
+		ProjectID:   p.ID,
+		MemberID:    s.Member.ID,
+		CreatedAt:   time.Now().UTC(),
+	})
 
-	return p, nil
+	return nil
 }
 
-func (s *service) DeleteWorkflow(id string) error {
-	s.r.DeleteWorkflow(s.Member.WorkspaceID, id)
+func (s *service) UnfavoriteProject(id string) error {
+	p, err := s.r.GetProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		return errors.New("project not found")
+	}
+
+	s.r.DeleteProjectFavorite(s.Member.WorkspaceID, p.ID, s.Member.ID)
+
 	return nil
 }
 
-func (s *service) GetWorkflowsByProject(id string) []*Workflow {
-	pp, err := s.r.FindWorkflowsByProject(s.Member.WorkspaceID, id)
+// Goals
+
+func targetDateIsValid(d string) bool {
+	if d == "" {
+		return true
+	}
+	_, err := time.Parse("2006-01-02", d)
+	return err == nil
+}
+
+func (s *service) GetGoalsByProject(projectID string) []*Goal {
+	gg, err := s.r.FindGoalsByProject(s.Member.WorkspaceID, projectID)
 	if err != nil {
 		log.Println(err)
 	}
-	return pp
+	return gg
 }
 
-func (s *service) UpdateWorkflowDescription(id string, d string) (*Workflow, error) {
-	x, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+// projectGoal finds a goal of the given project
+func (s *service) projectGoal(id string, projectID string) (*Goal, error) {
+	g, err := s.r.GetGoal(s.Member.WorkspaceID, id)
+	if err != nil || g.ProjectID != projectID {
+		return nil, errors.New("goal not found")
+	}
+	return g, nil
+}
+
+func (s *service) CreateGoalWithID(id string, projectID string, title string, description string, targetDate string) (*Goal, error) {
+	title, err := validateTitle(title)
 	if err != nil {
 		return nil, err
 	}
+	if !targetDateIsValid(targetDate) {
+		return nil, errors.New("invalid target date")
+	}
 
-	x.Description = d
-	x.LastModified = time.Now().UTC()
-	x.LastModifiedByName = s.Acc.Name
-	s.r.StoreWorkflow(x)
+	if x, _ := s.r.GetGoal(s.Member.WorkspaceID, id); x != nil {
+		return nil, errors.New("already exists")
+	}
+
+	if _, err := s.r.GetProject(s.Member.WorkspaceID, projectID); err != nil {
+		return nil, errors.New("project not found")
+	}
+
+	t := time.Now().UTC()
+	x := &Goal{
+		WorkspaceID:        s.Member.WorkspaceID,
+		ProjectID:          projectID,
+		ID:                 id,
+		Title:              title,
+		Description:        description,
+		TargetDate:         targetDate,
+		CreatedAt:          t,
+		CreatedByName:      s.Acc.Name,
+		LastModified:       t,
+		LastModifiedByName: s.Acc.Name,
+	}
+	s.r.StoreGoal(x)
 
 	return x, nil
 }
 
-func (s *service) ChangeColorOnWorkflow(id string, color string) (*Workflow, error) {
-
-	if !colorIsValid(color) {
-		return nil, errors.New("invalid color")
+func (s *service) UpdateGoal(id string, projectID string, title string, description string, targetDate string) (*Goal, error) {
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+	if !targetDateIsValid(targetDate) {
+		return nil, errors.New("invalid target date")
 	}
 
-	p, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
-	if p == nil {
+	x, err := s.projectGoal(id, projectID)
+	if err != nil {
 		return nil, err
 	}
 
-	p.Color = color
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
-
-	s.r.StoreWorkflow(p)
+	x.Title = title
+	x.Description = description
+	x.TargetDate = targetDate
+	x.LastModified = time.Now().UTC()
+	x.LastModifiedByName = s.Acc.Name
+	s.r.StoreGoal(x)
 
-	return p, nil
+	return x, nil
 }
 
-func (s *service) CloseWorkflow(id string) (*Workflow, error) {
-	p, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
-	if p == nil {
-		return nil, err
+func (s *service) DeleteGoal(id string, projectID string) error {
+	if _, err := s.projectGoal(id, projectID); err != nil {
+		return err
 	}
+	s.r.DeleteGoal(s.Member.WorkspaceID, id)
+	return nil
+}
 
-	p.Status = "CLOSED"
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
+func (s *service) LinkGoalToMilestone(id string, projectID string, milestoneID string) error {
+	if _, err := s.projectGoal(id, projectID); err != nil {
+		return err
+	}
 
-	s.r.StoreWorkflow(p)
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, milestoneID)
+	if err != nil || m.ProjectID != projectID {
+		return errors.New("milestone not found")
+	}
 
-	return p, nil
+	s.r.StoreGoalMilestone(&GoalMilestone{WorkspaceID: s.Member.WorkspaceID, ProjectID: projectID, GoalID: id, MilestoneID: m.ID})
+	return nil
 }
 
-func (s *service) OpenWorkflow(id string) (*Workflow, error) {
-	p, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
-	if p == nil {
-		return nil, err
+func (s *service) UnlinkGoalFromMilestone(id string, projectID string, milestoneID string) error {
+	if _, err := s.projectGoal(id, projectID); err != nil {
+		return err
 	}
+	s.r.DeleteGoalMilestone(s.Member.WorkspaceID, id, milestoneID)
+	return nil
+}
 
-	p.Status = "OPEN"
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
-
-	s.r.StoreWorkflow(p)
+// markGoals sets GoalIDs on the milestones of a project
+func (s *service) markGoals(projectID string, mm []*Milestone) {
+	links, err := s.r.FindGoalMilestonesByProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
 
-	return p, nil
+	byMilestone := map[string][]string{}
+	for _, l := range links {
+		byMilestone[l.MilestoneID] = append(byMilestone[l.MilestoneID], l.GoalID)
+	}
+	for _, m := range mm {
+		m.GoalIDs = byMilestone[m.ID]
+		if m.GoalIDs == nil {
+			m.GoalIDs = []string{}
+		}
+	}
 }
 
-func (s *service) UpdateAnnotationsOnWorkflow(id string, names string) (*Workflow, error) {
+// projectLink orders the pair of projects the way it is stored
+func projectLink(workspaceID string, a string, b string) *ProjectLink {
+	if b < a {
+		a, b = b, a
+	}
+	return &ProjectLink{WorkspaceID: workspaceID, ProjectID: a, RelatedProjectID: b}
+}
 
-	f, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
-	if f == nil {
-		return nil, err
+func (s *service) LinkProjects(id string, relatedID string) error {
+	if id == relatedID {
+		return errors.New("a project cannot be related to itself")
 	}
 
-	if !areAnnotationsValid(names) {
-		return nil, errors.New("invalid annotation")
+	for _, pid := range []string{id, relatedID} {
+		if _, err := s.r.GetProject(s.Member.WorkspaceID, pid); err != nil {
+			return errors.New("project not found")
+		}
 	}
 
-	f.Annotations = names
-	f.LastModifiedByName = s.Acc.Name
-	f.LastModified = time.Now().UTC()
+	x := projectLink(s.Member.WorkspaceID, id, relatedID)
+	x.CreatedAt = time.Now().UTC()
+	s.r.StoreProjectLink(x)
 
-	s.r.StoreWorkflow(f)
+	return nil
+}
 
-	return f, nil
+func (s *service) UnlinkProjects(id string, relatedID string) error {
+	x := projectLink(s.Member.WorkspaceID, id, relatedID)
+	s.r.DeleteProjectLink(x.WorkspaceID, x.ProjectID, x.RelatedProjectID)
+	return nil
 }
 
-// SubWorkflow
-func (s *service) CreateSubWorkflowWithID(id string, workflowID string, title string) (*SubWorkflow, error) {
+func (s *service) GetRelatedProjects(id string) []*Project {
+	pp, err := s.r.FindRelatedProjects(s.Member.WorkspaceID, id)
+	if err != nil {
+		log.Println(err)
+	}
+	return pp
+}
+
+// markFavorited sets Favorited on the projects the current member has favorited
+func (s *service) markFavorited(pp ...*Project) {
+	ids, err := s.r.FindFavoriteProjectIDsByMember(s.Member.WorkspaceID, s.Member.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	favorited := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		favorited[id] = true
+	}
+	for _, p := range pp {
+		p.Favorited = favorited[p.ID]
+	}
+}
 
+// Milestones
+
+func (s *service) CreateMilestoneWithID(id string, projectID string, title string) (*Milestone, error) {
 	title, err := validateTitle(title)
 	if err != nil {
 		return nil, err
 	}
 
-	a, _ := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	a, _ := s.r.GetMilestone(s.Member.WorkspaceID, id)
 	if a != nil {
 		return nil, errors.New("already exists")
 	}
 
-	mm, _ := s.r.FindSubWorkflowsByWorkflow(s.Member.WorkspaceID, workflowID)
+	mm, _ := s.r.FindMilestonesByProject(s.Member.WorkspaceID, projectID)
 
-	p := &SubWorkflow{
+	p := &Milestone{
 		WorkspaceID:   s.Member.WorkspaceID,
-		WorkflowID:    workflowID,
+		ProjectID:     projectID,
 		ID:            id,
 		Title:         title,
+		Status:        "OPEN",
 		Rank:          "",
 		CreatedAt:     time.Now().UTC(),
 		CreatedByName: s.Acc.Name,
 		Color:         "WHITE",
-		Status:        "OPEN",
 	}
 
 	n := len(mm)
@@ -1419,26 +2291,33 @@ func (s *service) CreateSubWorkflowWithID(id string, workflowID string, title st
 
 	p.LastModifiedByName = s.Acc.Name
 	p.LastModified = time.Now().UTC()
-	s.r.StoreSubWorkflow(p)
+	s.r.StoreMilestone(p)
 
 	return p, nil
 }
 
-func (s *service) MoveSubWorkflow(id string, toWorkflowID string, index int) (*SubWorkflow, error) {
+// errMoveTargetGone is returned when something was moved into a parent another user deleted meanwhile
+var errMoveTargetGone = errors.New("target no longer exists, refresh and try again")
+
+func (s *service) MoveMilestone(id string, index int) (*Milestone, error) {
 
 	if index < 0 || index > 1000 {
 		return nil, errors.New("index invalid")
 	}
 
-	m, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
 	if err != nil {
 		return nil, err
 	}
 
-	mm, _ := s.r.FindSubWorkflowsByWorkflow(s.Member.WorkspaceID, toWorkflowID)
+	if _, err := s.r.GetProject(s.Member.WorkspaceID, m.ProjectID); err != nil {
+		return nil, errMoveTargetGone
+	}
+
+	mm, _ := s.r.FindMilestonesByProject(s.Member.WorkspaceID, m.ProjectID)
 
 	// Remove the item we are moving
-	mmf := []*SubWorkflow{}
+	mmf := []*Milestone{}
 	for _, x := range mm {
 		if x.ID != id {
 			mmf = append(mmf, x)
@@ -1461,116 +2340,246 @@ func (s *service) MoveSubWorkflow(id string, toWorkflowID string, index int) (*S
 	rank, _ := lexorank.Rank(prevRank, nextRank)
 
 	m.Rank = rank
-	m.WorkflowID = toWorkflowID
 	m.LastModifiedByName = s.Acc.Name
 	m.LastModified = time.Now().UTC()
 
-	s.r.StoreSubWorkflow(m)
+	s.r.StoreMilestone(m)
 
 	return m, nil
 }
 
-func (s *service) RenameSubWorkflow(id string, title string) (*SubWorkflow, error) {
+// ShiftMilestone swaps a milestone with its neighbour, delta -1 being the one before it
+// and 1 the one after. It returns the milestones that changed, none at either end.
+func (s *service) ShiftMilestone(id string, delta int) ([]*Milestone, error) {
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	mm, err := s.r.FindMilestonesByProject(s.Member.WorkspaceID, m.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	i := -1
+	for k, x := range mm {
+		if x.ID == id {
+			i = k
+		}
+	}
+
+	j := i + delta
+	if i < 0 || j < 0 || j >= len(mm) {
+		return []*Milestone{}, nil
+	}
+
+	a, b := mm[i], mm[j]
+	a.Rank, b.Rank = b.Rank, a.Rank
+
+	t := time.Now().UTC()
+	for _, x := range []*Milestone{a, b} {
+		x.LastModifiedByName = s.Acc.Name
+		x.LastModified = t
+		s.r.StoreMilestone(x)
+	}
+
+	return []*Milestone{a, b}, nil
+}
+
+func (s *service) RenameMilestone(id string, title string) (*Milestone, error) {
 
 	title, err := validateTitle(title)
 	if err != nil {
 		return nil, err
 	}
 
-	p, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if s.autosaved("milestone", id, "title", p.Title, title) {
+		return p, nil
+	}
+
 	p.Title = title
 	p.LastModifiedByName = s.Acc.Name
 	p.LastModified = time.Now().UTC()
 
-	s.r.StoreSubWorkflow(p)
+	s.r.StoreMilestone(p)
 
 	return p, nil
 }
 
-func (s *service) DeleteSubWorkflow(id string) error {
-	s.r.DeleteSubWorkflow(s.Member.WorkspaceID, id)
+func (s *service) DeleteMilestone(id string) error {
+	s.r.DeleteMilestone(s.Member.WorkspaceID, id)
 	return nil
 }
 
-func (s *service) GetSubWorkflowsByProject(id string) []*SubWorkflow {
-	pp, err := s.r.FindSubWorkflowsByProject(s.Member.WorkspaceID, id)
+func (s *service) GetMilestonesByProject(id string) []*Milestone {
+	pp, err := s.r.FindMilestonesByProject(s.Member.WorkspaceID, id)
 	if err != nil {
 		log.Println(err)
 	}
+	s.markGoals(id, pp)
 	return pp
 }
 
-func (s *service) UpdateSubWorkflowDescription(id string, d string) (*SubWorkflow, error) {
-	x, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+func (s *service) GetMilestoneTree(id string) (*milestoneTreeResponse, error) {
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	features, err := s.r.FindFeaturesByMilestone(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.r.FindFeatureCommentsByMilestone(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	owners, _ := s.r.FindFeatureCommentOwnersByProject(s.Member.WorkspaceID, m.ProjectID)
+	for _, c := range comments {
+		for _, o := range owners {
+			if c.ID == o.FeatureCommentID {
+				c.MemberID = o.MemberID
+				break
+			}
+		}
+	}
+
+	s.markWatching(m.ProjectID, features)
+	s.markReferences(m.ProjectID, features)
+	s.markLoggedTime(m.ProjectID, features)
+
+	return &milestoneTreeResponse{
+		Milestone:       m,
+		Features:        features,
+		FeatureComments: comments,
+	}, nil
+}
+
+func (s *service) GetBurndownByMilestone(id string, from time.Time, to time.Time, loc *time.Location) ([]*burndownPoint, error) {
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.IsZero() {
+		from = m.CreatedAt
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if to.Before(from) {
+		return nil, errors.New("invalid period")
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return nil, errors.New("period too long")
+	}
+
+	events, err := s.r.FindFeatureEventsByMilestone(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return burndown(events, id, from, to, loc), nil
+}
+
+func (s *service) UpdateMilestoneDescription(id string, d string) (*Milestone, error) {
+	x, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if s.autosaved("milestone", id, "description", x.Description, d) {
+		return x, nil
+	}
+
 	x.Description = d
 	x.LastModified = time.Now().UTC()
 	x.LastModifiedByName = s.Acc.Name
-	s.r.StoreSubWorkflow(x)
+	s.r.StoreMilestone(x)
 
 	return x, nil
 }
 
-func (s *service) ChangeColorOnSubWorkflow(id string, color string) (*SubWorkflow, error) {
-
-	if !colorIsValid(color) {
-		return nil, errors.New("invalid color")
+func (s *service) CloseMilestone(id string) (*Milestone, error) {
+	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
 	}
 
-	p, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	p.Status = "CLOSED"
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreMilestone(p)
+
+	return p, nil
+}
+
+func (s *service) OpenMilestone(id string) (*Milestone, error) {
+	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
 	if p == nil {
 		return nil, err
 	}
 
-	p.Color = color
+	p.Status = "OPEN"
 	p.LastModifiedByName = s.Acc.Name
 	p.LastModified = time.Now().UTC()
 
-	s.r.StoreSubWorkflow(p)
+	s.r.StoreMilestone(p)
 
 	return p, nil
 }
 
-func (s *service) CloseSubWorkflow(id string) (*SubWorkflow, error) {
-	p, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+func (s *service) ChangeColorOnMilestone(id string, color string) (*Milestone, error) {
+
+	if !colorIsValid(color) {
+		return nil, errors.New("invalid color")
+	}
+
+	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
 	if p == nil {
 		return nil, err
 	}
 
-	p.Status = "CLOSED"
+	p.Color = color
 	p.LastModifiedByName = s.Acc.Name
 	p.LastModified = time.Now().UTC()
 
-	s.r.StoreSubWorkflow(p)
+	s.r.StoreMilestone(p)
 
 	return p, nil
 }
 
-func (s *service) OpenSubWorkflow(id string) (*SubWorkflow, error) {
-	p, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+func (s *service) ChangeIconOnMilestone(id string, icon string) (*Milestone, error) {
+
+	if !iconIsValid(icon) {
+		return nil, errors.New("invalid icon")
+	}
+
+	p, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
 	if p == nil {
 		return nil, err
 	}
 
-	p.Status = "OPEN"
+	p.Icon = icon
 	p.LastModifiedByName = s.Acc.Name
 	p.LastModified = time.Now().UTC()
 
-	s.r.StoreSubWorkflow(p)
+	s.r.StoreMilestone(p)
 
 	return p, nil
 }
 
-func (s *service) UpdateAnnotationsOnSubWorkflow(id string, names string) (*SubWorkflow, error) {
+func (s *service) UpdateAnnotationsOnMilestone(id string, names string) (*Milestone, error) {
 
-	f, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	f, err := s.r.GetMilestone(s.Member.WorkspaceID, id)
 	if f == nil {
 		return nil, err
 	}
@@ -1583,142 +2592,218 @@ func (s *service) UpdateAnnotationsOnSubWorkflow(id string, names string) (*SubW
 	f.LastModifiedByName = s.Acc.Name
 	f.LastModified = time.Now().UTC()
 
-	s.r.StoreSubWorkflow(f)
+	s.r.StoreMilestone(f)
 
 	return f, nil
 }
 
-// Features
+// Workflow
 
-func (s *service) CreateFeatureWithID(id string, subWorkflowID string, milestoneID string, title string) (*Feature, error) {
+func (s *service) CreateWorkflowWithID(id string, projectID string, title string) (*Workflow, error) {
 
 	title, err := validateTitle(title)
 	if err != nil {
 		return nil, err
 	}
 
-	pp, _ := s.r.GetFeature(s.Member.WorkspaceID, id)
-
-	if pp != nil {
+	a, _ := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+	if a != nil {
 		return nil, errors.New("already exists")
 	}
 
-	mm, _ := s.r.FindFeaturesByMilestoneAndSubWorkflow(s.Member.WorkspaceID, milestoneID, subWorkflowID)
+	ww, _ := s.r.FindWorkflowsByProject(s.Member.WorkspaceID, projectID)
 
-	p := &Feature{
+	p := &Workflow{
 		WorkspaceID:   s.Member.WorkspaceID,
-		MilestoneID:   milestoneID,
-		SubWorkflowID: subWorkflowID,
+		ProjectID:     projectID,
 		ID:            id,
 		Title:         title,
 		Rank:          "",
-		Description:   "",
-		Status:        "OPEN",
 		CreatedAt:     time.Now().UTC(),
 		CreatedByName: s.Acc.Name,
 		Color:         "WHITE",
+		Status:        "OPEN",
 	}
 
-	n := len(mm)
+	n := len(ww)
 	if n == 0 {
 		rank, _ := lexorank.Rank("", "")
 		p.Rank = rank
 	} else {
-		rank, _ := lexorank.Rank(mm[n-1].Rank, "")
+		rank, _ := lexorank.Rank(ww[n-1].Rank, "")
 		p.Rank = rank
 	}
 
 	p.LastModifiedByName = s.Acc.Name
 	p.LastModified = time.Now().UTC()
 
-	s.r.StoreFeature(p)
+	s.r.StoreWorkflow(p)
 
 	return p, nil
 }
 
-func (s *service) DeleteFeature(id string) error {
-	s.r.DeleteFeature(s.Member.WorkspaceID, id)
-	return nil
-}
+func (s *service) MoveWorkflow(id string, index int) (*Workflow, error) {
 
-func (s *service) RenameFeature(id string, title string) (*Feature, error) {
+	if index < 0 || index > 1000 {
+		return nil, errors.New("index invalid")
+	}
 
-	title, err := validateTitle(title)
+	m, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
 	if err != nil {
 		return nil, err
 	}
 
-	p, _ := s.r.GetFeature(s.Member.WorkspaceID, id)
-	if p == nil {
-		return nil, errors.Wrap(err, "could not find")
+	if _, err := s.r.GetProject(s.Member.WorkspaceID, m.ProjectID); err != nil {
+		return nil, errMoveTargetGone
 	}
 
-	p.Title = title
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
-
-	s.r.StoreFeature(p)
-
-	return p, nil
-}
+	mm, _ := s.r.FindWorkflowsByProject(s.Member.WorkspaceID, m.ProjectID)
 
-func (s *service) CloseFeature(id string) (*Feature, error) {
-	p, err := s.r.GetFeature(s.Member.WorkspaceID, id)
-	if p == nil {
-		return nil, err
+	// Remove the item we are moving
+	mmf := []*Workflow{}
+	for _, x := range mm {
+		if x.ID != id {
+			mmf = append(mmf, x)
+		}
 	}
 
-	p.Status = "CLOSED"
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
-
-	s.r.StoreFeature(p)
+	var prevRank, nextRank string
+	length := len(mmf)
 
-	return p, nil
-}
+	if length != 0 {
+		if (index - 1) >= 0 {
+			prevRank = mmf[index-1].Rank
+		}
 
-func (s *service) OpenFeature(id string) (*Feature, error) {
-	p, err := s.r.GetFeature(s.Member.WorkspaceID, id)
-	if p == nil {
-		return nil, err
+		if index < length {
+			nextRank = mmf[index].Rank
+		}
 	}
 
-	p.Status = "OPEN"
-	p.LastModifiedByName = s.Acc.Name
-	p.LastModified = time.Now().UTC()
+	rank, _ := lexorank.Rank(prevRank, nextRank)
 
-	s.r.StoreFeature(p)
+	m.Rank = rank
+	m.LastModifiedByName = s.Acc.Name
+	m.LastModified = time.Now().UTC()
 
-	return p, nil
+	s.r.StoreWorkflow(m)
+
+	return m, nil
 }
 
-func (s *service) ChangeColorOnFeature(id string, color string) (*Feature, error) {
+func (s *service) RenameWorkflow(id string, title string) (*Workflow, error) {
 
-	if !colorIsValid(color) {
-		return nil, errors.New("invalid color")
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
 	}
 
-	p, err := s.r.GetFeature(s.Member.WorkspaceID, id)
-	if p == nil {
+	p, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+	if err != nil {
 		return nil, err
 	}
 
-	p.Color = color
+	if s.autosaved("workflow", id, "title", p.Title, title) {
+		return p, nil
+	}
+
+	p.Title = title
 	p.LastModifiedByName = s.Acc.Name
 	p.LastModified = time.Now().UTC()
 
-	s.r.StoreFeature(p)
+	s.r.StoreWorkflow(p)
 
 	return p, nil
 }
 
-func (s *service) UpdateAnnotationsOnFeature(id string, names string) (*Feature, error) {
+func (s *service) DeleteWorkflow(id string) error {
+	s.r.DeleteWorkflow(s.Member.WorkspaceID, id)
+	return nil
+}
 
-	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
-	if f == nil {
-		return nil, err
+func (s *service) GetWorkflowsByProject(id string) []*Workflow {
+	pp, err := s.r.FindWorkflowsByProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		log.Println(err)
 	}
-
+	return pp
+}
+
+func (s *service) UpdateWorkflowDescription(id string, d string) (*Workflow, error) {
+	x, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.autosaved("workflow", id, "description", x.Description, d) {
+		return x, nil
+	}
+
+	x.Description = d
+	x.LastModified = time.Now().UTC()
+	x.LastModifiedByName = s.Acc.Name
+	s.r.StoreWorkflow(x)
+
+	return x, nil
+}
+
+func (s *service) ChangeColorOnWorkflow(id string, color string) (*Workflow, error) {
+
+	if !colorIsValid(color) {
+		return nil, errors.New("invalid color")
+	}
+
+	p, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	p.Color = color
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreWorkflow(p)
+
+	return p, nil
+}
+
+func (s *service) CloseWorkflow(id string) (*Workflow, error) {
+	p, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	p.Status = "CLOSED"
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreWorkflow(p)
+
+	return p, nil
+}
+
+func (s *service) OpenWorkflow(id string) (*Workflow, error) {
+	p, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	p.Status = "OPEN"
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreWorkflow(p)
+
+	return p, nil
+}
+
+func (s *service) UpdateAnnotationsOnWorkflow(id string, names string) (*Workflow, error) {
+
+	f, err := s.r.GetWorkflow(s.Member.WorkspaceID, id)
+	if f == nil {
+		return nil, err
+	}
+
 	if !areAnnotationsValid(names) {
 		return nil, errors.New("invalid annotation")
 	}
@@ -1727,160 +2812,1351 @@ func (s *service) UpdateAnnotationsOnFeature(id string, names string) (*Feature,
 	f.LastModifiedByName = s.Acc.Name
 	f.LastModified = time.Now().UTC()
 
-	s.r.StoreFeature(f)
+	s.r.StoreWorkflow(f)
 
 	return f, nil
 }
 
-func (s *service) UpdateEstimateOnFeature(id string, estimate int) (*Feature, error) {
-
-	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
-	if f == nil {
-		return nil, err
+// SubWorkflow
+func (s *service) CreateSubWorkflowWithID(id string, workflowID string, title string) (*SubWorkflow, error) {
+
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	a, _ := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if a != nil {
+		return nil, errors.New("already exists")
+	}
+
+	mm, _ := s.r.FindSubWorkflowsByWorkflow(s.Member.WorkspaceID, workflowID)
+
+	p := &SubWorkflow{
+		WorkspaceID:   s.Member.WorkspaceID,
+		WorkflowID:    workflowID,
+		ID:            id,
+		Title:         title,
+		Rank:          "",
+		CreatedAt:     time.Now().UTC(),
+		CreatedByName: s.Acc.Name,
+		Color:         "WHITE",
+		Status:        "OPEN",
+	}
+
+	n := len(mm)
+	if n == 0 {
+		rank, _ := lexorank.Rank("", "")
+		p.Rank = rank
+	} else {
+		rank, _ := lexorank.Rank(mm[n-1].Rank, "")
+		p.Rank = rank
+	}
+
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+	s.r.StoreSubWorkflow(p)
+
+	return p, nil
+}
+
+func (s *service) MoveSubWorkflow(id string, toWorkflowID string, index int) (*SubWorkflow, error) {
+
+	if index < 0 || index > 1000 {
+		return nil, errors.New("index invalid")
+	}
+
+	m, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.r.GetWorkflow(s.Member.WorkspaceID, toWorkflowID); err != nil {
+		return nil, errMoveTargetGone
+	}
+
+	mm, _ := s.r.FindSubWorkflowsByWorkflow(s.Member.WorkspaceID, toWorkflowID)
+
+	// Remove the item we are moving
+	mmf := []*SubWorkflow{}
+	for _, x := range mm {
+		if x.ID != id {
+			mmf = append(mmf, x)
+		}
+	}
+
+	var prevRank, nextRank string
+	length := len(mmf)
+
+	if length != 0 {
+		if (index - 1) >= 0 {
+			prevRank = mmf[index-1].Rank
+		}
+
+		if index < length {
+			nextRank = mmf[index].Rank
+		}
+	}
+
+	rank, _ := lexorank.Rank(prevRank, nextRank)
+
+	m.Rank = rank
+	m.WorkflowID = toWorkflowID
+	m.LastModifiedByName = s.Acc.Name
+	m.LastModified = time.Now().UTC()
+
+	s.r.StoreSubWorkflow(m)
+
+	return m, nil
+}
+
+func (s *service) RenameSubWorkflow(id string, title string) (*SubWorkflow, error) {
+
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.autosaved("subworkflow", id, "title", p.Title, title) {
+		return p, nil
+	}
+
+	p.Title = title
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreSubWorkflow(p)
+
+	return p, nil
+}
+
+func (s *service) DeleteSubWorkflow(id string) error {
+	s.r.DeleteSubWorkflow(s.Member.WorkspaceID, id)
+	return nil
+}
+
+func (s *service) GetSubWorkflowsByProject(id string) []*SubWorkflow {
+	pp, err := s.r.FindSubWorkflowsByProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		log.Println(err)
+	}
+	return pp
+}
+
+func (s *service) UpdateSubWorkflowDescription(id string, d string) (*SubWorkflow, error) {
+	x, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.autosaved("subworkflow", id, "description", x.Description, d) {
+		return x, nil
+	}
+
+	x.Description = d
+	x.LastModified = time.Now().UTC()
+	x.LastModifiedByName = s.Acc.Name
+	s.r.StoreSubWorkflow(x)
+
+	return x, nil
+}
+
+func (s *service) ChangeColorOnSubWorkflow(id string, color string) (*SubWorkflow, error) {
+
+	if !colorIsValid(color) {
+		return nil, errors.New("invalid color")
+	}
+
+	p, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	p.Color = color
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreSubWorkflow(p)
+
+	return p, nil
+}
+
+func (s *service) CloseSubWorkflow(id string) (*SubWorkflow, error) {
+	p, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	p.Status = "CLOSED"
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreSubWorkflow(p)
+
+	return p, nil
+}
+
+func (s *service) OpenSubWorkflow(id string) (*SubWorkflow, error) {
+	p, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	p.Status = "OPEN"
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreSubWorkflow(p)
+
+	return p, nil
+}
+
+func (s *service) UpdateAnnotationsOnSubWorkflow(id string, names string) (*SubWorkflow, error) {
+
+	f, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+	if f == nil {
+		return nil, err
+	}
+
+	if !areAnnotationsValid(names) {
+		return nil, errors.New("invalid annotation")
+	}
+
+	f.Annotations = names
+	f.LastModifiedByName = s.Acc.Name
+	f.LastModified = time.Now().UTC()
+
+	s.r.StoreSubWorkflow(f)
+
+	return f, nil
+}
+
+// Features
+
+func (s *service) CreateFeatureWithID(id string, subWorkflowID string, milestoneID string, title string, estimate *int) (*Feature, error) {
+
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, milestoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := s.projectEstimate(m.ProjectID, estimate)
+	if err != nil {
+		return nil, err
+	}
+
+	pp, _ := s.r.GetFeature(s.Member.WorkspaceID, id)
+
+	if pp != nil {
+		return nil, errors.New("already exists")
+	}
+
+	mm, _ := s.r.FindFeaturesByMilestoneAndSubWorkflow(s.Member.WorkspaceID, milestoneID, subWorkflowID)
+
+	if s.featureCapExceeded(len(mm) + 1) {
+		return nil, errors.New("too many features")
+	}
+
+	p := &Feature{
+		WorkspaceID:   s.Member.WorkspaceID,
+		MilestoneID:   milestoneID,
+		SubWorkflowID: subWorkflowID,
+		ID:            id,
+		Title:         title,
+		Rank:          "",
+		Description:   "",
+		Status:        "OPEN",
+		StatusID:      s.firstStatus(s.Member.WorkspaceID, m.ProjectID, false),
+		CreatedAt:     time.Now().UTC(),
+		CreatedByName: s.Acc.Name,
+		Color:         "WHITE",
+		Estimate:      e,
+		Annotations:   s.defaultAnnotations(m.ProjectID),
+	}
+
+	n := len(mm)
+	if n == 0 {
+		rank, _ := lexorank.Rank("", "")
+		p.Rank = rank
+	} else {
+		rank, _ := lexorank.Rank(mm[n-1].Rank, "")
+		p.Rank = rank
+	}
+
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+	trackBlocked(p, p.LastModified)
+
+	s.r.StoreFeature(p)
+	s.recordFeatureEvent(p, p.Status)
+
+	return p, nil
+}
+
+// DuplicateFeature copies a feature right after itself in the same cell. Annotations and
+// comments are only copied when asked for.
+func (s *service) DuplicateFeature(id string, newID string, copyAnnotations bool, copyComments bool) (*Feature, error) {
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, errors.New("feature not found")
+	}
+
+	if x, _ := s.r.GetFeature(s.Member.WorkspaceID, newID); x != nil {
+		return nil, errors.New("already exists")
+	}
+
+	mm, _ := s.r.FindFeaturesByMilestoneAndSubWorkflow(s.Member.WorkspaceID, f.MilestoneID, f.SubWorkflowID)
+	if s.featureCapExceeded(len(mm) + 1) {
+		return nil, errors.New("too many features")
+	}
+
+	next := ""
+	for i, x := range mm {
+		if x.ID == f.ID && i+1 < len(mm) {
+			next = mm[i+1].Rank
+		}
+	}
+	rank, _ := lexorank.Rank(f.Rank, next)
+
+	t := time.Now().UTC()
+	p := *f
+	p.ID = newID
+	p.Rank = rank
+	p.CreatedAt, p.CreatedByName = t, s.Acc.Name
+	p.LastModified, p.LastModifiedByName = t, s.Acc.Name
+	if !copyAnnotations {
+		p.Annotations = ""
+	}
+	p.BlockedSince, p.BlockedEscalated = nil, false
+	trackBlocked(&p, t)
+
+	s.r.StoreFeature(&p)
+	s.recordFeatureEvent(&p, p.Status)
+
+	if copyComments {
+		cc, err := s.r.FindFeatureCommentsByFeature(s.Member.WorkspaceID, f.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cc {
+			owner, _ := s.r.GetFeatureCommentOwnerByFeatureComment(s.Member.WorkspaceID, c.ID)
+
+			c.ID = uuid.Must(uuid.NewV4(), nil).String()
+			c.FeatureID = p.ID
+			s.r.StoreFeatureComment(c)
+
+			if owner != nil {
+				owner.ID = uuid.Must(uuid.NewV4(), nil).String()
+				owner.FeatureCommentID = c.ID
+				s.r.StoreFeatureCommentOwner(owner)
+			}
+		}
+	}
+
+	return &p, nil
+}
+
+type newFeature struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Color    string `json:"color"`
+	Estimate *int   `json:"estimate"`
+}
+
+func (s *service) featureCapExceeded(n int) bool {
+	return s.config.MaxFeaturesPerCell > 0 && n > s.config.MaxFeaturesPerCell
+}
+
+func (s *service) CreateFeatures(milestoneID string, subWorkflowID string, features []*newFeature) ([]*Feature, error) {
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, milestoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	sw, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, subWorkflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	wf, err := s.r.GetWorkflow(s.Member.WorkspaceID, sw.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wf.ProjectID != m.ProjectID {
+		return nil, errors.New("not in the same project")
+	}
+
+	if len(features) == 0 {
+		return nil, errors.New("no features")
+	}
+
+	mm, _ := s.r.FindFeaturesByMilestoneAndSubWorkflow(s.Member.WorkspaceID, milestoneID, subWorkflowID)
+
+	if s.featureCapExceeded(len(mm) + len(features)) {
+		return nil, errors.New("too many features")
+	}
+
+	// Validate everything before storing anything
+	seen := map[string]bool{}
+	for _, x := range features {
+		title, err := validateTitle(x.Title)
+		if err != nil {
+			return nil, err
+		}
+		x.Title = title
+
+		if x.Color == "" {
+			x.Color = "WHITE"
+		}
+		if !colorIsValid(x.Color) {
+			return nil, errors.New("invalid color")
+		}
+
+		e, err := s.projectEstimate(m.ProjectID, x.Estimate)
+		if err != nil {
+			return nil, err
+		}
+		x.Estimate = &e
+
+		if x.ID == "" {
+			x.ID = uuid.Must(uuid.NewV4(), nil).String()
+		} else if pp, _ := s.r.GetFeature(s.Member.WorkspaceID, x.ID); pp != nil {
+			return nil, errors.New("already exists")
+		}
+		if seen[x.ID] {
+			return nil, errRepeatedFeatureID
+		}
+		seen[x.ID] = true
+	}
+
+	var prevRank string
+	if n := len(mm); n > 0 {
+		prevRank = mm[n-1].Rank
+	}
+	ranks := make([]string, len(features))
+	for i := range features {
+		rank, ok := lexorank.Rank(prevRank, "")
+		if !ok {
+			return nil, errors.New("could not rank features")
+		}
+		ranks[i] = rank
+		prevRank = rank
+	}
+
+	t := time.Now().UTC()
+	annotations := s.defaultAnnotations(m.ProjectID)
+	statusID := s.firstStatus(s.Member.WorkspaceID, m.ProjectID, false)
+	created := []*Feature{}
+	for i, x := range features {
+		p := &Feature{
+			WorkspaceID:        s.Member.WorkspaceID,
+			MilestoneID:        milestoneID,
+			SubWorkflowID:      subWorkflowID,
+			ID:                 x.ID,
+			Title:              x.Title,
+			Rank:               ranks[i],
+			Description:        "",
+			Status:             "OPEN",
+			StatusID:           statusID,
+			CreatedAt:          t,
+			CreatedByName:      s.Acc.Name,
+			Color:              x.Color,
+			Estimate:           *x.Estimate,
+			Annotations:        annotations,
+			LastModified:       t,
+			LastModifiedByName: s.Acc.Name,
+		}
+		trackBlocked(p, t)
+
+		s.r.StoreFeature(p)
+		s.recordFeatureEvent(p, p.Status)
+		created = append(created, p)
+	}
+
+	return created, nil
+}
+
+func (s *service) DeleteFeature(id string) error {
+	if f, _ := s.r.GetFeature(s.Member.WorkspaceID, id); f != nil {
+		f.LastModified = time.Now().UTC()
+		s.recordFeatureEvent(f, "DELETED")
+	}
+	s.r.DeleteFeature(s.Member.WorkspaceID, id)
+	return nil
+}
+
+func (s *service) RenameFeature(id string, title string) (*Feature, error) {
+
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	p, _ := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, errors.Wrap(err, "could not find")
+	}
+
+	if s.autosaved("feature", id, "title", p.Title, title) {
+		return p, nil
+	}
+
+	p.Title = title
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(p)
+	s.recordFeatureEvent(p, p.Status)
+
+	return p, nil
+}
+
+func (s *service) CloseFeature(id string) (*Feature, error) {
+	p, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, p.MilestoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Estimate == 0 {
+		project, err := s.r.GetProject(s.Member.WorkspaceID, m.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if project.RequireEstimate {
+			return nil, errEstimateRequired
+		}
+	}
+
+	if p.Status != "CLOSED" {
+		p.StatusID = s.firstStatus(p.WorkspaceID, m.ProjectID, true)
+	}
+	p.Status = "CLOSED"
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(p)
+	s.recordFeatureEvent(p, p.Status)
+
+	s.notifyWatchers(p, m.ProjectID, "status", "closed the card", "")
+
+	return p, nil
+}
+
+func (s *service) OpenFeature(id string) (*Feature, error) {
+	p, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, p.MilestoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Status != "OPEN" {
+		p.StatusID = s.firstStatus(p.WorkspaceID, m.ProjectID, false)
+	}
+	p.Status = "OPEN"
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(p)
+	s.recordFeatureEvent(p, p.Status)
+
+	s.notifyWatchers(p, m.ProjectID, "status", "reopened the card", "")
+
+	return p, nil
+}
+
+func (s *service) ChangeColorOnFeature(id string, color string) (*Feature, error) {
+
+	if !colorIsValid(color) {
+		return nil, errors.New("invalid color")
+	}
+
+	p, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	p.Color = color
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(p)
+
+	return p, nil
+}
+
+func (s *service) ChangeIconOnFeature(id string, icon string) (*Feature, error) {
+
+	if !iconIsValid(icon) {
+		return nil, errors.New("invalid icon")
+	}
+
+	p, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if p == nil {
+		return nil, err
+	}
+
+	p.Icon = icon
+	p.LastModifiedByName = s.Acc.Name
+	p.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(p)
+
+	return p, nil
+}
+
+func (s *service) UpdateAnnotationsOnFeature(id string, names string) (*Feature, error) {
+
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if f == nil {
+		return nil, err
+	}
+
+	if !areAnnotationsValid(names) {
+		return nil, errors.New("invalid annotation")
+	}
+
+	f.Annotations = names
+	f.LastModifiedByName = s.Acc.Name
+	f.LastModified = time.Now().UTC()
+	trackBlocked(f, f.LastModified)
+
+	s.r.StoreFeature(f)
+
+	return f, nil
+}
+
+// BulkAnnotateFeatures adds and removes annotations on the features of a project matching filter
+// and returns how many features changed
+func (s *service) BulkAnnotateFeatures(projectID string, filter featureFilter, add []string, remove []string) (int, error) {
+	if len(add) == 0 && len(remove) == 0 {
+		return 0, errors.New("no annotations to add or remove")
+	}
+	if !areAnnotationsValid(strings.Join(add, ",")) || !areAnnotationsValid(strings.Join(remove, ",")) {
+		return 0, errors.New("invalid annotation")
+	}
+
+	ff, err := s.r.FindFeaturesByProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		return 0, err
+	}
+
+	t := time.Now().UTC()
+	n := 0
+	for _, f := range ff {
+		if !filter.matches(f) {
+			continue
+		}
+
+		names, changed := changeAnnotations(f.Annotations, add, remove)
+		if !changed {
+			continue
+		}
+
+		f.Annotations = names
+		f.LastModifiedByName = s.Acc.Name
+		f.LastModified = t
+		trackBlocked(f, t)
+		s.r.StoreFeature(f)
+		n++
+	}
+
+	return n, nil
+}
+
+func (s *service) UpdateEstimateOnFeature(id string, estimate int) (*Feature, error) {
+
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if f == nil {
+		return nil, err
+	}
+
+	if err := s.checkEstimate(estimate); err != nil {
+		return nil, err
+	}
+
+	f.Estimate = estimate
+	f.LastModifiedByName = s.Acc.Name
+	f.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(f)
+	s.recordFeatureEvent(f, f.Status)
+
+	return f, nil
+}
+
+func (s *service) UpdateProgressOnFeature(id string, progress int) (*Feature, error) {
+
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if f == nil {
+		return nil, err
+	}
+
+	if progress < 0 || progress > 100 {
+		return nil, errors.New("invalid progress")
+	}
+
+	f.Progress = progress
+	f.LastModifiedByName = s.Acc.Name
+	f.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(f)
+
+	return f, nil
+}
+
+func (s *service) GetRollupByProject(id string) (*projectRollup, error) {
+	ff, err := s.r.FindFeaturesByProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "features not found")
+	}
+	s.markLoggedTime(id, ff)
+	return rollupFeatures(ff), nil
+}
+
+func (s *service) MoveFeature(id string, toMilestoneID string, toSubWorkflowID string, index int) (*Feature, error) {
+
+	if index < 0 || index > 1000 {
+		return nil, errors.New("index invalid")
+	}
+
+	m, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.r.GetMilestone(s.Member.WorkspaceID, toMilestoneID); err != nil {
+		return nil, errMoveTargetGone
+	}
+	if _, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, toSubWorkflowID); err != nil {
+		return nil, errMoveTargetGone
+	}
+
+	mm, _ := s.r.FindFeaturesByMilestoneAndSubWorkflow(s.Member.WorkspaceID, toMilestoneID, toSubWorkflowID)
+
+	// Remove the item we are moving
+	mmf := []*Feature{}
+	for _, x := range mm {
+		if x.ID != id {
+			mmf = append(mmf, x)
+		}
+	}
+
+	var prevRank, nextRank string
+	length := len(mmf)
+
+	if length != 0 {
+		if (index - 1) >= 0 {
+			prevRank = mmf[index-1].Rank
+		}
+
+		if index < length {
+			nextRank = mmf[index].Rank
+		}
+	}
+
+	rank, _ := lexorank.Rank(prevRank, nextRank)
+	m.Rank = rank
+	m.MilestoneID = toMilestoneID
+	m.SubWorkflowID = toSubWorkflowID
+	m.LastModifiedByName = s.Acc.Name
+	m.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(m)
+	s.recordFeatureEvent(m, m.Status)
+
+	return m, nil
+}
+
+// BulkMoveColumns moves the cards of subworkflows in one milestone to the same subworkflows in
+// another, after the cards already there and in the order they had
+func (s *service) BulkMoveColumns(projectID string, subWorkflowIDs []string, fromMilestoneID string, toMilestoneID string) ([]*Feature, error) {
+	if len(subWorkflowIDs) > 1000 {
+		return nil, errors.New("invalid number of subworkflows")
+	}
+
+	from, err := s.r.GetMilestone(s.Member.WorkspaceID, fromMilestoneID)
+	if err != nil || from.ProjectID != projectID {
+		return nil, errors.New("milestone not found")
+	}
+
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, id := range subWorkflowIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		sw, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, id)
+		if err != nil {
+			return nil, errors.New("subworkflow not found")
+		}
+		wf, err := s.r.GetWorkflow(s.Member.WorkspaceID, sw.WorkflowID)
+		if err != nil || wf.ProjectID != projectID {
+			return nil, errors.New("subworkflow not found")
+		}
+
+		cell, err := s.r.FindFeaturesByMilestoneAndSubWorkflow(s.Member.WorkspaceID, from.ID, sw.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range cell {
+			ids = append(ids, f.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return []*Feature{}, nil
+	}
+	return s.BulkMoveFeatures(projectID, ids, toMilestoneID, "")
+}
+
+// BulkMoveFeatures moves features of a project to another milestone, and to another subworkflow
+// if one is given. They are appended to their new cell in the order given. Nothing is moved
+// unless every feature and the target are valid.
+func (s *service) BulkMoveFeatures(projectID string, ids []string, toMilestoneID string, toSubWorkflowID string) ([]*Feature, error) {
+	if len(ids) == 0 || len(ids) > 1000 {
+		return nil, errors.New("invalid number of features")
+	}
+
+	target, err := s.r.GetMilestone(s.Member.WorkspaceID, toMilestoneID)
+	if err != nil {
+		return nil, errMoveTargetGone
+	}
+	if target.ProjectID != projectID {
+		return nil, errors.New("not in the same project")
+	}
+	if toSubWorkflowID != "" {
+		sw, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, toSubWorkflowID)
+		if err != nil {
+			return nil, errMoveTargetGone
+		}
+		wf, err := s.r.GetWorkflow(s.Member.WorkspaceID, sw.WorkflowID)
+		if err != nil || wf.ProjectID != projectID {
+			return nil, errors.New("not in the same project")
+		}
+	}
+
+	mm, err := s.r.FindMilestonesByProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	inProject := map[string]bool{}
+	for _, m := range mm {
+		inProject[m.ID] = true
+	}
+
+	moving := map[string]bool{}
+	ff := []*Feature{}
+	for _, id := range ids {
+		if moving[id] {
+			continue
+		}
+		f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+		if err != nil || !inProject[f.MilestoneID] {
+			return nil, errors.New("feature not found")
+		}
+		moving[id] = true
+		ff = append(ff, f)
+	}
+
+	// The last rank of every target cell, leaving out the features being moved
+	last := map[string]string{}
+	count := map[string]int{}
+	for _, f := range ff {
+		sw := toSubWorkflowID
+		if sw == "" {
+			sw = f.SubWorkflowID
+		}
+		if _, ok := last[sw]; ok {
+			continue
+		}
+		last[sw] = ""
+		cell, _ := s.r.FindFeaturesByMilestoneAndSubWorkflow(s.Member.WorkspaceID, toMilestoneID, sw)
+		for _, x := range cell {
+			if !moving[x.ID] {
+				last[sw] = x.Rank
+				count[sw]++
+			}
+		}
+	}
+
+	t := time.Now().UTC()
+	for _, f := range ff {
+		if toSubWorkflowID != "" {
+			f.SubWorkflowID = toSubWorkflowID
+		}
+		count[f.SubWorkflowID]++
+		if s.featureCapExceeded(count[f.SubWorkflowID]) {
+			return nil, errors.New("too many features")
+		}
+
+		f.Rank, _ = lexorank.Rank(last[f.SubWorkflowID], "")
+		last[f.SubWorkflowID] = f.Rank
+		f.MilestoneID = toMilestoneID
+		f.LastModifiedByName = s.Acc.Name
+		f.LastModified = t
+	}
+
+	for _, f := range ff {
+		s.r.StoreFeature(f)
+		s.recordFeatureEvent(f, f.Status)
+	}
+
+	return ff, nil
+}
+
+// recordFeatureEvent stores the state of f after a change to its status, estimate or milestone
+func (s *service) recordFeatureEvent(f *Feature, status string) {
+	s.r.StoreFeatureEvent(&FeatureEvent{
+		WorkspaceID: f.WorkspaceID,
+		ID:          uuid.Must(uuid.NewV4(), nil).String(),
+		FeatureID:   f.ID,
+		MilestoneID: f.MilestoneID,
+		Status:      status,
+		Estimate:    f.Estimate,
+		CreatedAt:   f.LastModified,
+
+		Title:         f.Title,
+		CreatedByName: f.LastModifiedByName,
+	})
+}
+
+func (s *service) GetFeaturesByProject(id string) []*Feature {
+	pp, err := s.r.FindFeaturesByProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		log.Println(err)
+	}
+	s.markWatching(id, pp)
+	s.markReferences(id, pp)
+	s.markLoggedTime(id, pp)
+	return pp
+}
+
+func (s *service) UpdateFeatureDescription(id string, d string) (*Feature, error) {
+	x, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.autosaved("feature", id, "description", x.Description, d) {
+		return x, nil
+	}
+
+	x.Description = d
+	x.LastModified = time.Now().UTC()
+	x.LastModifiedByName = s.Acc.Name
+	s.r.StoreFeature(x)
+	s.recordFeatureEvent(x, x.Status)
+
+	return x, nil
+}
+
+// Feature comments
+
+func (s *service) CreateFeatureCommentWithID(id string, featureID string, post string) (*FeatureComment, error) {
+
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, featureID)
+	if err != nil {
+		return nil, errors.New("feature not found")
+	}
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, f.MilestoneID)
+	if err != nil {
+		return nil, errors.New("milestone not found")
+	}
+
+	if len(post) > 10000 {
+		return nil, errors.New("post_too_long")
+	}
+
+	t := time.Now().UTC()
+
+	p := &FeatureComment{
+		WorkspaceID:   s.Member.WorkspaceID,
+		ID:            id,
+		FeatureID:     featureID,
+		ProjectID:     m.ProjectID,
+		Post:          post,
+		CreatedAt:     t,
+		CreatedByName: s.Acc.Name,
+		LastModified:  t,
+	}
+
+	s.r.StoreFeatureComment(p)
+
+	owner := &FeatureCommentOwner{
+		WorkspaceID:      s.Member.WorkspaceID,
+		ID:               uuid.Must(uuid.NewV4(), nil).String(),
+		FeatureCommentID: p.ID,
+		MemberID:         s.Member.ID,
+		ProjectID:        p.ProjectID,
+	}
+
+	s.r.StoreFeatureCommentOwner(owner)
+
+	p.MemberID = owner.MemberID
+
+	s.r.StoreFeatureWatcher(&FeatureWatcher{
+		WorkspaceID: s.Member.WorkspaceID,
+		FeatureID:   featureID,
+		MemberID:    s.Member.ID,
+		ProjectID:   m.ProjectID,
+		CreatedAt:   t,
+	})
+	s.notifyWatchers(f, m.ProjectID, "comments", "commented", post)
+
+	return p, nil
+}
+
+func (s *service) DeleteFeatureComment(id string) error {
+
+	fco, err := s.r.GetFeatureCommentOwnerByFeatureComment(s.Member.WorkspaceID, id)
+	if err != nil {
+		return errors.New("feature comment owner not found")
+	}
+
+	if s.Member.ID != fco.MemberID {
+		return errors.New("something went wrong when deleting comment")
+	}
+
+	s.r.DeleteFeatureComment(s.Member.WorkspaceID, id)
+	return nil
+}
+
+// GetFeatureContext returns a feature with everything it belongs to, for deep links to a card
+func (s *service) GetFeatureContext(id string) (*featureContextResponse, error) {
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, errors.New("feature not found")
+	}
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, f.MilestoneID)
+	if err != nil {
+		return nil, errors.New("milestone not found")
+	}
+
+	sw, err := s.r.GetSubWorkflow(s.Member.WorkspaceID, f.SubWorkflowID)
+	if err != nil {
+		return nil, errors.New("subworkflow not found")
+	}
+
+	wf, err := s.r.GetWorkflow(s.Member.WorkspaceID, sw.WorkflowID)
+	if err != nil {
+		return nil, errors.New("workflow not found")
+	}
+
+	p, err := s.r.GetProject(s.Member.WorkspaceID, m.ProjectID)
+	if err != nil {
+		return nil, errors.New("project not found")
+	}
+
+	x := &featureContextResponse{
+		Feature:     f,
+		Milestone:   m,
+		SubWorkflow: sw,
+		Workflow:    wf,
+		Project:     p,
+	}
+
+	if f.StatusID != "" {
+		x.Status, _ = s.r.GetProjectStatus(s.Member.WorkspaceID, f.StatusID)
+	}
+
+	x.CommentCount, err = s.r.CountFeatureCommentsByFeature(s.Member.WorkspaceID, f.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.markWatching(p.ID, []*Feature{f})
+	s.markReferences(p.ID, []*Feature{f})
+	s.markLoggedTime(p.ID, []*Feature{f})
+	s.markFavorited(p)
+
+	return x, nil
+}
+
+// Feature watchers
+
+func (s *service) WatchFeature(id string) error {
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if err != nil {
+		return errors.New("feature not found")
+	}
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, f.MilestoneID)
+	if err != nil {
+		return errors.New("milestone not found")
+	}
+
+	s.r.StoreFeatureWatcher(&FeatureWatcher{
+		WorkspaceID: s.Member.WorkspaceID,
+		FeatureID:   f.ID,
+		MemberID:    s.Member.ID,
+		ProjectID:   m.ProjectID,
+		CreatedAt:   time.Now().UTC(),
+	})
+
+	return nil
+}
+
+func (s *service) UnwatchFeature(id string) error {
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if err != nil {
+		return errors.New("feature not found")
+	}
+
+	s.r.DeleteFeatureWatcher(s.Member.WorkspaceID, f.ID, s.Member.ID)
+
+	return nil
+}
+
+// markWatching sets Watching on the features the current member watches
+func (s *service) markWatching(projectID string, ff []*Feature) {
+	ids, err := s.r.FindWatchedFeatureIDsByProject(s.Member.WorkspaceID, projectID, s.Member.ID)
+	if err != nil {
+		log.Println(err)
+		return
 	}
 
-	if estimate < 0 || estimate > 999 {
-		return nil, errors.New("invalid estimate")
+	watched := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		watched[id] = true
+	}
+	for _, f := range ff {
+		f.Watching = watched[f.ID]
 	}
+}
 
-	f.Estimate = estimate
-	f.LastModifiedByName = s.Acc.Name
-	f.LastModified = time.Now().UTC()
+// Feature references
 
-	s.r.StoreFeature(f)
+// defaultMaxReferences is the number of references a feature can hold unless configured
+const defaultMaxReferences = 20
 
-	return f, nil
+func (s *service) maxReferences() int {
+	if s.config.MaxReferencesPerFeature > 0 {
+		return s.config.MaxReferencesPerFeature
+	}
+	return defaultMaxReferences
 }
 
-func (s *service) MoveFeature(id string, toMilestoneID string, toSubWorkflowID string, index int) (*Feature, error) {
-
-	if index < 0 || index > 1000 {
-		return nil, errors.New("index invalid")
+// referenceURLIsValid accepts absolute http and https urls
+func referenceURLIsValid(x string) bool {
+	u, err := url.Parse(x)
+	if err != nil || len(x) > 2000 {
+		return false
 	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && govalidator.IsURL(x)
+}
 
-	m, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+func (s *service) AddFeatureReference(featureID string, id string, label string, link string) (*FeatureReference, error) {
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, featureID)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("feature not found")
 	}
 
-	mm, _ := s.r.FindFeaturesByMilestoneAndSubWorkflow(s.Member.WorkspaceID, toMilestoneID, toSubWorkflowID)
-
-	// Remove the item we are moving
-	mmf := []*Feature{}
-	for _, x := range mm {
-		if x.ID != id {
-			mmf = append(mmf, x)
-		}
+	link = govalidator.Trim(link, "")
+	if !referenceURLIsValid(link) {
+		return nil, errors.New("invalid url")
 	}
 
-	var prevRank, nextRank string
-	length := len(mmf)
-
-	if length != 0 {
-		if (index - 1) >= 0 {
-			prevRank = mmf[index-1].Rank
-		}
-
-		if index < length {
-			nextRank = mmf[index].Rank
-		}
+	label = govalidator.Trim(label, "")
+	if len(label) > 200 {
+		return nil, errors.New("label too long")
 	}
 
-	rank, _ := lexorank.Rank(prevRank, nextRank)
-	m.Rank = rank
-	m.MilestoneID = toMilestoneID
-	m.SubWorkflowID = toSubWorkflowID
-	m.LastModifiedByName = s.Acc.Name
-	m.LastModified = time.Now().UTC()
-
-	s.r.StoreFeature(m)
-
-	return m, nil
-}
-
-func (s *service) GetFeaturesByProject(id string) []*Feature {
-	pp, err := s.r.FindFeaturesByProject(s.Member.WorkspaceID, id)
+	rr, err := s.r.FindFeatureReferencesByFeature(s.Member.WorkspaceID, f.ID)
 	if err != nil {
-		log.Println(err)
+		return nil, err
+	}
+	if len(rr) >= s.maxReferences() {
+		return nil, errors.New("too many references")
 	}
-	return pp
-}
 
-func (s *service) UpdateFeatureDescription(id string, d string) (*Feature, error) {
-	x, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, f.MilestoneID)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("milestone not found")
 	}
 
-	x.Description = d
-	x.LastModified = time.Now().UTC()
-	x.LastModifiedByName = s.Acc.Name
-	s.r.StoreFeature(x)
+	x := &FeatureReference{
+		WorkspaceID:   s.Member.WorkspaceID,
+		ProjectID:     m.ProjectID,
+		FeatureID:     f.ID,
+		ID:            id,
+		Label:         label,
+		URL:           link,
+		CreatedAt:     time.Now().UTC(),
+		CreatedByName: s.Acc.Name,
+	}
+	s.r.StoreFeatureReference(x)
 
 	return x, nil
 }
 
-// Feature comments
-
-func (s *service) CreateFeatureCommentWithID(id string, featureID string, post string) (*FeatureComment, error) {
-
+func (s *service) DeleteFeatureReference(featureID string, id string) error {
 	f, err := s.r.GetFeature(s.Member.WorkspaceID, featureID)
 	if err != nil {
-		return nil, errors.New("feature not found")
+		return errors.New("feature not found")
 	}
 
-	m, err := s.r.GetMilestone(s.Member.WorkspaceID, f.MilestoneID)
+	s.r.DeleteFeatureReference(s.Member.WorkspaceID, f.ID, id)
+	return nil
+}
+
+// markReferences sets References on the features of a project
+func (s *service) markReferences(projectID string, ff []*Feature) {
+	rr, err := s.r.FindFeatureReferencesByProject(s.Member.WorkspaceID, projectID)
 	if err != nil {
-		return nil, errors.New("milestone not found")
+		log.Println(err)
+		return
 	}
 
-	if len(post) > 10000 {
-		return nil, errors.New("post_too_long")
+	byFeature := map[string][]*FeatureReference{}
+	for _, r := range rr {
+		byFeature[r.FeatureID] = append(byFeature[r.FeatureID], r)
+	}
+	for _, f := range ff {
+		f.References = byFeature[f.ID]
+		if f.References == nil {
+			f.References = []*FeatureReference{}
+		}
 	}
+}
 
-	t := time.Now().UTC()
+// notifyWatchers emails everyone watching the feature except the member causing the change. The
+// event is the type of change, which the workspace may batch the notifications of.
+func (s *service) notifyWatchers(f *Feature, projectID string, event string, action string, post string) {
+	s.notifyFeatureWatchers(s.ws, s.Member.ID, s.Acc.Name, f, projectID, event, action, post)
+}
 
-	p := &FeatureComment{
-		WorkspaceID:   s.Member.WorkspaceID,
-		ID:            id,
-		FeatureID:     featureID,
-		ProjectID:     m.ProjectID,
-		Post:          post,
-		CreatedAt:     t,
-		CreatedByName: s.Acc.Name,
-		LastModified:  t,
+// notifyFeatureWatchers emails the watchers of a feature in ws about a change by actor. The
+// member with actorID, if any, is left out.
+func (s *service) notifyFeatureWatchers(ws *Workspace, actorID string, actor string, f *Feature, projectID string, event string, action string, post string) {
+	watchers, err := s.r.FindFeatureWatchersByFeature(ws.ID, f.ID)
+	if err != nil {
+		log.Println(err)
+		return
 	}
 
-	s.r.StoreFeatureComment(p)
+	for _, w := range watchers {
+		if w.ID == actorID {
+			continue
+		}
 
-	owner := &FeatureCommentOwner{
-		WorkspaceID:      s.Member.WorkspaceID,
-		ID:               uuid.Must(uuid.NewV4(), nil).String(),
-		FeatureCommentID: p.ID,
-		MemberID:         s.Member.ID,
-		ProjectID:        p.ProjectID,
+		body, err := watchNotificationBody(watchBody{
+			AppSiteURL:    s.config.AppSiteURL,
+			WorkspaceName: ws.Name,
+			ProjectID:     projectID,
+			FeatureID:     f.ID,
+			FeatureTitle:  f.Title,
+			Actor:         actor,
+			Action:        action,
+			Post:          post,
+		})
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		n := &NotificationEmail{AccountID: w.AccountID, Email: w.Email, Subject: "Featmap: " + f.Title, Body: body}
+		if window := batchWindow(ws.NotificationBatchWindows, event); window > 0 {
+			s.notifyBatched(n, event+":"+f.ID, window, s.sendNotificationEmail)
+			continue
+		}
+		s.notify(n, s.sendNotificationEmail)
 	}
+}
 
-	s.r.StoreFeatureCommentOwner(owner)
+// sendNotificationEmail sends n once the transaction has committed
+func (s *service) sendNotificationEmail(n *NotificationEmail) {
+	s.AfterCommit(func() {
+		_ = s.SendEmail(s.config.SMTPServer, s.config.SMTPPort, s.config.SMTPUser, s.config.SMTPPass, s.config.EmailFrom, n.Email, n.Subject, n.Body)
+	})
+}
 
-	p.MemberID = owner.MemberID
+// notify sends a notification unless the account already got the daily cap of them today. Those
+// over the cap are kept for the digest sent after the day ends. One with a batch key is kept even
+// without a cap, as it opens the batching window of that key.
+func (s *service) notify(n *NotificationEmail, send func(n *NotificationEmail)) {
+	limit := s.config.DailyNotificationCap
+	if limit <= 0 && n.BatchKey == "" {
+		send(n)
+		return
+	}
+
+	now := time.Now().UTC()
+	sent := 0
+	if limit > 0 {
+		var err error
+		if sent, err = s.r.CountNotificationEmailsSince(n.AccountID, now.Truncate(24*time.Hour)); err != nil {
+			log.Println(err)
+		}
+	}
 
-	return p, nil
+	n.ID = uuid.Must(uuid.NewV4(), nil).String()
+	n.CreatedAt = now
+	n.Digest = limit > 0 && sent >= limit
+	s.r.StoreNotificationEmail(n)
+
+	if !n.Digest {
+		send(n)
+	}
 }
 
-func (s *service) DeleteFeatureComment(id string) error {
+// SendNotificationDigests mails every account one digest of the notifications that went over the
+// cap on earlier days. It runs outside of a request.
+func (s *service) SendNotificationDigests(now time.Time) int {
+	today := now.UTC().Truncate(24 * time.Hour)
 
-	fco, err := s.r.GetFeatureCommentOwnerByFeatureComment(s.Member.WorkspaceID, id)
+	nn, err := s.r.FindDigestNotificationEmailsBefore(today)
 	if err != nil {
-		return errors.New("feature comment owner not found")
+		log.Println(err)
+		return 0
 	}
 
-	if s.Member.ID != fco.MemberID {
-		return errors.New("something went wrong when deleting comment")
+	byAccount := map[string][]*NotificationEmail{}
+	order := []string{}
+	for _, n := range nn {
+		if _, ok := byAccount[n.AccountID]; !ok {
+			order = append(order, n.AccountID)
+		}
+		byAccount[n.AccountID] = append(byAccount[n.AccountID], n)
 	}
 
-	s.r.DeleteFeatureComment(s.Member.WorkspaceID, id)
-	return nil
+	for _, id := range order {
+		items := byAccount[id]
+		body, err := digestBody(items)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		s.sendNotificationEmail(&NotificationEmail{AccountID: id, Email: items[0].Email, Subject: fmt.Sprintf("Featmap: %d more notifications", len(items)), Body: body})
+	}
+
+	s.r.DeleteNotificationEmailsBefore(today)
+
+	return len(order)
 }
 
 func (s *service) GetFeatureCommentsByProject(id string) []*FeatureComment {
@@ -1975,6 +4251,8 @@ func (s *service) ConfirmEmail(key string) error {
 
 	s.r.StoreAccount(a)
 
+	s.autoJoinWorkspaces(a)
+
 	return nil
 }
 
@@ -2025,6 +4303,18 @@ func (s *service) UpdateName(name string) error {
 	return nil
 }
 
+// UpdateLocale sets the locale of the account. An empty locale follows the browser again.
+func (s *service) UpdateLocale(locale string) error {
+	if locale != "" && !localeIsValid(locale) {
+		return errors.New("locale_invalid")
+	}
+
+	s.Acc.Locale = locale
+	s.r.StoreAccount(s.Acc)
+
+	return nil
+}
+
 func (s *service) ResendEmail() error {
 
 	a := s.Acc
@@ -2082,7 +4372,7 @@ func (s *service) SetPassword(password string, key string) error {
 }
 
 func levelIsValid(level string) bool {
-	return level == "VIEWER" || level == "EDITOR" || level == "ADMIN" || level == "OWNER"
+	return level == "VIEWER" || level == "COMMENTER" || level == "EDITOR" || level == "ADMIN" || level == "OWNER"
 }
 
 func colorIsValid(color string) bool {
@@ -2107,6 +4397,217 @@ func (s *service) GetPersonasByProject(id string) []*Persona {
 	return pp
 }
 
+// Project statuses
+
+func (s *service) GetProjectStatusesByProject(id string) []*ProjectStatus {
+	ss, err := s.r.FindProjectStatusesByProject(s.Member.WorkspaceID, id)
+	if err != nil {
+		log.Println(err)
+	}
+	return ss
+}
+
+// defaultStatuses are the statuses a new project starts with
+var defaultStatuses = []struct {
+	title  string
+	closed bool
+}{{"Open", false}, {"Closed", true}}
+
+func (s *service) createDefaultStatuses(p *Project) {
+	ranks := lexorank.Spread(len(defaultStatuses))
+	for i, x := range defaultStatuses {
+		s.r.StoreProjectStatus(&ProjectStatus{
+			WorkspaceID: p.WorkspaceID,
+			ProjectID:   p.ID,
+			ID:          uuid.Must(uuid.NewV4(), nil).String(),
+			Title:       x.title,
+			Rank:        ranks[i],
+			Closed:      x.closed,
+			CreatedAt:   p.CreatedAt,
+		})
+	}
+}
+
+// firstStatus returns the ID of the first closed, or open, status of a project. Every project
+// has at least one of each.
+func (s *service) firstStatus(workspaceID string, projectID string, closed bool) string {
+	ss, err := s.r.FindProjectStatusesByProject(workspaceID, projectID)
+	if err != nil {
+		log.Println(err)
+	}
+	if x := otherStatus(ss, "", closed); x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+// otherStatus returns the first status other than id that is closed, or open
+func otherStatus(ss []*ProjectStatus, id string, closed bool) *ProjectStatus {
+	for _, x := range ss {
+		if x.ID != id && x.Closed == closed {
+			return x
+		}
+	}
+	return nil
+}
+
+func statusFromClosed(closed bool) string {
+	if closed {
+		return "CLOSED"
+	}
+	return "OPEN"
+}
+
+func (s *service) CreateProjectStatusWithID(id string, projectID string, title string, closed bool) (*ProjectStatus, error) {
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	if x, _ := s.r.GetProjectStatus(s.Member.WorkspaceID, id); x != nil {
+		return nil, errors.New("already exists")
+	}
+
+	if _, err := s.r.GetProject(s.Member.WorkspaceID, projectID); err != nil {
+		return nil, errors.New("project not found")
+	}
+
+	ss, _ := s.r.FindProjectStatusesByProject(s.Member.WorkspaceID, projectID)
+
+	var prevRank string
+	if n := len(ss); n > 0 {
+		prevRank = ss[n-1].Rank
+	}
+	rank, _ := lexorank.Rank(prevRank, "")
+
+	x := &ProjectStatus{
+		WorkspaceID: s.Member.WorkspaceID,
+		ProjectID:   projectID,
+		ID:          id,
+		Title:       title,
+		Rank:        rank,
+		Closed:      closed,
+		CreatedAt:   time.Now().UTC(),
+	}
+	s.r.StoreProjectStatus(x)
+
+	return x, nil
+}
+
+func (s *service) UpdateProjectStatus(id string, title string, closed bool) (*ProjectStatus, error) {
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := s.r.GetProjectStatus(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := x.Closed != closed
+	if changed {
+		ss, _ := s.r.FindProjectStatusesByProject(s.Member.WorkspaceID, x.ProjectID)
+		if otherStatus(ss, x.ID, x.Closed) == nil {
+			return nil, errors.New("last " + strings.ToLower(statusFromClosed(x.Closed)) + " status")
+		}
+	}
+
+	x.Title = title
+	x.Closed = closed
+	s.r.StoreProjectStatus(x)
+
+	if changed {
+		status := statusFromClosed(closed)
+
+		// The features now open or closed show up in the burndown like any other change
+		flipped := []*Feature{}
+		ff, _ := s.r.FindFeaturesByProject(s.Member.WorkspaceID, x.ProjectID)
+		for _, f := range ff {
+			if f.StatusID == x.ID && f.Status != status {
+				flipped = append(flipped, f)
+			}
+		}
+
+		s.r.SyncFeatureStatuses(s.Member.WorkspaceID, x.ID, status)
+
+		t := time.Now().UTC()
+		for _, f := range flipped {
+			f.Status = status
+			f.LastModified, f.LastModifiedByName = t, s.Acc.Name
+			s.recordFeatureEvent(f, status)
+		}
+	}
+
+	return x, nil
+}
+
+// DeleteProjectStatus deletes a status and moves its features to the first other status that is
+// open, or closed, like it. The last open and the last closed status cannot be deleted.
+func (s *service) DeleteProjectStatus(id string) error {
+	x, err := s.r.GetProjectStatus(s.Member.WorkspaceID, id)
+	if err != nil {
+		return errors.New("status not found")
+	}
+
+	ss, _ := s.r.FindProjectStatusesByProject(s.Member.WorkspaceID, x.ProjectID)
+	replacement := otherStatus(ss, x.ID, x.Closed)
+	if replacement == nil {
+		return errors.New("last " + strings.ToLower(statusFromClosed(x.Closed)) + " status")
+	}
+
+	s.r.DeleteProjectStatus(s.Member.WorkspaceID, x.ID, replacement.ID)
+	return nil
+}
+
+// SetStatusOnFeature moves a feature to a custom status of its own project
+func (s *service) SetStatusOnFeature(id string, statusID string) (*Feature, error) {
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, f.MilestoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := s.r.GetProjectStatus(s.Member.WorkspaceID, statusID)
+	if err != nil {
+		return nil, errors.New("status not found")
+	}
+
+	if st.ProjectID != m.ProjectID {
+		return nil, errors.New("status not in project")
+	}
+
+	if st.Closed && f.Estimate == 0 {
+		project, err := s.r.GetProject(s.Member.WorkspaceID, m.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if project.RequireEstimate {
+			return nil, errEstimateRequired
+		}
+	}
+
+	previous := f.Status
+
+	f.StatusID = st.ID
+	f.Status = statusFromClosed(st.Closed)
+	f.LastModifiedByName = s.Acc.Name
+	f.LastModified = time.Now().UTC()
+
+	s.r.StoreFeature(f)
+
+	if f.Status != previous {
+		s.recordFeatureEvent(f, f.Status)
+	}
+	s.notifyWatchers(f, m.ProjectID, "status", "moved the card to "+st.Title, "")
+
+	return f, nil
+}
+
 // Workflow personas
 
 func (s *service) GetWorkflowPersonasByProject(id string) []*WorkflowPersona {
@@ -45,6 +45,174 @@ func isAnnotationValid(name string) bool {
 	return false
 }
 
+// Named icons the frontend can draw, besides a single emoji
+var validIcons = []string{
+	"star",
+	"flag",
+	"bug",
+	"rocket",
+	"bulb",
+	"lock",
+	"bolt",
+	"heart",
+	"check",
+	"warning",
+}
+
+// iconIsValid accepts no icon, one of the named icons or a single emoji. An emoji may be a
+// sequence of code points, such as a flag, a skin tone or people joined into one.
+func iconIsValid(icon string) bool {
+	if icon == "" || stringInSlice(icon, validIcons) {
+		return true
+	}
+	if len(icon) > 64 {
+		return false
+	}
+
+	rr := []rune(icon)
+	if isRegionalIndicator(rr[0]) {
+		return len(rr) == 2 && isRegionalIndicator(rr[1])
+	}
+
+	joined := true
+	for _, r := range rr {
+		switch {
+		case r == 0x200D:
+			if joined {
+				return false
+			}
+			joined = true
+		case r == 0xFE0F || r == 0x20E3 || (r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0xE0020 && r <= 0xE007F):
+			if joined {
+				return false
+			}
+		case isEmojiBase(r):
+			if !joined {
+				return false
+			}
+			joined = false
+		default:
+			return false
+		}
+	}
+	return !joined
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func isEmojiBase(r rune) bool {
+	return (r >= 0x1F300 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF) || (r >= 0x2B00 && r <= 0x2BFF) || r == 0x2122 || r == 0x2139 || (r >= 0x2190 && r <= 0x21FF) || (r >= 0x2300 && r <= 0x23FF)
+}
+
+// annotationUsage sums up the counts per annotation, in the order of validAnnotations
+func annotationUsage(cc []*AnnotationCount) []*AnnotationUsage {
+	x := []*AnnotationUsage{}
+	byName := map[string]*AnnotationUsage{}
+	for _, n := range validAnnotations {
+		u := &AnnotationUsage{Name: n}
+		byName[n] = u
+		x = append(x, u)
+	}
+
+	for _, c := range cc {
+		u, ok := byName[c.Name]
+		if !ok {
+			continue
+		}
+		switch c.Type {
+		case "project":
+			u.Projects += c.Count
+		case "milestone":
+			u.Milestones += c.Count
+		case "workflow":
+			u.Workflows += c.Count
+		case "subworkflow":
+			u.SubWorkflows += c.Count
+		case "feature":
+			u.Features += c.Count
+		}
+		u.Total += c.Count
+		if u.LastUsed == nil || c.LastUsed.After(*u.LastUsed) {
+			t := c.LastUsed
+			u.LastUsed = &t
+		}
+	}
+
+	return x
+}
+
+// changeAnnotations adds and removes annotation names on a comma separated list, keeping the
+// order of the names already there. It tells if anything changed.
+func changeAnnotations(names string, add []string, remove []string) (string, bool) {
+	x := []string{}
+	if names != "" {
+		x = strings.Split(names, ",")
+	}
+
+	has := func(n string) bool {
+		for _, m := range x {
+			if m == n {
+				return true
+			}
+		}
+		return false
+	}
+
+	changed := false
+	for _, n := range add {
+		if !has(n) {
+			x = append(x, n)
+			changed = true
+		}
+	}
+
+	for _, n := range remove {
+		kept := x[:0]
+		for _, m := range x {
+			if m != n {
+				kept = append(kept, m)
+			}
+		}
+		changed = changed || len(kept) != len(x)
+		x = kept
+	}
+
+	return strings.Join(x, ","), changed
+}
+
+// featureFilter selects features of a project, empty fields match everything
+type featureFilter struct {
+	MilestoneID   string `json:"milestoneId"`
+	SubWorkflowID string `json:"subWorkflowId"`
+	Status        string `json:"status"`
+	Annotation    string `json:"annotation"`
+	Text          string `json:"text"`
+}
+
+func (x featureFilter) matches(f *Feature) bool {
+	if x.MilestoneID != "" && f.MilestoneID != x.MilestoneID {
+		return false
+	}
+	if x.SubWorkflowID != "" && f.SubWorkflowID != x.SubWorkflowID {
+		return false
+	}
+	if x.Status != "" && f.Status != x.Status {
+		return false
+	}
+	if x.Annotation != "" && !stringInSlice(x.Annotation, strings.Split(f.Annotations, ",")) {
+		return false
+	}
+	if x.Text != "" {
+		text := strings.ToLower(x.Text)
+		if !strings.Contains(strings.ToLower(f.Title), text) && !strings.Contains(strings.ToLower(f.Description), text) {
+			return false
+		}
+	}
+	return true
+}
+
 var countries = []string{
 	"AF",
 	"AX",
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// annotateRepo holds the features of one project in memory
+type annotateRepo struct {
+	Repository
+	features []*Feature
+}
+
+func (a *annotateRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	x := []*Feature{}
+	for _, f := range a.features {
+		c := *f
+		x = append(x, &c)
+	}
+	return x, nil
+}
+
+func (a *annotateRepo) StoreFeature(x *Feature) {
+	for i, f := range a.features {
+		if f.ID == x.ID {
+			a.features[i] = x
+		}
+	}
+}
+
+func (a *annotateRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	for _, f := range a.features {
+		if f.ID == id {
+			c := *f
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func TestIconIsValid(t *testing.T) {
+	for _, icon := range []string{"", "rocket", "🚀", "☀️", "👍🏽", "🇸🇪", "👩‍💻", "👨‍👩‍👧"} {
+		if !iconIsValid(icon) {
+			t.Error("icon should be valid", icon)
+		}
+	}
+	for _, icon := range []string{"ROCKET", "spaceship", "a", "🚀🚀", "🇸", "‍🚀", "👩‍", "🚀x", "<svg>"} {
+		if iconIsValid(icon) {
+			t.Error("icon should be invalid", icon)
+		}
+	}
+}
+
+func TestChangeIconOnFeature(t *testing.T) {
+	repo := &annotateRepo{features: []*Feature{{ID: "f1"}}}
+	s := memberService(repo, "EDITOR")
+
+	if f, err := s.ChangeIconOnFeature("f1", "🐛"); err != nil || f.Icon != "🐛" || repo.features[0].Icon != "🐛" {
+		t.Error("icon should be stored", err)
+	}
+	if _, err := s.ChangeIconOnFeature("f1", "unicorn"); err == nil || repo.features[0].Icon != "🐛" {
+		t.Error("invalid icon should be rejected")
+	}
+}
+
+func TestChangeAnnotations(t *testing.T) {
+	if x, ok := changeAnnotations("RISKY,IDEA", []string{"BLOCKED", "IDEA"}, []string{"RISKY"}); x != "IDEA,BLOCKED" || !ok {
+		t.Error("unexpected annotations", x)
+	}
+	if x, ok := changeAnnotations("IDEA", []string{"IDEA"}, []string{"RISKY"}); x != "IDEA" || ok {
+		t.Error("nothing should change", x)
+	}
+}
+
+func TestBulkAnnotateFeatures(t *testing.T) {
+	repo := &annotateRepo{features: []*Feature{
+		{ID: "f1", MilestoneID: "m1", Title: "Login page", Status: "OPEN"},
+		{ID: "f2", MilestoneID: "m1", Title: "Signup", Status: "OPEN", Annotations: "IDEA"},
+		{ID: "f3", MilestoneID: "m2", Title: "Login API", Status: "OPEN"},
+	}}
+
+	s := memberService(repo, "EDITOR")
+
+	filter := featureFilter{MilestoneID: "m1"}
+
+	if n, err := s.BulkAnnotateFeatures("p1", filter, []string{"BLOCKED"}, nil); n != 2 || err != nil {
+		t.Error("both features of the milestone should be annotated", n, err)
+	}
+	if repo.features[0].Annotations != "BLOCKED" || repo.features[1].Annotations != "IDEA,BLOCKED" || repo.features[2].Annotations != "" {
+		t.Error("only matching features should change", repo.features[0], repo.features[1], repo.features[2])
+	}
+
+	if n, _ := s.BulkAnnotateFeatures("p1", filter, []string{"BLOCKED"}, nil); n != 0 {
+		t.Error("re-running should not affect any feature", n)
+	}
+
+	if n, _ := s.BulkAnnotateFeatures("p1", featureFilter{Text: "login"}, nil, []string{"BLOCKED"}); n != 1 || repo.features[0].Annotations != "" {
+		t.Error("text filter should match the title", n)
+	}
+
+	if _, err := s.BulkAnnotateFeatures("p1", filter, []string{"NOPE"}, nil); err == nil {
+		t.Error("unknown annotation should be rejected")
+	}
+}
+
+// usageRepo returns fixed annotation counts
+type usageRepo struct {
+	Repository
+	counts []*AnnotationCount
+}
+
+func (a *usageRepo) CountAnnotations(workspaceID string) ([]*AnnotationCount, error) {
+	return a.counts, nil
+}
+
+func TestGetAnnotationUsage(t *testing.T) {
+	older := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.AddDate(0, 1, 0)
+	repo := &usageRepo{counts: []*AnnotationCount{
+		{Name: "BLOCKED", Type: "feature", Count: 3, LastUsed: older},
+		{Name: "BLOCKED", Type: "subworkflow", Count: 1, LastUsed: newer},
+		{Name: "IDEA", Type: "milestone", Count: 2, LastUsed: older},
+		{Name: "GONE", Type: "feature", Count: 5, LastUsed: newer},
+	}}
+	s := memberService(repo, "EDITOR")
+
+	x, err := s.GetAnnotationUsage(false)
+	if err != nil || len(x) != len(validAnnotations) || x[0].Name != validAnnotations[0] {
+		t.Error("every annotation should be listed in the default order", err)
+	}
+
+	x, _ = s.GetAnnotationUsage(true)
+	blocked, idea, unused := x[0], x[1], x[2]
+	if blocked.Name != "BLOCKED" || blocked.Features != 3 || blocked.SubWorkflows != 1 || blocked.Total != 4 || !blocked.LastUsed.Equal(newer) {
+		t.Error("unexpected usage of BLOCKED", blocked)
+	}
+	if idea.Name != "IDEA" || idea.Milestones != 2 || idea.Total != 2 {
+		t.Error("unexpected usage of IDEA", idea)
+	}
+	if unused.Total != 0 || unused.LastUsed != nil {
+		t.Error("unused annotations should have no count", unused)
+	}
+}
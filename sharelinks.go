@@ -0,0 +1,155 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// A view of a share link is recorded at most this often, so busy links do not write on every view
+const shareLinkViewResolution = time.Hour
+
+// shareLink is the public link of a project, as listed to workspace admins
+type shareLink struct {
+	ProjectID     string     `json:"projectId"`
+	ProjectTitle  string     `json:"projectTitle"`
+	Link          string     `json:"link"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	CreatedByName string     `json:"createdByName"`
+	LastViewedAt  *time.Time `json:"lastViewedAt"`
+	ExpiresAt     *time.Time `json:"expiresAt"`
+}
+
+// shareLinkExpiry is when the link of p gets revoked if it is not viewed before, or nil if links
+// are never revoked
+func shareLinkExpiry(c Configuration, p *Project) *time.Time {
+	if c.RevokeShareLinksAfterDays <= 0 {
+		return nil
+	}
+	t := p.ExternalLinkCreatedAt
+	if p.ExternalLinkViewedAt != nil && p.ExternalLinkViewedAt.After(t) {
+		t = *p.ExternalLinkViewedAt
+	}
+	t = t.AddDate(0, 0, c.RevokeShareLinksAfterDays)
+	return &t
+}
+
+// GetShareLinks lists the share links of the workspace that are not revoked
+func (s *service) GetShareLinks() []*shareLink {
+	pp, err := s.r.FindProjectsByWorkspace(s.Member.WorkspaceID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	names := map[string]string{}
+	if mm, err := s.r.FindMembersByWorkspace(s.Member.WorkspaceID); err == nil {
+		for _, m := range mm {
+			names[m.ID] = m.Name
+		}
+	}
+
+	x := []*shareLink{}
+	for _, p := range pp {
+		if p.ExternalLinkRevokedAt != nil {
+			continue
+		}
+		createdBy, ok := names[p.ExternalLinkCreatedBy]
+		if !ok {
+			createdBy = p.CreatedByName
+		}
+		x = append(x, &shareLink{
+			ProjectID:     p.ID,
+			ProjectTitle:  p.Title,
+			Link:          p.ExternalLink,
+			CreatedAt:     p.ExternalLinkCreatedAt,
+			CreatedByName: createdBy,
+			LastViewedAt:  p.ExternalLinkViewedAt,
+			ExpiresAt:     shareLinkExpiry(s.config, p),
+		})
+	}
+	return x
+}
+
+// RenewShareLink gives the project a new share link. The old one stops working.
+func (s *service) RenewShareLink(projectID string) (*Project, error) {
+	p, err := s.r.GetProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		return nil, errors.New("project not found")
+	}
+
+	p.ExternalLink = uuid.Must(uuid.NewV4(), nil).String()
+	p.ExternalLinkCreatedAt = time.Now().UTC()
+	p.ExternalLinkCreatedBy = s.Member.ID
+	p.ExternalLinkViewedAt = nil
+	p.ExternalLinkRevokedAt = nil
+	s.r.StoreProject(p)
+
+	return p, nil
+}
+
+// RecordShareLinkView notes that the share link of p was just viewed
+func (s *service) RecordShareLinkView(p *Project) {
+	now := time.Now().UTC()
+	if p.ExternalLinkViewedAt != nil && now.Sub(*p.ExternalLinkViewedAt) < shareLinkViewResolution {
+		return
+	}
+	p.ExternalLinkViewedAt = &now
+	s.r.StoreProject(p)
+}
+
+// RevokeStaleShareLinks revokes the share links in all workspaces that have not been viewed for
+// RevokeShareLinksAfterDays, and tells the members who shared them. It runs outside of a request.
+func (s *service) RevokeStaleShareLinks(now time.Time) int {
+	days := s.config.RevokeShareLinksAfterDays
+	if days <= 0 {
+		return 0
+	}
+
+	pp, err := s.r.FindProjectsWithExternalLinkIdleSince(now.AddDate(0, 0, -days))
+	if err != nil {
+		log.Println(err)
+		return 0
+	}
+
+	for _, p := range pp {
+		// The revoked link is replaced so it can never be guessed back
+		p.ExternalLink = uuid.Must(uuid.NewV4(), nil).String()
+		p.ExternalLinkRevokedAt = &now
+		s.r.StoreProject(p)
+		s.notifyShareLinkRevoked(p, days)
+	}
+
+	return len(pp)
+}
+
+func (s *service) notifyShareLinkRevoked(p *Project, days int) {
+	if p.ExternalLinkCreatedBy == "" {
+		return
+	}
+
+	m, err := s.r.GetMemberWithAccount(p.WorkspaceID, p.ExternalLinkCreatedBy)
+	if err != nil {
+		return
+	}
+	ws, err := s.r.GetWorkspace(p.WorkspaceID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	body, err := shareLinkRevokedBody(shareLinkBody{
+		AppSiteURL:    s.config.AppSiteURL,
+		WorkspaceName: ws.Name,
+		ProjectID:     p.ID,
+		ProjectTitle:  p.Title,
+		Days:          days,
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	s.notify(&NotificationEmail{AccountID: m.AccountID, Email: m.Email, Subject: "Featmap: the share link of " + p.Title + " was revoked", Body: body}, s.sendNotificationEmail)
+}
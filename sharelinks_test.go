@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// shareLinkRepo keeps the projects of workspace "ws" in memory
+type shareLinkRepo struct {
+	notificationRepo
+	projects []*Project
+}
+
+func (a *shareLinkRepo) FindProjectsWithExternalLinkIdleSince(t time.Time) ([]*Project, error) {
+	x := []*Project{}
+	for _, p := range a.projects {
+		last := p.ExternalLinkCreatedAt
+		if p.ExternalLinkViewedAt != nil {
+			last = *p.ExternalLinkViewedAt
+		}
+		if p.ExternalLinkRevokedAt == nil && last.Before(t) {
+			c := *p
+			x = append(x, &c)
+		}
+	}
+	return x, nil
+}
+
+func (a *shareLinkRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	for _, p := range a.projects {
+		if p.ID == id {
+			c := *p
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *shareLinkRepo) FindProjectsByWorkspace(workspaceID string) ([]*Project, error) {
+	return a.projects, nil
+}
+
+func (a *shareLinkRepo) FindMembersByWorkspace(id string) ([]*Member, error) {
+	return []*Member{{WorkspaceID: id, ID: "ann", Name: "Ann"}}, nil
+}
+
+func (a *shareLinkRepo) StoreProject(x *Project) {
+	for i, p := range a.projects {
+		if p.ID == x.ID {
+			a.projects[i] = x
+		}
+	}
+}
+
+// Like the members table, GetMember knows nothing of the account
+func (a *shareLinkRepo) GetMember(workspaceID string, id string) (*Member, error) {
+	if id != "ann" {
+		return nil, errNotFound
+	}
+	return &Member{WorkspaceID: workspaceID, ID: id, AccountID: "a-ann"}, nil
+}
+
+func (a *shareLinkRepo) GetMemberWithAccount(workspaceID string, id string) (*Member, error) {
+	m, err := a.GetMember(workspaceID, id)
+	if err != nil {
+		return nil, err
+	}
+	m.Name, m.Email = "Ann", "ann@example.com"
+	return m, nil
+}
+
+func (a *shareLinkRepo) GetWorkspace(id string) (*Workspace, error) {
+	return &Workspace{ID: id, Name: "acme"}, nil
+}
+
+// Over the cap, notifications are stored for the digest instead of sent
+func (a *shareLinkRepo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	return 1, nil
+}
+
+func TestRevokeStaleShareLinks(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	recently := now.AddDate(0, 0, -2)
+
+	repo := &shareLinkRepo{projects: []*Project{
+		{WorkspaceID: "ws", ID: "stale", Title: "Stale", ExternalLink: "l1", ExternalLinkCreatedAt: now.AddDate(0, 0, -60), ExternalLinkCreatedBy: "ann"},
+		{WorkspaceID: "ws", ID: "viewed", Title: "Viewed", ExternalLink: "l2", ExternalLinkCreatedAt: now.AddDate(0, 0, -60), ExternalLinkCreatedBy: "ann", ExternalLinkViewedAt: &recently},
+	}}
+
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+	s.SetConfig(Configuration{DailyNotificationCap: 1})
+
+	if n := s.RevokeStaleShareLinks(now); n != 0 {
+		t.Error("links should not be revoked unless configured", n)
+	}
+
+	s.SetConfig(Configuration{RevokeShareLinksAfterDays: 30, DailyNotificationCap: 1})
+	s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "ann"})
+
+	if ll := s.GetShareLinks(); len(ll) != 2 || ll[0].CreatedByName != "Ann" || !ll[1].ExpiresAt.Equal(recently.AddDate(0, 0, 30)) {
+		t.Error("active links should be listed with their expiry", ll)
+	}
+
+	if n := s.RevokeStaleShareLinks(now); n != 1 {
+		t.Error("one link should be revoked", n)
+	}
+
+	stale, viewed := repo.projects[0], repo.projects[1]
+	if stale.ExternalLinkRevokedAt == nil || stale.ExternalLink == "l1" {
+		t.Error("an unused link past the threshold should be revoked", stale)
+	}
+	if viewed.ExternalLinkRevokedAt != nil || viewed.ExternalLink != "l2" {
+		t.Error("a recently viewed link should be kept", viewed)
+	}
+	if len(repo.emails) != 1 || repo.emails[0].Email != "ann@example.com" {
+		t.Error("the creator of the link should be notified", repo.emails)
+	}
+	if ll := s.GetShareLinks(); len(ll) != 1 || ll[0].ProjectID != "viewed" {
+		t.Error("revoked links should not be listed", ll)
+	}
+
+	if n := s.RevokeStaleShareLinks(now); n != 0 {
+		t.Error("a revoked link should not be revoked again", n)
+	}
+
+	if p, err := s.RenewShareLink("stale"); err != nil || p.ExternalLinkRevokedAt != nil {
+		t.Error("a revoked link should be renewable", p, err)
+	}
+	if ll := s.GetShareLinks(); len(ll) != 2 {
+		t.Error("a renewed link should be listed again", ll)
+	}
+}
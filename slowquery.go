@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// slowQueryTx is the transaction used by the repository. It times every statement and logs
+// the ones that take longer than threshold. A zero threshold turns the logging off. It also keeps
+// the first serialization failure of a read, which a caller may have ignored.
+type slowQueryTx struct {
+	*sqlx.Tx
+	threshold time.Duration
+	requestID string
+	logf      func(format string, v ...interface{})
+	failure   error
+}
+
+func (t *slowQueryTx) Get(dest interface{}, query string, args ...interface{}) error {
+	defer t.observe(query, time.Now())
+	return t.remember(t.Tx.Get(dest, query, args...))
+}
+
+func (t *slowQueryTx) Select(dest interface{}, query string, args ...interface{}) error {
+	defer t.observe(query, time.Now())
+	return t.remember(t.Tx.Select(dest, query, args...))
+}
+
+func (t *slowQueryTx) remember(err error) error {
+	if t.failure == nil && isSerializationFailure(err) {
+		t.failure = err
+	}
+	return err
+}
+
+func (t *slowQueryTx) MustExec(query string, args ...interface{}) {
+	defer t.observe(query, time.Now())
+	t.Tx.MustExec(query, args...)
+}
+
+func (t *slowQueryTx) observe(query string, start time.Time) {
+	if t.threshold <= 0 {
+		return
+	}
+	d := time.Since(start)
+	if d < t.threshold {
+		return
+	}
+
+	logf := t.logf
+	if logf == nil {
+		logf = log.Printf
+	}
+	// The statements only hold placeholders, never the arguments
+	logf("slow query: duration_ms=%d request_id=%q statement=%q", d.Milliseconds(), t.requestID, strings.Join(strings.Fields(query), " "))
+}
+
+func slowQueryThreshold(c Configuration) time.Duration {
+	return time.Duration(c.SlowQueryThresholdMs) * time.Millisecond
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowQueryLog(t *testing.T) {
+	var logged []string
+	tx := &slowQueryTx{threshold: 50 * time.Millisecond, requestID: "req-1", logf: func(format string, v ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, v...))
+	}}
+
+	tx.observe("SELECT * FROM features WHERE workspace_id = $1", time.Now())
+	if len(logged) != 0 {
+		t.Error("fast query should not be logged", logged)
+	}
+
+	tx.observe("SELECT *\n\tFROM features WHERE workspace_id = $1", time.Now().Add(-80*time.Millisecond))
+	if len(logged) != 1 || !strings.Contains(logged[0], `request_id="req-1"`) || !strings.Contains(logged[0], `statement="SELECT * FROM features WHERE workspace_id = $1"`) {
+		t.Error("slow query should be logged with request ID and statement", logged)
+	}
+
+	tx.threshold = 0
+	tx.observe("SELECT 1", time.Now().Add(-time.Hour))
+	if len(logged) != 1 {
+		t.Error("logging should be off without a threshold")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import "testing"
+
+// statusRepo holds project p1 with features f1 and f2 in milestone m1, and status "s-other" of
+// project p2
+type statusRepo struct {
+	Repository
+	project  *Project
+	statuses []*ProjectStatus
+	features []*Feature
+	events   []*FeatureEvent
+}
+
+func (a *statusRepo) GetProject(workspaceID string, id string) (*Project, error) {
+	if a.project == nil || id != a.project.ID {
+		return nil, errNotFound
+	}
+	return a.project, nil
+}
+
+func (a *statusRepo) StoreProject(x *Project) { a.project = x }
+
+func (a *statusRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *statusRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	for _, f := range a.features {
+		if f.ID == id {
+			return f, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *statusRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	return a.features, nil
+}
+
+func (a *statusRepo) StoreFeature(x *Feature) {}
+
+func (a *statusRepo) StoreFeatureEvent(x *FeatureEvent) { a.events = append(a.events, x) }
+
+func (a *statusRepo) FindFeatureWatchersByFeature(workspaceID string, featureID string) ([]*Member, error) {
+	return []*Member{}, nil
+}
+
+func (a *statusRepo) GetProjectStatus(workspaceID string, id string) (*ProjectStatus, error) {
+	for _, x := range a.statuses {
+		if x.ID == id {
+			return x, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *statusRepo) FindProjectStatusesByProject(workspaceID string, projectID string) ([]*ProjectStatus, error) {
+	x := []*ProjectStatus{}
+	for _, y := range a.statuses {
+		if y.ProjectID == projectID {
+			x = append(x, y)
+		}
+	}
+	return x, nil
+}
+
+func (a *statusRepo) StoreProjectStatus(x *ProjectStatus) {
+	for i, y := range a.statuses {
+		if y.ID == x.ID {
+			a.statuses[i] = x
+			return
+		}
+	}
+	a.statuses = append(a.statuses, x)
+}
+
+func (a *statusRepo) DeleteProjectStatus(workspaceID string, id string, replacementID string) {
+	for _, f := range a.features {
+		if f.StatusID == id {
+			f.StatusID = replacementID
+		}
+	}
+	for i, x := range a.statuses {
+		if x.ID == id {
+			a.statuses = append(a.statuses[:i], a.statuses[i+1:]...)
+			return
+		}
+	}
+}
+
+func (a *statusRepo) SyncFeatureStatuses(workspaceID string, statusID string, status string) {
+	for _, f := range a.features {
+		if f.StatusID == statusID {
+			f.Status = status
+		}
+	}
+}
+
+func TestProjectStatuses(t *testing.T) {
+	repo := &statusRepo{statuses: []*ProjectStatus{{ProjectID: "p2", ID: "s-other"}}}
+	s := &service{}
+	s.SetRepoObject(repo)
+	s.SetAccountObject(&Account{Name: "ann"})
+	s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "m-ann", Level: "EDITOR"})
+	s.SetWorkspaceObject(&Workspace{ID: "ws"})
+
+	if _, err := s.CreateProjectWithID("p1", "Shop"); err != nil {
+		t.Fatal(err)
+	}
+	open, closed := s.firstStatus("ws", "p1", false), s.firstStatus("ws", "p1", true)
+	if open == "" || closed == "" {
+		t.Fatal("a new project should get an open and a closed status", repo.statuses)
+	}
+
+	for _, id := range []string{"f1", "f2"} {
+		repo.features = append(repo.features, &Feature{WorkspaceID: "ws", ID: id, MilestoneID: "m1", Status: "OPEN", StatusID: open})
+	}
+
+	if f, err := s.CloseFeature("f1"); err != nil || f.StatusID != closed {
+		t.Error("a closed feature should get the closed status", f, err)
+	}
+	if f, err := s.OpenFeature("f1"); err != nil || f.StatusID != open {
+		t.Error("an opened feature should get the open status", f, err)
+	}
+
+	if _, err := s.UpdateProjectStatus(open, "Open", true); err == nil {
+		t.Error("the last open status should stay open")
+	}
+	if err := s.DeleteProjectStatus(closed); err == nil {
+		t.Error("the last closed status should not be deleted")
+	}
+
+	if _, err := s.SetStatusOnFeature("f1", "s-other"); err == nil {
+		t.Error("a status of another project should be rejected")
+	}
+
+	if _, err := s.CreateProjectStatusWithID("s-doing", "p1", "Doing", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetStatusOnFeature("f2", "s-doing"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo.events = nil
+	if _, err := s.UpdateProjectStatus("s-doing", "Done", true); err != nil {
+		t.Fatal(err)
+	}
+	if f := repo.features[1]; f.Status != "CLOSED" {
+		t.Error("the features of a status that closes should be closed", f)
+	}
+	if len(repo.events) != 1 || repo.events[0].FeatureID != "f2" || repo.events[0].Status != "CLOSED" {
+		t.Error("a status that closes should count its features as closed", repo.events)
+	}
+
+	if err := s.DeleteProjectStatus("s-doing"); err != nil {
+		t.Fatal(err)
+	}
+	if f := repo.features[1]; f.StatusID != closed || f.Status != "CLOSED" {
+		t.Error("the features of a deleted status should move to a status of the same kind", f)
+	}
+}
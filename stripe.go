@@ -89,7 +89,9 @@ func (s *service) handleCheckoutSession(ses *stripe.CheckoutSession) error {
 		return errors.New("workspace not found")
 	}
 
-	stripeSub, _ := sub.Get(ses.Subscription.ID, nil)
+	getParams := &stripe.SubscriptionParams{}
+	getParams.Headers = s.outboundHeaders()
+	stripeSub, _ := sub.Get(ses.Subscription.ID, getParams)
 
 	newSubscription := &Subscription{
 		WorkspaceID:                ses.ClientReferenceID,
@@ -142,7 +144,9 @@ func (s *service) handleSubscriptionUpdate(subscription *stripe.Subscription) er
 func (s *service) handleCancelSubscription() error {
 	localSub := s.Subscription
 
-	_, err := sub.Cancel(localSub.ExternalSubscriptionID, nil)
+	params := &stripe.SubscriptionCancelParams{}
+	params.Headers = s.outboundHeaders()
+	_, err := sub.Cancel(localSub.ExternalSubscriptionID, params)
 	if err != nil {
 		return err
 	}
@@ -172,6 +176,7 @@ func (s *service) handleChangeSubscription(externalPlanID string, quantity int64
 		},
 		Prorate: stripe.Bool(true),
 	}
+	params.Headers = s.outboundHeaders()
 
 	externalSub, err := sub.Update(localSub.ExternalSubscriptionID, params)
 	if err != nil {
@@ -261,6 +266,7 @@ func (s *service) GetSubscriptionPlanSession(plan string, quantity int64) (strin
 		CustomerEmail:     stripe.String(s.ws.ExternalBillingEmail),
 		// Customer:          stripe.String(s.ws.ExternalCustomerID),
 	}
+	params.Headers = s.outboundHeaders()
 
 	ses, err := session.New(params)
 	if err != nil {
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// usageReport is what an instance reports when telemetry is on. It only holds counts, nothing
+// that names an account or a workspace.
+type usageReport struct {
+	Mode       string    `json:"mode"`
+	Accounts   int       `json:"accounts"`
+	Workspaces int       `json:"workspaces"`
+	SentAt     time.Time `json:"sentAt"`
+}
+
+// telemetrySink receives the usage reports
+type telemetrySink interface {
+	Send(x *usageReport) error
+}
+
+// httpSink posts the reports as JSON to the telemetryUrl
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+// telemetry is where the usage reports of this instance go
+var telemetry telemetrySink
+
+func newHTTPSink(url string) *httpSink {
+	return &httpSink{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (x *httpSink) Send(r *usageReport) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	resp, err := x.client.Post(x.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ReportUsage sends a usage report, and tells if it was sent. Nothing is read or sent unless
+// telemetry is on. It runs outside of a request.
+func (s *service) ReportUsage(now time.Time) bool {
+	if !s.config.Telemetry || telemetry == nil {
+		return false
+	}
+
+	accounts, err := s.r.CountAccounts()
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	ww, err := s.r.FindAllWorkspaces()
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	x := &usageReport{Mode: s.config.Mode, Accounts: accounts, Workspaces: len(ww), SentAt: now}
+	if err := telemetry.Send(x); err != nil {
+		log.Println(err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// telemetryRepo holds two accounts in one workspace
+type telemetryRepo struct {
+	Repository
+}
+
+func (a *telemetryRepo) CountAccounts() (int, error) {
+	return 2, nil
+}
+
+func (a *telemetryRepo) FindAllWorkspaces() ([]*Workspace, error) {
+	return []*Workspace{{ID: "ws", Name: "acme"}}, nil
+}
+
+type recordingSink struct {
+	reports []*usageReport
+}
+
+func (x *recordingSink) Send(r *usageReport) error {
+	x.reports = append(x.reports, r)
+	return nil
+}
+
+func TestReportUsage(t *testing.T) {
+	sink := &recordingSink{}
+	telemetry = sink
+	defer func() { telemetry = nil }()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// The repository is not set, so reading any count would panic
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{Mode: "selfhosted"})
+	if s.ReportUsage(now) || len(sink.reports) != 0 {
+		t.Fatal("nothing should be sent when telemetry is off", sink.reports)
+	}
+
+	s.SetConfig(Configuration{Mode: "selfhosted", Telemetry: true})
+	s.SetRepoObject(&telemetryRepo{})
+	if !s.ReportUsage(now) || len(sink.reports) != 1 {
+		t.Fatal("a report should be sent when telemetry is on", sink.reports)
+	}
+	if x := sink.reports[0]; x.Mode != "selfhosted" || x.Accounts != 2 || x.Workspaces != 1 || !x.SentAt.Equal(now) {
+		t.Error("wrong report", x)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxTimeEntryMinutes is the most time one entry can hold
+const maxTimeEntryMinutes = 24 * 60
+
+func checkTimeEntry(minutes int, note string) error {
+	if minutes <= 0 || minutes > maxTimeEntryMinutes {
+		return errors.New("invalid duration")
+	}
+	if len(note) > 1000 {
+		return errors.New("note too long")
+	}
+	return nil
+}
+
+// GetTimeEntries lists the time logged against a feature, oldest first
+func (s *service) GetTimeEntries(featureID string) ([]*TimeEntry, error) {
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, featureID)
+	if err != nil {
+		return nil, errors.New("feature not found")
+	}
+	return s.r.FindTimeEntriesByFeature(s.Member.WorkspaceID, f.ID)
+}
+
+// LogTime logs minutes of work by the member against a feature
+func (s *service) LogTime(featureID string, id string, minutes int, note string) (*TimeEntry, error) {
+	if err := checkTimeEntry(minutes, note); err != nil {
+		return nil, err
+	}
+
+	f, err := s.r.GetFeature(s.Member.WorkspaceID, featureID)
+	if err != nil {
+		return nil, errors.New("feature not found")
+	}
+
+	if _, err := s.r.GetTimeEntry(s.Member.WorkspaceID, f.ID, id); err == nil {
+		return nil, errors.New("already exist")
+	}
+
+	m, err := s.r.GetMilestone(s.Member.WorkspaceID, f.MilestoneID)
+	if err != nil {
+		return nil, errors.New("milestone not found")
+	}
+
+	t := time.Now().UTC()
+	x := &TimeEntry{
+		WorkspaceID:   s.Member.WorkspaceID,
+		ProjectID:     m.ProjectID,
+		FeatureID:     f.ID,
+		ID:            id,
+		MemberID:      s.Member.ID,
+		Minutes:       minutes,
+		Note:          note,
+		CreatedAt:     t,
+		CreatedByName: s.Acc.Name,
+		LastModified:  t,
+	}
+	s.r.StoreTimeEntry(x)
+
+	return x, nil
+}
+
+// timeEntryOf returns an entry the member may change: their own, or any for an admin
+func (s *service) timeEntryOf(featureID string, id string) (*TimeEntry, error) {
+	x, err := s.r.GetTimeEntry(s.Member.WorkspaceID, featureID, id)
+	if err != nil {
+		return nil, errors.New("time entry not found")
+	}
+	if x.MemberID != s.Member.ID && s.Member.Level != "ADMIN" && s.Member.Level != "OWNER" {
+		return nil, errors.New("not allowed")
+	}
+	return x, nil
+}
+
+func (s *service) UpdateTimeEntry(featureID string, id string, minutes int, note string) (*TimeEntry, error) {
+	if err := checkTimeEntry(minutes, note); err != nil {
+		return nil, err
+	}
+
+	x, err := s.timeEntryOf(featureID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	x.Minutes, x.Note = minutes, note
+	x.LastModified = time.Now().UTC()
+	s.r.StoreTimeEntry(x)
+
+	return x, nil
+}
+
+func (s *service) DeleteTimeEntry(featureID string, id string) error {
+	x, err := s.timeEntryOf(featureID, id)
+	if err != nil {
+		return err
+	}
+
+	s.r.DeleteTimeEntry(s.Member.WorkspaceID, x.FeatureID, x.ID)
+	return nil
+}
+
+// markLoggedTime sets LoggedMinutes on the features of a project
+func (s *service) markLoggedTime(projectID string, ff []*Feature) {
+	tt, err := s.r.FindTimeEntriesByProject(s.Member.WorkspaceID, projectID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	byFeature := map[string]int{}
+	for _, t := range tt {
+		byFeature[t.FeatureID] += t.Minutes
+	}
+	for _, f := range ff {
+		f.LoggedMinutes = byFeature[f.ID]
+	}
+}
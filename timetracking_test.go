@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// timeRepo holds features f1 and f2 in milestone m1 and f3 in m2 of project p1
+type timeRepo struct {
+	Repository
+	entries []*TimeEntry
+}
+
+var timeFeatures = []*Feature{
+	{ID: "f1", MilestoneID: "m1", SubWorkflowID: "s1"},
+	{ID: "f2", MilestoneID: "m1", SubWorkflowID: "s2"},
+	{ID: "f3", MilestoneID: "m2", SubWorkflowID: "s1"},
+}
+
+func (a *timeRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	for _, f := range timeFeatures {
+		if f.ID == id {
+			x := *f
+			return &x, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *timeRepo) FindFeaturesByProject(workspaceID string, projectID string) ([]*Feature, error) {
+	x := []*Feature{}
+	for _, f := range timeFeatures {
+		f := *f
+		x = append(x, &f)
+	}
+	return x, nil
+}
+
+func (a *timeRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *timeRepo) GetTimeEntry(workspaceID string, featureID string, id string) (*TimeEntry, error) {
+	for _, x := range a.entries {
+		if x.FeatureID == featureID && x.ID == id {
+			return x, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (a *timeRepo) StoreTimeEntry(x *TimeEntry) {
+	for i, y := range a.entries {
+		if y.ID == x.ID {
+			a.entries[i] = x
+			return
+		}
+	}
+	a.entries = append(a.entries, x)
+}
+
+func (a *timeRepo) FindTimeEntriesByProject(workspaceID string, projectID string) ([]*TimeEntry, error) {
+	return a.entries, nil
+}
+
+func (a *timeRepo) FindWatchedFeatureIDsByProject(workspaceID string, projectID string, memberID string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (a *timeRepo) FindFeatureReferencesByProject(workspaceID string, projectID string) ([]*FeatureReference, error) {
+	return []*FeatureReference{}, nil
+}
+
+func TestLogTime(t *testing.T) {
+	repo := &timeRepo{}
+	s := memberService(repo, "EDITOR")
+
+	x, err := s.LogTime("f1", "t1", 90, "Pairing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.ProjectID != "p1" || x.MemberID != "m-ann" || x.Minutes != 90 || x.Note != "Pairing" {
+		t.Error("wrong entry", x)
+	}
+
+	for _, minutes := range []int{0, -30, maxTimeEntryMinutes + 1} {
+		if _, err := s.LogTime("f1", "t2", minutes, ""); err == nil {
+			t.Error("duration should be rejected", minutes)
+		}
+	}
+	if _, err := s.LogTime("gone", "t2", 30, ""); err == nil {
+		t.Error("time on a missing feature should be rejected")
+	}
+	if _, err := s.UpdateTimeEntry("f1", "t1", 0, ""); err == nil {
+		t.Error("an update to no time should be rejected")
+	}
+
+	s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "m-bob", Level: "EDITOR"})
+	if _, err := s.UpdateTimeEntry("f1", "t1", 60, ""); err == nil {
+		t.Error("an editor should not change the time of others")
+	}
+	s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "m-bob", Level: "ADMIN"})
+	if x, err := s.UpdateTimeEntry("f1", "t1", 60, ""); err != nil || x.Minutes != 60 {
+		t.Error("an admin should change the time of others", err)
+	}
+}
+
+func TestLoggedTimeTotals(t *testing.T) {
+	repo := &timeRepo{}
+	s := memberService(repo, "EDITOR")
+
+	for _, e := range []struct {
+		feature string
+		minutes int
+	}{{"f1", 30}, {"f1", 45}, {"f2", 15}, {"f3", 60}} {
+		if _, err := s.LogTime(e.feature, fmt.Sprintf("t%d", len(repo.entries)), e.minutes, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logged := map[string]int{}
+	for _, f := range s.GetFeaturesByProject("p1") {
+		logged[f.ID] = f.LoggedMinutes
+	}
+	if logged["f1"] != 75 || logged["f2"] != 15 || logged["f3"] != 60 {
+		t.Error("each card should total its logged time", logged)
+	}
+
+	rollups := map[string]int{}
+	res, err := s.GetRollupByProject("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range res.Milestones {
+		rollups[x.ID] = x.LoggedMinutes
+	}
+	for _, x := range res.SubWorkflows {
+		rollups[x.ID] = x.LoggedMinutes
+	}
+	if rollups["m1"] != 90 || rollups["m2"] != 60 || rollups["s1"] != 135 || rollups["s2"] != 15 {
+		t.Error("logged time should roll up per milestone and subworkflow", rollups)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	pkgerrors "github.com/pkg/errors"
+)
+
+// conflictDriver is a database where the first of two overlapping transactions that wrote wins
+// the commit, and the other fails to serialize like Postgres does. The first failedReads reads
+// fail to serialize too, and abort their transaction.
+type conflictDriver struct {
+	mu          sync.Mutex
+	version     int
+	open        int
+	maxOpen     int
+	levels      []driver.IsolationLevel
+	failedReads int
+}
+
+type conflictConn struct {
+	d  *conflictDriver
+	tx *conflictTx
+}
+
+type conflictTx struct {
+	c       *conflictConn
+	level   driver.IsolationLevel
+	version int
+	wrote   bool
+	aborted bool
+}
+
+func (d *conflictDriver) Open(name string) (driver.Conn, error) {
+	return &conflictConn{d: d}, nil
+}
+
+func (c *conflictConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *conflictConn) Close() error { return nil }
+
+func (c *conflictConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conflictConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+
+	c.d.open++
+	if c.d.open > c.d.maxOpen {
+		c.d.maxOpen = c.d.open
+	}
+	c.tx = &conflictTx{c: c, level: opts.Isolation, version: c.d.version}
+	return c.tx, nil
+}
+
+func (c *conflictConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.tx.wrote = true
+	return driver.RowsAffected(1), nil
+}
+
+func (c *conflictConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+
+	if c.d.failedReads > 0 {
+		c.d.failedReads--
+		c.tx.aborted = true
+		return nil, &pq.Error{Code: "40001", Message: "could not serialize access due to read/write dependencies among transactions"}
+	}
+	return noRows{}, nil
+}
+
+// noRows is the result of a read that found nothing
+type noRows struct{}
+
+func (noRows) Columns() []string              { return []string{} }
+func (noRows) Close() error                   { return nil }
+func (noRows) Next(dest []driver.Value) error { return io.EOF }
+
+func (t *conflictTx) Commit() error {
+	d := t.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.open--
+	if t.aborted {
+		return errors.New("pq: Could not complete operation in a failed transaction")
+	}
+	if !t.wrote {
+		return nil
+	}
+	if t.version != d.version {
+		return &pq.Error{Code: "40001", Message: "could not serialize access due to read/write dependencies among transactions"}
+	}
+	d.version++
+	d.levels = append(d.levels, t.level)
+	return nil
+}
+
+func (t *conflictTx) Rollback() error {
+	t.c.d.mu.Lock()
+	defer t.c.d.mu.Unlock()
+
+	t.c.d.open--
+	return nil
+}
+
+func TestSerializableConflictingWriters(t *testing.T) {
+	d := &conflictDriver{}
+	db := sqlx.NewDb(sql.OpenDB(connector{d}), "postgres")
+
+	var calls, sent int32
+	var overlap sync.WaitGroup
+	overlap.Add(2)
+
+	r := chi.NewRouter()
+	r.Use(ContextSkeleton(Configuration{}))
+	r.Use(Transaction(db))
+	r.With(Serializable()).Post("/", func(w http.ResponseWriter, r *http.Request) {
+		s := GetEnv(r).Service
+		s.GetRepoObject().Savepoint("write")
+		s.AfterCommit(func() { atomic.AddInt32(&sent, 1) })
+
+		// Both first attempts are in flight before either commits
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			overlap.Done()
+			overlap.Wait()
+		}
+	})
+
+	codes := make([]int, 2)
+	var done sync.WaitGroup
+	for i := range codes {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+			codes[i] = w.Code
+		}(i)
+	}
+	done.Wait()
+
+	if codes[0] != 200 || codes[1] != 200 {
+		t.Error("the loser should be retried and succeed", codes)
+	}
+	if calls != 3 {
+		t.Error("only the loser should run again", calls)
+	}
+	if len(d.levels) != 2 || d.levels[0] != driver.IsolationLevel(sql.LevelSerializable) || d.levels[1] != driver.IsolationLevel(sql.LevelSerializable) {
+		t.Error("both writes should commit at serializable", d.levels)
+	}
+	if d.maxOpen != 2 {
+		t.Error("each request should hold one transaction at a time", d.maxOpen)
+	}
+	if sent != 2 {
+		t.Error("what was queued should run once per committed request", sent)
+	}
+}
+
+func TestSerializableIgnoredReadFailure(t *testing.T) {
+	d := &conflictDriver{failedReads: 1}
+	db := sqlx.NewDb(sql.OpenDB(connector{d}), "postgres")
+
+	var calls int32
+	r := chi.NewRouter()
+	r.Use(ContextSkeleton(Configuration{}))
+	r.Use(Transaction(db))
+	r.With(Serializable()).Post("/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// The handler goes on without what it could not read
+		_, _ = GetEnv(r).Service.GetRepoObject().GetWorkspace("ws")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+	if w.Code != 200 || calls != 2 {
+		t.Error("a serialization failure of an ignored read should be retried", w.Code, calls)
+	}
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	for err, failure := range map[error]bool{
+		&pq.Error{Code: "40001"}:                                        true,
+		&pq.Error{Code: "40P01"}:                                        true,
+		errors.New("pq: could not serialize access"):                    false,
+		&pq.Error{Code: "23505", Message: "could not serialize access"}: false,
+	} {
+		if isSerializationFailure(err) != failure {
+			t.Error("wrong serialization failure for", err)
+		}
+	}
+	if !isSerializationFailure(pkgerrors.Wrap(&pq.Error{Code: "40001"}, "store")) {
+		t.Error("a wrapped serialization failure should be found")
+	}
+}
+
+type connector struct {
+	d driver.Driver
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) { return c.d.Open("") }
+
+func (c connector) Driver() driver.Driver { return c.d }
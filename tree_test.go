@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// treeRepo is timeRepo with a comment on every feature
+type treeRepo struct {
+	timeRepo
+}
+
+func (a *treeRepo) FindFeaturesByMilestone(workspaceID string, milestoneID string) ([]*Feature, error) {
+	x := []*Feature{}
+	for _, f := range timeFeatures {
+		if f.MilestoneID == milestoneID {
+			f := *f
+			x = append(x, &f)
+		}
+	}
+	return x, nil
+}
+
+func (a *treeRepo) FindFeatureCommentsByMilestone(workspaceID string, milestoneID string) ([]*FeatureComment, error) {
+	x := []*FeatureComment{}
+	for _, f := range timeFeatures {
+		if f.MilestoneID == milestoneID {
+			x = append(x, &FeatureComment{ID: "c-" + f.ID, FeatureID: f.ID})
+		}
+	}
+	return x, nil
+}
+
+func (a *treeRepo) FindFeatureCommentOwnersByProject(workspaceID string, projectID string) ([]*FeatureCommentOwner, error) {
+	return []*FeatureCommentOwner{{FeatureCommentID: "c-f1", MemberID: "m-ann"}}, nil
+}
+
+func TestMilestoneTree(t *testing.T) {
+	serve := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/v1/milestones/m1/tree", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		return serveWith(memberService(&treeRepo{}, "EDITOR"), "/v1/", workspaceAPI, req)
+	}
+
+	w := serve("")
+	if w.Code != 200 {
+		t.Fatal(w.Code, w.Body.String())
+	}
+
+	tree := &milestoneTreeResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), tree); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Milestone.ID != "m1" {
+		t.Error("the tree should hold the milestone", tree.Milestone)
+	}
+	if len(tree.Features) != 2 || tree.Features[0].ID != "f1" || tree.Features[1].ID != "f2" {
+		t.Error("the tree should hold the features of the milestone and not its siblings", tree.Features)
+	}
+	if len(tree.FeatureComments) != 2 || tree.FeatureComments[0].MemberID != "m-ann" {
+		t.Error("the tree should hold the comments of the milestone with their owners", tree.FeatureComments)
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("the tree should have an ETag")
+	}
+	if w := serve(etag); w.Code != 304 {
+		t.Error("an unchanged tree should not be sent again", w.Code)
+	}
+}
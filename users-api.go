@@ -30,7 +30,7 @@ func usersAPI(r chi.Router) {
 
 				r.Route("/invite/{CODE}", func(r chi.Router) {
 					r.Get("/", getInvite)
-					r.Post("/", acceptInvite)
+					r.With(Serializable()).Post("/", acceptInvite)
 				})
 			})
 	})
@@ -55,16 +55,19 @@ func UsersLogin(w http.ResponseWriter, r *http.Request) {
 	// Return token
 	token := s.Token(acc.ID)
 	addCookie(w, "jwt", token, s.GetConfig().Environment)
+	csrf := addCSRFCookie(w, s.GetConfig(), token)
 
 	type response struct {
-		Token string `json:"token"`
+		Token     string `json:"token"`
+		CSRFToken string `json:"csrfToken,omitempty"`
 	}
-	render.JSON(w, r, &response{Token: token})
+	render.JSON(w, r, &response{Token: token, CSRFToken: csrf})
 }
 
 // UsersLogout ...
 func UsersLogout(w http.ResponseWriter, r *http.Request) {
 	deleteCookie(w, "jwt")
+	deleteCookie(w, csrfCookieName)
 	render.Status(r, http.StatusOK)
 }
 
@@ -86,9 +89,10 @@ func UsersSignup(w http.ResponseWriter, r *http.Request) {
 	token := s.Token(acc.ID)
 
 	addCookie(w, "jwt", token, s.GetConfig().Environment)
+	csrf := addCSRFCookie(w, s.GetConfig(), token)
 
 	render.Status(r, http.StatusOK)
-	_ = render.Render(w, r, &TokenResponse{Token: token})
+	_ = render.Render(w, r, &TokenResponse{Token: token, CSRFToken: csrf})
 }
 
 // LoginRequest ...
@@ -117,7 +121,8 @@ func (p *SignupRequest) Bind(r *http.Request) error {
 
 // TokenResponse  ...
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token     string `json:"token"`
+	CSRFToken string `json:"csrfToken,omitempty"`
 }
 
 // Render ...
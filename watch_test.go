@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+// watchRepo holds feature "f1" and the members watching it
+type watchRepo struct {
+	notificationRepo
+	watchers map[string]bool
+}
+
+func (a *watchRepo) GetFeature(workspaceID string, id string) (*Feature, error) {
+	if id != "f1" {
+		return nil, errNotFound
+	}
+	return &Feature{WorkspaceID: workspaceID, ID: id, MilestoneID: "m1", Title: "Pay by card"}, nil
+}
+
+func (a *watchRepo) GetMilestone(workspaceID string, id string) (*Milestone, error) {
+	return &Milestone{WorkspaceID: workspaceID, ID: id, ProjectID: "p1"}, nil
+}
+
+func (a *watchRepo) StoreFeatureComment(x *FeatureComment) {}
+
+func (a *watchRepo) StoreFeatureCommentOwner(x *FeatureCommentOwner) {}
+
+func (a *watchRepo) StoreFeatureWatcher(x *FeatureWatcher) { a.watchers[x.MemberID] = true }
+
+func (a *watchRepo) DeleteFeatureWatcher(workspaceID string, featureID string, memberID string) {
+	delete(a.watchers, memberID)
+}
+
+func (a *watchRepo) FindFeatureWatchersByFeature(workspaceID string, featureID string) ([]*Member, error) {
+	x := []*Member{}
+	for id := range a.watchers {
+		x = append(x, &Member{ID: id, AccountID: "a" + id[1:], Email: id[2:] + "@example.com"})
+	}
+	sort.Slice(x, func(i, j int) bool { return x[i].ID < x[j].ID })
+	return x, nil
+}
+
+func (a *watchRepo) FindWatchedFeatureIDsByProject(workspaceID string, projectID string, memberID string) ([]string, error) {
+	if a.watchers[memberID] {
+		return []string{"f1"}, nil
+	}
+	return []string{}, nil
+}
+
+// Over the cap, notifications are stored for the digest instead of sent
+func (a *watchRepo) CountNotificationEmailsSince(accountID string, t time.Time) (int, error) {
+	return 1, nil
+}
+
+func TestWatchers(t *testing.T) {
+	repo := &watchRepo{watchers: map[string]bool{}}
+	s := NewFeatmapService()
+	s.SetConfig(Configuration{DailyNotificationCap: 1})
+	s.SetRepoObject(repo)
+	s.SetWorkspaceObject(&Workspace{ID: "ws", Name: "acme"})
+
+	as := func(name string) {
+		s.SetAccountObject(&Account{ID: "a-" + name, Name: name})
+		s.SetMemberObject(&Member{WorkspaceID: "ws", ID: "m-" + name, Level: "EDITOR"})
+	}
+
+	as("bob")
+	if err := s.WatchFeature("f1"); err != nil {
+		t.Fatal(err)
+	}
+	ff := []*Feature{{ID: "f1"}}
+	s.(*service).markWatching("p1", ff)
+	if !ff[0].Watching {
+		t.Error("a watched feature should say so")
+	}
+
+	as("ann")
+	if _, err := s.CreateFeatureCommentWithID("c1", "f1", "Cards only?"); err != nil {
+		t.Fatal(err)
+	}
+	if x := notified(repo.emails); x != "a-bob" {
+		t.Error("the watchers but not the commenter should be notified", x)
+	}
+	if !repo.watchers["m-ann"] {
+		t.Error("a commenter should watch the feature")
+	}
+
+	as("bob")
+	if err := s.UnwatchFeature("f1"); err != nil {
+		t.Fatal(err)
+	}
+	s.(*service).markWatching("p1", ff)
+	if ff[0].Watching {
+		t.Error("an unwatched feature should say so")
+	}
+
+	repo.emails = nil
+	as("cy")
+	if _, err := s.CreateFeatureCommentWithID("c2", "f1", "And cash"); err != nil {
+		t.Fatal(err)
+	}
+	if x := notified(repo.emails); x != "a-ann" {
+		t.Error("a member who stopped watching should not be notified", x)
+	}
+}
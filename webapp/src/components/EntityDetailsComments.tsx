@@ -14,6 +14,7 @@ import { EntityTypes } from '../core/card'
 import { Button } from './elements';
 import { IFeatureComment } from '../store/featurecomments/types';
 import Comment from './Comment';
+import { Roles } from '../core/misc';
 
 const mapStateToProps = (state: AppState) => ({
     application: application(state)
@@ -105,6 +106,7 @@ class EntityDetailsComments extends Component<Props, State> {
 
     render() {
         const member = getMembership(this.props.app, this.props.entity.workspaceId)
+        const viewOnly = this.props.viewOnly && !(member && member.level === Roles.COMMENTER)
 
         return (
             <div className=" self-start w-full mb-4 " >
@@ -114,7 +116,7 @@ class EntityDetailsComments extends Component<Props, State> {
                         DISCUSSION {this.props.comments.length === 0 ? "" : "(" + this.props.comments.length + ")"}
                     </div>
 
-                    {!this.props.viewOnly ?
+                    {!viewOnly ?
                         <Formik
                             initialValues={{ comment: "" }}
 
@@ -212,7 +214,7 @@ class EntityDetailsComments extends Component<Props, State> {
                                         comment => {
                                             return <div className=" bg-white  mt-4" key={comment.id} >
 
-                                                <Comment demo={this.props.demo} viewOnly={this.props.viewOnly} comment={comment} member={member} deleteComment={this.deleteComment} editComment={this.editComment} />
+                                                <Comment demo={this.props.demo} viewOnly={viewOnly} comment={comment} member={member} deleteComment={this.deleteComment} editComment={this.editComment} />
 
                                                 {/* <div className="flex flex-row items-center">
                                                     <div className="flex-grow text-xs  "><span className="font-medium">{comment.createdByName}</span> wrote <TimeAgo date={comment.createdAt} /> </div>
@@ -2,6 +2,7 @@ import { ISubscription } from "../store/application/types";
 
 export enum Roles {
     VIEWER = "VIEWER",
+    COMMENTER = "COMMENTER",
     EDITOR = "EDITOR",
     ADMIN = "ADMIN",
     OWNER = "OWNER",
@@ -54,6 +55,8 @@ export const memberLevelToTitle = (level: string) => {
     switch (level) {
         case "VIEWER":
             return "Viewer"
+        case "COMMENTER":
+            return "Commenter"
         case "EDITOR":
             return "Editor"
         case "ADMIN":
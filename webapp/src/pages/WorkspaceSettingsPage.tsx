@@ -175,6 +175,7 @@ class WorkspaceSettingsPage extends Component<Props, State> {
                                                         className="rounded p-1 border mr-2"
                                                     >
                                                         <option value="VIEWER">{memberLevelToTitle("VIEWER")}</option>
+                                                        <option value="COMMENTER">{memberLevelToTitle("COMMENTER")}</option>
                                                         <option value="EDITOR">{memberLevelToTitle("EDITOR")}</option>
                                                         <option value="ADMIN">{memberLevelToTitle("ADMIN")}</option>
                                                         <option value="OWNER">{memberLevelToTitle("OWNER")}</option>
@@ -494,6 +495,7 @@ class WorkspaceSettingsPage extends Component<Props, State> {
                                                                     className="rounded p-2 border  w-64  bg-white  "
                                                                 >
                                                                     <option value="VIEWER">{memberLevelToTitle("VIEWER")}</option>
+                                                                    <option value="COMMENTER">{memberLevelToTitle("COMMENTER")}</option>
                                                                     <option value="EDITOR">{memberLevelToTitle("EDITOR")}</option>
                                                                     <option value="ADMIN">{memberLevelToTitle("ADMIN")}</option>
                                                                     <option value="OWNER">{memberLevelToTitle("OWNER")}</option>
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/go-chi/render"
 
@@ -14,9 +20,11 @@ func workspaceAPI(r chi.Router) {
 
 	r.Use(RequireAccount())
 	r.Use(RequireMember())
+	r.Use(RateLimit())
 
 	r.Group(func(r chi.Router) {
 		r.Post("/leave", leaveWorkspace)
+		r.Get("/settings/rate-plan", getRatePlan)
 	})
 
 	r.Group(func(r chi.Router) {
@@ -28,7 +36,13 @@ func workspaceAPI(r chi.Router) {
 	r.Group(func(r chi.Router) {
 		r.Use(RequireAdmin())
 		r.Get("/members", getMembers)
+		r.Get("/members/reclaimable", getReclaimableMembers)
+		r.Get("/annotations/usage", getAnnotationUsage)
 		r.Get("/invites", getInvites)
+		r.Get("/share-links", getShareLinks)
+		r.Get("/project-archives", getProjectArchives)
+		r.Get("/project-archives/{ID}", getProjectArchive)
+		r.Post("/email-templates/{TYPE}/preview", previewEmail)
 	})
 
 	r.Group(func(r chi.Router) {
@@ -37,11 +51,12 @@ func workspaceAPI(r chi.Router) {
 		r.Route("/members/{ID}", func(r chi.Router) {
 			r.Group(func(r chi.Router) {
 				r.Use(RequireSubscription())
-				r.Post("/level", updateMemberLevel)
+				r.With(Serializable()).Post("/level", updateMemberLevel)
 			})
 
 			r.Group(func(r chi.Router) {
 				r.Delete("/", deleteMember)
+				r.Post("/deactivate", deactivateMember)
 			})
 		})
 	})
@@ -56,6 +71,12 @@ func workspaceAPI(r chi.Router) {
 		r.Use(RequireAdmin())
 		r.Use(RequireSubscription())
 		r.Post("/settings/allow-external-sharing", changeExternalSharingRequest)
+		r.Post("/settings/archive-deleted-projects", changeArchiveDeletedProjects)
+		r.Post("/settings/viewer-redactions", changeViewerRedactions)
+		r.Post("/settings/notification-batching", changeNotificationBatchWindows)
+		r.Post("/settings/timezone", changeTimezone)
+		r.Post("/settings/auto-join", changeAutoJoin)
+		r.Post("/settings/estimate-bounds", changeEstimateBounds)
 	})
 
 	r.Group(func(r chi.Router) {
@@ -84,11 +105,34 @@ func workspaceAPI(r chi.Router) {
 			func(r chi.Router) {
 
 				r.Get("/projects", getProjects)
+				r.Get("/recent", getRecentChanges)
+				r.Get("/resolve", resolveLink)
+
+				r.Route("/import", func(r chi.Router) {
+					r.Use(RequireSubscription())
+					r.Use(RequireEditor())
+					r.Post("/markdown", importMarkdown)
+					r.Post("/json", importJSON)
+				})
 
 				r.Route("/projects/{ID}", func(r chi.Router) {
 
 					r.Group(func(r chi.Router) {
 						r.Get("/", getProjectExtended)
+						r.Get("/rollup", getProjectRollup)
+						r.Get("/export.mmd", exportProjectMermaid)
+						r.Get("/export.dot", exportProjectDot)
+						r.Post("/favorite", favoriteProject)
+						r.Delete("/favorite", unfavoriteProject)
+						r.Get("/goals", getGoals)
+						r.Post("/access-request", createAccessRequest)
+					})
+
+					r.Group(func(r chi.Router) {
+						r.Use(RequireAdmin())
+						r.Get("/access-requests", getAccessRequests)
+						r.Post("/access-requests/{REQUEST}/deny", denyAccessRequest)
+						r.With(RequireSubscription(), Serializable()).Post("/access-requests/{REQUEST}/approve", approveAccessRequest)
 					})
 
 					r.Group(func(r chi.Router) {
@@ -96,23 +140,51 @@ func workspaceAPI(r chi.Router) {
 						r.Use(RequireEditor())
 						r.Post("/", createProject)
 						r.Delete("/", deleteProject)
+						r.Post("/share-link", renewShareLink)
 						r.Post("/rename", renameProject)
+						r.Post("/related", linkProject)
+						r.Post("/features/bulk-annotations", bulkAnnotateFeatures)
+						r.With(Serializable()).Post("/features/bulk-move", bulkMoveFeatures)
+						r.Delete("/related", unlinkProject)
 						r.Post("/description", updateProjectDescription)
+						r.Post("/settings/auto-close", changeAutoCloseOnProject)
+						r.Post("/settings/estimates", changeEstimateSettingsOnProject)
+						r.Post("/settings/default-annotations", changeDefaultAnnotationsOnProject)
+						r.Post("/settings/default-placement", changeDefaultPlacementOnProject)
+						r.Route("/goals/{GOAL}", func(r chi.Router) {
+							r.Post("/", createGoal)
+							r.Put("/", updateGoal)
+							r.Delete("/", deleteGoal)
+							r.Post("/milestones/{MILESTONE}", linkGoalToMilestone)
+							r.Delete("/milestones/{MILESTONE}", unlinkGoalFromMilestone)
+						})
 					})
 				})
 
 				r.Route("/milestones/{ID}", func(r chi.Router) {
-					r.Use(RequireSubscription())
-					r.Use(RequireEditor())
-					r.Post("/", createMilestone)
-					r.Delete("/", deleteMilestone)
-					r.Post("/rename", renameMilestone)
-					r.Post("/move", moveMilestone)
-					r.Post("/description", updateMilestoneDescription)
-					r.Post("/open", openMilestone)
-					r.Post("/close", closeMilestone)
-					r.Post("/color", changeColorOnMilestone)
-					r.Post("/annotations", changeAnnotationsOnMilestone)
+
+					r.Group(func(r chi.Router) {
+						r.Get("/tree", getMilestoneTree)
+						r.Get("/burndown", getMilestoneBurndown)
+					})
+
+					r.Group(func(r chi.Router) {
+						r.Use(RequireSubscription())
+						r.Use(RequireEditor())
+						r.Post("/", createMilestone)
+						r.Delete("/", deleteMilestone)
+						r.Post("/rename", renameMilestone)
+						r.With(Serializable()).Post("/move", moveMilestone)
+						r.With(Serializable()).Post("/move-up", moveMilestoneUp)
+						r.With(Serializable()).Post("/move-down", moveMilestoneDown)
+						r.Post("/description", updateMilestoneDescription)
+						r.Post("/open", openMilestone)
+						r.Post("/close", closeMilestone)
+						r.Post("/color", changeColorOnMilestone)
+						r.Post("/icon", changeIconOnMilestone)
+						r.Post("/annotations", changeAnnotationsOnMilestone)
+						r.Post("/features/bulk", createFeaturesInMilestone)
+					})
 				})
 
 				r.Route("/workflows/{ID}", func(r chi.Router) {
@@ -121,7 +193,7 @@ func workspaceAPI(r chi.Router) {
 					r.Post("/", createWorkflow)
 					r.Delete("/", deleteWorkflow)
 					r.Post("/rename", renameWorkflow)
-					r.Post("/move", moveWorkflow)
+					r.With(Serializable()).Post("/move", moveWorkflow)
 					r.Post("/description", updateWorkflowDescription)
 					r.Post("/color", changeColorOnWorkflow)
 					r.Post("/open", openWorkflow)
@@ -135,7 +207,7 @@ func workspaceAPI(r chi.Router) {
 					r.Post("/", createSubWorkflow)
 					r.Post("/rename", renameSubWorkflow)
 					r.Delete("/", deleteSubWorkflow)
-					r.Post("/move", moveSubWorkflow)
+					r.With(Serializable()).Post("/move", moveSubWorkflow)
 					r.Post("/description", updateSubWorkflowDescription)
 					r.Post("/color", changeColorOnSubWorkflow)
 					r.Post("/open", openSubWorkflow)
@@ -144,23 +216,45 @@ func workspaceAPI(r chi.Router) {
 				})
 
 				r.Route("/features/{ID}", func(r chi.Router) {
-					r.Use(RequireSubscription())
-					r.Use(RequireEditor())
-					r.Post("/", createFeature)
-					r.Post("/rename", renameFeature)
-					r.Delete("/", deleteFeature)
-					r.Post("/move", moveFeature)
-					r.Post("/description", updateFeatureDescription)
-					r.Post("/open", openFeature)
-					r.Post("/close", closeFeature)
-					r.Post("/color", changeColorOnFeature)
-					r.Post("/annotations", changeAnnotationsOnFeature)
-					r.Post("/estimate", changeEstimateOnFeature)
+
+					r.Group(func(r chi.Router) {
+						r.Get("/", getFeatureContext)
+						r.Get("/history", getFeatureHistory)
+						r.Get("/time", getTimeEntries)
+						r.Post("/watch", watchFeature)
+						r.Delete("/watch", unwatchFeature)
+					})
+
+					r.Group(func(r chi.Router) {
+						r.Use(RequireSubscription())
+						r.Use(RequireEditor())
+						r.Post("/", createFeature)
+						r.Put("/", replaceFeature)
+						r.Patch("/", patchFeature)
+						r.Post("/rename", renameFeature)
+						r.Delete("/", deleteFeature)
+						r.With(Serializable()).Post("/move", moveFeature)
+						r.Post("/description", updateFeatureDescription)
+						r.Post("/open", openFeature)
+						r.Post("/close", closeFeature)
+						r.Post("/color", changeColorOnFeature)
+						r.Post("/icon", changeIconOnFeature)
+						r.Post("/duplicate", duplicateFeature)
+						r.Post("/references", addFeatureReference)
+						r.Delete("/references/{REFERENCE}", deleteFeatureReference)
+						r.Post("/time", logTime)
+						r.Put("/time/{ENTRY}", updateTimeEntry)
+						r.Delete("/time/{ENTRY}", deleteTimeEntry)
+						r.Post("/annotations", changeAnnotationsOnFeature)
+						r.Post("/estimate", changeEstimateOnFeature)
+						r.Post("/progress", changeProgressOnFeature)
+						r.Post("/status", changeStatusOnFeature)
+					})
 				})
 
 				r.Route("/featurecomments/{ID}", func(r chi.Router) {
 					r.Use(RequireSubscription())
-					r.Use(RequireEditor())
+					r.Use(RequireCommenter())
 					r.Post("/", createFeatureComment)
 					r.Delete("/", deleteFeatureComment)
 					r.Post("/post", updateFeatureCommentPost)
@@ -173,6 +267,14 @@ func workspaceAPI(r chi.Router) {
 					r.Delete("/", deleteWorkflowPersona)
 				})
 
+				r.Route("/statuses/{ID}", func(r chi.Router) {
+					r.Use(RequireSubscription())
+					r.Use(RequireEditor())
+					r.Post("/", createProjectStatus)
+					r.Put("/", updateProjectStatus)
+					r.Delete("/", deleteProjectStatus)
+				})
+
 				r.Route("/personas/{ID}", func(r chi.Router) {
 					r.Use(RequireSubscription())
 					r.Use(RequireEditor())
@@ -226,6 +328,44 @@ func deleteMember(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func getAnnotationUsage(w http.ResponseWriter, r *http.Request) {
+	renderAggregate(w, r, func() (interface{}, error) {
+		return GetEnv(r).Service.GetAnnotationUsage(r.URL.Query().Get("sort") == "usage")
+	})
+}
+
+func getReclaimableMembers(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, GetEnv(r).Service.GetReclaimableMembers())
+}
+
+func deactivateMember(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	member, err := GetEnv(r).Service.DeactivateMember(id)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, member)
+}
+
+func getRatePlan(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Tier    string    `json:"tier"`
+		Limited bool      `json:"limited"`
+		Plan    *RatePlan `json:"plan,omitempty"`
+	}
+
+	s := GetEnv(r).Service
+	tier := s.GetSubscriptionObject().Level
+
+	x := response{Tier: tier}
+	if plan, ok := ratePlan(s.GetConfig(), tier); ok {
+		x.Limited = true
+		x.Plan = &plan
+	}
+	render.JSON(w, r, x)
+}
+
 func leaveWorkspace(w http.ResponseWriter, r *http.Request) {
 	err := GetEnv(r).Service.Leave()
 	if err != nil {
@@ -314,326 +454,1260 @@ func changeExternalSharingRequest(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func changeGeneralInfo(w http.ResponseWriter, r *http.Request) {
-	data := &changeGeneralInfoRequest{}
+func changeArchiveDeletedProjects(w http.ResponseWriter, r *http.Request) {
+	data := &booleanSettingRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 
-	err := GetEnv(r).Service.ChangeGeneralInfo(data.EUVAT, data.ExternalBillingEmail)
+	err := GetEnv(r).Service.ChangeArchiveDeletedProjects(data.Value)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 }
 
-// Projects
-type createProjectRequest struct {
-	Title string `json:"title"`
-}
-
-func (p *createProjectRequest) Bind(r *http.Request) error {
-	return nil
+func getProjectArchives(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, GetEnv(r).Service.GetProjectArchives())
 }
-func createProject(w http.ResponseWriter, r *http.Request) {
-	data := &createProjectRequest{}
-	if err := render.Bind(r, data); err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
-		return
-	}
 
+func getProjectArchive(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "ID")
 
-	s := GetEnv(r).Service
-	p, err := s.CreateProjectWithID(id, data.Title)
+	a, err := GetEnv(r).Service.GetProjectArchive(id)
 	if err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
+		_ = render.Render(w, r, ErrNotFound(err))
 		return
 	}
-	render.JSON(w, r, p)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="featmap-project-`+a.ProjectID+`.json"`)
+	_, _ = w.Write(a.Data)
 }
 
-type projectResponse struct {
-	Project          *Project           `json:"project"`
-	Milestones       []*Milestone       `json:"milestones"`
-	Workflows        []*Workflow        `json:"workflows"`
-	SubWorkflows     []*SubWorkflow     `json:"subWorkflows"`
-	Features         []*Feature         `json:"features"`
-	FeatureComments  []*FeatureComment  `json:"featureComments"`
-	Personas         []*Persona         `json:"personas"`
-	WorkflowPersonas []*WorkflowPersona `json:"workflowPersonas"`
+type previewEmailRequest struct {
+	Template string `json:"template"`
 }
 
-func getProjectExtended(w http.ResponseWriter, r *http.Request) {
+func (p *previewEmailRequest) Bind(r *http.Request) error {
+	return nil
+}
 
-	s := GetEnv(r).Service
-	id := chi.URLParam(r, "ID")
+func previewEmail(w http.ResponseWriter, r *http.Request) {
+	data := &previewEmailRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
 
-	project := s.GetProject(id)
-	milestones := s.GetMilestonesByProject(id)
-	workflows := s.GetWorkflowsByProject(id)
-	subworkflows := s.GetSubWorkflowsByProject(id)
-	features := s.GetFeaturesByProject(id)
-	featureComments := s.GetFeatureCommentsByProject(id)
-	personas := s.GetPersonasByProject(id)
-	workflowPersonas := s.GetWorkflowPersonasByProject(id)
-	oo := projectResponse{
-		Project:          project,
-		Milestones:       milestones,
-		Workflows:        workflows,
-		SubWorkflows:     subworkflows,
-		Features:         features,
-		FeatureComments:  featureComments,
-		Personas:         personas,
-		WorkflowPersonas: workflowPersonas,
+	x, err := GetEnv(r).Service.PreviewEmail(chi.URLParam(r, "TYPE"), data.Template)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
 	}
+	render.JSON(w, r, x)
+}
 
-	render.JSON(w, r, oo)
+type stringSettingRequest struct {
+	Value string `json:"value"`
 }
 
-func getProjects(w http.ResponseWriter, r *http.Request) {
-	s := GetEnv(r).Service
-	render.JSON(w, r, s.GetProjects())
+func (p *stringSettingRequest) Bind(r *http.Request) error {
+	return nil
 }
 
-func renameProject(w http.ResponseWriter, r *http.Request) {
-	data := &renameRequest{}
+func changeViewerRedactions(w http.ResponseWriter, r *http.Request) {
+	data := &stringSettingRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	id := chi.URLParam(r, "ID")
 
-	p, err := GetEnv(r).Service.RenameProject(id, data.Title)
+	err := GetEnv(r).Service.ChangeViewerRedactions(data.Value)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, p)
 }
 
-func updateProjectDescription(w http.ResponseWriter, r *http.Request) {
-	data := &updateDescriptionRequest{}
+func changeNotificationBatchWindows(w http.ResponseWriter, r *http.Request) {
+	data := &stringSettingRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	id := chi.URLParam(r, "ID")
 
-	m, err := GetEnv(r).Service.UpdateProjectDescription(id, data.Description)
+	err := GetEnv(r).Service.ChangeNotificationBatchWindows(data.Value)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
 }
 
-func deleteProject(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "ID")
+func changeTimezone(w http.ResponseWriter, r *http.Request) {
+	data := &stringSettingRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
 
-	if err := GetEnv(r).Service.DeleteProject(id); err != nil {
+	err := GetEnv(r).Service.ChangeTimezone(data.Value)
+	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.Status(r, http.StatusOK)
 }
 
-// Milestones
-
-type createMilestoneRequest struct {
-	ProjectID string `json:"projectId"`
-	Title     string `json:"title"`
+type autoJoinRequest struct {
+	Domains string `json:"domains"`
+	Level   string `json:"level"`
 }
 
-func (p *createMilestoneRequest) Bind(r *http.Request) error {
+func (p *autoJoinRequest) Bind(r *http.Request) error {
 	return nil
 }
 
-func createMilestone(w http.ResponseWriter, r *http.Request) {
-
-	data := &createMilestoneRequest{}
+func changeAutoJoin(w http.ResponseWriter, r *http.Request) {
+	data := &autoJoinRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	id := chi.URLParam(r, "ID")
 
-	m, err := GetEnv(r).Service.CreateMilestoneWithID(id, data.ProjectID, data.Title)
+	err := GetEnv(r).Service.ChangeAutoJoin(data.Domains, data.Level)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
 }
 
-type moveMilestoneRequest struct {
-	Index int `json:"index"`
+type estimateBoundsRequest struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
 }
 
-func (p *moveMilestoneRequest) Bind(r *http.Request) error {
+func (p *estimateBoundsRequest) Bind(r *http.Request) error {
 	return nil
 }
 
-func moveMilestone(w http.ResponseWriter, r *http.Request) {
-
-	data := &moveMilestoneRequest{}
+func changeEstimateBounds(w http.ResponseWriter, r *http.Request) {
+	data := &estimateBoundsRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	id := chi.URLParam(r, "ID")
 
-	m, err := GetEnv(r).Service.MoveMilestone(id, data.Index)
-	if err != nil {
+	if err := GetEnv(r).Service.ChangeEstimateBounds(data.Min, data.Max); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
 }
 
-func renameMilestone(w http.ResponseWriter, r *http.Request) {
-	data := &renameRequest{}
+func changeGeneralInfo(w http.ResponseWriter, r *http.Request) {
+	data := &changeGeneralInfoRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	id := chi.URLParam(r, "ID")
 
-	m, err := GetEnv(r).Service.RenameMilestone(id, data.Title)
+	err := GetEnv(r).Service.ChangeGeneralInfo(data.EUVAT, data.ExternalBillingEmail)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
+}
 
+// Projects
+type createProjectRequest struct {
+	Title string `json:"title"`
 }
 
-func updateMilestoneDescription(w http.ResponseWriter, r *http.Request) {
-	data := &updateDescriptionRequest{}
+func (p *createProjectRequest) Bind(r *http.Request) error {
+	return nil
+}
+func createProject(w http.ResponseWriter, r *http.Request) {
+	data := &createProjectRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
+
 	id := chi.URLParam(r, "ID")
 
-	m, err := GetEnv(r).Service.UpdateMilestoneDescription(id, data.Description)
+	s := GetEnv(r).Service
+	p, err := s.CreateProjectWithID(id, data.Title)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
+	render.JSON(w, r, p)
 }
 
-func deleteMilestone(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "ID")
-
-	if err := GetEnv(r).Service.DeleteMilestone(id); err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
-		return
-	}
-	render.Status(r, http.StatusOK)
+type importMarkdownRequest struct {
+	Title    string `json:"title"`
+	Markdown string `json:"markdown"`
 }
 
-func closeMilestone(w http.ResponseWriter, r *http.Request) {
-
-	id := chi.URLParam(r, "ID")
-
-	f, err := GetEnv(r).Service.CloseMilestone(id)
-	if err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
-		return
-	}
-	render.JSON(w, r, f)
+func (p *importMarkdownRequest) Bind(r *http.Request) error {
+	return nil
 }
 
-func openMilestone(w http.ResponseWriter, r *http.Request) {
-
-	id := chi.URLParam(r, "ID")
-
-	f, err := GetEnv(r).Service.OpenMilestone(id)
-	if err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
-		return
-	}
-	render.JSON(w, r, f)
+type importResponse struct {
+	Project *Project      `json:"project"`
+	Summary importSummary `json:"summary"`
+	DryRun  bool          `json:"dryRun"`
+	Outline *outline      `json:"outline,omitempty"`
 }
 
-func changeColorOnMilestone(w http.ResponseWriter, r *http.Request) {
-	data := &changeColorRequest{}
+func importMarkdown(w http.ResponseWriter, r *http.Request) {
+	data := &importMarkdownRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 
-	id := chi.URLParam(r, "ID")
-
-	f, err := GetEnv(r).Service.ChangeColorOnMilestone(id, data.Color)
+	o, err := parseMarkdownOutline(data.Markdown)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, f)
-}
 
-func changeAnnotationsOnMilestone(w http.ResponseWriter, r *http.Request) {
-	data := &changeAnnotationRequest{}
-	if err := render.Bind(r, data); err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
+	s := GetEnv(r).Service
+
+	if r.URL.Query().Get("dryRun") == "true" {
+		var p *Project
+		err := s.DryRun(func() (err error) {
+			p, err = s.ImportOutline(data.Title, o)
+			return err
+		})
+		if err != nil {
+			_ = render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+
+		render.JSON(w, r, importResponse{Project: p, Summary: o.summary(), DryRun: true, Outline: o})
 		return
 	}
 
-	id := chi.URLParam(r, "ID")
-
-	f, err := GetEnv(r).Service.UpdateAnnotationsOnMilestone(id, data.Annotations)
+	p, err := s.ImportOutline(data.Title, o)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, f)
-}
 
-// Workflows
+	render.JSON(w, r, importResponse{Project: p, Summary: o.summary()})
+}
 
-type createWorkflowRequest struct {
-	ProjectID string `json:"projectId"`
-	Title     string `json:"title"`
+type importJSONRequest struct {
+	Title  string          `json:"title"`
+	Export json.RawMessage `json:"export"`
 }
 
-func (p *createWorkflowRequest) Bind(r *http.Request) error {
+func (p *importJSONRequest) Bind(r *http.Request) error {
 	return nil
 }
 
-func createWorkflow(w http.ResponseWriter, r *http.Request) {
-	data := &createWorkflowRequest{}
+type importJSONResponse struct {
+	Project *Project `json:"project"`
+	*exportReport
+	DryRun bool `json:"dryRun"`
+}
+
+// importJSON creates a project from an export of this or another instance. The response
+// lists the fields that were defaulted and those that could not be carried over.
+func importJSON(w http.ResponseWriter, r *http.Request) {
+	data := &importJSONRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	id := chi.URLParam(r, "ID")
-	wf, err := GetEnv(r).Service.CreateWorkflowWithID(id, data.ProjectID, data.Title)
+
+	d, err := parseExport(data.Export)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 
-	render.JSON(w, r, wf)
-}
+	s := GetEnv(r).Service
 
-func renameWorkflow(w http.ResponseWriter, r *http.Request) {
-	data := &renameRequest{}
-	if err := render.Bind(r, data); err != nil {
+	var p *Project
+	var report *exportReport
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	do := func() (err error) {
+		p, report, err = s.ImportExport(data.Title, d)
+		return err
+	}
+	if dryRun {
+		err = s.DryRun(do)
+	} else {
+		err = do()
+	}
+	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	id := chi.URLParam(r, "ID")
 
-	wf, err := GetEnv(r).Service.RenameWorkflow(id, data.Title)
+	render.JSON(w, r, importJSONResponse{Project: p, exportReport: report, DryRun: dryRun})
+}
+
+type projectResponse struct {
+	Project          *Project           `json:"project"`
+	Milestones       []*Milestone       `json:"milestones"`
+	Workflows        []*Workflow        `json:"workflows"`
+	SubWorkflows     []*SubWorkflow     `json:"subWorkflows"`
+	Features         []*Feature         `json:"features"`
+	FeatureComments  []*FeatureComment  `json:"featureComments"`
+	Personas         []*Persona         `json:"personas"`
+	WorkflowPersonas []*WorkflowPersona `json:"workflowPersonas"`
+	Statuses         []*ProjectStatus   `json:"statuses"`
+	Related          []*Project         `json:"related"`
+	Goals            []*Goal            `json:"goals"`
+}
+
+func getProjectExtended(w http.ResponseWriter, r *http.Request) {
+
+	s := GetEnv(r).Service
+	id := chi.URLParam(r, "ID")
+
+	project := s.GetProject(id)
+	milestones := s.GetMilestonesByProject(id)
+	workflows := s.GetWorkflowsByProject(id)
+	subworkflows := s.GetSubWorkflowsByProject(id)
+	features := s.GetFeaturesByProject(id)
+	featureComments := s.GetFeatureCommentsByProject(id)
+	personas := s.GetPersonasByProject(id)
+	workflowPersonas := s.GetWorkflowPersonasByProject(id)
+	statuses := s.GetProjectStatusesByProject(id)
+	related := s.GetRelatedProjects(id)
+	goals := s.GetGoalsByProject(id)
+	oo := projectResponse{
+		Project:          project,
+		Milestones:       milestones,
+		Workflows:        workflows,
+		SubWorkflows:     subworkflows,
+		Features:         features,
+		FeatureComments:  featureComments,
+		Personas:         personas,
+		WorkflowPersonas: workflowPersonas,
+		Statuses:         statuses,
+		Related:          related,
+		Goals:            goals,
+	}
+
+	if goalID := r.URL.Query().Get("goal"); goalID != "" {
+		filterProjectByGoal(&oo, goalID)
+	}
+
+	if redactsFor(s.GetMemberObject().Level) {
+		redactProjectResponse(&oo, s.GetWorkspaceObject().ViewerRedactions)
+	}
+
+	render.JSON(w, r, oo)
+}
+
+// filterProjectByGoal narrows the tree to the milestones linked to a goal and the features within them
+func filterProjectByGoal(x *projectResponse, goalID string) {
+	milestones := []*Milestone{}
+	kept := map[string]bool{}
+	for _, m := range x.Milestones {
+		for _, g := range m.GoalIDs {
+			if g == goalID {
+				milestones = append(milestones, m)
+				kept[m.ID] = true
+				break
+			}
+		}
+	}
+	x.Milestones = milestones
+
+	features := []*Feature{}
+	keptFeatures := map[string]bool{}
+	for _, f := range x.Features {
+		if kept[f.MilestoneID] {
+			features = append(features, f)
+			keptFeatures[f.ID] = true
+		}
+	}
+	x.Features = features
+
+	comments := []*FeatureComment{}
+	for _, c := range x.FeatureComments {
+		if keptFeatures[c.FeatureID] {
+			comments = append(comments, c)
+		}
+	}
+	x.FeatureComments = comments
+}
+
+func getProjectRollup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	renderAggregate(w, r, func() (interface{}, error) {
+		s := GetEnv(r).Service
+		x, err := s.GetRollupByProject(id)
+		if err != nil {
+			return nil, err
+		}
+		if redactsFor(s.GetMemberObject().Level) {
+			redactRollup(x, s.GetWorkspaceObject().ViewerRedactions)
+		}
+		return x, nil
+	})
+}
+
+func exportProjectMermaid(w http.ResponseWriter, r *http.Request) {
+	exportProjectDiagram(w, r, "text/plain; charset=utf-8", (*projectDiagram).mermaid)
+}
+
+func exportProjectDot(w http.ResponseWriter, r *http.Request) {
+	exportProjectDiagram(w, r, "text/vnd.graphviz; charset=utf-8", (*projectDiagram).dot)
+}
+
+func exportProjectDiagram(w http.ResponseWriter, r *http.Request, contentType string, format func(*projectDiagram) string) {
+	id := chi.URLParam(r, "ID")
+
+	d, err := GetEnv(r).Service.GetProjectDiagram(id)
+	if err != nil {
+		_ = render.Render(w, r, ErrNotFound(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write([]byte(format(d)))
+}
+
+func getRecentChanges(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	since, err := time.Parse(time.RFC3339, q.Get("since"))
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(errors.New("invalid since")))
+		return
+	}
+
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			_ = render.Render(w, r, ErrInvalidRequest(errors.New("invalid limit")))
+			return
+		}
+	}
+
+	changes, err := GetEnv(r).Service.GetRecentChanges(since, limit)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, changes)
+}
+
+func resolveLink(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	x, err := GetEnv(r).Service.Resolve(q.Get("type"), q.Get("id"))
+	switch {
+	case err == errUnresolvable:
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	case err != nil:
+		_ = render.Render(w, r, ErrNotFound(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func getProjects(w http.ResponseWriter, r *http.Request) {
+	s := GetEnv(r).Service
+	pp := s.GetProjects()
+
+	if r.URL.Query().Get("favorite") == "true" {
+		pp = favoriteProjects(pp)
+	}
+
+	render.JSON(w, r, pp)
+}
+
+func favoriteProjects(pp []*Project) []*Project {
+	x := []*Project{}
+	for _, p := range pp {
+		if p.Favorited {
+			x = append(x, p)
+		}
+	}
+	return x
+}
+
+type bulkAnnotationsRequest struct {
+	Filter featureFilter `json:"filter"`
+	Add    []string      `json:"add"`
+	Remove []string      `json:"remove"`
+}
+
+func (p *bulkAnnotationsRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func bulkAnnotateFeatures(w http.ResponseWriter, r *http.Request) {
+	data := &bulkAnnotationsRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	n, err := GetEnv(r).Service.BulkAnnotateFeatures(id, data.Filter, data.Add, data.Remove)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, map[string]int{"affected": n})
+}
+
+// bulkMoveRequest moves either the features listed, or all features of the subworkflows listed
+// in the milestone they are moved from
+type bulkMoveRequest struct {
+	FeatureIDs      []string `json:"featureIds"`
+	SubWorkflowIDs  []string `json:"subWorkflowIds"`
+	FromMilestoneID string   `json:"fromMilestoneId"`
+	ToMilestoneID   string   `json:"toMilestoneId"`
+	ToSubWorkflowID string   `json:"toSubWorkflowId"`
+}
+
+func (p *bulkMoveRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func bulkMoveFeatures(w http.ResponseWriter, r *http.Request) {
+	data := &bulkMoveRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	s := GetEnv(r).Service
+
+	var ff []*Feature
+	var err error
+	if len(data.SubWorkflowIDs) > 0 {
+		ff, err = s.BulkMoveColumns(id, data.SubWorkflowIDs, data.FromMilestoneID, data.ToMilestoneID)
+	} else {
+		ff, err = s.BulkMoveFeatures(id, data.FeatureIDs, data.ToMilestoneID, data.ToSubWorkflowID)
+	}
+	if err == errMoveTargetGone {
+		_ = render.Render(w, r, ErrConflict(err))
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, ff)
+}
+
+type relatedProjectRequest struct {
+	ProjectID string `json:"projectId"`
+}
+
+func (p *relatedProjectRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func linkProject(w http.ResponseWriter, r *http.Request) {
+	data := &relatedProjectRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.LinkProjects(id, data.ProjectID); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+}
+
+func unlinkProject(w http.ResponseWriter, r *http.Request) {
+	data := &relatedProjectRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.UnlinkProjects(id, data.ProjectID); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+}
+
+func getGoals(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	render.JSON(w, r, GetEnv(r).Service.GetGoalsByProject(id))
+}
+
+type goalRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	TargetDate  string `json:"targetDate"`
+}
+
+func (p *goalRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func createGoal(w http.ResponseWriter, r *http.Request) {
+	data := &goalRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "GOAL")
+	projectID := chi.URLParam(r, "ID")
+
+	goal, err := GetEnv(r).Service.CreateGoalWithID(id, projectID, data.Title, data.Description, data.TargetDate)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, goal)
+}
+
+func updateGoal(w http.ResponseWriter, r *http.Request) {
+	data := &goalRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "GOAL")
+	projectID := chi.URLParam(r, "ID")
+
+	goal, err := GetEnv(r).Service.UpdateGoal(id, projectID, data.Title, data.Description, data.TargetDate)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, goal)
+}
+
+func deleteGoal(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "GOAL")
+	projectID := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.DeleteGoal(id, projectID); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+}
+
+func linkGoalToMilestone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "GOAL")
+	projectID := chi.URLParam(r, "ID")
+	milestoneID := chi.URLParam(r, "MILESTONE")
+
+	if err := GetEnv(r).Service.LinkGoalToMilestone(id, projectID, milestoneID); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+}
+
+func unlinkGoalFromMilestone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "GOAL")
+	projectID := chi.URLParam(r, "ID")
+	milestoneID := chi.URLParam(r, "MILESTONE")
+
+	if err := GetEnv(r).Service.UnlinkGoalFromMilestone(id, projectID, milestoneID); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+}
+
+type createAccessRequestRequest struct {
+	ID    string `json:"id"`
+	Level string `json:"level"`
+}
+
+func (p *createAccessRequestRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func createAccessRequest(w http.ResponseWriter, r *http.Request) {
+	data := &createAccessRequestRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+
+	x, err := GetEnv(r).Service.RequestAccess(id, data.ID, data.Level)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func getShareLinks(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, GetEnv(r).Service.GetShareLinks())
+}
+
+func renewShareLink(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	p, err := GetEnv(r).Service.RenewShareLink(id)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, p)
+}
+
+func getAccessRequests(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	render.JSON(w, r, GetEnv(r).Service.GetAccessRequests(id))
+}
+
+func approveAccessRequest(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	requestID := chi.URLParam(r, "REQUEST")
+
+	x, err := GetEnv(r).Service.ApproveAccessRequest(id, requestID)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func denyAccessRequest(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	requestID := chi.URLParam(r, "REQUEST")
+
+	x, err := GetEnv(r).Service.DenyAccessRequest(id, requestID)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func favoriteProject(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.FavoriteProject(id); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+}
+
+func unfavoriteProject(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.UnfavoriteProject(id); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+}
+
+func renameProject(w http.ResponseWriter, r *http.Request) {
+	data := &renameRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	p, err := GetEnv(r).Service.RenameProject(id, data.Title)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	renderUpdated(w, r, p, "title")
+}
+
+func updateProjectDescription(w http.ResponseWriter, r *http.Request) {
+	data := &updateDescriptionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	m, err := GetEnv(r).Service.UpdateProjectDescription(id, data.Description)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	renderUpdated(w, r, m, "description")
+}
+
+type updateAutoCloseRequest struct {
+	Days int `json:"days"`
+}
+
+func (p *updateAutoCloseRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func changeAutoCloseOnProject(w http.ResponseWriter, r *http.Request) {
+	data := &updateAutoCloseRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	p, err := GetEnv(r).Service.UpdateAutoCloseOnProject(id, data.Days)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, p)
+}
+
+type updateEstimateSettingsRequest struct {
+	RequireEstimate bool `json:"requireEstimate"`
+	DefaultEstimate int  `json:"defaultEstimate"`
+}
+
+func (p *updateEstimateSettingsRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func changeEstimateSettingsOnProject(w http.ResponseWriter, r *http.Request) {
+	data := &updateEstimateSettingsRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	p, err := GetEnv(r).Service.UpdateEstimateSettingsOnProject(id, data.RequireEstimate, data.DefaultEstimate)
+	if err == errEstimateOutOfRange {
+		_ = render.Render(w, r, ErrUnprocessable(err))
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, p)
+}
+
+func changeDefaultAnnotationsOnProject(w http.ResponseWriter, r *http.Request) {
+	data := &changeAnnotationRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	p, err := GetEnv(r).Service.UpdateDefaultAnnotationsOnProject(id, data.Annotations)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, p)
+}
+
+type changeDefaultPlacementRequest struct {
+	MilestoneID   string `json:"milestoneId"`
+	SubWorkflowID string `json:"subWorkflowId"`
+}
+
+func (p *changeDefaultPlacementRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func changeDefaultPlacementOnProject(w http.ResponseWriter, r *http.Request) {
+	data := &changeDefaultPlacementRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	p, err := GetEnv(r).Service.UpdateDefaultPlacementOnProject(id, data.MilestoneID, data.SubWorkflowID)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, p)
+}
+
+func deleteProject(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.DeleteProject(id); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.Status(r, http.StatusOK)
+}
+
+// Milestones
+
+type createMilestoneRequest struct {
+	ProjectID string `json:"projectId"`
+	Title     string `json:"title"`
+}
+
+func (p *createMilestoneRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func createMilestone(w http.ResponseWriter, r *http.Request) {
+
+	data := &createMilestoneRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	m, err := GetEnv(r).Service.CreateMilestoneWithID(id, data.ProjectID, data.Title)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, m)
+}
+
+type milestoneTreeResponse struct {
+	Milestone       *Milestone        `json:"milestone"`
+	Features        []*Feature        `json:"features"`
+	FeatureComments []*FeatureComment `json:"featureComments"`
+}
+
+func getMilestoneTree(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	s := GetEnv(r).Service
+	tree, err := s.GetMilestoneTree(id)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	if redactsFor(s.GetMemberObject().Level) {
+		redactMilestoneTree(tree, s.GetWorkspaceObject().ViewerRedactions)
+	}
+	renderJSONWithETag(w, r, tree)
+}
+
+func getFeatureHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	q := r.URL.Query()
+
+	var err error
+	offset := 0
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			_ = render.Render(w, r, ErrInvalidRequest(errors.New("invalid offset")))
+			return
+		}
+	}
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			_ = render.Render(w, r, ErrInvalidRequest(errors.New("invalid limit")))
+			return
+		}
+	}
+
+	s := GetEnv(r).Service
+	x, err := s.GetFeatureHistory(id, offset, limit)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	if redactsFor(s.GetMemberObject().Level) {
+		redactFeatureHistory(x, s.GetWorkspaceObject().ViewerRedactions)
+	}
+	render.JSON(w, r, x)
+}
+
+// getMilestoneBurndown counts days in the time zone of the workspace. A tz parameter, an IANA
+// name, overrides it, for a member who wants to see the days of their own time zone.
+func getMilestoneBurndown(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+	q := r.URL.Query()
+
+	tz := q.Get("tz")
+	if tz == "" {
+		tz = GetEnv(r).Service.GetWorkspaceObject().Timezone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(errors.New("invalid time zone")))
+		return
+	}
+
+	var from, to time.Time
+	if v := q.Get("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, loc); err != nil {
+			_ = render.Render(w, r, ErrInvalidRequest(errors.New("invalid from date")))
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, loc); err != nil {
+			_ = render.Render(w, r, ErrInvalidRequest(errors.New("invalid to date")))
+			return
+		}
+	}
+
+	renderAggregate(w, r, func() (interface{}, error) {
+		s := GetEnv(r).Service
+		x, err := s.GetBurndownByMilestone(id, from, to, loc)
+		if err != nil {
+			return nil, err
+		}
+		if redactsFor(s.GetMemberObject().Level) {
+			redactBurndown(x, s.GetWorkspaceObject().ViewerRedactions)
+		}
+		return x, nil
+	})
+}
+
+type moveMilestoneRequest struct {
+	Index int `json:"index"`
+}
+
+func (p *moveMilestoneRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func moveMilestone(w http.ResponseWriter, r *http.Request) {
+
+	data := &moveMilestoneRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	m, err := GetEnv(r).Service.MoveMilestone(id, data.Index)
+	if err == errMoveTargetGone {
+		_ = render.Render(w, r, ErrConflict(err))
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, m)
+}
+
+func shiftMilestone(w http.ResponseWriter, r *http.Request, delta int) {
+	id := chi.URLParam(r, "ID")
+
+	mm, err := GetEnv(r).Service.ShiftMilestone(id, delta)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, mm)
+}
+
+func moveMilestoneUp(w http.ResponseWriter, r *http.Request) {
+	shiftMilestone(w, r, -1)
+}
+
+func moveMilestoneDown(w http.ResponseWriter, r *http.Request) {
+	shiftMilestone(w, r, 1)
+}
+
+func renameMilestone(w http.ResponseWriter, r *http.Request) {
+	data := &renameRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	m, err := GetEnv(r).Service.RenameMilestone(id, data.Title)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	renderUpdated(w, r, m, "title")
+
+}
+
+func updateMilestoneDescription(w http.ResponseWriter, r *http.Request) {
+	data := &updateDescriptionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	m, err := GetEnv(r).Service.UpdateMilestoneDescription(id, data.Description)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	renderUpdated(w, r, m, "description")
+}
+
+func deleteMilestone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.DeleteMilestone(id); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.Status(r, http.StatusOK)
+}
+
+func closeMilestone(w http.ResponseWriter, r *http.Request) {
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.CloseMilestone(id)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+func openMilestone(w http.ResponseWriter, r *http.Request) {
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.OpenMilestone(id)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+func changeColorOnMilestone(w http.ResponseWriter, r *http.Request) {
+	data := &changeColorRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.ChangeColorOnMilestone(id, data.Color)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+func changeIconOnMilestone(w http.ResponseWriter, r *http.Request) {
+	data := &changeIconRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.ChangeIconOnMilestone(id, data.Icon)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+func changeAnnotationsOnMilestone(w http.ResponseWriter, r *http.Request) {
+	data := &changeAnnotationRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.UpdateAnnotationsOnMilestone(id, data.Annotations)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+type createFeaturesRequest struct {
+	SubWorkflowID string        `json:"subWorkflowId"`
+	Features      []*newFeature `json:"features"`
+}
+
+func (p *createFeaturesRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func createFeaturesInMilestone(w http.ResponseWriter, r *http.Request) {
+	data := &createFeaturesRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	ff, err := GetEnv(r).Service.CreateFeatures(id, data.SubWorkflowID, data.Features)
+	if err == errEstimateRequired || err == errEstimateOutOfRange || err == errRepeatedFeatureID {
+		_ = render.Render(w, r, ErrUnprocessable(err))
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, ff)
+}
+
+// Workflows
+
+type createWorkflowRequest struct {
+	ProjectID string `json:"projectId"`
+	Title     string `json:"title"`
+}
+
+func (p *createWorkflowRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func createWorkflow(w http.ResponseWriter, r *http.Request) {
+	data := &createWorkflowRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+	wf, err := GetEnv(r).Service.CreateWorkflowWithID(id, data.ProjectID, data.Title)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	render.JSON(w, r, wf)
+}
+
+func renameWorkflow(w http.ResponseWriter, r *http.Request) {
+	data := &renameRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	wf, err := GetEnv(r).Service.RenameWorkflow(id, data.Title)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, wf)
+	renderUpdated(w, r, wf, "title")
 
 }
 
@@ -665,6 +1739,10 @@ func moveWorkflow(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "ID")
 
 	m, err := GetEnv(r).Service.MoveWorkflow(id, data.Index)
+	if err == errMoveTargetGone {
+		_ = render.Render(w, r, ErrConflict(err))
+		return
+	}
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
@@ -685,7 +1763,7 @@ func updateWorkflowDescription(w http.ResponseWriter, r *http.Request) {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
+	renderUpdated(w, r, m, "description")
 }
 
 func changeColorOnWorkflow(w http.ResponseWriter, r *http.Request) {
@@ -729,112 +1807,376 @@ func openWorkflow(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, f)
 }
 
-func changeAnnotationsOnWorkflow(w http.ResponseWriter, r *http.Request) {
-	data := &changeAnnotationRequest{}
+func changeAnnotationsOnWorkflow(w http.ResponseWriter, r *http.Request) {
+	data := &changeAnnotationRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.UpdateAnnotationsOnWorkflow(id, data.Annotations)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+// SubWorkflows
+
+type createSubWorkflowRequest struct {
+	WorkflowID string `json:"workflowId"`
+	Title      string `json:"title"`
+}
+
+func (p *createSubWorkflowRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func createSubWorkflow(w http.ResponseWriter, r *http.Request) {
+	data := &createSubWorkflowRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+	sw, err := GetEnv(r).Service.CreateSubWorkflowWithID(id, data.WorkflowID, data.Title)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, sw)
+}
+
+func renameSubWorkflow(w http.ResponseWriter, r *http.Request) {
+	data := &renameRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	sw, err := GetEnv(r).Service.RenameSubWorkflow(id, data.Title)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	renderUpdated(w, r, sw, "title")
+
+}
+
+func updateSubWorkflowDescription(w http.ResponseWriter, r *http.Request) {
+	data := &updateDescriptionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+	m, err := GetEnv(r).Service.UpdateSubWorkflowDescription(id, data.Description)
+
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	renderUpdated(w, r, m, "description")
+}
+
+func deleteSubWorkflow(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.DeleteSubWorkflow(id); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.Status(r, http.StatusOK)
+}
+
+type moveSubWorkflowRequest struct {
+	Index        int    `json:"index"`
+	ToWorkflowID string `json:"toWorkflowId"`
+}
+
+func (p *moveSubWorkflowRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func moveSubWorkflow(w http.ResponseWriter, r *http.Request) {
+
+	data := &moveSubWorkflowRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	m, err := GetEnv(r).Service.MoveSubWorkflow(id, data.ToWorkflowID, data.Index)
+	if err == errMoveTargetGone {
+		_ = render.Render(w, r, ErrConflict(err))
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, m)
+}
+
+func changeColorOnSubWorkflow(w http.ResponseWriter, r *http.Request) {
+	data := &changeColorRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.ChangeColorOnSubWorkflow(id, data.Color)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+func closeSubWorkflow(w http.ResponseWriter, r *http.Request) {
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.CloseSubWorkflow(id)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+func openSubWorkflow(w http.ResponseWriter, r *http.Request) {
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.OpenSubWorkflow(id)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+func changeAnnotationsOnSubWorkflow(w http.ResponseWriter, r *http.Request) {
+	data := &changeAnnotationRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.UpdateAnnotationsOnSubWorkflow(id, data.Annotations)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+// Features
+
+type createFeatureRequest struct {
+	ProjectID     string `json:"projectId"`
+	SubWorkflowID string `json:"subWorkflowId"`
+	MilestoneID   string `json:"milestoneId"`
+	Title         string `json:"title"`
+	Estimate      *int   `json:"estimate"`
+}
+
+func (p *createFeatureRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func createFeature(w http.ResponseWriter, r *http.Request) {
+	data := &createFeatureRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	s := GetEnv(r).Service
+
+	milestoneID, subWorkflowID, err := s.FeaturePlacement(data.ProjectID, data.MilestoneID, data.SubWorkflowID)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+	f, err := s.CreateFeatureWithID(id, subWorkflowID, milestoneID, data.Title, data.Estimate)
+	if err == errEstimateRequired || err == errEstimateOutOfRange {
+		_ = render.Render(w, r, ErrUnprocessable(err))
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
+func renameFeature(w http.ResponseWriter, r *http.Request) {
+	data := &renameRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	f, err := GetEnv(r).Service.RenameFeature(id, data.Title)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	renderUpdated(w, r, f, "title")
+}
+
+func updateFeatureDescription(w http.ResponseWriter, r *http.Request) {
+	data := &updateDescriptionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	id := chi.URLParam(r, "ID")
+
+	m, err := GetEnv(r).Service.UpdateFeatureDescription(id, data.Description)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	renderUpdated(w, r, m, "description")
+}
+
+func deleteFeature(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.DeleteFeature(id); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.Status(r, http.StatusOK)
+}
+
+func changeEstimateOnFeature(w http.ResponseWriter, r *http.Request) {
+
+	id := chi.URLParam(r, "ID")
+
+	data := &updateEstimateRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 
-	id := chi.URLParam(r, "ID")
-
-	f, err := GetEnv(r).Service.UpdateAnnotationsOnWorkflow(id, data.Annotations)
+	f, err := GetEnv(r).Service.UpdateEstimateOnFeature(id, data.Estimate)
+	if err == errEstimateOutOfRange {
+		_ = render.Render(w, r, ErrUnprocessable(err))
+		return
+	}
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
+
 	render.JSON(w, r, f)
 }
 
-// SubWorkflows
-
-type createSubWorkflowRequest struct {
-	WorkflowID string `json:"workflowId"`
-	Title      string `json:"title"`
-}
+func changeProgressOnFeature(w http.ResponseWriter, r *http.Request) {
 
-func (p *createSubWorkflowRequest) Bind(r *http.Request) error {
-	return nil
-}
+	id := chi.URLParam(r, "ID")
 
-func createSubWorkflow(w http.ResponseWriter, r *http.Request) {
-	data := &createSubWorkflowRequest{}
+	data := &updateProgressRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 
-	id := chi.URLParam(r, "ID")
-	sw, err := GetEnv(r).Service.CreateSubWorkflowWithID(id, data.WorkflowID, data.Title)
+	f, err := GetEnv(r).Service.UpdateProgressOnFeature(id, data.Progress)
+
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, sw)
+
+	render.JSON(w, r, f)
 }
 
-func renameSubWorkflow(w http.ResponseWriter, r *http.Request) {
-	data := &renameRequest{}
-	if err := render.Bind(r, data); err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
-		return
-	}
+type featureContextResponse struct {
+	Feature      *Feature       `json:"feature"`
+	Milestone    *Milestone     `json:"milestone"`
+	SubWorkflow  *SubWorkflow   `json:"subWorkflow"`
+	Workflow     *Workflow      `json:"workflow"`
+	Project      *Project       `json:"project"`
+	Status       *ProjectStatus `json:"status"`
+	CommentCount int            `json:"commentCount"`
+}
+
+func getFeatureContext(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "ID")
 
-	sw, err := GetEnv(r).Service.RenameSubWorkflow(id, data.Title)
+	s := GetEnv(r).Service
+	x, err := s.GetFeatureContext(id)
 	if err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
+		_ = render.Render(w, r, ErrNotFound(err))
 		return
 	}
-	render.JSON(w, r, sw)
 
+	if redactsFor(s.GetMemberObject().Level) {
+		redactFeatureContext(x, s.GetWorkspaceObject().ViewerRedactions)
+	}
+	renderJSONWithETag(w, r, x)
 }
 
-func updateSubWorkflowDescription(w http.ResponseWriter, r *http.Request) {
-	data := &updateDescriptionRequest{}
-	if err := render.Bind(r, data); err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
-		return
-	}
+func watchFeature(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "ID")
-	m, err := GetEnv(r).Service.UpdateSubWorkflowDescription(id, data.Description)
 
-	if err != nil {
+	if err := GetEnv(r).Service.WatchFeature(id); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
 }
 
-func deleteSubWorkflow(w http.ResponseWriter, r *http.Request) {
+func unwatchFeature(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "ID")
 
-	if err := GetEnv(r).Service.DeleteSubWorkflow(id); err != nil {
+	if err := GetEnv(r).Service.UnwatchFeature(id); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.Status(r, http.StatusOK)
 }
 
-type moveSubWorkflowRequest struct {
-	Index        int    `json:"index"`
-	ToWorkflowID string `json:"toWorkflowId"`
+type moveFeatureRequest struct {
+	Index           int    `json:"index"`
+	ToSubWorkflowID string `json:"toSubWorkflowId"`
+	ToMilestoneID   string `json:"toMilestoneId"`
 }
 
-func (p *moveSubWorkflowRequest) Bind(r *http.Request) error {
+func (p *moveFeatureRequest) Bind(r *http.Request) error {
 	return nil
 }
 
-func moveSubWorkflow(w http.ResponseWriter, r *http.Request) {
+func moveFeature(w http.ResponseWriter, r *http.Request) {
 
-	data := &moveSubWorkflowRequest{}
+	data := &moveFeatureRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 	id := chi.URLParam(r, "ID")
 
-	m, err := GetEnv(r).Service.MoveSubWorkflow(id, data.ToWorkflowID, data.Index)
+	m, err := GetEnv(r).Service.MoveFeature(id, data.ToMilestoneID, data.ToSubWorkflowID, data.Index)
+	if err == errMoveTargetGone {
+		_ = render.Render(w, r, ErrConflict(err))
+		return
+	}
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
@@ -842,16 +2184,15 @@ func moveSubWorkflow(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, m)
 }
 
-func changeColorOnSubWorkflow(w http.ResponseWriter, r *http.Request) {
-	data := &changeColorRequest{}
-	if err := render.Bind(r, data); err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
-		return
-	}
+func closeFeature(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, "ID")
 
-	f, err := GetEnv(r).Service.ChangeColorOnSubWorkflow(id, data.Color)
+	f, err := GetEnv(r).Service.CloseFeature(id)
+	if err == errEstimateRequired {
+		_ = render.Render(w, r, ErrUnprocessable(err))
+		return
+	}
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
@@ -859,23 +2200,27 @@ func changeColorOnSubWorkflow(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, f)
 }
 
-func closeSubWorkflow(w http.ResponseWriter, r *http.Request) {
+func openFeature(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, "ID")
 
-	f, err := GetEnv(r).Service.CloseSubWorkflow(id)
+	f, err := GetEnv(r).Service.OpenFeature(id)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 	render.JSON(w, r, f)
 }
-
-func openSubWorkflow(w http.ResponseWriter, r *http.Request) {
+func changeColorOnFeature(w http.ResponseWriter, r *http.Request) {
+	data := &changeColorRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
 
 	id := chi.URLParam(r, "ID")
 
-	f, err := GetEnv(r).Service.OpenSubWorkflow(id)
+	f, err := GetEnv(r).Service.ChangeColorOnFeature(id, data.Color)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
@@ -883,60 +2228,86 @@ func openSubWorkflow(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, f)
 }
 
-func changeAnnotationsOnSubWorkflow(w http.ResponseWriter, r *http.Request) {
-	data := &changeAnnotationRequest{}
-	if err := render.Bind(r, data); err != nil {
+func patchFeature(w http.ResponseWriter, r *http.Request) {
+	data := map[string]json.RawMessage{}
+	if err := render.Decode(r, &data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	p, err := parseFeaturePatch(data)
+	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 
 	id := chi.URLParam(r, "ID")
 
-	f, err := GetEnv(r).Service.UpdateAnnotationsOnSubWorkflow(id, data.Annotations)
+	f, err := GetEnv(r).Service.PatchFeature(id, p)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, f)
-}
 
-// Features
+	changed := []string{}
+	for k := range data {
+		changed = append(changed, k)
+	}
+	sort.Strings(changed)
+	renderUpdated(w, r, f, changed...)
+}
 
-type createFeatureRequest struct {
-	SubWorkflowID string `json:"subWorkflowId"`
-	MilestoneID   string `json:"milestoneId"`
-	Title         string `json:"title"`
+type replaceFeatureRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Icon        string `json:"icon"`
+	Annotations string `json:"annotations"`
+	Estimate    int    `json:"estimate"`
+	Progress    int    `json:"progress"`
 }
 
-func (p *createFeatureRequest) Bind(r *http.Request) error {
+func (p *replaceFeatureRequest) Bind(r *http.Request) error {
 	return nil
 }
 
-func createFeature(w http.ResponseWriter, r *http.Request) {
-	data := &createFeatureRequest{}
+func replaceFeature(w http.ResponseWriter, r *http.Request) {
+	data := &replaceFeatureRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 
 	id := chi.URLParam(r, "ID")
-	f, err := GetEnv(r).Service.CreateFeatureWithID(id, data.SubWorkflowID, data.MilestoneID, data.Title)
+
+	f, err := GetEnv(r).Service.ReplaceFeature(id, data.Title, data.Description, data.Color, data.Icon, data.Annotations, data.Estimate, data.Progress)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, f)
+	renderUpdated(w, r, f, "title", "description", "color", "icon", "annotations", "estimate", "progress")
 }
 
-func renameFeature(w http.ResponseWriter, r *http.Request) {
-	data := &renameRequest{}
+type duplicateFeatureRequest struct {
+	ID              string `json:"id"`
+	CopyAnnotations bool   `json:"copyAnnotations"`
+	CopyComments    bool   `json:"copyComments"`
+}
+
+func (p *duplicateFeatureRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func duplicateFeature(w http.ResponseWriter, r *http.Request) {
+	data := &duplicateFeatureRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
+
 	id := chi.URLParam(r, "ID")
 
-	f, err := GetEnv(r).Service.RenameFeature(id, data.Title)
+	f, err := GetEnv(r).Service.DuplicateFeature(id, data.ID, data.CopyAnnotations, data.CopyComments)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
@@ -944,104 +2315,111 @@ func renameFeature(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, f)
 }
 
-func updateFeatureDescription(w http.ResponseWriter, r *http.Request) {
-	data := &updateDescriptionRequest{}
+type addFeatureReferenceRequest struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+func (p *addFeatureReferenceRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func addFeatureReference(w http.ResponseWriter, r *http.Request) {
+	data := &addFeatureReferenceRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
+
 	id := chi.URLParam(r, "ID")
 
-	m, err := GetEnv(r).Service.UpdateFeatureDescription(id, data.Description)
+	x, err := GetEnv(r).Service.AddFeatureReference(id, data.ID, data.Label, data.URL)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
+	render.JSON(w, r, x)
 }
 
-func deleteFeature(w http.ResponseWriter, r *http.Request) {
+func deleteFeatureReference(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "ID")
+	referenceID := chi.URLParam(r, "REFERENCE")
 
-	if err := GetEnv(r).Service.DeleteFeature(id); err != nil {
+	if err := GetEnv(r).Service.DeleteFeatureReference(id, referenceID); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.Status(r, http.StatusOK)
 }
 
-func changeEstimateOnFeature(w http.ResponseWriter, r *http.Request) {
-
+func getTimeEntries(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "ID")
 
-	data := &updateEstimateRequest{}
-	if err := render.Bind(r, data); err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
-		return
-	}
-
-	f, err := GetEnv(r).Service.UpdateEstimateOnFeature(id, data.Estimate)
-
+	x, err := GetEnv(r).Service.GetTimeEntries(id)
 	if err != nil {
-		_ = render.Render(w, r, ErrInvalidRequest(err))
+		_ = render.Render(w, r, ErrNotFound(err))
 		return
 	}
-
-	render.JSON(w, r, f)
+	render.JSON(w, r, x)
 }
 
-type moveFeatureRequest struct {
-	Index           int    `json:"index"`
-	ToSubWorkflowID string `json:"toSubWorkflowId"`
-	ToMilestoneID   string `json:"toMilestoneId"`
+type timeEntryRequest struct {
+	ID      string `json:"id"`
+	Minutes int    `json:"minutes"`
+	Note    string `json:"note"`
 }
 
-func (p *moveFeatureRequest) Bind(r *http.Request) error {
+func (p *timeEntryRequest) Bind(r *http.Request) error {
 	return nil
 }
 
-func moveFeature(w http.ResponseWriter, r *http.Request) {
-
-	data := &moveFeatureRequest{}
+func logTime(w http.ResponseWriter, r *http.Request) {
+	data := &timeEntryRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
+
 	id := chi.URLParam(r, "ID")
 
-	m, err := GetEnv(r).Service.MoveFeature(id, data.ToMilestoneID, data.ToSubWorkflowID, data.Index)
+	x, err := GetEnv(r).Service.LogTime(id, data.ID, data.Minutes, data.Note)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, m)
+	render.JSON(w, r, x)
 }
 
-func closeFeature(w http.ResponseWriter, r *http.Request) {
+func updateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	data := &timeEntryRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
 
 	id := chi.URLParam(r, "ID")
+	entryID := chi.URLParam(r, "ENTRY")
 
-	f, err := GetEnv(r).Service.CloseFeature(id)
+	x, err := GetEnv(r).Service.UpdateTimeEntry(id, entryID, data.Minutes, data.Note)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, f)
+	render.JSON(w, r, x)
 }
 
-func openFeature(w http.ResponseWriter, r *http.Request) {
-
+func deleteTimeEntry(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "ID")
+	entryID := chi.URLParam(r, "ENTRY")
 
-	f, err := GetEnv(r).Service.OpenFeature(id)
-	if err != nil {
+	if err := GetEnv(r).Service.DeleteTimeEntry(id, entryID); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
-	render.JSON(w, r, f)
 }
-func changeColorOnFeature(w http.ResponseWriter, r *http.Request) {
-	data := &changeColorRequest{}
+
+func changeIconOnFeature(w http.ResponseWriter, r *http.Request) {
+	data := &changeIconRequest{}
 	if err := render.Bind(r, data); err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
@@ -1049,7 +2427,7 @@ func changeColorOnFeature(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, "ID")
 
-	f, err := GetEnv(r).Service.ChangeColorOnFeature(id, data.Color)
+	f, err := GetEnv(r).Service.ChangeIconOnFeature(id, data.Icon)
 	if err != nil {
 		_ = render.Render(w, r, ErrInvalidRequest(err))
 		return
@@ -1210,6 +2588,88 @@ func updatePersona(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, f)
 }
 
+// Project statuses
+
+type projectStatusRequest struct {
+	ProjectID string `json:"projectId"`
+	Title     string `json:"title"`
+	Closed    bool   `json:"closed"`
+}
+
+func (p *projectStatusRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func createProjectStatus(w http.ResponseWriter, r *http.Request) {
+	data := &projectStatusRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+	x, err := GetEnv(r).Service.CreateProjectStatusWithID(id, data.ProjectID, data.Title, data.Closed)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func updateProjectStatus(w http.ResponseWriter, r *http.Request) {
+	data := &projectStatusRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+	x, err := GetEnv(r).Service.UpdateProjectStatus(id, data.Title, data.Closed)
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, x)
+}
+
+func deleteProjectStatus(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ID")
+
+	if err := GetEnv(r).Service.DeleteProjectStatus(id); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.Status(r, http.StatusOK)
+}
+
+type changeStatusRequest struct {
+	StatusID string `json:"statusId"`
+}
+
+func (p *changeStatusRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+func changeStatusOnFeature(w http.ResponseWriter, r *http.Request) {
+	data := &changeStatusRequest{}
+	if err := render.Bind(r, data); err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "ID")
+	f, err := GetEnv(r).Service.SetStatusOnFeature(id, data.StatusID)
+	if err == errEstimateRequired {
+		_ = render.Render(w, r, ErrUnprocessable(err))
+		return
+	}
+	if err != nil {
+		_ = render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	render.JSON(w, r, f)
+}
+
 type createPersonaRequest struct {
 	ProjectID         string `json:"projectId"`
 	Avatar            string `json:"avatar"`
@@ -1250,6 +2710,14 @@ func (p *updateEstimateRequest) Bind(r *http.Request) error {
 	return nil
 }
 
+type updateProgressRequest struct {
+	Progress int `json:"progress"`
+}
+
+func (p *updateProgressRequest) Bind(r *http.Request) error {
+	return nil
+}
+
 type changeColorRequest struct {
 	Color string `json:"color"`
 }
@@ -1258,6 +2726,14 @@ func (p *changeColorRequest) Bind(r *http.Request) error {
 	return nil
 }
 
+type changeIconRequest struct {
+	Icon string `json:"icon"`
+}
+
+func (p *changeIconRequest) Bind(r *http.Request) error {
+	return nil
+}
+
 type changeAnnotationRequest struct {
 	Annotations string `json:"annotations"`
 }
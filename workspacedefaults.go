@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+)
+
+// workspaceSettings are settings of a new workspace. Settings left out keep their value, so the
+// same struct changes the defaults of an account and overrides them when creating a workspace.
+type workspaceSettings struct {
+	AllowExternalSharing *bool   `json:"allowExternalSharing"`
+	ViewerRedactions     *string `json:"viewerRedactions"`
+	AutoJoinLevel        *string `json:"autoJoinLevel"`
+	MinEstimate          *int    `json:"minEstimate"`
+	MaxEstimate          *int    `json:"maxEstimate"`
+}
+
+func (x workspaceSettings) applyTo(w *Workspace) {
+	if x.AllowExternalSharing != nil {
+		w.AllowExternalSharing = *x.AllowExternalSharing
+	}
+	if x.ViewerRedactions != nil {
+		w.ViewerRedactions = *x.ViewerRedactions
+	}
+	if x.AutoJoinLevel != nil {
+		w.AutoJoinLevel = *x.AutoJoinLevel
+	}
+	if x.MinEstimate != nil {
+		w.MinEstimate = *x.MinEstimate
+	}
+	if x.MaxEstimate != nil {
+		w.MaxEstimate = *x.MaxEstimate
+	}
+}
+
+// applyAccountDefaults gives w the workspace defaults of the account
+func applyAccountDefaults(a *Account, w *Workspace) {
+	w.AllowExternalSharing = a.DefaultAllowExternalSharing
+	w.ViewerRedactions = a.DefaultViewerRedactions
+	w.AutoJoinLevel = a.DefaultAutoJoinLevel
+	w.MinEstimate = a.DefaultMinEstimate
+	w.MaxEstimate = a.DefaultMaxEstimate
+}
+
+func validateWorkspaceSettings(w *Workspace) error {
+	if !redactionsAreValid(w.ViewerRedactions) {
+		return errors.New("invalid redactions")
+	}
+	if !(w.AutoJoinLevel == "VIEWER" || w.AutoJoinLevel == "COMMENTER" || w.AutoJoinLevel == "EDITOR") {
+		return errors.New("invalid level")
+	}
+	if w.MinEstimate < 0 || w.MaxEstimate < 0 || w.MaxEstimate > maxEstimate || (w.MaxEstimate > 0 && w.MinEstimate > w.MaxEstimate) {
+		return errors.New("invalid bounds")
+	}
+	return nil
+}
+
+// UpdateWorkspaceDefaults changes the settings every workspace the account creates starts with
+func (s *service) UpdateWorkspaceDefaults(x workspaceSettings) (*Account, error) {
+	w := &Workspace{}
+	applyAccountDefaults(s.Acc, w)
+	x.applyTo(w)
+	if err := validateWorkspaceSettings(w); err != nil {
+		return nil, err
+	}
+
+	s.Acc.DefaultAllowExternalSharing = w.AllowExternalSharing
+	s.Acc.DefaultViewerRedactions = w.ViewerRedactions
+	s.Acc.DefaultAutoJoinLevel = w.AutoJoinLevel
+	s.Acc.DefaultMinEstimate = w.MinEstimate
+	s.Acc.DefaultMaxEstimate = w.MaxEstimate
+	s.r.StoreAccount(s.Acc)
+
+	return s.Acc, nil
+}
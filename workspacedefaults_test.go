@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+)
+
+// newWorkspaceRepo keeps the workspaces created in memory
+type newWorkspaceRepo struct {
+	Repository
+	workspaces []*Workspace
+}
+
+func (a *newWorkspaceRepo) GetWorkspaceByName(name string) (*Workspace, error) {
+	return nil, errNotFound
+}
+
+func (a *newWorkspaceRepo) StoreWorkspace(x *Workspace) {
+	a.workspaces = append(a.workspaces, x)
+}
+
+func (a *newWorkspaceRepo) StoreSubscription(x *Subscription) {}
+
+func (a *newWorkspaceRepo) StoreMember(x *Member) {}
+
+func (a *newWorkspaceRepo) StoreAccount(x *Account) {}
+
+func TestWorkspaceDefaults(t *testing.T) {
+	repo := &newWorkspaceRepo{}
+
+	s := NewFeatmapService()
+	s.SetRepoObject(repo)
+	s.SetAccountObject(&Account{ID: "a", Name: "Ann", DefaultAllowExternalSharing: true, DefaultAutoJoinLevel: "VIEWER"})
+
+	off, redactions, level, max := false, "descriptions,comments", "COMMENTER", 13
+	if _, err := s.UpdateWorkspaceDefaults(workspaceSettings{AllowExternalSharing: &off, ViewerRedactions: &redactions, AutoJoinLevel: &level, MaxEstimate: &max}); err != nil {
+		t.Fatal(err)
+	}
+
+	w, _, _, err := s.CreateWorkspace("agencyclient", workspaceSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.AllowExternalSharing || w.ViewerRedactions != redactions || w.AutoJoinLevel != "COMMENTER" || w.MaxEstimate != 13 {
+		t.Error("a new workspace should get the defaults of the account", w)
+	}
+
+	on, editor := true, "EDITOR"
+	w, _, _, err = s.CreateWorkspace("otherclient", workspaceSettings{AllowExternalSharing: &on, AutoJoinLevel: &editor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.AllowExternalSharing || w.AutoJoinLevel != "EDITOR" || w.ViewerRedactions != redactions || w.MaxEstimate != 13 {
+		t.Error("settings given on creation should override the defaults", w)
+	}
+
+	owner := "OWNER"
+	if _, _, _, err := s.CreateWorkspace("badclient", workspaceSettings{AutoJoinLevel: &owner}); err == nil {
+		t.Error("an invalid override should be rejected")
+	}
+	min := 20
+	if _, err := s.UpdateWorkspaceDefaults(workspaceSettings{MinEstimate: &min}); err == nil {
+		t.Error("invalid defaults should be rejected")
+	}
+	if len(repo.workspaces) != 2 {
+		t.Error("only valid workspaces should be stored", len(repo.workspaces))
+	}
+}
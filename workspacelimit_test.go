@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// limitRepo is newWorkspaceRepo that keeps the memberships and subscriptions it is given
+type limitRepo struct {
+	newWorkspaceRepo
+	members []*Member
+	subs    []*Subscription
+}
+
+func (a *limitRepo) StoreMember(x *Member) { a.members = append(a.members, x) }
+
+func (a *limitRepo) StoreSubscription(x *Subscription) { a.subs = append(a.subs, x) }
+
+func (a *limitRepo) GetMembersByAccount(id string) ([]*Member, error) { return a.members, nil }
+
+func (a *limitRepo) FindSubscriptionsByAccount(accountID string) ([]*Subscription, error) {
+	return a.subs, nil
+}
+
+func TestWorkspaceLimit(t *testing.T) {
+	repo := &limitRepo{}
+	c := Configuration{MaxWorkspacesPerAccount: 2, WorkspaceLimitExemptTiers: []string{"PRO"}}
+
+	serve := func(name string) *httptest.ResponseRecorder {
+		s := NewFeatmapService()
+		s.SetConfig(c)
+		s.SetRepoObject(repo)
+		s.SetAccountObject(&Account{ID: "a1", Name: "ann", DefaultAutoJoinLevel: "VIEWER"})
+
+		return serveWith(s, "/v1/account", accountAPI, jsonRequest("POST", "/v1/account/workspaces", `{"name": "`+name+`"}`))
+	}
+
+	// Only the workspaces the account owns count
+	repo.members = []*Member{{WorkspaceID: "other", AccountID: "a1", Level: "EDITOR"}}
+
+	if w := serve("one"); w.Code != 200 {
+		t.Fatal("the first workspace should be created", w.Code, w.Body.String())
+	}
+	if w := serve("two"); w.Code != 200 {
+		t.Fatal("a workspace up to the limit should be created", w.Code, w.Body.String())
+	}
+
+	w := serve("three")
+	if w.Code != 403 {
+		t.Fatal("a workspace past the limit should be refused", w.Code)
+	}
+	e := &workspaceLimitError{}
+	if err := json.Unmarshal(w.Body.Bytes(), e); err != nil || e.Current != 2 || e.Allowed != 2 {
+		t.Error("the refusal should give the current and allowed counts", w.Body.String())
+	}
+
+	repo.subs[1].Level = "PRO"
+	if w := serve("three"); w.Code != 200 {
+		t.Error("an account with an exempt workspace should bypass the limit", w.Code, w.Body.String())
+	}
+
+	c.MaxWorkspacesPerAccount = 0
+	if w := serve("four"); w.Code != 200 {
+		t.Error("without a limit any number of workspaces should be created", w.Code)
+	}
+}